@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
-const challongeAPIURL = "https://%s:%s@api.challonge.com/v1/"
+const challongeAPIURL = "https://api.challonge.com/v1"
 
 type Client struct {
 	Tournament  *tournamentClient
@@ -22,9 +24,14 @@ type baseClient struct {
 type Config struct {
 	Username string
 	Apikey   string
+	//APIURL - optional override of the challonge api location
+	APIURL string
 }
 
 func New(config Config) *Client {
+	if config.APIURL == "" {
+		config.APIURL = challongeAPIURL
+	}
 	b := baseClient{
 		httpClient: *http.DefaultClient,
 		Config:     config}
@@ -36,48 +43,57 @@ func New(config Config) *Client {
 }
 
 func (c *baseClient) getAPIURL() string {
-	return fmt.Sprintf(challongeAPIURL, c.Username, c.Apikey)
+	return c.APIURL
 }
 
 func (c *baseClient) getRequest(url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
+	return c.doRequest("GET", url, nil)
+}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
+func (c *baseClient) postRequest(url string, params url.Values) ([]byte, error) {
+	return c.doRequest("POST", url, params)
+}
 
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("error status code %v", res.StatusCode)
+func (c *baseClient) putRequest(url string, params url.Values) ([]byte, error) {
+	return c.doRequest("PUT", url, params)
+}
+
+func (c *baseClient) deleteRequest(url string) ([]byte, error) {
+	return c.doRequest("DELETE", url, nil)
+}
+
+//doRequest sends params form encoded, the same way the challonge website does
+func (c *baseClient) doRequest(method string, url string, params url.Values) ([]byte, error) {
+	var body *strings.Reader
+	if params != nil {
+		body = strings.NewReader(params.Encode())
+	} else {
+		body = strings.NewReader("")
 	}
 
-	body, err := ioutil.ReadAll(res.Body)
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return nil, err
 	}
-
-	return body, nil
-}
-
-func (c *baseClient) putRequest(url string) error {
-	req, err := http.NewRequest("PUT", url, nil)
-	if err != nil {
-		return err
+	req.SetBasicAuth(c.Username, c.Apikey)
+	if params != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 
 	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return  err
+		return nil, err
 	}
 	defer res.Body.Close()
 
+	result, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
 	if res.StatusCode != 200 {
-		return fmt.Errorf("error status code %v", res.StatusCode)
+		return nil, fmt.Errorf("error status code %v %s", res.StatusCode, parseErrors(result))
 	}
 
-	return  nil
+	return result, nil
 }
@@ -1 +1,227 @@
 package challonge_test
+
+import (
+	"discordbot/challonge"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	form   map[string]string
+}
+
+// newChallongeServer - stand in for the challonge api that records requests and replies with the given bodies by path
+func newChallongeServer(t *testing.T, responses map[string]string) (*httptest.Server, *challonge.Client, *[]recordedRequest) {
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, key, ok := r.BasicAuth()
+		if !ok || user != "user" || key != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		form := make(map[string]string)
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		requests = append(requests, recordedRequest{r.Method, r.URL.Path, form})
+
+		body, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"errors": ["Unknown request"]}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	client := challonge.New(challonge.Config{Username: "user", Apikey: "key", APIURL: server.URL})
+	return server, client, &requests
+}
+
+func TestCreateTournament(t *testing.T) {
+	server, client, requests := newChallongeServer(t, map[string]string{
+		"POST /tournaments.json": `{"tournament": {"id": 10, "name": "Weekly #1", "url": "weekly1", "tournament_type": "double elimination", "state": "pending"}}`,
+	})
+	defer server.Close()
+
+	tourney, err := client.Tournament.Create(challonge.TournamentParams{
+		Name:           "Weekly #1",
+		TournamentType: challonge.DoubleElimination,
+		URL:            "weekly1",
+		GameName:       "Super Smash Bros. Melee",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tourney.Tournament.ID != 10 || tourney.Tournament.URL != "weekly1" || tourney.Tournament.State != "pending" {
+		t.Error("Tournament not decoded ", tourney.Tournament)
+	}
+	r := (*requests)[0]
+	if r.form["tournament[name]"] != "Weekly #1" || r.form["tournament[tournament_type]"] != "double elimination" || r.form["tournament[game_name]"] != "Super Smash Bros. Melee" {
+		t.Error("Tournament params not encoded ", r.form)
+	}
+	if _, ok := r.form["tournament[description]"]; ok {
+		t.Error("Empty params should not be sent.")
+	}
+}
+
+func TestTournamentActions(t *testing.T) {
+	server, client, requests := newChallongeServer(t, map[string]string{
+		"POST /tournaments/weekly1/start.json":    `{"tournament": {"state": "underway"}}`,
+		"POST /tournaments/weekly1/reset.json":    `{"tournament": {"state": "pending"}}`,
+		"POST /tournaments/weekly1/finalize.json": `{"tournament": {"state": "complete"}}`,
+	})
+	defer server.Close()
+
+	states := []string{}
+	for _, action := range []func(string) (*challonge.Tournaments, error){client.Tournament.Start, client.Tournament.Reset, client.Tournament.Finalize} {
+		tourney, err := action("weekly1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, tourney.Tournament.State)
+	}
+
+	if states[0] != "underway" || states[1] != "pending" || states[2] != "complete" {
+		t.Error("Unexpected states ", states)
+	}
+	if len(*requests) != 3 {
+		t.Error("Expected three requests. Got ", *requests)
+	}
+}
+
+func TestOpenCheckIn(t *testing.T) {
+	server, client, requests := newChallongeServer(t, map[string]string{
+		"PUT /tournaments/weekly1.json": `{"tournament": {"state": "checking_in"}}`,
+	})
+	defer server.Close()
+
+	_, err := client.Tournament.OpenCheckIn("weekly1", 30*time.Minute)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := (*requests)[0]
+	if r.form["tournament[check_in_duration]"] != "30" {
+		t.Error("Check in duration not sent ", r.form)
+	}
+	startAt, err := time.Parse(time.RFC3339, r.form["tournament[start_at]"])
+	if err != nil || time.Until(startAt) < 29*time.Minute || time.Until(startAt) > 31*time.Minute {
+		t.Error("Start time not set to the end of check in ", r.form["tournament[start_at]"])
+	}
+}
+
+func TestParticipantLifecycle(t *testing.T) {
+	server, client, requests := newChallongeServer(t, map[string]string{
+		"POST /tournaments/weekly1/participants.json":      `{"participant": {"id": 55, "name": "Mang0", "seed": 1}}`,
+		"PUT /tournaments/weekly1/participants/55.json":    `{"participant": {"id": 55, "name": "Mang0", "seed": 3}}`,
+		"DELETE /tournaments/weekly1/participants/55.json": `{"participant": {"id": 55}}`,
+	})
+	defer server.Close()
+
+	p, err := client.Participant.Create("weekly1", challonge.ParticipantParams{Name: "Mang0"})
+	if err != nil || p.Participant.ID != 55 {
+		t.Fatal("Participant not created ", p, err)
+	}
+
+	p, err = client.Participant.Update("weekly1", 55, challonge.ParticipantParams{Seed: 3})
+	if err != nil || p.Participant.Seed != 3 {
+		t.Error("Participant not seeded ", p, err)
+	}
+	if (*requests)[1].form["participant[seed]"] != "3" {
+		t.Error("Seed not encoded ", (*requests)[1].form)
+	}
+
+	err = client.Participant.Destroy("weekly1", 55)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckIns(t *testing.T) {
+	server, client, requests := newChallongeServer(t, map[string]string{
+		"POST /tournaments/weekly1/participants/55/check_in.json":      `{"participant": {"id": 55, "checked_in": true}}`,
+		"POST /tournaments/weekly1/participants/55/undo_check_in.json": `{"participant": {"id": 55, "checked_in": false}}`,
+		"POST /tournaments/weekly1/process_check_ins.json":             `{"tournament": {"state": "checked_in"}}`,
+	})
+	defer server.Close()
+
+	p, err := client.Participant.CheckIn("weekly1", 55)
+	if err != nil || !p.Participant.CheckedIn {
+		t.Error("Participant not checked in ", p, err)
+	}
+	p, err = client.Participant.UndoCheckIn("weekly1", 55)
+	if err != nil || p.Participant.CheckedIn {
+		t.Error("Check in not undone ", p, err)
+	}
+	tourney, err := client.Tournament.ProcessCheckIns("weekly1")
+	if err != nil || tourney.Tournament.State != "checked_in" {
+		t.Error("Check ins not processed ", tourney, err)
+	}
+	if len(*requests) != 3 {
+		t.Error("Unexpected requests ", *requests)
+	}
+}
+
+func TestUpdateMatchEncodesScores(t *testing.T) {
+	server, client, requests := newChallongeServer(t, map[string]string{
+		"PUT /tournaments/weekly1/matches/7.json": `{"match": {"id": 7}}`,
+	})
+	defer server.Close()
+
+	err := client.Match.Update("weekly1", "7", challonge.MatchQueryParams{WinnerID: 55, MatchScore: challonge.MatchScore{Player1Score: 2, Player2Score: 1}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := (*requests)[0].form
+	if form["match[winner_id]"] != "55" || form["match[scores_csv]"] != "2-1" {
+		t.Error("Match params not encoded ", form)
+	}
+}
+
+func TestReopenMatch(t *testing.T) {
+	server, client, requests := newChallongeServer(t, map[string]string{
+		"POST /tournaments/weekly1/matches/7/reopen.json": `{"match": {"id": 7, "state": "open"}}`,
+	})
+	defer server.Close()
+
+	err := client.Match.Reopen("weekly1", "7")
+
+	if err != nil || len(*requests) != 1 {
+		t.Error("Match not reopened ", err)
+	}
+}
+
+func TestIndexAndErrors(t *testing.T) {
+	server, client, _ := newChallongeServer(t, map[string]string{
+		"GET /tournaments/weekly1/participants.json": `[{"participant": {"id": 1, "name": "a"}}, {"participant": {"id": 2, "name": "b"}}]`,
+		"GET /tournaments/weekly1/matches.json":      `[{"match": {"id": 7, "round": -1, "player1_id": 1, "player2_id": 2, "state": "open"}}]`,
+	})
+	defer server.Close()
+
+	ps, err := client.Participant.Index("weekly1")
+	if err != nil || len(ps) != 2 || ps[1].Participant.Name != "b" {
+		t.Error("Participants not decoded ", ps, err)
+	}
+
+	ms, err := client.Match.Index("weekly1")
+	if err != nil || len(ms) != 1 || ms[0].Match.Round != -1 || ms[0].Match.Player2ID != 2 {
+		t.Error("Matches not decoded ", ms, err)
+	}
+
+	//Failures are returned so callers can tell them from an empty bracket
+	if ms, err := client.Match.Index("missing"); err == nil || ms != nil {
+		t.Error("Expected an error for a missing tournament. Got ", ms)
+	}
+
+	_, err = client.Tournament.Start("missing")
+	if err == nil || err.Error() != "error status code 422 Unknown request" {
+		t.Error("Expected challonge error message. Got ", err)
+	}
+}
@@ -0,0 +1,19 @@
+package challonge
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type errorResponse struct {
+	Errors []string
+}
+
+//parseErrors - challonge explains validation failures as {"errors": ["..."]}
+func parseErrors(body []byte) string {
+	e := errorResponse{}
+	if err := json.Unmarshal(body, &e); err != nil {
+		return ""
+	}
+	return strings.Join(e.Errors, ", ")
+}
@@ -3,6 +3,8 @@ package challonge
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -10,6 +12,7 @@ import (
 
 const matchIndexURL = "/tournaments/%s/matches.json"
 const matchUpdateURL = "/tournaments/%s/matches/%s.json"
+const matchReopenURL = "/tournaments/%s/matches/%s/reopen.json"
 
 type MatchContainer struct {
 	Match Match
@@ -36,6 +39,7 @@ type Match struct {
 	ScheduledTime             *time.Time `json:"scheduled_time"`
 	StartedAt                 *time.Time `json:"started_at"`
 	State                     string
+	SuggestedPlayOrder        int        `json:"suggested_play_order"`
 	TournamentID              int        `json:"tournament_id"`
 	UnderwayAt                string     `json:"underway_at"`
 	UpdatedAt                 *time.Time `json:"updated_at"`
@@ -51,15 +55,15 @@ type MatchQueryParams struct {
 	Player2Vote int
 }
 
-func createMatchParams(m MatchQueryParams) string {
-	p := "?"
+func (m MatchQueryParams) values() url.Values {
+	v := url.Values{}
 	if m.WinnerID != 0 {
-		p = fmt.Sprintf("%smatch[winner_id]=%v&", p, m.WinnerID)
-		p = fmt.Sprintf("%smatch[scores_csv]=\"%v-%v\"&", p, m.MatchScore.Player1Score, m.MatchScore.Player2Score)
+		v.Set("match[winner_id]", strconv.Itoa(m.WinnerID))
+		v.Set("match[scores_csv]", fmt.Sprintf("%v-%v", m.MatchScore.Player1Score, m.MatchScore.Player2Score))
 	}
-	p = fmt.Sprintf("%smatch[player1_votes]=%v&", p, m.Player1Vote)
-	p = fmt.Sprintf("%smatch[player2_votes]=%v", p, m.Player2Vote)
-	return p
+	v.Set("match[player1_votes]", strconv.Itoa(m.Player1Vote))
+	v.Set("match[player2_votes]", strconv.Itoa(m.Player2Vote))
+	return v
 }
 
 type MatchScore struct {
@@ -76,21 +80,19 @@ func createMatchIndexURL(t string) string {
 }
 
 //Index - matches do not show until tournament has started
-func (c *matchClient) Index(tournamentID string) []*MatchContainer {
+func (c *matchClient) Index(tournamentID string) ([]*MatchContainer, error) {
 	body, err := c.getRequest(c.getAPIURL() + createMatchIndexURL(tournamentID))
 	if err != nil {
-		log.Error(err)
-		return nil
+		return nil, err
 	}
 
 	t := []*MatchContainer{}
 	err = json.Unmarshal([]byte(body), &t)
 	if err != nil {
-		log.Error(err)
-		return nil
+		return nil, err
 	}
 
-	return t
+	return t, nil
 }
 
 func (c *matchClient) Show(tournamentID string, matchID string) MatchContainer {
@@ -111,7 +113,12 @@ func (c *matchClient) Show(tournamentID string, matchID string) MatchContainer {
 }
 
 func (c *matchClient) Update(tournamentID string, matchID string, params MatchQueryParams) error {
-	p := createMatchParams(params)
-	url := c.getAPIURL() + fmt.Sprintf(matchUpdateURL, tournamentID, matchID) + p
-	return c.putRequest(url)
+	_, err := c.putRequest(c.getAPIURL()+fmt.Sprintf(matchUpdateURL, tournamentID, matchID), params.values())
+	return err
+}
+
+//Reopen - clears the result of a match and any matches that depended on it
+func (c *matchClient) Reopen(tournamentID string, matchID string) error {
+	_, err := c.postRequest(c.getAPIURL()+fmt.Sprintf(matchReopenURL, tournamentID, matchID), nil)
+	return err
 }
@@ -3,13 +3,15 @@ package challonge
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
-
-	log "github.com/sirupsen/logrus"
 )
 
 
 const participantsURL = "/tournaments/%s/participants.json"
+const participantURL = "/tournaments/%s/participants/%d.json"
+const participantActionURL = "/tournaments/%s/participants/%d/%s.json"
 
 type ParticipantContainer struct {
 	Participant Participant
@@ -54,20 +56,83 @@ func getParticipantsIndexURL(t string) string {
 	return fmt.Sprintf(participantsURL, t)
 }
 
-func (c *participantsClient) Index(tournamentID string) []*ParticipantContainer {
+func (c *participantsClient) Index(tournamentID string) ([]*ParticipantContainer, error) {
 	body, err := c.getRequest(c.getAPIURL() + getParticipantsIndexURL(tournamentID))
 
 	if err != nil {
-		log.Error(err)
-		return nil
+		return nil, err
 	}
 
 	t := []*ParticipantContainer{}
 	err = json.Unmarshal([]byte(body), &t)
 	if err != nil {
-		log.Error(err)
-		return nil
+		return nil, err
+	}
+
+	return t, nil
+}
+
+type ParticipantParams struct {
+	Name string
+	Seed int
+	Misc string
+}
+
+func (p ParticipantParams) values() url.Values {
+	v := url.Values{}
+	setIfNotEmpty(v, "participant[name]", p.Name)
+	setIfNotEmpty(v, "participant[misc]", p.Misc)
+	if p.Seed != 0 {
+		v.Set("participant[seed]", strconv.Itoa(p.Seed))
 	}
+	return v
+}
 
-	return t
-}
\ No newline at end of file
+func (c *participantsClient) decode(body []byte) (*ParticipantContainer, error) {
+	p := ParticipantContainer{}
+	err := json.Unmarshal(body, &p)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (c *participantsClient) Create(tournamentID string, params ParticipantParams) (*ParticipantContainer, error) {
+	body, err := c.postRequest(c.getAPIURL()+getParticipantsIndexURL(tournamentID), params.values())
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(body)
+}
+
+//Update - changing the seed moves the other participants to make room
+func (c *participantsClient) Update(tournamentID string, participantID int, params ParticipantParams) (*ParticipantContainer, error) {
+	body, err := c.putRequest(c.getAPIURL()+fmt.Sprintf(participantURL, tournamentID, participantID), params.values())
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(body)
+}
+
+//Destroy - before the tournament starts the participant is deleted, afterwards they forfeit remaining matches
+func (c *participantsClient) Destroy(tournamentID string, participantID int) error {
+	_, err := c.deleteRequest(c.getAPIURL() + fmt.Sprintf(participantURL, tournamentID, participantID))
+	return err
+}
+
+func (c *participantsClient) action(tournamentID string, participantID int, action string) (*ParticipantContainer, error) {
+	body, err := c.postRequest(c.getAPIURL()+fmt.Sprintf(participantActionURL, tournamentID, participantID, action), url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(body)
+}
+
+//CheckIn - only works while the tournament's check in window is open
+func (c *participantsClient) CheckIn(tournamentID string, participantID int) (*ParticipantContainer, error) {
+	return c.action(tournamentID, participantID, "check_in")
+}
+
+func (c *participantsClient) UndoCheckIn(tournamentID string, participantID int) (*ParticipantContainer, error) {
+	return c.action(tournamentID, participantID, "undo_check_in")
+}
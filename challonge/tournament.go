@@ -3,12 +3,23 @@ package challonge
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
 const tournamentURL = "/tournaments/%s.json"
+const tournamentsURL = "/tournaments.json"
+const tournamentActionURL = "/tournaments/%s/%s.json"
+
+const (
+	SingleElimination = "single elimination"
+	DoubleElimination = "double elimination"
+	RoundRobin        = "round robin"
+	Swiss             = "swiss"
+)
 
 type Tournaments struct {
 	Tournament Tournament
@@ -104,3 +115,99 @@ func (c *tournamentClient) Show(ID string) *Tournaments {
 
 	return &t
 }
+
+
+type TournamentParams struct {
+	Name           string
+	TournamentType string
+	URL            string
+	GameName       string
+	Description    string
+	StartAt        *time.Time
+	//CheckInDuration - minutes before StartAt that check in opens
+	CheckInDuration int
+}
+
+func (p TournamentParams) values() url.Values {
+	v := url.Values{}
+	setIfNotEmpty(v, "tournament[name]", p.Name)
+	setIfNotEmpty(v, "tournament[tournament_type]", p.TournamentType)
+	setIfNotEmpty(v, "tournament[url]", p.URL)
+	setIfNotEmpty(v, "tournament[game_name]", p.GameName)
+	setIfNotEmpty(v, "tournament[description]", p.Description)
+	if p.StartAt != nil {
+		v.Set("tournament[start_at]", p.StartAt.Format(time.RFC3339))
+	}
+	if p.CheckInDuration != 0 {
+		v.Set("tournament[check_in_duration]", strconv.Itoa(p.CheckInDuration))
+	}
+	return v
+}
+
+func setIfNotEmpty(v url.Values, key string, value string) {
+	if value != "" {
+		v.Set(key, value)
+	}
+}
+
+func (c *tournamentClient) decode(body []byte) (*Tournaments, error) {
+	t := Tournaments{}
+	err := json.Unmarshal(body, &t)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (c *tournamentClient) Create(params TournamentParams) (*Tournaments, error) {
+	body, err := c.postRequest(c.getAPIURL()+tournamentsURL, params.values())
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(body)
+}
+
+func (c *tournamentClient) Update(ID string, params TournamentParams) (*Tournaments, error) {
+	body, err := c.putRequest(c.getAPIURL()+c.createTournamentURL(ID), params.values())
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(body)
+}
+
+func (c *tournamentClient) action(ID string, action string) (*Tournaments, error) {
+	body, err := c.postRequest(c.getAPIURL()+fmt.Sprintf(tournamentActionURL, ID, action), url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(body)
+}
+
+//Start - at least 2 participants are needed. Opens the first matches.
+func (c *tournamentClient) Start(ID string) (*Tournaments, error) {
+	return c.action(ID, "start")
+}
+
+//Reset - clears all scores and attachments, participants can be edited again
+func (c *tournamentClient) Reset(ID string) (*Tournaments, error) {
+	return c.action(ID, "reset")
+}
+
+//Finalize - all matches need scores before results can be finalized
+func (c *tournamentClient) Finalize(ID string) (*Tournaments, error) {
+	return c.action(ID, "finalize")
+}
+
+//OpenCheckIn - challonge opens check in a set time before the start, so the start is moved to the end of the window
+func (c *tournamentClient) OpenCheckIn(ID string, duration time.Duration) (*Tournaments, error) {
+	startAt := time.Now().Add(duration)
+	return c.Update(ID, TournamentParams{
+		StartAt:         &startAt,
+		CheckInDuration: int(duration / time.Minute),
+	})
+}
+
+//ProcessCheckIns - removes participants who did not check in, ready for the tournament to start
+func (c *tournamentClient) ProcessCheckIns(ID string) (*Tournaments, error) {
+	return c.action(ID, "process_check_ins")
+}
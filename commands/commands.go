@@ -1,24 +1,54 @@
 package commands
 
 const MangaNotificationString = "manga-notification"
+const MangaString = "manga"
 const RoleReactString = "react"
 const StrawPollDeadlineString = "strawpoll-deadline"
+const StrawPollString = "poll"
 const TwitterFollowListString = "twitter-follow-list"
 const TwitterFollowString = "twitter-follow"
 const TwitterUnfollowString = "twitter-unfollow"
 const EmojifyString = "emote"
+const VoteString = "vote"
 
 /*
 $tourney {link (optional?)} - done
-$add_organizer {discord_name} - done
+$add-organizer @user - done
+$remove-organizer @user - done
 $next_losers_match - done
+$next-match {optional - station} - done
+$match-queue - done
 $ammend_participant {tourney_name} {discord_name} - not rn
 $win {optional - participant}  {optional - score format "1-1"} - also sends results to person if specified (done)
-$finish_tourney - done
-$organizer-list - list organizers
+$finish_tourney {optional - --force} - done, results are archived, --force ends it without them when challonge can not be read
+$bracket - playing, upcoming and completed matches (done)
+$standings - final placements with seeds (done)
+$results {optional - id} {optional - --format csv|json} - past tournaments and their export (done)
+$player-stats {optional - @user} - sets, games and placements across archived tournaments (done)
+$head-to-head @user @user - done
+$rating {optional - @user} {optional - --game name} / $rating import {challonge url} - elo per game ladder, updated as matches are reported (done)
+$leaderboard {optional - game} - done
+$checkin open {duration} / close / status - players react to check in, reminded before it closes and no-shows removed at close (done)
+$tournament game {name} / seeds {optional - apply} - ladder for the tournament and seeding by rating before it starts (done)
+$organizer-list - done
+$tournament list / bind {name} / unbind - several tournaments per server, any command takes --tourney {name} (done)
+$tournament announce {#channel|off} - post new matches and results as they happen (done)
 */
 const TournamentCommandString = "tournament"
 const TournamentAddOrganizerString = "add-organizer"
+const TournamentRemoveOrganizerString = "remove-organizer"
+const TournamentOrganizerListString = "organizer-list"
 const TournamentNextLosersMatchString = "next-losers-match"
+const TournamentNextMatchString = "next-match"
+const TournamentMatchQueueString = "match-queue"
 const TournamentMatchWinString = "match-win"
-const TournamentFinishString = "end-tournament"
\ No newline at end of file
+const TournamentReportString = "report"
+const TournamentFinishString = "end-tournament"
+const TournamentBracketString = "bracket"
+const TournamentStandingsString = "standings"
+const TournamentResultsString = "results"
+const TournamentPlayerStatsString = "player-stats"
+const TournamentHeadToHeadString = "head-to-head"
+const TournamentRatingString = "rating"
+const TournamentLeaderboardString = "leaderboard"
+const TournamentCheckInString = "checkin"
\ No newline at end of file
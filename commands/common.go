@@ -2,7 +2,9 @@ package commands
 
 import (
 	"discordbot/challonge"
+	"discordbot/strawpoll"
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -18,8 +20,30 @@ var log = &logrus.Logger{
 }
 
 type challongeClient interface {
-	GetParticipants(tourneyID string) []challonge.Participant
-	GetMatches(tourneyID string) []challonge.Match
+	GetParticipants(tourneyID string) ([]challonge.Participant, error)
+	GetMatches(tourneyID string) ([]challonge.Match, error)
 	GetMatch(tourneyID string, matchID int) challonge.Match
-	UpdateMatch(tourneyID string, matchID int, params challonge.MatchQueryParams)
+	UpdateMatch(tourneyID string, matchID int, params challonge.MatchQueryParams) error
+	ReopenMatch(tourneyID string, matchID int) error
+	CreateTournament(params challonge.TournamentParams) (challonge.Tournament, error)
+	StartTournament(tourneyID string) error
+	ResetTournament(tourneyID string) error
+	FinalizeTournament(tourneyID string) error
+	OpenCheckIn(tourneyID string, duration time.Duration) error
+	AddParticipant(tourneyID string, name string) (challonge.Participant, error)
+	RemoveParticipant(tourneyID string, participantID int) error
+	SeedParticipant(tourneyID string, participantID int, seed int) error
+	CheckInParticipant(tourneyID string, participantID int) error
+	UndoCheckInParticipant(tourneyID string, participantID int) error
+	ProcessCheckIns(tourneyID string) error
+}
+
+//httpFetcher - the shared rate limited fetcher downloads go through
+type httpFetcher interface {
+	Get(link string) ([]byte, error)
+}
+
+type strawpollClient interface {
+	strawpoll.StrawPollGetClient
+	strawpoll.StrawPollCreateClient
 }
\ No newline at end of file
@@ -2,6 +2,9 @@ package commands_test
 
 import (
 	"discordbot/commands"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/andersfylling/disgord"
 )
@@ -14,32 +17,97 @@ type mockSession struct {
 	message          string
 	reactedMessageID commands.Snowflake
 	guild            commands.Guild
+	reactions        []interface{}
+	removedReactions []interface{}
+	sentMessages     []string
+	editedMessages   map[commands.Snowflake]string
+	pinnedMessages   []commands.Snowflake
+	sentParams       []*disgord.CreateMessageParams
+	directMessages   map[commands.Snowflake][]string
+	channel          *mockChannel
 }
 
 func (s *mockSession) SendSimpleMessage(channel commands.Snowflake, m string) (*disgord.Message, error) {
 	s.message = m
-	return nil, nil
+	s.sentMessages = append(s.sentMessages, m)
+	return &disgord.Message{ID: 999, ChannelID: channel, Content: m}, nil
+}
+
+func (s *mockSession) SendDirectMessage(user commands.Snowflake, m string) (*disgord.Message, error) {
+	if s.directMessages == nil {
+		s.directMessages = make(map[commands.Snowflake][]string)
+	}
+	s.directMessages[user] = append(s.directMessages[user], m)
+	return &disgord.Message{ID: 998, Content: m}, nil
+}
+
+func (s *mockSession) EditMessage(channel commands.Snowflake, msg commands.Snowflake, m string) (*disgord.Message, error) {
+	if s.editedMessages == nil {
+		s.editedMessages = make(map[commands.Snowflake]string)
+	}
+	s.editedMessages[msg] = m
+	return &disgord.Message{ID: msg, ChannelID: channel, Content: m}, nil
+}
+
+func (s *mockSession) PinMessage(channel commands.Snowflake, msg commands.Snowflake) error {
+	s.pinnedMessages = append(s.pinnedMessages, msg)
+	return nil
 }
 
 func (s *mockSession) ReactToMessage(msg commands.Snowflake, channel commands.Snowflake, emoji interface{}) {
 	s.reactedMessageID = msg
+	s.reactions = append(s.reactions, emoji)
+}
+
+func (s *mockSession) RemoveUserReaction(msg commands.Snowflake, channel commands.Snowflake, emoji interface{}, user commands.Snowflake) {
+	s.removedReactions = append(s.removedReactions, emoji)
 }
 
 func (s *mockSession) getReactedMessage() commands.Snowflake {
 	return s.reactedMessageID
 }
 
-func (s *mockSession) CurrentUser() (*disgord.User, error)        { return nil, nil }
+func (s *mockSession) CurrentUser() (*disgord.User, error)        { return &disgord.User{ID: mockBotID}, nil }
 func (s *mockSession) Guild(id commands.Snowflake) commands.Guild { return s.guild }
 func (s *mockSession) ReactWithThumbsDown(*disgord.Message) {}
 func (s *mockSession) ReactWithThumbsUp(*disgord.Message) {}
-func (s *mockSession) Channel(commands.Snowflake) commands.Channel {return nil}
-func (s *mockSession) SendMessage(channel commands.Snowflake, params *disgord.CreateMessageParams) (*disgord.Message, error) {return nil, nil}
+func (s *mockSession) Channel(commands.Snowflake) commands.Channel {
+	if s.channel == nil {
+		return nil
+	}
+	return s.channel
+}
+func (s *mockSession) SendMessage(channel commands.Snowflake, params *disgord.CreateMessageParams) (*disgord.Message, error) {
+	s.sentParams = append(s.sentParams, params)
+	return nil, nil
+}
 
 type mockGuild struct {
 	channels []*disgord.Channel
 	roles    []*disgord.Role
 	emojis   []*disgord.Emoji
+	members  []*disgord.Member
+	info     *disgord.Guild
+	created  []*disgord.CreateGuildEmojiParams
+
+	//createErr - discord refusing new emoji
+	createErr error
+}
+
+//mockBotID - the user the mock session is logged in as
+const mockBotID commands.Snowflake = 4
+
+//mockMember - only Get is used by the commands
+type mockMember struct {
+	disgord.GuildMemberQueryBuilder
+	member *disgord.Member
+}
+
+func (m *mockMember) Get() (*disgord.Member, error) {
+	if m.member == nil {
+		return nil, errors.New("unknown member")
+	}
+	return m.member, nil
 }
 
 var commonMockGuild = mockGuild{
@@ -66,6 +134,13 @@ var commonMockGuild = mockGuild{
 	},
 }
 
+func (g *mockGuild) Get() (*disgord.Guild, error) {
+	if g.info == nil {
+		return &disgord.Guild{}, nil
+	}
+	return g.info, nil
+}
+
 func (g *mockGuild) GetChannels() ([]*disgord.Channel, error) {
 	return g.channels, nil
 }
@@ -78,6 +153,68 @@ func (g *mockGuild) GetEmojis() ([]*disgord.Emoji, error) {
 	return g.emojis, nil
 }
 
+func (g *mockGuild) CreateEmoji(params *disgord.CreateGuildEmojiParams) (*disgord.Emoji, error) {
+	if g.createErr != nil {
+		return nil, g.createErr
+	}
+	g.created = append(g.created, params)
+	return &disgord.Emoji{ID: 900, Name: params.Name, Animated: strings.HasPrefix(params.Image, "data:image/gif")}, nil
+}
+
+func (g *mockGuild) GetMembers(params *disgord.GetMembersParams) ([]*disgord.Member, error) {
+	return g.members, nil
+}
+
 func (g *mockGuild) Member(userID commands.Snowflake) disgord.GuildMemberQueryBuilder {
+	for _, m := range g.members {
+		if m.UserID == userID {
+			return &mockMember{member: m}
+		}
+	}
+	return &mockMember{}
+}
+
+//mockChannel - messages are newest first, the way discord returns them
+type mockChannel struct {
+	messages []*disgord.Message
+	deleted  []commands.Snowflake
+}
+
+func (c *mockChannel) GetMessages(params *disgord.GetMessagesParams) ([]*disgord.Message, error) {
+	var result []*disgord.Message
+	for _, m := range c.messages {
+		if (params.Before == 0 || m.ID < params.Before) && uint(len(result)) < params.Limit {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+func (c *mockChannel) DeleteMessages(params *disgord.DeleteMessagesParams) error {
+	return nil
+}
+
+func (c *mockChannel) Message(id commands.Snowflake) disgord.MessageQueryBuilder {
+	return &mockMessageQuery{channel: c, id: id}
+}
+
+//mockMessageQuery - only what the commands use, anything else panics
+type mockMessageQuery struct {
+	disgord.MessageQueryBuilder
+	channel *mockChannel
+	id      commands.Snowflake
+}
+
+func (q *mockMessageQuery) Get() (*disgord.Message, error) {
+	for _, m := range q.channel.messages {
+		if m.ID == q.id {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("no message %v", q.id)
+}
+
+func (q *mockMessageQuery) Delete() error {
+	q.channel.deleted = append(q.channel.deleted, q.id)
 	return nil
 }
@@ -11,7 +11,11 @@ const discordEmojiCDN = "https://cdn.discordapp.com/emojis/"
 type DiscordSession interface {
 	SendMessage(Snowflake, *disgord.CreateMessageParams) (*disgord.Message, error)
 	SendSimpleMessage(Snowflake, string) (*disgord.Message, error)
+	SendDirectMessage(user Snowflake, msg string) (*disgord.Message, error)
+	EditMessage(channel Snowflake, msg Snowflake, content string) (*disgord.Message, error)
+	PinMessage(channel Snowflake, msg Snowflake) error
 	ReactToMessage(msg Snowflake, channel Snowflake, emoji interface{})
+	RemoveUserReaction(msg Snowflake, channel Snowflake, emoji interface{}, user Snowflake)
 	ReactWithThumbsDown(*disgord.Message)
 	ReactWithThumbsUp(*disgord.Message)
 	CurrentUser() (*disgord.User, error)
@@ -31,14 +35,34 @@ func (s *simpleDiscordSession) SendSimpleMessage(channel Snowflake, msg string)
 	return s.disgordSession.WithContext(context.Background()).SendMsg(channel, createSimpleDisgordMessage(msg))
 }
 
+func (s *simpleDiscordSession) SendDirectMessage(user Snowflake, msg string) (*disgord.Message, error) {
+	channel, err := s.disgordSession.User(user).WithContext(context.Background()).CreateDM()
+	if err != nil {
+		return nil, err
+	}
+	return s.SendSimpleMessage(channel.ID, msg)
+}
+
 func (s *simpleDiscordSession) SendMessage(channel Snowflake, params *disgord.CreateMessageParams) (*disgord.Message, error) {
 	return s.disgordSession.WithContext(context.Background()).SendMsg(channel, params)
 }
 
+func (s *simpleDiscordSession) EditMessage(channel Snowflake, msg Snowflake, content string) (*disgord.Message, error) {
+	return s.disgordSession.Channel(channel).Message(msg).WithContext(context.Background()).SetContent(content)
+}
+
+func (s *simpleDiscordSession) PinMessage(channel Snowflake, msg Snowflake) error {
+	return s.disgordSession.Channel(channel).Message(msg).WithContext(context.Background()).Pin()
+}
+
 func (s *simpleDiscordSession) ReactToMessage(msg Snowflake, channel Snowflake, emoji interface{}) {
 	s.disgordSession.Channel(channel).Message(msg).Reaction(emoji).WithContext(context.Background()).Create()
 }
 
+func (s *simpleDiscordSession) RemoveUserReaction(msg Snowflake, channel Snowflake, emoji interface{}, user Snowflake) {
+	s.disgordSession.Channel(channel).Message(msg).Reaction(emoji).WithContext(context.Background()).DeleteUser(user)
+}
+
 func (s *simpleDiscordSession) ReactWithThumbsDown(msg *disgord.Message) {
 	s.ReactToMessage(msg.ID, msg.ChannelID, "👎")
 }
@@ -66,9 +90,12 @@ func createSimpleDisgordMessage(m string) *disgord.CreateMessageParams {
 }
 
 type Guild interface {
+	Get() (*disgord.Guild, error)
 	GetChannels() ([]*disgord.Channel, error)
 	GetRoles() ([]*disgord.Role, error)
 	GetEmojis() ([]*disgord.Emoji, error)
+	CreateEmoji(params *disgord.CreateGuildEmojiParams) (*disgord.Emoji, error)
+	GetMembers(params *disgord.GetMembersParams) ([]*disgord.Member, error)
 	Member(userID Snowflake) disgord.GuildMemberQueryBuilder
 }
 
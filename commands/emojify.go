@@ -2,22 +2,17 @@ package commands
 
 import (
 	"bytes"
-	"image"
-	"image/gif"
-	"image/png"
-	"io"
-	"math/rand"
+	"discordbot/emote"
 	"regexp"
-	"strings"
 
 	"github.com/andersfylling/disgord"
-	"github.com/disintegration/gift"
 )
 
 const discordEmojiFormat = "<a?:[0-9a-zA-Z_]+:\\d+>"
 
 type emojifyCommandFactory struct {
 	session     DiscordSession
+	fetcher     httpFetcher
 	emojiParser *regexp.Regexp
 }
 
@@ -25,192 +20,125 @@ type emojifyCommand struct {
 	*emojifyCommandFactory
 	msg  *disgord.MessageCreate
 	user *Users
+	//save - upload the result as a custom emoji, named saveName or after the image
+	save     bool
+	saveName string
 }
 
 func (c *emojifyCommandFactory) CreateRequest(data *disgord.MessageCreate, user *Users) interface{} {
 	return &emojifyCommand{
-		c,
-		data,
-		user,
+		emojifyCommandFactory: c,
+		msg:                   data,
+		user:                  user,
 	}
 }
 
-func NewEmojifyCommandFactory(s DiscordSession) *emojifyCommandFactory {
+func NewEmojifyCommandFactory(s DiscordSession, f httpFetcher) *emojifyCommandFactory {
 	r, _ := regexp.Compile(discordEmojiFormat)
 	return &emojifyCommandFactory{
 		session:     s,
+		fetcher:     f,
 		emojiParser: r,
 	}
 }
 
+func (c *emojifyCommandFactory) PrintHelp() string {
+	return CommandPrefix + EmojifyString + " {emoji|sticker|image|link|mention} {filters} - Posts the image, or the one replied to or last posted, bigger with filters applied in order, like blur(5) hue(-45) flip resize(3x). Filters: " + emote.Usage() +
+		". Effects that animate the emoji: " + emote.EffectUsage() + ". " +
+		CommandPrefix + EmojifyString + " combine {side|stack|overlay} {2 to 4 images} {filters} - Puts the images side by side, stacked or over the first, animated ones keep playing. " +
+		CommandPrefix + EmojifyString + " caption \"top text\" \"bottom text\" {image} {filters} - Writes meme text over the image, a single quote goes along the bottom. Add --save {name} to any of these to keep the result as a server emoji."
+}
+
 func (c *emojifyCommand) ExecuteMessageCreateCommand() {
-	var emojiString, emoteArgs string
-	emojiIndex := c.emojiParser.FindStringIndex(c.msg.Message.Content)
-	if emojiIndex != nil {
-		emojiString = c.msg.Message.Content[emojiIndex[0] : emojiIndex[1]-1]
-		emoteArgs = c.msg.Message.Content[emojiIndex[1]:]
-		err := c.session.Channel(c.msg.Message.ChannelID).Message(c.msg.Message.ID).Delete()
-		if err != nil {
-			log.Error(err)
+	msg := c.msg.Message
+	content, saveName, save := takeEmojiName(msg.Content)
+	c.save, c.saveName = save, saveName
+	if c.save && !c.checkCanSaveEmoji(msg) {
+		return
+	}
+	mode, content := emoteMode(content)
+	if mode == emoteCombine {
+		c.combine(msg, content)
+		return
+	}
+	var top, bottom string
+	if mode == emoteCaption {
+		content, top, bottom = captionTexts(content)
+		if top == "" && bottom == "" {
+			c.session.SendSimpleMessage(msg.ChannelID, "Write the caption in quotes, like "+CommandPrefix+EmojifyString+" caption \"top text\" \"bottom text\" :emoji:")
+			return
 		}
-	} else {
-		params := disgord.GetMessagesParams{Before: c.msg.Message.ID, Limit: 1}
-		msgs, err := c.session.Channel(c.msg.Message.ChannelID).GetMessages(&params)
-		
+	}
+
+	source, emoteArgs := c.sourceInCommand(msg, content)
+	inCommand := source != nil
+	if source == nil {
+		source = c.sourceInMessage(msg)
+	}
+
+	//Checked before anything else so a typo does not cost the user their message
+	pipeline, err := emote.Parse(emoteArgs)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not apply filters: "+err.Error())
+		return
+	}
+
+	if source == nil {
+		source, err = c.sourceBefore(msg)
 		if err != nil {
 			log.Error(err)
-			c.session.ReactWithThumbsDown(c.msg.Message)
-			return
-		}
-		index := c.emojiParser.FindStringIndex(msgs[0].Content)
-		if index == nil {
-			log.Error("Emoji string not found in content ", msgs[0].Content)
-			c.session.ReactWithThumbsDown(c.msg.Message)
+			c.session.ReactWithThumbsDown(msg)
 			return
 		}
-		emojiString = msgs[0].Content[index[0] : index[1]-1]
-		emoteArgs = c.msg.Message.Content
-	}
-	
-	firstColon := strings.Index(emojiString, ":")
-	lastColon := strings.LastIndex(emojiString, ":")
-	//Get is animated
-	extension := ".png"
-	if emojiString[1:firstColon] == "a" {
-		extension = ".gif"
-	}
-	//Get emoji name
-	emojiName := emojiString[firstColon+1 : lastColon]
-	//Get Emoji ID
-	emojiId := emojiString[lastColon+1:]
-
-	response := doHttpGetRequest(discordEmojiCDN + string(emojiId) + extension)
-	var emojiFilters []func(image.Image) gift.Filter
-	if len(emoteArgs) > 0 {
-		emojiFilters = append(emojiFilters, parseArguments(emoteArgs)...)
-	}
-	emojiFilters = append(emojiFilters,
-		func(img image.Image) gift.Filter {
-			return gift.Resize(img.Bounds().Max.X*2, img.Bounds().Max.Y*2, gift.BoxResampling)
-		})
-
-	var reader io.Reader
-	var err error
-	if extension == ".png" {
-		reader, err = filterPng(response, emojiFilters)
-	} else if extension == ".gif" {
-		reader, err = filterGif(response, emojiFilters)
 	}
+	if source == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "No image found. Use an emoji, sticker, image, link or mention, or reply to a message with one.")
+		return
+	}
+	//The result takes the place of an emoji or link, attachments are kept
+	if inCommand {
+		c.deleteCommand(msg)
+	}
+
+	body, err := c.fetcher.Get(source.link)
 	if err != nil {
 		log.Error(err)
-		c.session.ReactWithThumbsDown(c.msg.Message)
+		c.session.ReactWithThumbsDown(msg)
+		return
 	}
-	fileMsg := disgord.CreateMessageFileParams{
-		Reader:     reader,
-		FileName:   emojiName + extension,
-		SpoilerTag: false,
+	result, extension, err := pipeline.Render(body)
+	if err == nil && mode == emoteCaption {
+		result, extension, err = emote.Caption(result, top, bottom)
 	}
-	_, err = c.session.SendMessage(c.msg.Message.ChannelID, &disgord.CreateMessageParams{Files: []disgord.CreateMessageFileParams{fileMsg}})
 	if err != nil {
 		log.Error(err)
-		c.session.ReactWithThumbsDown(c.msg.Message)
+		c.session.ReactWithThumbsDown(msg)
 		return
 	}
+	c.sendEmote(msg, source.name, extension, result)
 }
 
-func parseArguments(args string) []func(image.Image) gift.Filter {
-	var fs []func(image.Image) gift.Filter
-	for _, s := range strings.ToLower(args) {
-		switch s {
-		case 'p':
-			f := func(img image.Image) gift.Filter { return gift.Pixelate(5) }
-			fs = append(fs, f)
-		case 'i':
-			f := func(img image.Image) gift.Filter { return gift.Invert() }
-			fs = append(fs, f)
-		case 'r':
-			f := func(img image.Image) gift.Filter { return gift.Rotate90() }
-			fs = append(fs, f)
-		case 'b':
-			f := func(img image.Image) gift.Filter { return gift.GaussianBlur(3) }
-			fs = append(fs, f)
-		case 'h':
-			f := func(img image.Image) gift.Filter { return gift.Hue(90) }
-			fs = append(fs, f)
-		case 'c':
-			f := func(img image.Image) gift.Filter {
-				return gift.CropToSize(rand.Intn(img.Bounds().Max.X), rand.Intn(img.Bounds().Max.Y), randomAnchor())
-			}
-			fs = append(fs, f)
-		}
+func (c *emojifyCommand) deleteCommand(msg *disgord.Message) {
+	err := c.session.Channel(msg.ChannelID).Message(msg.ID).Delete()
+	if err != nil {
+		log.Error(err)
 	}
-	return fs
 }
 
-func randomAnchor() gift.Anchor {
-	switch rand.Intn(9) {
-	case 0:
-		return gift.CenterAnchor
-	case 1:
-		return gift.TopLeftAnchor
-	case 2:
-		return gift.TopAnchor
-	case 3:
-		return gift.TopRightAnchor
-	case 4:
-		return gift.LeftAnchor
-	case 5:
-		return gift.RightAnchor
-	case 6:
-		return gift.BottomLeftAnchor
-	case 7:
-		return gift.BottomAnchor
-	case 8:
-		return gift.BottomRightAnchor
-	}
-	return gift.CenterAnchor
-}
-
-func filterPng(src io.Reader, fs []func(image.Image) gift.Filter) (io.Reader, error) {
-	img, _, err := image.Decode(src)
-	giftFilter := gift.New()
-	for _, f := range fs {
-		giftFilter.Add(f(img))
-	}
-	destinationImg := image.NewRGBA(giftFilter.Bounds(img.Bounds()))
-	giftFilter.Draw(destinationImg, img)
-	if err != nil {
-		return nil, err
+//sendEmote posts the result, then keeps it as an emoji when asked to
+func (c *emojifyCommand) sendEmote(msg *disgord.Message, name string, extension string, result []byte) {
+	fileMsg := disgord.CreateMessageFileParams{
+		Reader:     bytes.NewReader(result),
+		FileName:   name + extension,
+		SpoilerTag: false,
 	}
-	buff := new(bytes.Buffer)
-	err = png.Encode(buff, destinationImg)
+	_, err := c.session.SendMessage(msg.ChannelID, &disgord.CreateMessageParams{Files: []disgord.CreateMessageFileParams{fileMsg}})
 	if err != nil {
-		return nil, err
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
 	}
-	return bytes.NewReader(buff.Bytes()), nil
-}
-
-func filterGif(src io.Reader, fs []func(image.Image) gift.Filter) (io.Reader, error) {
-	srcGif, err := gif.DecodeAll(src)
-	if err != nil {
-		return nil, err
-	}
-	giftFilter := gift.New()
-	for _, f := range fs {
-		giftFilter.Add(f(srcGif.Image[0]))
-	}
-	srcGif.Config.Height = giftFilter.Bounds(srcGif.Image[0].Rect).Max.Y
-	srcGif.Config.Width = giftFilter.Bounds(srcGif.Image[0].Rect).Max.X
-	for i := range srcGif.Image {
-		frame := srcGif.Image[i]
-		dst := image.NewPaletted(giftFilter.Bounds(frame.Bounds()), frame.Palette)
-		giftFilter.Draw(dst, frame)
-		srcGif.Image[i] = dst
-	}
-	buff := new(bytes.Buffer)
-	err = gif.EncodeAll(buff, srcGif)
-	if err != nil {
-		return nil, err
+	if c.save {
+		c.saveEmoji(msg, name, result)
 	}
-	return bytes.NewReader(buff.Bytes()), nil
 }
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"discordbot/emote"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const (
+	emoteCombine = "combine"
+	emoteCaption = "caption"
+)
+
+//captionQuote - text in straight or curly quotes, as phones write them
+var captionQuote = regexp.MustCompile(`["“”]([^"“”]*)["“”]`)
+
+//emoteMode reads combine or caption from the start of the command, the rest is left for sources and filters
+func emoteMode(content string) (string, string) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return "", content
+	}
+	mode := strings.ToLower(fields[0])
+	if mode != emoteCombine && mode != emoteCaption {
+		return "", content
+	}
+	return mode, strings.TrimPrefix(strings.TrimSpace(content), fields[0])
+}
+
+//captionTexts takes the first quoted text as the top caption and the second as the bottom.
+//A single quote goes along the bottom, as most memes have it.
+func captionTexts(content string) (string, string, string) {
+	quotes := captionQuote.FindAllStringSubmatchIndex(content, 2)
+	var texts []string
+	for i := len(quotes) - 1; i >= 0; i-- {
+		q := quotes[i]
+		texts = append([]string{content[q[2]:q[3]]}, texts...)
+		content = content[:q[0]] + " " + content[q[1]:]
+	}
+	switch len(texts) {
+	case 0:
+		return content, "", ""
+	case 1:
+		return content, "", texts[0]
+	}
+	return content, texts[0], texts[1]
+}
+
+//combineLayout takes the first layout named in the command, side by side when there is none
+func combineLayout(content string) (string, string) {
+	fields := strings.Fields(content)
+	for i, field := range fields {
+		for _, layout := range emote.Layouts {
+			if strings.EqualFold(field, layout) {
+				return layout, strings.Join(append(fields[:i:i], fields[i+1:]...), " ")
+			}
+		}
+	}
+	return emote.LayoutSide, content
+}
+
+//combine draws every image in the command, and those attached, into one
+func (c *emojifyCommand) combine(msg *disgord.Message, content string) {
+	sources, rest := c.sourcesInCommand(msg, content)
+	inCommand := len(sources) > 0
+	sources = append(sources, attachedImages(msg)...)
+	layout, rest := combineLayout(rest)
+
+	pipeline, err := emote.Parse(rest)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not apply filters: "+err.Error())
+		return
+	}
+	//Combine already fits the result in an upload, scaling it up again could not
+	pipeline.KeepSize()
+	if len(sources) < 2 || len(sources) > emote.MaxCombined {
+		c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("Combine takes 2 to %d emoji, images, links or mentions, got %d", emote.MaxCombined, len(sources)))
+		return
+	}
+	if inCommand {
+		c.deleteCommand(msg)
+	}
+
+	var bodies [][]byte
+	var names []string
+	for _, source := range sources {
+		body, err := c.fetcher.Get(source.link)
+		if err != nil {
+			log.Error(err)
+			c.session.ReactWithThumbsDown(msg)
+			return
+		}
+		bodies = append(bodies, body)
+		names = append(names, source.name)
+	}
+	combined, _, err := emote.Combine(layout, bodies...)
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+	result, extension, err := pipeline.Render(combined)
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+	c.sendEmote(msg, strings.Join(names, "_"), extension, result)
+}
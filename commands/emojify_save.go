@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"discordbot/emote"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const emojiNameLength = 32
+
+//emoteSaveFlag - --save with an optional emoji name, a name is only taken when it could be one
+var emoteSaveFlag = regexp.MustCompile(`(?:^|\s)--save(?:\s+(\w+))?(?:\s|$)`)
+
+var emojiNameCharacters = regexp.MustCompile(`[^0-9A-Za-z_]+`)
+
+//emojiSlots - custom emoji a server can have at each boost level, still and animated are counted apart
+var emojiSlots = map[disgord.PremiumTier]int{
+	disgord.PremiumTierNone: 50,
+	disgord.PremiumTier1:    100,
+	disgord.PremiumTier2:    150,
+	disgord.PremiumTier3:    250,
+}
+
+//takeEmojiName pulls "--save name" out of a command, saying whether it was there
+func takeEmojiName(content string) (string, string, bool) {
+	m := emoteSaveFlag.FindStringSubmatchIndex(content)
+	if m == nil {
+		return content, "", false
+	}
+	name := ""
+	if m[2] >= 0 {
+		name = content[m[2]:m[3]]
+	}
+	return content[:m[0]] + " " + content[m[1]:], name, true
+}
+
+//emojiName makes a file name into one discord takes for an emoji
+func emojiName(name string) string {
+	name = emojiNameCharacters.ReplaceAllString(name, "_")
+	if len(name) > emojiNameLength {
+		name = name[:emojiNameLength]
+	}
+	if len(strings.Trim(name, "_")) < 2 {
+		return "emote"
+	}
+	return name
+}
+
+//checkCanSaveEmoji tells the user why the result can not be kept before any work is done
+func (c *emojifyCommand) checkCanSaveEmoji(msg *disgord.Message) bool {
+	if msg.GuildID.IsZero() {
+		c.session.SendSimpleMessage(msg.ChannelID, "Emoji can only be saved in a server.")
+		return false
+	}
+	if len(c.saveName) > emojiNameLength {
+		c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("Emoji names are 2 to %d letters, numbers or underscores.", emojiNameLength))
+		return false
+	}
+	guild := c.session.Guild(msg.GuildID)
+	info, err := guild.Get()
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return false
+	}
+	roles, err := guild.GetRoles()
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return false
+	}
+	var author Snowflake
+	if msg.Author != nil {
+		author = msg.Author.ID
+	}
+	if !hasGuildPermission(info, roles, author, msg.Member, disgord.PermissionManageEmojis) {
+		c.session.SendSimpleMessage(msg.ChannelID, "You need the Manage Emojis permission to save emoji.")
+		return false
+	}
+
+	bot, err := c.session.CurrentUser()
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return false
+	}
+	member, err := guild.Member(bot.ID).Get()
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return false
+	}
+	if !hasGuildPermission(info, roles, bot.ID, member, disgord.PermissionManageEmojis) {
+		c.session.SendSimpleMessage(msg.ChannelID, "I need the Manage Emojis permission to save emoji.")
+		return false
+	}
+	return true
+}
+
+//saveEmoji uploads the result as a custom emoji, made small enough and checked against the free slots
+func (c *emojifyCommand) saveEmoji(msg *disgord.Message, name string, result []byte) {
+	if c.saveName != "" {
+		name = c.saveName
+	}
+	name = emojiName(name)
+
+	fitted, extension, err := emote.FitEmoji(result)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not save the emoji: "+err.Error())
+		return
+	}
+	animated := extension == emote.ExtensionGIF
+
+	guild := c.session.Guild(msg.GuildID)
+	info, err := guild.Get()
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+	emojis, err := guild.GetEmojis()
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+	used := 0
+	for _, e := range emojis {
+		if e.Animated == animated {
+			used++
+		}
+	}
+	if slots := emojiSlots[info.PremiumTier]; used >= slots {
+		kind := "still"
+		if animated {
+			kind = "animated"
+		}
+		c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("No room to save the emoji, this server uses all %d of its %s emoji slots.", slots, kind))
+		return
+	}
+
+	emoji, err := guild.CreateEmoji(&disgord.CreateGuildEmojiParams{
+		Name:   name,
+		Image:  "data:image/" + strings.TrimPrefix(extension, ".") + ";base64," + base64.StdEncoding.EncodeToString(fitted),
+		Reason: "Saved with " + CommandPrefix + EmojifyString,
+	})
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not save the emoji: "+err.Error())
+		return
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, "Saved "+emoji.Mention()+" as :"+emoji.Name+":")
+}
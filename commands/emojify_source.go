@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const (
+	discordStickerCDN = "https://media.discordapp.net/stickers/"
+	//emoteHistoryLength - how many earlier messages are looked through for an image
+	emoteHistoryLength = 20
+	emoteAvatarSize    = 128
+	//stickerFormatGIF - not yet known to disgord
+	stickerFormatGIF disgord.MessageStickerFormatType = 4
+)
+
+var emoteImageExtensions = []string{".png", ".gif", ".jpg", ".jpeg", ".webp"}
+
+//emoteSource - an image to filter, named for the file posted back
+type emoteSource struct {
+	name string
+	link string
+}
+
+//sourceInCommand finds an emoji, image link or user mention written in the command, returning the rest as filters
+func (c *emojifyCommand) sourceInCommand(msg *disgord.Message, content string) (*emoteSource, string) {
+	if index := c.emojiParser.FindStringIndex(content); index != nil {
+		return emojiSource(content[index[0]:index[1]]), content[:index[0]] + content[index[1]:]
+	}
+
+	fields := strings.Fields(content)
+	for i, field := range fields {
+		rest := strings.Join(append(append([]string{}, fields[:i]...), fields[i+1:]...), " ")
+		if u, err := url.Parse(field); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			return &emoteSource{name: imageName(u.Path), link: field}, rest
+		}
+		if ID, ok := parseUserMention(field); ok {
+			for _, m := range msg.Mentions {
+				if m.ID == ID {
+					return avatarSource(m), rest
+				}
+			}
+		}
+	}
+	return nil, content
+}
+
+//sourcesInCommand takes every emoji, link and mention from the command in order
+func (c *emojifyCommand) sourcesInCommand(msg *disgord.Message, content string) ([]*emoteSource, string) {
+	var sources []*emoteSource
+	for {
+		source, rest := c.sourceInCommand(msg, content)
+		if source == nil {
+			break
+		}
+		sources = append(sources, source)
+		content = rest
+	}
+	return sources, content
+}
+
+func attachedImages(msg *disgord.Message) []*emoteSource {
+	var sources []*emoteSource
+	for _, a := range msg.Attachments {
+		if isImageLink(a.Filename) {
+			sources = append(sources, &emoteSource{name: imageName(a.Filename), link: a.URL})
+		}
+	}
+	return sources
+}
+
+//sourceInMessage finds an image posted with a message, the emoji in it or a link to an image
+func (c *emojifyCommand) sourceInMessage(msg *disgord.Message) *emoteSource {
+	if msg == nil {
+		return nil
+	}
+	if attached := attachedImages(msg); len(attached) > 0 {
+		return attached[0]
+	}
+	for _, s := range msg.StickerItems {
+		switch s.FormatType {
+		case disgord.MessageStickerFormatPNG, disgord.MessageStickerFormatAPNG:
+			return &emoteSource{name: s.Name, link: discordStickerCDN + s.ID.String() + ".png"}
+		case stickerFormatGIF:
+			return &emoteSource{name: s.Name, link: discordStickerCDN + s.ID.String() + ".gif"}
+		}
+	}
+	if index := c.emojiParser.FindStringIndex(msg.Content); index != nil {
+		return emojiSource(msg.Content[index[0]:index[1]])
+	}
+	for _, e := range msg.Embeds {
+		if e.Image != nil && e.Image.URL != "" {
+			return &emoteSource{name: imageName(e.Image.URL), link: e.Image.URL}
+		}
+		if e.Thumbnail != nil && e.Thumbnail.URL != "" {
+			return &emoteSource{name: imageName(e.Thumbnail.URL), link: e.Thumbnail.URL}
+		}
+	}
+	for _, field := range strings.Fields(msg.Content) {
+		if u, err := url.Parse(field); err == nil && u.Host != "" && isImageLink(u.Path) {
+			return &emoteSource{name: imageName(u.Path), link: field}
+		}
+	}
+	return nil
+}
+
+//sourceBefore looks at the message replied to, then back through the channel for the newest image
+func (c *emojifyCommand) sourceBefore(msg *disgord.Message) (*emoteSource, error) {
+	if msg.MessageReference != nil {
+		replied := msg.ReferencedMessage
+		if replied == nil {
+			var err error
+			replied, err = c.session.Channel(msg.ChannelID).Message(msg.MessageReference.MessageID).Get()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return c.sourceInMessage(replied), nil
+	}
+
+	params := disgord.GetMessagesParams{Before: msg.ID, Limit: emoteHistoryLength}
+	msgs, err := c.session.Channel(msg.ChannelID).GetMessages(&params)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		if s := c.sourceInMessage(m); s != nil {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+//emojiSource reads <:name:id> or <a:name:id>
+func emojiSource(emoji string) *emoteSource {
+	emoji = strings.TrimSuffix(emoji, ">")
+	firstColon := strings.Index(emoji, ":")
+	lastColon := strings.LastIndex(emoji, ":")
+	extension := ".png"
+	if emoji[1:firstColon] == "a" {
+		extension = ".gif"
+	}
+	return &emoteSource{name: emoji[firstColon+1 : lastColon], link: discordEmojiCDN + emoji[lastColon+1:] + extension}
+}
+
+func avatarSource(u *disgord.User) *emoteSource {
+	link, _ := u.AvatarURL(emoteAvatarSize, true)
+	return &emoteSource{name: u.Username, link: link}
+}
+
+func isImageLink(link string) bool {
+	extension := strings.ToLower(path.Ext(link))
+	for _, e := range emoteImageExtensions {
+		if extension == e {
+			return true
+		}
+	}
+	return false
+}
+
+//imageName is the file name without its extension, or emote when there is none
+func imageName(link string) string {
+	name := strings.TrimSuffix(path.Base(link), path.Ext(link))
+	if name == "" || name == "." || name == "/" {
+		return "emote"
+	}
+	return name
+}
@@ -0,0 +1,378 @@
+package commands_test
+
+import (
+	"bytes"
+	"discordbot/commands"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+)
+
+//mockFetcher - serves the same image for every link
+type mockFetcher struct {
+	body  []byte
+	links []string
+}
+
+func (f *mockFetcher) Get(link string) ([]byte, error) {
+	f.links = append(f.links, link)
+	if f.body == nil {
+		return nil, fmt.Errorf("not found %s", link)
+	}
+	return f.body, nil
+}
+
+func testEmojiImage(encode func(*bytes.Buffer, image.Image) error) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := 0; i < 8; i++ {
+		img.Set(i, i, color.NRGBA{R: 255, A: 255})
+	}
+	buff := new(bytes.Buffer)
+	encode(buff, img)
+	return buff.Bytes()
+}
+
+func encodeTestPNG(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) }
+
+func encodeTestJPEG(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) }
+
+func runEmojify(msg *disgord.Message, earlier []*disgord.Message, body []byte) (*mockSession, *mockFetcher) {
+	s := &mockSession{channel: &mockChannel{messages: append([]*disgord.Message{msg}, earlier...)}}
+	f := &mockFetcher{body: body}
+	commands.NewEmojifyCommandFactory(s, f).CreateRequest(&disgord.MessageCreate{Message: msg}, &commands.Users{}).(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s, f
+}
+
+func sentFileName(s *mockSession) string {
+	if len(s.sentParams) != 1 || len(s.sentParams[0].Files) != 1 {
+		return ""
+	}
+	return s.sentParams[0].Files[0].FileName
+}
+
+func TestEmojifySources(t *testing.T) {
+	avatarUser := &disgord.User{ID: 300, Username: "someone", Avatar: "abc"}
+	inputs := []struct {
+		name    string
+		msg     *disgord.Message
+		earlier []*disgord.Message
+		link    string
+		file    string
+		deleted bool
+	}{
+		{
+			name:    "emoji",
+			msg:     &disgord.Message{ID: 50, Content: "<:pog:123> flip"},
+			link:    "https://cdn.discordapp.com/emojis/123.png",
+			file:    "pog.png",
+			deleted: true,
+		},
+		{
+			name:    "link",
+			msg:     &disgord.Message{ID: 50, Content: "blur https://example.com/images/cat.jpg?size=large"},
+			link:    "https://example.com/images/cat.jpg?size=large",
+			file:    "cat.png",
+			deleted: true,
+		},
+		{
+			name:    "mention",
+			msg:     &disgord.Message{ID: 50, Content: "<@!300> hue(20)", Mentions: []*disgord.User{avatarUser}},
+			link:    "https://cdn.discordapp.com/avatars/300/abc.webp?size=128",
+			file:    "someone.png",
+			deleted: true,
+		},
+		{
+			name: "attachment",
+			msg: &disgord.Message{ID: 50, Content: "mirror", Attachments: []*disgord.Attachment{
+				{Filename: "notes.txt", URL: "https://cdn.discordapp.com/attachments/1/notes.txt"},
+				{Filename: "photo.JPEG", URL: "https://cdn.discordapp.com/attachments/1/photo.JPEG"},
+			}},
+			link: "https://cdn.discordapp.com/attachments/1/photo.JPEG",
+			file: "photo.png",
+		},
+		{
+			name: "sticker",
+			msg:  &disgord.Message{ID: 50, StickerItems: []*disgord.StickerItem{{ID: 77, Name: "wave", FormatType: disgord.MessageStickerFormatPNG}}},
+			link: "https://media.discordapp.net/stickers/77.png",
+			file: "wave.png",
+		},
+		{
+			name: "reply",
+			msg: &disgord.Message{ID: 50, Content: "grayscale", MessageReference: &disgord.MessageReference{MessageID: 20},
+				ReferencedMessage: &disgord.Message{ID: 20, Content: "look <:kek:456>"}},
+			earlier: []*disgord.Message{{ID: 49, Content: "<:newer:789>"}},
+			link:    "https://cdn.discordapp.com/emojis/456.png",
+			file:    "kek.png",
+		},
+		{
+			name:    "reply fetched",
+			msg:     &disgord.Message{ID: 50, MessageReference: &disgord.MessageReference{MessageID: 20}},
+			earlier: []*disgord.Message{{ID: 49, Content: "<:newer:789>"}, {ID: 20, Embeds: []*disgord.Embed{{Image: &disgord.EmbedImage{URL: "https://example.com/meme.webp"}}}}},
+			link:    "https://example.com/meme.webp",
+			file:    "meme.png",
+		},
+		{
+			name: "history",
+			msg:  &disgord.Message{ID: 50, Content: "spin"},
+			earlier: []*disgord.Message{
+				{ID: 49, Content: "no image here https://example.com/article"},
+				{ID: 48, StickerItems: []*disgord.StickerItem{{ID: 78, Name: "lottie", FormatType: disgord.MessageStickerFormatLOTTIE}}},
+				{ID: 47, Content: "https://example.com/a/dance.gif"},
+				{ID: 46, Content: "<:older:1>"},
+			},
+			link: "https://example.com/a/dance.gif",
+			file: "dance.gif",
+		},
+	}
+	for _, input := range inputs {
+		s, f := runEmojify(input.msg, input.earlier, testEmojiImage(encodeTestPNG))
+
+		if len(f.links) != 1 || f.links[0] != input.link {
+			t.Error(input.name, ": unexpected links ", f.links)
+		}
+		if file := sentFileName(s); file != input.file {
+			t.Error(input.name, ": unexpected file ", file, " ", s.message)
+		}
+		if deleted := len(s.channel.deleted) == 1; deleted != input.deleted {
+			t.Error(input.name, ": command message deleted ", deleted)
+		}
+	}
+}
+
+func TestEmojifyJPEG(t *testing.T) {
+	msg := &disgord.Message{ID: 50, Content: "https://example.com/photo.jpg resize(16)"}
+
+	s, _ := runEmojify(msg, nil, testEmojiImage(encodeTestJPEG))
+
+	if len(s.sentParams) != 1 {
+		t.Fatal("Nothing posted ", s.message)
+	}
+	img, err := png.Decode(s.sentParams[0].Files[0].Reader)
+	if err != nil || img.Bounds().Dx() != 16 {
+		t.Error("Unexpected result ", err)
+	}
+}
+
+func TestEmojifyNoImage(t *testing.T) {
+	msg := &disgord.Message{ID: 50, Content: "blur"}
+
+	s, f := runEmojify(msg, []*disgord.Message{{ID: 49, Content: "hello"}}, testEmojiImage(encodeTestPNG))
+
+	if s.message != "No image found. Use an emoji, sticker, image, link or mention, or reply to a message with one." || len(f.links) != 0 {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestEmojifyFilterError(t *testing.T) {
+	msg := &disgord.Message{ID: 50, Content: "<:pog:123> blur(100)"}
+
+	s, f := runEmojify(msg, nil, testEmojiImage(encodeTestPNG))
+
+	if s.message != "Could not apply filters: blur takes a number from 0.1 to 20, got 100" {
+		t.Error("Unexpected message ", s.message)
+	}
+	if len(s.channel.deleted) != 0 || len(f.links) != 0 {
+		t.Error("Message deleted or image fetched for a mistyped command")
+	}
+}
+
+func TestEmojifyCombine(t *testing.T) {
+	inputs := []struct {
+		name    string
+		msg     *disgord.Message
+		links   []string
+		file    string
+		width   int
+		deleted bool
+	}{
+		{
+			name:    "side",
+			msg:     &disgord.Message{ID: 50, Content: "combine <:a:1> <:b:2> resize(1x)"},
+			links:   []string{"https://cdn.discordapp.com/emojis/1.png", "https://cdn.discordapp.com/emojis/2.png"},
+			file:    "a_b.png",
+			width:   16,
+			deleted: true,
+		},
+		{
+			name:    "kept size",
+			msg:     &disgord.Message{ID: 50, Content: "combine <:a:1> <:b:2>"},
+			links:   []string{"https://cdn.discordapp.com/emojis/1.png", "https://cdn.discordapp.com/emojis/2.png"},
+			file:    "a_b.png",
+			width:   16,
+			deleted: true,
+		},
+		{
+			name: "stack with attachments",
+			msg: &disgord.Message{ID: 50, Content: "COMBINE stack resize(1x)", Attachments: []*disgord.Attachment{
+				{Filename: "top.png", URL: "https://cdn.discordapp.com/attachments/1/top.png"},
+				{Filename: "bottom.gif", URL: "https://cdn.discordapp.com/attachments/1/bottom.gif"},
+			}},
+			links: []string{"https://cdn.discordapp.com/attachments/1/top.png", "https://cdn.discordapp.com/attachments/1/bottom.gif"},
+			file:  "top_bottom.png",
+			width: 8,
+		},
+	}
+	for _, input := range inputs {
+		s, f := runEmojify(input.msg, nil, testEmojiImage(encodeTestPNG))
+
+		if fmt.Sprint(f.links) != fmt.Sprint(input.links) {
+			t.Error(input.name, ": unexpected links ", f.links)
+		}
+		if file := sentFileName(s); file != input.file {
+			t.Fatal(input.name, ": unexpected file ", file, " ", s.message)
+		}
+		img, err := png.Decode(s.sentParams[0].Files[0].Reader)
+		if err != nil || img.Bounds().Dx() != input.width {
+			t.Error(input.name, ": unexpected result ", err)
+		}
+		if deleted := len(s.channel.deleted) == 1; deleted != input.deleted {
+			t.Error(input.name, ": command message deleted ", deleted)
+		}
+	}
+}
+
+func TestEmojifyCombineCount(t *testing.T) {
+	msg := &disgord.Message{ID: 50, Content: "combine <:a:1>"}
+
+	s, f := runEmojify(msg, nil, testEmojiImage(encodeTestPNG))
+
+	if s.message != "Combine takes 2 to 4 emoji, images, links or mentions, got 1" || len(f.links) != 0 {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestEmojifyCaption(t *testing.T) {
+	msg := &disgord.Message{ID: 50, Content: "caption “top text” \"bottom text\" flip"}
+	s, f := runEmojify(msg, []*disgord.Message{{ID: 49, Content: "<:kek:456>"}}, testEmojiImage(encodeTestPNG))
+
+	if len(f.links) != 1 || f.links[0] != "https://cdn.discordapp.com/emojis/456.png" {
+		t.Error("Unexpected links ", f.links)
+	}
+	if file := sentFileName(s); file != "kek.png" {
+		t.Fatal("Unexpected file ", file, " ", s.message)
+	}
+	captioned, _ := png.Decode(s.sentParams[0].Files[0].Reader)
+
+	plain, _ := runEmojify(&disgord.Message{ID: 50, Content: "<:kek:456> flip"}, nil, testEmojiImage(encodeTestPNG))
+	img, _ := png.Decode(plain.sentParams[0].Files[0].Reader)
+	if captioned.Bounds() != img.Bounds() || captioned.At(8, 1) == img.At(8, 1) {
+		t.Error("Caption not drawn")
+	}
+}
+
+func TestEmojifyCaptionNoText(t *testing.T) {
+	msg := &disgord.Message{ID: 50, Content: "caption <:pog:123>"}
+
+	s, f := runEmojify(msg, nil, testEmojiImage(encodeTestPNG))
+
+	if s.message != "Write the caption in quotes, like $emote caption \"top text\" \"bottom text\" :emoji:" || len(f.links) != 0 {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestEmojifySave(t *testing.T) {
+	manager := []*disgord.Role{{ID: 2, Permissions: disgord.PermissionManageEmojis}, {ID: 3}}
+	fullGuild := make([]*disgord.Emoji, 50)
+	for i := range fullGuild {
+		fullGuild[i] = &disgord.Emoji{Name: fmt.Sprint("e", i)}
+	}
+	inputs := []struct {
+		name    string
+		content string
+		guildID commands.Snowflake
+		member  []commands.Snowflake
+		//bot - roles of the bot, manager unless given
+		bot       []commands.Snowflake
+		createErr error
+		roles     []*disgord.Role
+		emojis    []*disgord.Emoji
+		saved     string
+		message   string
+	}{
+		{
+			name:    "named",
+			content: "<:pog:123> flip --save flipped_pog",
+			guildID: 10, member: []commands.Snowflake{2}, roles: manager,
+			saved:   "flipped_pog",
+			message: "Saved <:flipped_pog:900> as :flipped_pog:",
+		},
+		{
+			name:    "named after the image",
+			content: "--save <:pog:123>",
+			guildID: 10, member: []commands.Snowflake{2}, roles: manager,
+			saved:   "pog",
+			message: "Saved <:pog:900> as :pog:",
+		},
+		{
+			name:    "everyone can",
+			content: "<:pog:123> --save",
+			guildID: 10, roles: []*disgord.Role{{ID: 10, Permissions: disgord.PermissionAdministrator}},
+			saved:   "pog",
+			message: "Saved <:pog:900> as :pog:",
+		},
+		{
+			name:    "no permission",
+			content: "<:pog:123> --save",
+			guildID: 10, member: []commands.Snowflake{3}, roles: manager,
+			message: "You need the Manage Emojis permission to save emoji.",
+		},
+		{
+			name:    "bot has no permission",
+			content: "<:pog:123> --save",
+			guildID: 10, member: []commands.Snowflake{2}, bot: []commands.Snowflake{3}, roles: manager,
+			message: "I need the Manage Emojis permission to save emoji.",
+		},
+		{
+			name:    "discord refuses",
+			content: "<:pog:123> --save",
+			guildID: 10, member: []commands.Snowflake{2}, roles: manager, createErr: errors.New("invalid emoji name"),
+			message: "Could not save the emoji: invalid emoji name",
+		},
+		{
+			name:    "direct message",
+			content: "<:pog:123> --save",
+			message: "Emoji can only be saved in a server.",
+		},
+		{
+			name:    "no slots",
+			content: "<:pog:123> --save",
+			guildID: 10, member: []commands.Snowflake{2}, roles: manager, emojis: fullGuild,
+			message: "No room to save the emoji, this server uses all 50 of its still emoji slots.",
+		},
+	}
+	for _, input := range inputs {
+		msg := &disgord.Message{ID: 50, GuildID: input.guildID, Content: input.content, Author: &disgord.User{ID: 5},
+			Member: &disgord.Member{Roles: input.member}}
+		bot := input.bot
+		if bot == nil {
+			bot = []commands.Snowflake{2}
+		}
+		g := &mockGuild{roles: input.roles, emojis: input.emojis, info: &disgord.Guild{ID: input.guildID, OwnerID: 1},
+			members: []*disgord.Member{{UserID: mockBotID, Roles: bot}}, createErr: input.createErr}
+		s := &mockSession{guild: g, channel: &mockChannel{messages: []*disgord.Message{msg}}}
+		commands.NewEmojifyCommandFactory(s, &mockFetcher{body: testEmojiImage(encodeTestPNG)}).CreateRequest(&disgord.MessageCreate{Message: msg}, &commands.Users{}).(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+		if s.message != input.message {
+			t.Error(input.name, ": unexpected message ", s.message)
+		}
+		if input.saved == "" {
+			if len(g.created) != 0 {
+				t.Error(input.name, ": emoji saved")
+			}
+			continue
+		}
+		if len(g.created) != 1 || g.created[0].Name != input.saved || !strings.HasPrefix(g.created[0].Image, "data:image/png;base64,") {
+			t.Fatal(input.name, ": unexpected emoji ", g.created)
+		}
+		if sentFileName(s) != "pog.png" {
+			t.Error(input.name, ": result not posted")
+		}
+	}
+}
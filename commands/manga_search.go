@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+const (
+	//mangaSearchLength - most results shown, one number emoji each
+	mangaSearchLength = 5
+	//mangaSearchLifetime - how long the results of a search can be followed
+	mangaSearchLifetime = 24 * time.Hour
+)
+
+//search posts the best matches for a title, each with its cover, and numbers them to be followed
+func (c *mangaCommand) search(title string) {
+	msg := c.data.Message
+	if title == "" {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+MangaString+" search {title}")
+		return
+	}
+
+	rs, err := c.sources.Search(title, mangaSearchLength)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, manga sites could not be searched.")
+		return
+	}
+	if len(rs) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "No manga found for "+title+".")
+		return
+	}
+
+	search := MangaSearch{Guild: msg.GuildID, Channel: msg.ChannelID, Created: time.Now()}
+	for i, r := range rs {
+		position := i + 1
+		embed := &disgord.Embed{
+			Title:       voteOptionEmojis[i] + " " + r.Title,
+			URL:         r.URL,
+			Description: r.Source,
+		}
+		if r.LatestChapter != "" {
+			embed.Description = "Latest: " + r.LatestChapter + " on " + r.Source
+		}
+		if r.Cover != "" {
+			embed.Thumbnail = &disgord.EmbedThumbnail{URL: r.Cover}
+		}
+		if _, err := c.session.SendMessage(msg.ChannelID, &disgord.CreateMessageParams{Embed: embed}); err != nil {
+			log.Error(err)
+		}
+		search.Results = append(search.Results, MangaSearchResult{Position: position, Title: r.Title, Link: r.URL})
+	}
+
+	prompt, err := c.session.SendSimpleMessage(msg.ChannelID, "React with a number or reply to this message with one to get new chapters by DM.")
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	search.Message = prompt.ID
+	for i := range rs {
+		c.session.ReactToMessage(prompt.ID, msg.ChannelID, voteOptionEmojis[i])
+	}
+
+	if err := c.mangaNotificationRepo.RemoveMangaSearchesBefore(time.Now().Add(-mangaSearchLifetime)); err != nil {
+		log.Error(err)
+	}
+	if err := c.mangaNotificationRepo.SaveMangaSearch(&search); err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, search results unable to be followed.")
+	}
+}
+
+//followSearchResult follows the result at the position by DM and says how it went
+func followSearchResult(repo MangaNotificationRepository, linkRepo MangaLinksRepository, search MangaSearch, position int, user *Users) string {
+	var result *MangaSearchResult
+	for i := range search.Results {
+		if search.Results[i].Position == position {
+			result = &search.Results[i]
+		}
+	}
+	if result == nil {
+		return "There is no result " + strconv.Itoa(position) + " in that search."
+	}
+
+	followed, err := followByDM(repo, linkRepo, user, search.Guild, result.Link)
+	if err != nil {
+		log.Error(err)
+		return "Something went wrong, " + result.Title + " unable to be followed."
+	}
+	if !followed {
+		return "You already follow " + result.Title + "."
+	}
+	return "You will get new chapters of " + result.Title + " by DM."
+}
+
+type mangaSearchReact struct {
+	repo      MangaNotificationRepository
+	linkRepo  MangaLinksRepository
+	usersRepo UsersRepository
+	session   DiscordSession
+	data      *disgord.MessageReactionAdd
+}
+
+func NewMangaSearchReact(repo MangaNotificationRepository, linkRepo MangaLinksRepository, usersRepo UsersRepository, s DiscordSession, d *disgord.MessageReactionAdd) *mangaSearchReact {
+	return &mangaSearchReact{
+		repo:      repo,
+		linkRepo:  linkRepo,
+		usersRepo: usersRepo,
+		session:   s,
+		data:      d,
+	}
+}
+
+//OnReactionAdd - reacting with a result's number follows it by DM
+func (c *mangaSearchReact) OnReactionAdd() {
+	index := voteOptionIndex(c.data.PartialEmoji)
+	if index < 0 {
+		return
+	}
+	search, err := c.repo.GetMangaSearchByMessage(c.data.MessageID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	if !c.usersRepo.DoesUserExist(c.data.UserID) {
+		if err := c.usersRepo.SaveUser(&Users{DiscordUsersID: c.data.UserID}); err != nil {
+			log.Error(err)
+			return
+		}
+	}
+	user, err := c.usersRepo.GetUserByDiscordId(c.data.UserID)
+	if err != nil || user.UsersID == 0 {
+		log.Error(err)
+		return
+	}
+
+	c.session.SendDirectMessage(c.data.UserID, followSearchResult(c.repo, c.linkRepo, search, index+1, &user))
+}
+
+type mangaSearchReply struct {
+	repo     MangaNotificationRepository
+	linkRepo MangaLinksRepository
+	session  DiscordSession
+	data     *disgord.MessageCreate
+	user     *Users
+}
+
+func NewMangaSearchReply(repo MangaNotificationRepository, linkRepo MangaLinksRepository, s DiscordSession, d *disgord.MessageCreate, user *Users) *mangaSearchReply {
+	return &mangaSearchReply{
+		repo:     repo,
+		linkRepo: linkRepo,
+		session:  s,
+		data:     d,
+		user:     user,
+	}
+}
+
+//ExecuteMessageCreateCommand - replying to the search with a result's number follows it by DM
+func (c *mangaSearchReply) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	if msg.MessageReference == nil {
+		return
+	}
+	position, err := strconv.Atoi(strings.TrimSpace(msg.Content))
+	if err != nil {
+		return
+	}
+	search, err := c.repo.GetMangaSearchByMessage(msg.MessageReference.MessageID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	c.session.SendSimpleMessage(msg.ChannelID, createUserMention(c.user.DiscordUsersID)+" "+
+		followSearchResult(c.repo, c.linkRepo, search, position, c.user))
+}
@@ -0,0 +1,149 @@
+package commands_test
+
+import (
+	"discordbot/commands"
+	"discordbot/manga"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+//stubMangaSearcher - finds the same results for every title
+type stubMangaSearcher struct {
+	stubMangaSource
+	results []manga.SearchResult
+}
+
+func (s *stubMangaSearcher) Matches(u *url.URL) bool {
+	return u.Host == "manga.test"
+}
+
+func (s *stubMangaSearcher) Search(f manga.Fetcher, title string) ([]manga.SearchResult, error) {
+	return s.results, nil
+}
+
+func newMangaSearch() commands.MangaSearch {
+	return commands.MangaSearch{MangaSearchID: 1, Guild: 123, Channel: 10, Message: 999, Results: []commands.MangaSearchResult{
+		{Position: 1, Title: "Followed Manga", Link: followedManga},
+		{Position: 2, Title: "Test Manga", Link: "https://manga.test/2"},
+	}}
+}
+
+func TestMangaSearch(t *testing.T) {
+	//Given: A source finding two manga
+	searcher := &stubMangaSearcher{results: []manga.SearchResult{
+		{Title: "Test Manga", URL: "https://manga.test/1", Cover: "https://manga.test/1.jpg", LatestChapter: "Chapter 12", Source: "manga.test"},
+		{Title: "Test Manga 2", URL: "https://manga.test/2", Source: "manga.test"},
+	}}
+	repo := &mockMangaNotificationRepo{}
+	s := &mockSession{}
+	msg := &disgord.MessageCreate{Message: &disgord.Message{ID: 40, Content: "search test manga", GuildID: 123, ChannelID: 10}}
+	factory := commands.NewMangaNotificationFactory(repo, &mockMangaLinkRepo{}, s, manga.NewRegistry(nil, searcher))
+
+	//When: A user searches for it
+	factory.CreateMangaCommand(msg, mangaReader).(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+	//Then: Each result is posted with its cover and latest chapter
+	if len(s.sentParams) != 2 {
+		t.Fatal("Results not posted ", len(s.sentParams))
+	}
+	first := s.sentParams[0].Embed
+	if first.Title != "1️⃣ Test Manga" || first.URL != "https://manga.test/1" || first.Description != "Latest: Chapter 12 on manga.test" ||
+		first.Thumbnail == nil || first.Thumbnail.URL != "https://manga.test/1.jpg" {
+		t.Error("Unexpected result ", first)
+	}
+	if second := s.sentParams[1].Embed; second.Description != "manga.test" || second.Thumbnail != nil {
+		t.Error("Unexpected result without a cover ", second)
+	}
+
+	//And: The prompt gets a reaction per result and the search is kept to be followed
+	if len(s.reactions) != 2 || s.reactions[1] != "2️⃣" || s.reactedMessageID != 999 {
+		t.Error("Unexpected reactions ", s.reactions)
+	}
+	if len(repo.searches) != 1 {
+		t.Fatal("Search not saved")
+	}
+	search := repo.searches[0]
+	if search.Message != 999 || search.Guild != 123 || len(search.Results) != 2 || search.Results[1].Position != 2 || search.Results[1].Link != "https://manga.test/2" {
+		t.Error("Unexpected search saved ", search)
+	}
+	if repo.searchesRemovedBefore.IsZero() || time.Since(repo.searchesRemovedBefore) < 23*time.Hour {
+		t.Error("Old searches not removed ", repo.searchesRemovedBefore)
+	}
+}
+
+func TestMangaSearchNothingFound(t *testing.T) {
+	repo := &mockMangaNotificationRepo{}
+	s := &mockSession{}
+	msg := &disgord.MessageCreate{Message: &disgord.Message{ID: 40, Content: "search nothing", GuildID: 123, ChannelID: 10}}
+	factory := commands.NewMangaNotificationFactory(repo, &mockMangaLinkRepo{}, s, manga.NewRegistry(nil, &stubMangaSearcher{}))
+
+	factory.CreateMangaCommand(msg, mangaReader).(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+	if s.message != "No manga found for nothing." || len(repo.searches) != 0 {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestMangaSearchReact(t *testing.T) {
+	//Given: A search and a user the bot has not seen yet
+	repo := newMangaSubscriptions()
+	repo.searches = []commands.MangaSearch{newMangaSearch()}
+	lrepo := &mockMangaLinkRepo{}
+	users := &mockUsersDB{}
+	s := &mockSession{}
+
+	//When: They react with the second result's number
+	e := &disgord.MessageReactionAdd{MessageID: 999, ChannelID: 10, UserID: 800, PartialEmoji: &disgord.Emoji{Name: "2️⃣"}}
+	commands.NewMangaSearchReact(repo, lrepo, users, s, e).OnReactionAdd()
+
+	//Then: They follow it by DM
+	n := repo.notifications[len(repo.notifications)-1]
+	if len(repo.notifications) != 5 || n.DiscordUserID != 800 || n.User != 100 || n.Guild != 123 {
+		t.Fatal("DM subscription not saved ", repo.notifications)
+	}
+	if len(lrepo.link) != 1 || lrepo.link[0].MangaLink != "https://manga.test/2" {
+		t.Error("Followed the wrong manga ", lrepo.link)
+	}
+	if dms := s.directMessages[800]; len(dms) != 1 || dms[0] != "You will get new chapters of Test Manga by DM." {
+		t.Error("Unexpected DMs ", s.directMessages)
+	}
+
+	//And: Other reactions are ignored
+	e = &disgord.MessageReactionAdd{MessageID: 999, ChannelID: 10, UserID: 800, PartialEmoji: &disgord.Emoji{Name: "👍"}}
+	commands.NewMangaSearchReact(repo, lrepo, users, s, e).OnReactionAdd()
+	if len(s.directMessages[800]) != 1 || len(repo.notifications) != 5 {
+		t.Error("Reaction without a number followed a manga")
+	}
+}
+
+func TestMangaSearchReply(t *testing.T) {
+	inputs := []struct {
+		content  string
+		expected string
+		added    bool
+	}{
+		{"2", "<@700> You will get new chapters of Test Manga by DM.", true},
+		{"1", "<@700> You already follow Followed Manga.", false},
+		{"6", "<@700> There is no result 6 in that search.", false},
+		{"the second one", "", false},
+	}
+	for _, input := range inputs {
+		repo := newMangaSubscriptions()
+		repo.searches = []commands.MangaSearch{newMangaSearch()}
+		s := &mockSession{}
+		msg := &disgord.MessageCreate{Message: &disgord.Message{ID: 41, Content: input.content, GuildID: 123, ChannelID: 10,
+			MessageReference: &disgord.MessageReference{MessageID: 999}}}
+
+		commands.NewMangaSearchReply(repo, &mockMangaLinkRepo{}, s, msg, mangaReader).ExecuteMessageCreateCommand()
+
+		if s.message != input.expected {
+			t.Error("Unexpected message for ", input.content, ": ", s.message)
+		}
+		if added := len(repo.notifications) == 5; added != input.added {
+			t.Error("Unexpected subscriptions after ", input.content, ": ", repo.notifications)
+		}
+	}
+}
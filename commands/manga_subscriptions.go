@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const mangaUsage = "Usage: " + CommandPrefix + MangaString + " list | search {title} | follow {manga_url} | remove {manga_url|id}"
+
+func (c *mangaNotificationCommandFactory) PrintHelp() string {
+	return CommandPrefix + MangaString + " list / search {title} / follow {manga_url} / remove {manga_url|id} - See this server's manga subscriptions, find a manga, get new chapters by DM or remove a subscription. " +
+		CommandPrefix + MangaNotificationString + " {manga_url} {channel_name} {role_name} pings a role instead."
+}
+
+func (c *mangaNotificationCommandFactory) CreateMangaCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &mangaCommand{
+		mangaNotificationCommandFactory: c,
+		data:                            data,
+		user:                            user,
+	}
+}
+
+type mangaCommand struct {
+	*mangaNotificationCommandFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *mangaCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	split := strings.Fields(msg.Content)
+	if len(split) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, mangaUsage)
+		return
+	}
+
+	switch strings.ToLower(split[0]) {
+	case "list":
+		c.list()
+	case "follow":
+		c.follow(split[1:])
+	case "remove":
+		c.remove(split[1:])
+	case "search":
+		c.search(strings.Join(split[1:], " "))
+	default:
+		c.session.SendSimpleMessage(msg.ChannelID, mangaUsage)
+	}
+}
+
+//list shows the server's channel subscriptions and the caller's own DM subscriptions
+func (c *mangaCommand) list() {
+	msg := c.data.Message
+	ns, err := c.mangaNotificationRepo.GetMangaNotifications(msg.GuildID, c.user.DiscordUsersID)
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+
+	if len(ns) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "No manga subscriptions in this server. Add one with "+CommandPrefix+MangaNotificationString+
+			" {manga_url} {channel_name} {role_name} or "+CommandPrefix+MangaString+" follow {manga_url}")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Manga subscriptions:")
+	for _, n := range ns {
+		b.WriteString(fmt.Sprintf("\n%d - %s", n.MangaNotificationID, n.Link))
+		if n.DiscordUserID != 0 {
+			b.WriteString(" by DM")
+		} else {
+			b.WriteString(" in <#" + n.Channel.String() + "> for " + createMention(n.Role))
+		}
+	}
+	b.WriteString("\nRemove one with " + CommandPrefix + MangaString + " remove {id}")
+	c.session.SendSimpleMessage(msg.ChannelID, b.String())
+}
+
+//follow sends new chapters of a manga to the caller by DM
+func (c *mangaCommand) follow(args []string) {
+	msg := c.data.Message
+	if len(args) != 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+MangaString+" follow {manga_url}")
+		return
+	}
+	link := args[0]
+	if !c.checkMangaSource(msg, link) {
+		return
+	}
+
+	followed, err := followByDM(c.mangaNotificationRepo, c.mangaLinkRepo, c.user, msg.GuildID, link)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, manga unable to be followed.")
+		return
+	}
+	if !followed {
+		c.session.SendSimpleMessage(msg.ChannelID, "You already follow "+link+".")
+		return
+	}
+	c.session.ReactWithThumbsUp(msg)
+}
+
+//remove takes a subscription id or a manga link.
+//Channel subscriptions can be removed by whoever added them or members with Manage Server, DM subscriptions only by the user getting them.
+func (c *mangaCommand) remove(args []string) {
+	msg := c.data.Message
+	if len(args) != 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+MangaString+" remove {manga_url|id}")
+		return
+	}
+
+	ns, err := c.mangaNotificationRepo.GetMangaNotifications(msg.GuildID, c.user.DiscordUsersID)
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+
+	var found []MangaNotification
+	ID, idErr := strconv.ParseInt(args[0], 10, 64)
+	for _, n := range ns {
+		if (idErr == nil && n.MangaNotificationID == ID) || n.Link == args[0] {
+			found = append(found, n)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		c.session.SendSimpleMessage(msg.ChannelID, "No manga subscription "+args[0]+" found. See "+CommandPrefix+MangaString+" list")
+		return
+	case 1:
+	default:
+		ids := make([]string, len(found))
+		for i, n := range found {
+			ids[i] = strconv.FormatInt(n.MangaNotificationID, 10)
+		}
+		c.session.SendSimpleMessage(msg.ChannelID, args[0]+" has more than one subscription, remove one by id: "+strings.Join(ids, ", "))
+		return
+	}
+
+	if found[0].DiscordUserID.IsZero() && found[0].User != c.user.UsersID && !c.canManageServer(msg) {
+		c.session.SendSimpleMessage(msg.ChannelID, "Only whoever added this subscription or members with Manage Server can remove it.")
+		return
+	}
+
+	if err := c.mangaNotificationRepo.RemoveMangaNotification(found[0].MangaNotificationID); err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, manga subscription unable to be removed.")
+		return
+	}
+	c.session.ReactWithThumbsUp(msg)
+}
+
+func (c *mangaCommand) canManageServer(msg *disgord.Message) bool {
+	guild := c.session.Guild(msg.GuildID)
+	info, err := guild.Get()
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	roles, err := guild.GetRoles()
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	return hasGuildPermission(info, roles, c.user.DiscordUsersID, msg.Member, disgord.PermissionManageServer)
+}
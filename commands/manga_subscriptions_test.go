@@ -0,0 +1,156 @@
+package commands_test
+
+import (
+	"discordbot/commands"
+	"discordbot/manga"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+)
+
+const followedManga = "https://manganato.com/manga-dr980474"
+
+var (
+	mangaReader      = &commands.Users{UsersID: 2, DiscordUsersID: 700}
+	otherMangaReader = &commands.Users{UsersID: 3, DiscordUsersID: 701}
+	mangaManager     = &commands.Users{UsersID: 4, DiscordUsersID: 702}
+)
+
+//mangaGuild - role 8 has Manage Server and only mangaManager has it
+func mangaGuild() *mockGuild {
+	return &mockGuild{
+		info:  &disgord.Guild{ID: 123, OwnerID: 900},
+		roles: []*disgord.Role{{ID: 123}, {ID: 8, Permissions: disgord.PermissionManageServer}},
+	}
+}
+
+func newMangaSubscriptions() *mockMangaNotificationRepo {
+	return &mockMangaNotificationRepo{id: 4, notifications: []commands.MangaNotification{
+		{MangaNotificationID: 1, User: 1, Guild: 123, Channel: 10, Role: 5, Link: followedManga},
+		{MangaNotificationID: 2, User: 2, Guild: 123, DiscordUserID: 700, Link: followedManga},
+		{MangaNotificationID: 3, User: 3, Guild: 123, DiscordUserID: 701, Link: "https://earlymanga.org/manga/other"},
+		{MangaNotificationID: 4, User: 1, Guild: 456, Channel: 11, Role: 6, Link: followedManga},
+	}}
+}
+
+func runMangaCommand(content string, user *commands.Users, repo *mockMangaNotificationRepo, lrepo *mockMangaLinkRepo) *mockSession {
+	s := &mockSession{guild: mangaGuild()}
+	member := &disgord.Member{}
+	if user == mangaManager {
+		member.Roles = []commands.Snowflake{8}
+	}
+	msg := &disgord.MessageCreate{Message: &disgord.Message{ID: 40, Content: content, GuildID: 123, ChannelID: 10, Member: member}}
+	factory := commands.NewMangaNotificationFactory(repo, lrepo, s, manga.DefaultRegistry(nil))
+	factory.CreateMangaCommand(msg, user).(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestMangaList(t *testing.T) {
+	repo := newMangaSubscriptions()
+
+	s := runMangaCommand("list", mangaReader, repo, &mockMangaLinkRepo{})
+
+	expected := "Manga subscriptions:\n" +
+		"1 - " + followedManga + " in <#10> for <@&5>\n" +
+		"2 - " + followedManga + " by DM\n" +
+		"Remove one with $manga remove {id}"
+	if s.message != expected {
+		t.Error("Unexpected list ", s.message)
+	}
+}
+
+func TestMangaFollow(t *testing.T) {
+	//Given: A manga followed by a role in the server
+	repo := newMangaSubscriptions()
+	lrepo := &mockMangaLinkRepo{link: []commands.MangaLink{{MangaLinkID: 1, MangaLink: followedManga}}}
+
+	//When: Another user follows it by DM
+	runMangaCommand("follow "+followedManga, otherMangaReader, repo, lrepo)
+
+	//Then: A DM subscription is added for the existing manga
+	n := repo.notifications[len(repo.notifications)-1]
+	if len(repo.notifications) != 5 || n.DiscordUserID != 701 || n.User != 3 || n.Channel != 0 {
+		t.Fatal("DM subscription not saved ", repo.notifications)
+	}
+	if len(repo.links) != 1 || repo.links[0].linkId != 1 || len(lrepo.link) != 1 {
+		t.Error("Subscription not linked to the manga ", repo.links)
+	}
+
+	//When: A user follows a manga they already get by DM
+	s := runMangaCommand("follow "+followedManga, mangaReader, repo, lrepo)
+
+	//Then: Nothing is added
+	if s.message != "You already follow "+followedManga+"." || len(repo.notifications) != 5 {
+		t.Error("Manga followed twice ", s.message)
+	}
+
+	//And: Unsupported sites are refused
+	s = runMangaCommand("follow https://example.com/manga", mangaReader, repo, lrepo)
+	if s.message != "Manga site not supported. Supported sites: manganato.com, earlymanga.org, mangadex.org" {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestMangaRemove(t *testing.T) {
+	inputs := []struct {
+		content  string
+		user     *commands.Users
+		removed  int64
+		expected string
+	}{
+		{"remove 1", mangaManager, 1, ""},
+		{"remove 1", &commands.Users{UsersID: 1, DiscordUsersID: 703}, 1, ""},
+		{"remove 1", otherMangaReader, 0, "Only whoever added this subscription or members with Manage Server can remove it."},
+		{"remove 3", otherMangaReader, 3, ""},
+		{"remove https://earlymanga.org/manga/other", otherMangaReader, 3, ""},
+		{"remove 3", mangaReader, 0, "No manga subscription 3 found. See $manga list"},
+		{"remove 4", mangaReader, 0, "No manga subscription 4 found. See $manga list"},
+		{"remove " + followedManga, mangaReader, 0, followedManga + " has more than one subscription, remove one by id: 1, 2"},
+		{"remove", mangaReader, 0, "Usage: $manga remove {manga_url|id}"},
+	}
+	for _, input := range inputs {
+		repo := newMangaSubscriptions()
+
+		s := runMangaCommand(input.content, input.user, repo, &mockMangaLinkRepo{})
+
+		if s.message != input.expected {
+			t.Error("Unexpected message for ", input.content, ": ", s.message)
+		}
+		removed := len(repo.notifications) == 3
+		for _, n := range repo.notifications {
+			if n.MangaNotificationID == input.removed {
+				removed = false
+			}
+		}
+		if (input.removed != 0) != removed {
+			t.Error("Wrong subscriptions left after ", input.content, ": ", repo.notifications)
+		}
+	}
+}
+
+func TestNewChapterSentByDM(t *testing.T) {
+	source := &stubMangaSource{manga.Manga{Title: "Test Manga", Chapters: []manga.Chapter{
+		{Number: 12, Title: "The End", URL: "https://manga.test/1/12"},
+		{Number: 11, Title: "Chapter 11", URL: "https://manga.test/1/11"},
+	}}}
+	lrepo := &mockMangaLinkRepo{link: []commands.MangaLink{{
+		MangaLink:      "https://manga.test/1",
+		LastChapter:    11,
+		LastChapterURL: "https://manga.test/1/11",
+		MangaNotifications: []commands.MangaNotification{
+			{Channel: 10, Role: 5},
+			{DiscordUserID: 700},
+		},
+	}}}
+	s := &mockSession{}
+
+	commands.LookForNewMangaChapter(lrepo, manga.NewRegistry(nil, source), s)
+
+	if s.message != "<@&5> New chapter of Test Manga found: Chapter 12: The End https://manga.test/1/12" {
+		t.Error("Unexpected channel announcement ", s.message)
+	}
+	dms := s.directMessages[700]
+	if len(dms) != 1 || dms[0] != "New chapter of Test Manga found: Chapter 12: The End https://manga.test/1/12" {
+		t.Error("Unexpected DMs ", s.directMessages)
+	}
+}
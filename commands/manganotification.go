@@ -1,30 +1,31 @@
 package commands
 
 import (
+	"discordbot/manga"
 	"fmt"
-	"net/http"
-	"net/url"
+	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/andersfylling/disgord"
-	"golang.org/x/net/html"
 )
 
-const earlyMangaTimeFormat = "2006-01-02 15:04:05 MST (-07:00)"
-const manganeloTimeFormat = "Jan 2,2006 15:04"
+//mangaChapterBatchLength - most chapters listed in one announcement
+const mangaChapterBatchLength = 10
 
 type mangaNotificationCommandFactory struct {
 	mangaNotificationRepo MangaNotificationRepository
 	mangaLinkRepo         MangaLinksRepository
 	session               DiscordSession
+	sources               *manga.Registry
 }
 
-func NewMangaNotificationFactory(repo MangaNotificationRepository, mangaLinkRepo MangaLinksRepository, session DiscordSession) *mangaNotificationCommandFactory {
+func NewMangaNotificationFactory(repo MangaNotificationRepository, mangaLinkRepo MangaLinksRepository, session DiscordSession, sources *manga.Registry) *mangaNotificationCommandFactory {
 	return &mangaNotificationCommandFactory{
 		mangaNotificationRepo: repo,
 		mangaLinkRepo:         mangaLinkRepo,
 		session:               session,
+		sources:               sources,
 	}
 }
 
@@ -68,10 +69,7 @@ func (c mangaNotificationCommand) ExecuteMessageCreateCommand() {
 	}
 
 	mangaUrl := split[0]
-	mangaLink, err := c.mangaLinkRepo.GetMangaLinkByLink(mangaUrl)
-	if err != nil {
-		log.Error(err)
-		c.session.ReactWithThumbsDown(msg)
+	if !c.checkMangaSource(msg, mangaUrl) {
 		return
 	}
 
@@ -81,225 +79,170 @@ func (c mangaNotificationCommand) ExecuteMessageCreateCommand() {
 		Channel: channel.ID,
 		Role:    role.ID,
 	}
-
-	err = c.mangaNotificationRepo.SaveMangaNotification(&mn)
-
-	if err != nil {
+	if err := subscribeToManga(c.mangaNotificationRepo, c.mangaLinkRepo, &mn, mangaUrl); err != nil {
 		log.Error(err)
 		c.session.ReactToMessage(msg.ID, msg.ChannelID, "👎")
 		return
 	}
 
+	c.session.ReactToMessage(msg.ID, msg.ChannelID, "👍")
+}
+
+//checkMangaSource tells the user when the link is not on a supported site
+func (c *mangaNotificationCommandFactory) checkMangaSource(msg *disgord.Message, link string) bool {
+	if _, err := c.sources.Find(link); err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Manga site not supported. Supported sites: "+strings.Join(c.sources.Names(), ", "))
+		c.session.ReactWithThumbsDown(msg)
+		return false
+	}
+	return true
+}
+
+//subscribeToManga saves the notification and links it to the manga, adding the manga when nobody followed it yet
+func subscribeToManga(repo MangaNotificationRepository, linkRepo MangaLinksRepository, mn *MangaNotification, link string) error {
+	mangaLink, err := linkRepo.GetMangaLinkByLink(link)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.SaveMangaNotification(mn); err != nil {
+		return err
+	}
+
 	if mangaLink.MangaLinkID == 0 {
-		mangaLink = MangaLink{MangaLink: mangaUrl, MangaNotifications: []MangaNotification{mn}}
-		c.mangaLinkRepo.SaveMangaLink(&mangaLink)
+		mangaLink = MangaLink{MangaLink: link, MangaNotifications: []MangaNotification{*mn}}
+		if err := linkRepo.SaveMangaLink(&mangaLink); err != nil {
+			return err
+		}
 	}
 
-	err = c.mangaNotificationRepo.AddMangaLink(mn.MangaNotificationID, mangaLink.MangaLinkID)
+	return repo.AddMangaLink(mn.MangaNotificationID, mangaLink.MangaLinkID)
+}
 
+//followByDM subscribes a user to a manga by DM unless they already are
+func followByDM(repo MangaNotificationRepository, linkRepo MangaLinksRepository, user *Users, guild Snowflake, link string) (bool, error) {
+	ns, err := repo.GetMangaNotifications(guild, user.DiscordUsersID)
 	if err != nil {
-		log.Error(err)
-		c.session.ReactToMessage(msg.ID, msg.ChannelID, "👎")
-		return
+		return false, err
+	}
+	for _, n := range ns {
+		if n.DiscordUserID == user.DiscordUsersID && n.Link == link {
+			return false, nil
+		}
 	}
 
-	c.session.ReactToMessage(msg.ID, msg.ChannelID, "👍")
+	mn := MangaNotification{
+		User:          user.UsersID,
+		Guild:         guild,
+		DiscordUserID: user.DiscordUsersID,
+	}
+	if err := subscribeToManga(repo, linkRepo, &mn, link); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-func LookForNewMangaChapter(repo MangaLinksRepository, s DiscordSession) {
+//LookForNewMangaChapter checks every followed manga and pings the roles following it about chapters newer than the last one announced
+func LookForNewMangaChapter(repo MangaLinksRepository, sources *manga.Registry, s DiscordSession) {
 	mangaLinks, err := repo.GetAllMangaLinks()
 	if err != nil {
 		log.Error(err)
 		return
 	}
+	var wg sync.WaitGroup
 	for _, mangaLink := range mangaLinks {
-		go searchForNewChapter(mangaLink, s)
+		wg.Add(1)
+		go func(mangaLink MangaLink) {
+			defer wg.Done()
+			searchForNewChapter(mangaLink, repo, sources, s)
+		}(mangaLink)
 	}
+	wg.Wait()
 }
 
-func searchForNewChapter(mangaLink MangaLink, s DiscordSession) {
-	url, err := url.Parse(mangaLink.MangaLink)
-		if err != nil {
-			log.Error(err)
-			return
-		}
-		node, err := getHtmlPage(mangaLink.MangaLink)
-		if err != nil {
-			log.WithField("manga link", mangaLink.MangaLink).Error(err)
-			return
-		}
-		newChapter := false
-		switch url.Host {
-		case "readmanganato.com":
-			newChapter = findNewMangeloChapter(node)
-		case "earlymanga.org":
-			newChapter = checkEarlyManga(node)
-		default:
-			log.Error("unknown host ", mangaLink.MangaLink)
+func searchForNewChapter(mangaLink MangaLink, repo MangaLinksRepository, sources *manga.Registry, s DiscordSession) {
+	m, err := sources.Fetch(mangaLink.MangaLink)
+	if err != nil {
+		log.WithField("manga link", mangaLink.MangaLink).Error(err)
+		return
+	}
+	if len(m.Chapters) == 0 {
+		return
+	}
+
+	//The first check only records where the manga is up to
+	if mangaLink.LastChapterURL != "" {
+		chapters := newChapters(m.Chapters, mangaLink)
+		if len(chapters) == 0 {
 			return
 		}
-		if newChapter {
-			for _, guild := range mangaLink.MangaNotifications {
-				msg := fmt.Sprintf("%s New chapter found at %s", createMention(guild.Role), mangaLink.MangaLink)
-				s.SendSimpleMessage(guild.Channel, msg)
-				log.Info("New chapter found at ", mangaLink.MangaLink)
+		log.Info(len(chapters), " new chapters found at ", mangaLink.MangaLink)
+		announcement := formatNewChapters(m.Title, chapters)
+		for _, guild := range mangaLink.MangaNotifications {
+			var err error
+			if guild.DiscordUserID != 0 {
+				_, err = s.SendDirectMessage(guild.DiscordUserID, announcement)
+			} else {
+				_, err = s.SendSimpleMessage(guild.Channel, createMention(guild.Role)+" "+announcement)
+			}
+			if err != nil {
+				log.WithField("manga notification", guild.MangaNotificationID).Error(err)
 			}
 		}
-}
-
-func getHtmlPage(mangaLink string) (*html.Node, error) {
-	req, err := http.NewRequest("GET", mangaLink, nil)
-	if err != nil {
-		return nil, err
 	}
 
-	req.Header.Add("Accept", "*/*")
-	req.Header.Add("Accept-Language", "en-US,en;q=0.9")
-	r, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	node, _ := html.Parse(r.Body)
-	r.Body.Close()
-	return node, nil
-}
-
-func checkEarlyManga(node *html.Node) bool {
-	c := earlymangacrawler{0, false}
-	// body := node.FirstChild.NextSibling.FirstChild.NextSibling.NextSibling
-	body := traverseNodes(node, "fnfnn")
-	if body == nil {
-		return false
+	mangaLink.LastChapter = m.Chapters[0].Number
+	mangaLink.LastChapterURL = m.Chapters[0].URL
+	if err := repo.UpdateLastChapter(&mangaLink); err != nil {
+		log.WithField("manga link", mangaLink.MangaLink).Error(err)
 	}
-	c.isThereNewChapter(body)
-	return c.newChapter
 }
 
-type earlymangacrawler struct {
-	row        int
-	newChapter bool
-}
-
-func (t *earlymangacrawler) isThereNewChapter(n *html.Node) {
-	for child := n.FirstChild; child != nil; child = child.NextSibling {
-		if child.Data == "div" {
-			for _, attr := range child.Attr {
-				if attr.Key == "class" && strings.Contains(attr.Val, "chapter-row") {
-					if t.row == 2 {
-						t.row++
-						t.newChapter = isChapterNew(child)
-						return
-					}
-					t.row++
-				}
-			}
+//newChapters returns the chapters after the last one announced, oldest first.
+//Chapters are compared by number when the site gives one, otherwise everything listed before the last announced link is new.
+func newChapters(chapters []manga.Chapter, mangaLink MangaLink) []manga.Chapter {
+	var result []manga.Chapter
+	for _, c := range chapters {
+		if c.URL == mangaLink.LastChapterURL {
+			break
 		}
-		t.isThereNewChapter(child)
-	}
-}
-
-func isChapterNew(n *html.Node) bool {
-	for child := n.FirstChild; child != nil; child = child.NextSibling {
-		for _, attr := range child.Attr {
-			if attr.Key == "title" {
-				releaseTime, err := time.Parse(earlyMangaTimeFormat, attr.Val)
-				if err != nil {
-					log.Error(err)
-					return false
-				} else {
-					now := time.Now().UTC()
-					return now.Sub(releaseTime).Hours() <= 1
-				}
-			}
+		if c.Number != 0 && mangaLink.LastChapter != 0 && c.Number <= mangaLink.LastChapter {
+			break
 		}
+		result = append([]manga.Chapter{c}, result...)
 	}
-	return false
+	return result
 }
 
-func findNewMangeloChapter(n *html.Node) bool {
-	// body := n.FirstChild.FirstChild.NextSibling
-	body := traverseNodes(n, "ffn")
-	if body == nil {
-		return false
-	}
-	// bodySite := body.LastChild.PrevSibling.PrevSibling.PrevSibling.PrevSibling.PrevSibling.PrevSibling.PrevSibling
-	bodySite := traverseNodes(body, "lppppppp")
-	if bodySite == nil {
-		return false
+func formatNewChapters(title string, chapters []manga.Chapter) string {
+	if len(chapters) == 1 {
+		return fmt.Sprintf("New chapter of %s found: %s %s", title, chapterLabel(chapters[0]), chapters[0].URL)
 	}
-	// containerMain := bodySite.FirstChild.NextSibling.NextSibling.NextSibling.NextSibling.NextSibling
-	containerMain := traverseNodes(bodySite, "fnnnnn")
-	if containerMain == nil {
-		return false
-	}
-	// containerMainLeft := containerMain.FirstChild.NextSibling
-	containerMainerLeft := traverseNodes(containerMain, "fn")
-	if containerMainerLeft == nil {
-		return false
-	}
-	// chapterList := containerMainLeft.FirstChild.NextSibling.NextSibling.NextSibling.NextSibling.NextSibling.NextSibling.NextSibling.NextSibling.NextSibling.NextSibling.NextSibling
-	chapterList := traverseNodes(containerMainerLeft, "fnnnnnnnnnnn")
-	if chapterList == nil {
-		return false
-	}
-	// chapterRows := chapterList.FirstChild.NextSibling.NextSibling.NextSibling
-	chapterRows := traverseNodes(chapterList, "fnnn")
-	if chapterRows == nil {
-		return false
-	}
-	// firstRow := chapterRows.FirstChild.NextSibling
-	firstRow := traverseNodes(chapterRows, "fn")
-	if firstRow == nil {
-		return false
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d new chapters of %s found:", len(chapters), title))
+	//The newest chapters are the ones worth linking when there are too many
+	if len(chapters) > mangaChapterBatchLength {
+		b.WriteString(fmt.Sprintf("\n... %d earlier chapters", len(chapters)-mangaChapterBatchLength))
+		chapters = chapters[len(chapters)-mangaChapterBatchLength:]
 	}
-	// time := firstRow.FirstChild.NextSibling.NextSibling.NextSibling.NextSibling.NextSibling
-	time := traverseNodes(firstRow, "fnnnnn")
-	if time == nil {
-		return false
+	for _, c := range chapters {
+		b.WriteString("\n" + chapterLabel(c) + " " + c.URL)
 	}
-	return findMangeloTime(time)
+	return b.String()
 }
 
-/*
-l - last child
-f - first child
-p - prev sibling
-n - next sibling
-*/
-func traverseNodes(n *html.Node, path string) *html.Node {
-	currentNode := n
-	for _, s := range strings.ToLower(path) {
-		if currentNode == nil {
-			return nil
-		}
-		switch s {
-		case 'l':
-			currentNode = currentNode.LastChild
-		case 'f':
-			currentNode = currentNode.FirstChild
-		case 'p':
-			currentNode = currentNode.PrevSibling
-		case 'n':
-			currentNode = currentNode.NextSibling
-		default:
-			return nil
-		}
+//chapterLabel makes sure the chapter number is shown when the site gives one
+func chapterLabel(c manga.Chapter) string {
+	if c.Number == 0 {
+		return c.Title
 	}
-	return currentNode
-}
-
-func findMangeloTime(n *html.Node) bool {
-	for _, attr := range n.Attr {
-		if attr.Key == "title" {
-			releaseTime, err := time.Parse(manganeloTimeFormat, attr.Val)
-			if err != nil {
-				fmt.Print(err)
-			} else {
-				now := time.Now().Add(time.Hour * 8)
-				return now.Sub(releaseTime).Hours() <= 1 && now.Sub(releaseTime).Hours() >= 0
-			}
-		}
+	n := strconv.FormatFloat(c.Number, 'f', -1, 64)
+	switch {
+	case c.Title == "":
+		return "Chapter " + n
+	case strings.Contains(c.Title, n):
+		return c.Title
 	}
-
-	return false
+	return "Chapter " + n + ": " + c.Title
 }
@@ -2,8 +2,13 @@ package commands_test
 
 import (
 	"discordbot/commands"
+	"discordbot/manga"
+	"fmt"
 	"log"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/andersfylling/disgord"
 )
@@ -12,6 +17,8 @@ type mockMangaNotificationRepo struct {
 	id int64
 	notifications []commands.MangaNotification
 	links []xref
+	searches []commands.MangaSearch
+	searchesRemovedBefore time.Time
 }
 
 type xref struct {
@@ -39,6 +46,51 @@ func (r *mockMangaNotificationRepo) AddMangaLink(mangaNotificationId int64, mang
 	return nil
 }
 
+func (r *mockMangaNotificationRepo) GetMangaNotifications(guild commands.Snowflake, discordUserID commands.Snowflake) ([]commands.MangaNotification, error) {
+	var result []commands.MangaNotification
+	for _, n := range r.notifications {
+		if (n.Guild == guild && n.DiscordUserID == 0) || (n.DiscordUserID != 0 && n.DiscordUserID == discordUserID) {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+func (r *mockMangaNotificationRepo) RemoveMangaNotification(ID int64) error {
+	for i, n := range r.notifications {
+		if n.MangaNotificationID == ID {
+			r.notifications = append(r.notifications[:i], r.notifications[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *mockMangaNotificationRepo) SaveMangaSearch(search *commands.MangaSearch) error {
+	search.MangaSearchID = int64(len(r.searches) + 1)
+	r.searches = append(r.searches, *search)
+	return nil
+}
+
+func (r *mockMangaNotificationRepo) GetMangaSearchByMessage(msg commands.Snowflake) (commands.MangaSearch, error) {
+	for _, search := range r.searches {
+		if search.Message == msg {
+			return search, nil
+		}
+	}
+	return commands.MangaSearch{}, fmt.Errorf("no search for message %v", msg)
+}
+
+func (r *mockMangaNotificationRepo) IsMangaSearchMessage(msg commands.Snowflake) (bool, error) {
+	_, err := r.GetMangaSearchByMessage(msg)
+	return err == nil, nil
+}
+
+func (r *mockMangaNotificationRepo) RemoveMangaSearchesBefore(t time.Time) error {
+	r.searchesRemovedBefore = t
+	return nil
+}
+
 func (r *mockMangaLinkRepo) SaveMangaLink(link *commands.MangaLink) error {
 	r.link = append(r.link, *link)
 	return nil
@@ -57,6 +109,16 @@ func (r *mockMangaLinkRepo) GetAllMangaLinks() ([]commands.MangaLink, error) {
 	return r.link, nil
 }
 
+func (r *mockMangaLinkRepo) UpdateLastChapter(link *commands.MangaLink) error {
+	for i, l := range r.link {
+		if l.MangaLink == link.MangaLink {
+			r.link[i].LastChapter = link.LastChapter
+			r.link[i].LastChapterURL = link.LastChapterURL
+		}
+	}
+	return nil
+}
+
 func TestCreateMangaNotification(t *testing.T) {
 	const content = "https://earlymanga.org/manga/a-returner-s-magic-should-be-special channel role"
 	repo := mockMangaNotificationRepo{}
@@ -70,7 +132,7 @@ func TestCreateMangaNotification(t *testing.T) {
 		},
 	}
 	user := &commands.Users{UsersID: 1, DiscordUsersID: 1}
-	factory := commands.NewMangaNotificationFactory(&repo, &lrepo, s)
+	factory := commands.NewMangaNotificationFactory(&repo, &lrepo, s, manga.DefaultRegistry(nil))
 	c := factory.CreateRequest(msg, user)
 	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
 	result, _ := repo.GetAllMangaNotifications()
@@ -84,4 +146,110 @@ func TestCreateMangaNotification(t *testing.T) {
 		log.Println("Manga Link not saved")
 		t.FailNow()
 	}
-}
\ No newline at end of file
+}
+
+func TestCreateMangaNotificationUnsupportedSite(t *testing.T) {
+	repo := mockMangaNotificationRepo{}
+	lrepo := mockMangaLinkRepo{}
+	s := &mockSession{guild: &commonMockGuild}
+	msg := &disgord.MessageCreate{Message: &disgord.Message{Content: "https://example.com/manga/1 channel role"}}
+	factory := commands.NewMangaNotificationFactory(&repo, &lrepo, s, manga.DefaultRegistry(nil))
+
+	factory.CreateRequest(msg, &commands.Users{UsersID: 1}).(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+	if s.message != "Manga site not supported. Supported sites: manganato.com, earlymanga.org, mangadex.org" {
+		t.Error("Unexpected message ", s.message)
+	}
+	if len(repo.notifications) != 0 || len(lrepo.link) != 0 {
+		t.Error("Notification saved for an unsupported site")
+	}
+}
+
+//stubMangaSource - serves one manga for every link on manga.test
+type stubMangaSource struct {
+	manga manga.Manga
+}
+
+func (s *stubMangaSource) Name() string {
+	return "manga.test"
+}
+
+func (s *stubMangaSource) Matches(u *url.URL) bool {
+	return u.Host == "manga.test"
+}
+
+func (s *stubMangaSource) Fetch(f manga.Fetcher, link string) (manga.Manga, error) {
+	return s.manga, nil
+}
+
+func newStubChapters(numbered bool, newest int, count int) []manga.Chapter {
+	var chapters []manga.Chapter
+	for n := newest; n > newest-count; n-- {
+		c := manga.Chapter{Title: fmt.Sprintf("Chapter %d", n), URL: fmt.Sprintf("https://manga.test/1/chapter-%d", n)}
+		if numbered {
+			c.Number = float64(n)
+		}
+		chapters = append(chapters, c)
+	}
+	return chapters
+}
+
+func TestLookForNewMangaChapter(t *testing.T) {
+	inputs := []struct {
+		numbered    bool
+		lastChapter float64
+		lastURL     string
+		expected    string
+	}{
+		{true, 0, "", ""},
+		{true, 12, "https://manga.test/1/chapter-12", ""},
+		{true, 11, "https://manga.test/1/chapter-11", "<@&5> New chapter of Test Manga found: Chapter 12 https://manga.test/1/chapter-12"},
+		{true, 10, "https://old.manga.test/1/10", "<@&5> 2 new chapters of Test Manga found:\nChapter 11 https://manga.test/1/chapter-11\nChapter 12 https://manga.test/1/chapter-12"},
+		{false, 0, "https://manga.test/1/chapter-10", "<@&5> 2 new chapters of Test Manga found:\nChapter 11 https://manga.test/1/chapter-11\nChapter 12 https://manga.test/1/chapter-12"},
+	}
+	for _, input := range inputs {
+		source := &stubMangaSource{manga.Manga{Title: "Test Manga", Chapters: newStubChapters(input.numbered, 12, 5)}}
+		lrepo := &mockMangaLinkRepo{link: []commands.MangaLink{{
+			MangaLink:          "https://manga.test/1",
+			LastChapter:        input.lastChapter,
+			LastChapterURL:     input.lastURL,
+			MangaNotifications: []commands.MangaNotification{{Channel: 10, Role: 5}},
+		}}}
+		s := &mockSession{}
+		sources := manga.NewRegistry(nil, source)
+
+		commands.LookForNewMangaChapter(lrepo, sources, s)
+
+		if s.message != input.expected {
+			t.Error("Unexpected message after chapter ", input.lastURL, ": ", s.message)
+		}
+		if l := lrepo.link[0]; l.LastChapterURL != "https://manga.test/1/chapter-12" || (input.numbered && l.LastChapter != 12) {
+			t.Error("Last chapter not recorded ", l.LastChapter, " ", l.LastChapterURL)
+		}
+
+		//Checking again announces nothing new
+		commands.LookForNewMangaChapter(lrepo, sources, s)
+		if len(s.sentMessages) > 1 {
+			t.Error("Chapters announced twice ", s.sentMessages)
+		}
+	}
+}
+
+func TestLookForNewMangaChapterBatchLimit(t *testing.T) {
+	source := &stubMangaSource{manga.Manga{Title: "Test Manga", Chapters: newStubChapters(true, 30, 30)}}
+	lrepo := &mockMangaLinkRepo{link: []commands.MangaLink{{
+		MangaLink:          "https://manga.test/1",
+		LastChapter:        5,
+		LastChapterURL:     "https://manga.test/1/chapter-5",
+		MangaNotifications: []commands.MangaNotification{{Channel: 10, Role: 5}},
+	}}}
+	s := &mockSession{}
+
+	commands.LookForNewMangaChapter(lrepo, manga.NewRegistry(nil, source), s)
+
+	lines := strings.Split(s.message, "\n")
+	if len(lines) != 12 || lines[0] != "<@&5> 25 new chapters of Test Manga found:" || lines[1] != "... 15 earlier chapters" ||
+		lines[2] != "Chapter 21 https://manga.test/1/chapter-21" || lines[11] != "Chapter 30 https://manga.test/1/chapter-30" {
+		t.Error("Unexpected batch ", s.message)
+	}
+}
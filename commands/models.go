@@ -1,6 +1,10 @@
 package commands
 
-import "github.com/andersfylling/disgord"
+import (
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
 
 type Snowflake = disgord.Snowflake
 
@@ -61,6 +65,45 @@ type StrawpollDeadline struct {
 	Role                Snowflake
 }
 
+/*
+StrawpollStandings - periodic standings updates posted before a strawpoll deadline
+*/
+type StrawpollStandings struct {
+	StrawpollDeadlineID int64
+	UpdateInterval      time.Duration
+	Reminder            time.Duration
+	Pinned              bool
+	Message             Snowflake
+	LastUpdate          time.Time
+	ReminderSent        bool
+}
+
+/*
+VotePoll - reaction poll run by the bot
+*/
+type VotePoll struct {
+	VotePollID  int64
+	User        int64
+	Guild       Snowflake
+	Channel     Snowflake
+	Message     Snowflake
+	Role        Snowflake
+	Title       string
+	Options     []string
+	MultiChoice bool
+	Anonymous   bool
+	Deadline    time.Time
+}
+
+/*
+VotePollVote - a single user's vote for an option of a vote poll
+*/
+type VotePollVote struct {
+	VotePollID int64
+	Option     int
+	User       Snowflake
+}
+
 type Tournament struct {
 	TournamentID    int64
 	User            int64
@@ -68,25 +111,150 @@ type Tournament struct {
 	DiscordServerID Snowflake
 	Organizers      []Users
 	Participants    []TournamentParticipant
-	CurrentMatch    int
+	//CurrentMatch - match called on the default setup, named setups are in Stations
+	CurrentMatch int
+	Stations     []TournamentStation
+	//SelfReporting - players can report their own matches and have their opponent confirm
+	SelfReporting     bool
+	LastReportedMatch int
+	//Name - short name picking the tournament when a server runs more than one
+	Name string
+	//ChannelID - channel whose commands go to this tournament, 0 when not bound
+	ChannelID Snowflake
+	//AnnounceChannel - channel match announcements are posted to, 0 when off
+	AnnounceChannel Snowflake
+	//Game - ladder the tournament's matches are rated on
+	Game string
+	//CheckInMessage - message players react to while check in is open, 0 when closed
+	CheckInMessage  Snowflake
+	CheckInChannel  Snowflake
+	CheckInEnds     time.Time
+	CheckInReminded bool
+}
+
+//MatchReport - a score reported by a player waiting for their opponent to confirm
+type MatchReport struct {
+	MatchReportID int64
+	TournamentID  int64
+	Guild         Snowflake
+	MatchID       int
+	Reporter      Snowflake
+	WinnerID      int
+	Player1Score  int
+	Player2Score  int
+	Channel       Snowflake
+	Message       Snowflake
+	Disputed      bool
+}
+
+//TournamentResult - a finished tournament archived when it ends
+type TournamentResult struct {
+	TournamentResultID int64                   `json:"id"`
+	DiscordServerID    Snowflake               `json:"discord_server_id"`
+	Name               string                  `json:"name"`
+	ChallongeID        string                  `json:"challonge_id"`
+	EndedAt            time.Time               `json:"ended_at"`
+	Placements         []TournamentPlacement   `json:"placements"`
+	Matches            []TournamentResultMatch `json:"matches"`
+}
+
+type TournamentPlacement struct {
+	Name          string    `json:"name"`
+	DiscordUserID Snowflake `json:"discord_user_id"`
+	Seed          int       `json:"seed"`
+	//Rank - final rank from challonge, 0 when the tournament ended before it was finalized
+	Rank int `json:"rank"`
+}
+
+//TournamentResultMatch - a played match kept with the players' names since the participants are not archived
+type TournamentResultMatch struct {
+	MatchID          int       `json:"match_id"`
+	Round            int       `json:"round"`
+	Player1          string    `json:"player1"`
+	Player1DiscordID Snowflake `json:"player1_discord_id"`
+	Player2          string    `json:"player2"`
+	Player2DiscordID Snowflake `json:"player2_discord_id"`
+	//Winner - 1 or 2 for the player who won
+	Winner    int    `json:"winner"`
+	ScoresCsv string `json:"scores_csv"`
+}
+
+//MatchAnnouncement - an announcement already posted for a match so it is not posted again
+type MatchAnnouncement struct {
+	TournamentID int64
+	MatchID      int
+	Event        string
+}
+
+type TournamentStation struct {
+	Name         string
+	CurrentMatch int
 }
 
 type TournamentParticipant struct {
 	TournamentParticipantID int64
 	Name                    string
 	ChallongeID             int
+	DiscordUserID           Snowflake
 }
 
+//MangaNotification - DiscordUserID is set for subscriptions sent by DM instead of to a channel and role.
+//Link is only filled in when notifications are looked up with their manga.
 type MangaNotification struct {
 	MangaNotificationID int64
 	User                int64
 	Guild               Snowflake
 	Channel             Snowflake
 	Role                Snowflake
+	DiscordUserID       Snowflake
+	Link                string
 }
 
+//MangaLink - LastChapter and LastChapterURL are the newest chapter already announced, empty until the link is first checked
 type MangaLink struct {
 	MangaLinkID        int64
 	MangaLink          string
+	LastChapter        float64
+	LastChapterURL     string
 	MangaNotifications []MangaNotification
 }
+
+//MangaSearch - search results posted to a channel, followed by reacting with or replying with their number
+type MangaSearch struct {
+	MangaSearchID int64
+	Guild         Snowflake
+	Channel       Snowflake
+	Message       Snowflake
+	Created       time.Time
+	Results       []MangaSearchResult
+}
+
+//MangaSearchResult - Position starts at 1
+type MangaSearchResult struct {
+	Position int
+	Title    string
+	Link     string
+}
+
+//PlayerRating - a player's rating on one game's ladder in a server.
+//Players imported from challonge without a discord account are kept by name with DiscordUserID 0.
+type PlayerRating struct {
+	PlayerRatingID  int64
+	DiscordServerID Snowflake
+	Game            string
+	DiscordUserID   Snowflake
+	Name            string
+	Rating          float64
+	Wins            int
+	Losses          int
+}
+
+//RatedMatch - a match already counted in the ratings with the change it made, so it can be undone
+type RatedMatch struct {
+	DiscordServerID Snowflake
+	ChallongeID     string
+	MatchID         int
+	WinnerRatingID  int64
+	LoserRatingID   int64
+	Change          float64
+}
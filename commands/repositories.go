@@ -1,5 +1,7 @@
 package commands
 
+import "time"
+
 type UsersRepository interface {
 	GetUserByDiscordId(user Snowflake) (Users, error)
 	DoesUserExist(user Snowflake) bool
@@ -37,25 +39,78 @@ StrawpollDeadlineRepository interface for strawpoll commands
 type StrawpollDeadlineRepository interface {
 	SaveStrawpollDeadline(*StrawpollDeadline) error
 	GetAllStrawpollDeadlines() ([]StrawpollDeadline, error)
+	GetStrawpollDeadlinesByGuild(guild Snowflake) ([]StrawpollDeadline, error)
+	GetStrawpollDeadlineByID(ID int64) (StrawpollDeadline, error)
 	DeleteStrawpollDeadlineByID(ID int64) error
+	SaveStrawpollStandings(*StrawpollStandings) error
+	GetStrawpollStandings(strawpollDeadlineID int64) (StrawpollStandings, error)
+	GetAllStrawpollStandings() ([]StrawpollStandings, error)
+}
+
+/*
+VotePollRepository interface for reaction polls and their votes
+*/
+type VotePollRepository interface {
+	SaveVotePoll(*VotePoll) error
+	GetVotePollByMessage(msg Snowflake) (VotePoll, error)
+	GetAllVotePolls() ([]VotePoll, error)
+	IsVotePollMessage(msg Snowflake) (bool, error)
+	DeleteVotePollByID(ID int64) error
+	AddVote(*VotePollVote) error
+	RemoveVote(*VotePollVote) error
+	RemoveUserVotes(pollID int64, user Snowflake) error
+	GetVotes(pollID int64) ([]VotePollVote, error)
 }
 
 type TournamentRepository interface {
 	SaveTourney(*Tournament) error
-	GetTourneyByServer(Snowflake) (Tournament, error)
+	GetTourneyByID(tournamentID int64) (Tournament, error)
+	GetTourneysByServer(discordServerID Snowflake) ([]Tournament, error)
+	GetTourneysByParticipant(discordUserID Snowflake) ([]Tournament, error)
 	AddTourneyOrganizer(userID int64, tourneyID int64) error
 	IsUserTourneyOrganizer(userID int64, tourneyID int64) (bool, error)
-	RemoveTourney(discordServerID Snowflake) error
+	RemoveTourneyOrganizer(userID int64, tourneyID int64) error
+	RemoveTourney(tournamentID int64) error
+	SaveMatchReport(*MatchReport) error
+	GetMatchReport(tournamentID int64, matchID int) (MatchReport, error)
+	GetMatchReportByMessage(msg Snowflake) (MatchReport, error)
+	IsMatchReportMessage(msg Snowflake) (bool, error)
+	RemoveMatchReport(ID int64) error
+	GetAnnouncedTourneys() ([]Tournament, error)
+	GetCheckInTourneys() ([]Tournament, error)
+	GetTourneyByCheckInMessage(msg Snowflake) (Tournament, error)
+	IsCheckInMessage(msg Snowflake) (bool, error)
+	GetMatchAnnouncements(tournamentID int64) ([]MatchAnnouncement, error)
+	SaveMatchAnnouncement(*MatchAnnouncement) error
+	RemoveMatchAnnouncement(*MatchAnnouncement) error
+	SaveTournamentResult(*TournamentResult) error
+	GetTournamentResult(ID int64) (TournamentResult, error)
+	GetTournamentResultsByServer(discordServerID Snowflake) ([]TournamentResult, error)
+	GetTournamentResultsByPlayer(discordServerID Snowflake, discordUserID Snowflake) ([]TournamentResult, error)
+	SavePlayerRating(*PlayerRating) error
+	GetPlayerRating(discordServerID Snowflake, game string, discordUserID Snowflake, name string) (PlayerRating, error)
+	GetPlayerRatingByID(ID int64) (PlayerRating, error)
+	GetPlayerRatingsByServer(discordServerID Snowflake) ([]PlayerRating, error)
+	SaveRatedMatch(*RatedMatch) error
+	GetRatedMatch(discordServerID Snowflake, challongeID string, matchID int) (RatedMatch, error)
+	RemoveRatedMatch(discordServerID Snowflake, challongeID string, matchID int) error
 }
 
 type MangaNotificationRepository interface {
 	SaveMangaNotification(*MangaNotification) error
 	GetAllMangaNotifications() ([]MangaNotification, error)
 	AddMangaLink(mangaNotificationId int64, mangaLinkId int64) error
+	GetMangaNotifications(guild Snowflake, discordUserID Snowflake) ([]MangaNotification, error)
+	RemoveMangaNotification(ID int64) error
+	SaveMangaSearch(*MangaSearch) error
+	GetMangaSearchByMessage(msg Snowflake) (MangaSearch, error)
+	IsMangaSearchMessage(msg Snowflake) (bool, error)
+	RemoveMangaSearchesBefore(time.Time) error
 }
 
 type MangaLinksRepository interface {
 	SaveMangaLink(*MangaLink) error
 	GetMangaLinkByLink(string) (MangaLink, error)
 	GetAllMangaLinks() ([]MangaLink, error)
+	UpdateLastChapter(*MangaLink) error
 }
\ No newline at end of file
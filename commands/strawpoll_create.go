@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"discordbot/strawpoll"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+const strawpollCreateUsage = "Usage: " + CommandPrefix + StrawPollString + " create \"Title\" \"option 1\" \"option 2\" ... [--deadline 2d] [--channel channel_name] [--role role_name] [--updates 6h] [--reminder 1h] [--pin]"
+
+func (c *strawpollDeadlineCommandFactory) CreatePollRequest(data *disgord.MessageCreate, user *Users) interface{} {
+	return &strawpollCreateCommand{
+		strawpollDeadlineCommandFactory: c,
+		data:                            data,
+		user:                            user,
+	}
+}
+
+type strawpollCreateCommand struct {
+	*strawpollDeadlineCommandFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *strawpollCreateCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+
+	args, flags := parseFlags(splitArguments(msg.Content), "pin")
+	if len(args) == 0 || strings.ToLower(args[0]) != "create" {
+		c.session.SendSimpleMessage(msg.ChannelID, strawpollCreateUsage)
+		return
+	}
+	args = args[1:]
+	if len(args) < 3 {
+		c.session.SendSimpleMessage(msg.ChannelID, "A poll needs a title and at least two options.\n"+strawpollCreateUsage)
+		return
+	}
+
+	standings, err := parseStandingsFlags(flags)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not set standings updates, "+err.Error()+". Use a duration like 30m, 6h or 1d.")
+		return
+	}
+
+	var deadline time.Time
+	if d, ok := flags["deadline"]; ok {
+		duration, err := parseDuration(d)
+		if err != nil || duration <= 0 {
+			c.session.SendSimpleMessage(msg.ChannelID, "Could not read deadline "+d+". Use a duration like 30m, 12h or 2d.")
+			return
+		}
+		deadline = time.Now().Add(duration)
+	} else if standings != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Standings updates need a --deadline.")
+		return
+	}
+
+	channelID := msg.ChannelID
+	if name, ok := flags["channel"]; ok {
+		channel := FindChannelByName(name, c.session.Guild(msg.GuildID))
+		if channel == nil {
+			c.session.SendSimpleMessage(msg.ChannelID, "Channel name not found")
+			return
+		}
+		channelID = channel.ID
+	}
+
+	var roleID Snowflake
+	if name, ok := flags["role"]; ok {
+		roles, _ := c.session.Guild(msg.GuildID).GetRoles()
+		role := FindRoleByName(name, roles)
+		if role == nil {
+			c.session.SendSimpleMessage(msg.ChannelID, "Role name not found")
+			return
+		}
+		roleID = role.ID
+	}
+
+	poll, err := c.strawpollClient.CreatePoll(strawpoll.NewCreatePollRequest(args[0], args[1:], deadline))
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, strawpoll unable to be created.")
+		return
+	}
+
+	c.session.SendSimpleMessage(msg.ChannelID, args[0]+" "+poll.Link())
+
+	if deadline.IsZero() {
+		return
+	}
+
+	strawpollDeadline := &StrawpollDeadline{
+		User:        c.user.UsersID,
+		Guild:       msg.GuildID,
+		Channel:     channelID,
+		Role:        roleID,
+		StrawpollID: poll.ID,
+	}
+	err = c.repo.SaveStrawpollDeadline(strawpollDeadline)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Poll created but the deadline announcement could not be saved.")
+		return
+	}
+	err = saveStrawpollStandings(c.repo, standings, strawpollDeadline.StrawpollDeadlineID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Poll created but standings updates could not be set.")
+	}
+	waitForStrawpollDeadline(c.session, c.repo, c.strawpollClient, *strawpollDeadline, deadline)
+}
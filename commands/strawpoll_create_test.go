@@ -0,0 +1,193 @@
+package commands_test
+
+import (
+	"discordbot/commands"
+	"discordbot/strawpoll"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+type mockStrawpollClient struct {
+	polls   map[string]*strawpoll.StrawPollResults
+	created []*strawpoll.CreatePollRequest
+}
+
+func (c *mockStrawpollClient) GetPoll(ID string) (*strawpoll.StrawPollResults, error) {
+	p, ok := c.polls[ID]
+	if !ok {
+		return nil, errors.New("error status code 404")
+	}
+	return p, nil
+}
+
+func (c *mockStrawpollClient) CreatePoll(r *strawpoll.CreatePollRequest) (*strawpoll.CreatePollResponse, error) {
+	c.created = append(c.created, r)
+	return &strawpoll.CreatePollResponse{ID: "newpoll"}, nil
+}
+
+type mockStrawpollDeadlineRepo struct {
+	deadlines []commands.StrawpollDeadline
+	standings map[int64]commands.StrawpollStandings
+}
+
+func (r *mockStrawpollDeadlineRepo) SaveStrawpollDeadline(s *commands.StrawpollDeadline) error {
+	s.StrawpollDeadlineID = int64(len(r.deadlines) + 1)
+	r.deadlines = append(r.deadlines, *s)
+	return nil
+}
+
+func (r *mockStrawpollDeadlineRepo) GetAllStrawpollDeadlines() ([]commands.StrawpollDeadline, error) {
+	return r.deadlines, nil
+}
+
+func (r *mockStrawpollDeadlineRepo) GetStrawpollDeadlinesByGuild(guild commands.Snowflake) ([]commands.StrawpollDeadline, error) {
+	var result []commands.StrawpollDeadline
+	for _, d := range r.deadlines {
+		if d.Guild == guild {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+func (r *mockStrawpollDeadlineRepo) GetStrawpollDeadlineByID(ID int64) (commands.StrawpollDeadline, error) {
+	for _, d := range r.deadlines {
+		if d.StrawpollDeadlineID == ID {
+			return d, nil
+		}
+	}
+	return commands.StrawpollDeadline{}, errors.New("sql: no rows in result set")
+}
+
+func (r *mockStrawpollDeadlineRepo) DeleteStrawpollDeadlineByID(ID int64) error {
+	for i, d := range r.deadlines {
+		if d.StrawpollDeadlineID == ID {
+			r.deadlines = append(r.deadlines[:i], r.deadlines[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func (r *mockStrawpollDeadlineRepo) SaveStrawpollStandings(s *commands.StrawpollStandings) error {
+	if r.standings == nil {
+		r.standings = make(map[int64]commands.StrawpollStandings)
+	}
+	r.standings[s.StrawpollDeadlineID] = *s
+	return nil
+}
+
+func (r *mockStrawpollDeadlineRepo) GetStrawpollStandings(ID int64) (commands.StrawpollStandings, error) {
+	s, ok := r.standings[ID]
+	if !ok {
+		return commands.StrawpollStandings{}, errors.New("sql: no rows in result set")
+	}
+	return s, nil
+}
+
+func (r *mockStrawpollDeadlineRepo) GetAllStrawpollStandings() ([]commands.StrawpollStandings, error) {
+	var result []commands.StrawpollStandings
+	for _, s := range r.standings {
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func TestCreateStrawpoll(t *testing.T) {
+	//Given: A poll create command with a deadline, channel and role
+	guild := mockGuild{
+		channels: []*disgord.Channel{{Name: "polls", ID: 55}},
+		roles:    []*disgord.Role{{Name: "voters", ID: 66}},
+	}
+	s := &mockSession{guild: &guild}
+	client := &mockStrawpollClient{}
+	repo := &mockStrawpollDeadlineRepo{}
+	msg := &disgord.MessageCreate{Message: &disgord.Message{
+		Content:   `create "Best game" "Melee" “Project M” --deadline 2d --channel polls --role voters`,
+		ChannelID: 10,
+		GuildID:   20,
+	}}
+	user := &commands.Users{UsersID: 1, DiscordUsersID: 1}
+	factory := commands.NewCommandFactory(s, client, repo)
+	c := factory.CreatePollRequest(msg, user)
+
+	//When: The command is executed
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+	//Then: The poll is created with the quoted title and options
+	if len(client.created) != 1 {
+		t.Fatal("Poll not created.")
+	}
+	p := client.created[0]
+	if p.Title != "Best game" || len(p.PollOptions) != 2 || p.PollOptions[1].Value != "Project M" {
+		log.Println("Poll arguments parsed incorrectly ", p)
+		t.Fail()
+	}
+	deadline := time.Unix(p.PollConfig.DeadlineAt, 0)
+	if time.Until(deadline) < 47*time.Hour || time.Until(deadline) > 49*time.Hour {
+		log.Println("Deadline not set two days out ", deadline)
+		t.Fail()
+	}
+	//And: The link is posted
+	if s.message != "Best game https://strawpoll.com/polls/newpoll" {
+		log.Println("Poll link not posted ", s.message)
+		t.Fail()
+	}
+	//And: The deadline announcement is registered
+	if len(repo.deadlines) != 1 {
+		t.Fatal("Deadline not saved.")
+	}
+	d := repo.deadlines[0]
+	if d.StrawpollID != "newpoll" || d.Channel != 55 || d.Role != 66 || d.Guild != 20 {
+		log.Println("Deadline saved incorrectly ", d)
+		t.Fail()
+	}
+}
+
+func TestCreateStrawpollWithoutDeadline(t *testing.T) {
+	s := &mockSession{guild: &commonMockGuild}
+	client := &mockStrawpollClient{}
+	repo := &mockStrawpollDeadlineRepo{}
+	msg := &disgord.MessageCreate{Message: &disgord.Message{
+		Content: `create "Lunch?" pizza sushi`,
+	}}
+	factory := commands.NewCommandFactory(s, client, repo)
+	c := factory.CreatePollRequest(msg, &commands.Users{UsersID: 1})
+
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+	if len(client.created) != 1 || client.created[0].PollConfig.DeadlineAt != 0 {
+		t.Error("Poll without deadline not created correctly.")
+	}
+	if len(repo.deadlines) != 0 {
+		t.Error("Deadline saved for poll without deadline.")
+	}
+}
+
+func TestCreateStrawpollBadArguments(t *testing.T) {
+	inputs := []string{
+		``,
+		`create "Only title"`,
+		`create "Title" a b --deadline soon`,
+		`create "Title" a b --channel missing`,
+	}
+	for _, input := range inputs {
+		s := &mockSession{guild: &commonMockGuild}
+		client := &mockStrawpollClient{}
+		msg := &disgord.MessageCreate{Message: &disgord.Message{Content: input}}
+		factory := commands.NewCommandFactory(s, client, &mockStrawpollDeadlineRepo{})
+		c := factory.CreatePollRequest(msg, &commands.Users{UsersID: 1})
+
+		c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+		if len(client.created) != 0 {
+			t.Error("Poll created for bad input ", input)
+		}
+		if s.message == "" {
+			t.Error("No error message sent for input ", input)
+		}
+	}
+}
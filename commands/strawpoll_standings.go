@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"discordbot/strawpoll"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultStandingsReminder = time.Hour
+
+//parseStandingsFlags reads --updates, --reminder and --pin. Nil is returned when no standings updates were asked for.
+func parseStandingsFlags(flags map[string]string) (*StrawpollStandings, error) {
+	interval, hasInterval := flags["updates"]
+	reminder, hasReminder := flags["reminder"]
+	_, pinned := flags["pin"]
+	if !hasInterval && !hasReminder {
+		if pinned {
+			return nil, errors.New("--pin needs --updates or --reminder")
+		}
+		return nil, nil
+	}
+
+	standings := &StrawpollStandings{Pinned: pinned, Reminder: defaultStandingsReminder}
+	if hasInterval {
+		d, err := parseDuration(interval)
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("could not read update interval %s", interval)
+		}
+		standings.UpdateInterval = d
+	}
+	if hasReminder {
+		d, err := parseDuration(reminder)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("could not read reminder %s", reminder)
+		}
+		standings.Reminder = d
+	}
+	return standings, nil
+}
+
+func saveStrawpollStandings(repo StrawpollDeadlineRepository, standings *StrawpollStandings, strawpollDeadlineID int64) error {
+	if standings == nil {
+		return nil
+	}
+	standings.StrawpollDeadlineID = strawpollDeadlineID
+	standings.LastUpdate = time.Now()
+	return repo.SaveStrawpollStandings(standings)
+}
+
+//PostStrawpollStandings posts or edits the current standings of polls whose update interval or reminder is due
+func PostStrawpollStandings(repo StrawpollDeadlineRepository, client strawpoll.StrawPollGetClient, s DiscordSession) {
+	standings, err := repo.GetAllStrawpollStandings()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	now := time.Now()
+	for _, st := range standings {
+		updateStrawpollStandings(s, repo, client, st, now)
+	}
+}
+
+func updateStrawpollStandings(s DiscordSession, repo StrawpollDeadlineRepository, client strawpoll.StrawPollGetClient, standings StrawpollStandings, now time.Time) {
+	strawpollDeadline, err := repo.GetStrawpollDeadlineByID(standings.StrawpollDeadlineID)
+	if err != nil {
+		log.WithField("strawpolldeadline", standings.StrawpollDeadlineID).Error(err)
+		return
+	}
+
+	poll, err := client.GetPoll(strawpollDeadline.StrawpollID)
+	if err != nil {
+		log.WithField("pollid", strawpollDeadline.StrawpollID).Error("Error fetching strawpoll ", err)
+		return
+	}
+
+	remaining := time.Unix(poll.Poll.PollConfig.DeadlineAt, 0).Sub(now)
+	if remaining <= 0 {
+		return
+	}
+	reminderDue := standings.Reminder > 0 && !standings.ReminderSent && remaining <= standings.Reminder
+	updateDue := standings.UpdateInterval > 0 && now.Sub(standings.LastUpdate) >= standings.UpdateInterval
+	if !reminderDue && !updateDue {
+		return
+	}
+
+	content := formatStrawpollStandings(strawpollDeadline.StrawpollID, poll.Poll)
+	if standings.Pinned {
+		if standings.Message != 0 {
+			if _, err := s.EditMessage(strawpollDeadline.Channel, standings.Message, content); err != nil {
+				log.Error(err)
+				standings.Message = 0
+			}
+		}
+		if standings.Message == 0 {
+			msg, err := s.SendSimpleMessage(strawpollDeadline.Channel, content)
+			if err != nil {
+				log.Error(err)
+				return
+			}
+			standings.Message = msg.ID
+			if err := s.PinMessage(strawpollDeadline.Channel, msg.ID); err != nil {
+				log.Error(err)
+			}
+		}
+	} else if !reminderDue {
+		s.SendSimpleMessage(strawpollDeadline.Channel, content)
+	}
+
+	if reminderDue {
+		//Updates run on a schedule, so the reminder says how long is really left rather than when it was asked for
+		left := remaining.Round(time.Minute)
+		if left < time.Minute {
+			left = time.Minute
+		}
+		reminder := fmt.Sprintf("%s left to vote on %s %s", formatDuration(left), poll.Poll.Title, strawpoll.PollURL(strawpollDeadline.StrawpollID))
+		if strawpollDeadline.Role != 0 {
+			reminder = createMention(strawpollDeadline.Role) + " " + reminder
+		}
+		if !standings.Pinned {
+			reminder += "\n" + content
+		}
+		s.SendSimpleMessage(strawpollDeadline.Channel, reminder)
+		standings.ReminderSent = true
+	}
+
+	standings.LastUpdate = now
+	err = repo.SaveStrawpollStandings(&standings)
+	if err != nil {
+		log.WithField("strawpollstandings", standings).Error(err)
+	}
+}
+
+func formatStrawpollStandings(pollID string, poll strawpoll.Poll) string {
+	options := make([]strawpoll.PollOptions, len(poll.PollOptions))
+	copy(options, poll.PollOptions)
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].VoteCount > options[j].VoteCount
+	})
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Current standings for %s (closes <t:%d:R>) %s", poll.Title, poll.PollConfig.DeadlineAt, strawpoll.PollURL(pollID)))
+	for i, o := range options {
+		b.WriteString(fmt.Sprintf("\n%d. %s - %d votes", i+1, o.Value, o.VoteCount))
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package commands_test
+
+import (
+	"discordbot/commands"
+	"discordbot/strawpoll"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newStandingsFixture(deadline time.Time, standings commands.StrawpollStandings) (*mockStrawpollClient, *mockStrawpollDeadlineRepo) {
+	client := &mockStrawpollClient{polls: map[string]*strawpoll.StrawPollResults{
+		"abc": newStrawpollResults("Lunch", deadline,
+			strawpoll.PollOptions{Value: "pizza", VoteCount: 2},
+			strawpoll.PollOptions{Value: "sushi", VoteCount: 5}),
+	}}
+	repo := &mockStrawpollDeadlineRepo{}
+	repo.SaveStrawpollDeadline(&commands.StrawpollDeadline{StrawpollID: "abc", Guild: 20, Channel: 55, Role: 66})
+	standings.StrawpollDeadlineID = 1
+	repo.SaveStrawpollStandings(&standings)
+	return client, repo
+}
+
+func TestStrawpollDeadlineWithStandings(t *testing.T) {
+	client := &mockStrawpollClient{polls: map[string]*strawpoll.StrawPollResults{
+		"abc": newStrawpollResults("Best game", time.Now().Add(48*time.Hour)),
+	}}
+	repo := &mockStrawpollDeadlineRepo{}
+
+	runStrawpollDeadlineCommand("https://strawpoll.com/polls/abc polls poll voters --updates 6h --pin", client, repo)
+
+	standings, err := repo.GetStrawpollStandings(1)
+	if err != nil {
+		t.Fatal("Standings not saved.")
+	}
+	if standings.UpdateInterval != 6*time.Hour || standings.Reminder != time.Hour || !standings.Pinned {
+		t.Error("Standings saved incorrectly ", standings)
+	}
+	if repo.deadlines[0].Role != 66 {
+		t.Error("Flags parsed into role name.")
+	}
+}
+
+func TestStrawpollDeadlineBadStandingsInterval(t *testing.T) {
+	client := &mockStrawpollClient{polls: map[string]*strawpoll.StrawPollResults{
+		"abc": newStrawpollResults("Best game", time.Now().Add(48*time.Hour)),
+	}}
+	repo := &mockStrawpollDeadlineRepo{}
+
+	s := runStrawpollDeadlineCommand("https://strawpoll.com/polls/abc polls poll voters --updates often", client, repo)
+
+	if len(repo.deadlines) != 0 || !strings.HasPrefix(s.message, "Could not set standings updates") {
+		t.Error("Bad interval accepted. Got ", s.message)
+	}
+}
+
+func TestPostStrawpollStandingsWhenDue(t *testing.T) {
+	//Given: A poll with six hourly updates last posted seven hours ago
+	client, repo := newStandingsFixture(time.Now().Add(24*time.Hour), commands.StrawpollStandings{
+		UpdateInterval: 6 * time.Hour,
+		Reminder:       time.Hour,
+		LastUpdate:     time.Now().Add(-7 * time.Hour),
+	})
+	s := &mockSession{}
+
+	//When: Standings are checked
+	commands.PostStrawpollStandings(repo, client, s)
+
+	//Then: The standings are posted ordered by votes
+	if len(s.sentMessages) != 1 || !strings.HasPrefix(s.message, "Current standings for Lunch") {
+		t.Fatal("Standings not posted. Got ", s.sentMessages)
+	}
+	if !strings.Contains(s.message, "1. sushi - 5 votes\n2. pizza - 2 votes") {
+		t.Error("Standings not ordered. Got ", s.message)
+	}
+
+	//And: Checking again right away posts nothing new
+	commands.PostStrawpollStandings(repo, client, s)
+	if len(s.sentMessages) != 1 {
+		t.Error("Standings posted before interval passed.")
+	}
+}
+
+func TestPostStrawpollStandingsReminder(t *testing.T) {
+	client, repo := newStandingsFixture(time.Now().Add(50*time.Minute), commands.StrawpollStandings{
+		Reminder:   time.Hour,
+		LastUpdate: time.Now(),
+	})
+	s := &mockSession{}
+
+	commands.PostStrawpollStandings(repo, client, s)
+
+	//The reminder is for an hour before, but the poll closes sooner than that
+	if !strings.HasPrefix(s.message, "<@&66> 50 minutes left to vote on Lunch") {
+		t.Error("Reminder not sent. Got ", s.message)
+	}
+
+	commands.PostStrawpollStandings(repo, client, s)
+	if len(s.sentMessages) != 1 {
+		t.Error("Reminder sent more than once. Got ", s.sentMessages)
+	}
+}
+
+func TestPostStrawpollStandingsPinned(t *testing.T) {
+	client, repo := newStandingsFixture(time.Now().Add(24*time.Hour), commands.StrawpollStandings{
+		UpdateInterval: time.Hour,
+		Pinned:         true,
+		LastUpdate:     time.Now().Add(-2 * time.Hour),
+	})
+	s := &mockSession{}
+
+	//First update posts and pins a message
+	commands.PostStrawpollStandings(repo, client, s)
+	if len(s.pinnedMessages) != 1 || repo.standings[1].Message != 999 {
+		t.Fatal("Standings message not pinned.")
+	}
+
+	//Later updates edit the pinned message
+	st := repo.standings[1]
+	st.LastUpdate = time.Now().Add(-2 * time.Hour)
+	repo.standings[1] = st
+	commands.PostStrawpollStandings(repo, client, s)
+	if len(s.sentMessages) != 1 || !strings.HasPrefix(s.editedMessages[999], "Current standings for Lunch") {
+		t.Error("Pinned standings not edited. Sent ", s.sentMessages, " edited ", s.editedMessages)
+	}
+}
+
+func TestPostStrawpollStandingsAfterDeadline(t *testing.T) {
+	client, repo := newStandingsFixture(time.Now().Add(-time.Minute), commands.StrawpollStandings{
+		UpdateInterval: time.Hour,
+		LastUpdate:     time.Now().Add(-2 * time.Hour),
+	})
+	s := &mockSession{}
+
+	commands.PostStrawpollStandings(repo, client, s)
+
+	if len(s.sentMessages) != 0 {
+		t.Error("Standings posted after the poll closed.")
+	}
+}
@@ -3,7 +3,7 @@ package commands
 import (
 	"discordbot/strawpoll"
 	"fmt"
-	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,16 +11,16 @@ import (
 )
 
 type strawpollDeadlineCommandFactory struct {
-	strawpollClient *strawpoll.Client
+	strawpollClient strawpollClient
 	repo            StrawpollDeadlineRepository
 	session         DiscordSession
 }
 
 func (c *strawpollDeadlineCommandFactory) PrintHelp() string {
-	return CommandPrefix + StrawPollDeadlineString + "{strawpoll_url} {channel_name} {role_name} - Ping role in given channel when deadline is met and announce results."
+	return CommandPrefix + StrawPollDeadlineString + " {strawpoll_url} {channel_name} {role_name} [--updates 6h] [--reminder 1h] [--pin] - Ping role in given channel when deadline is met and announce results, optionally posting standings until then. Use list to see pending deadlines and cancel {id} to remove one."
 }
 
-func NewCommandFactory(session DiscordSession, strawpollClient *strawpoll.Client, repo StrawpollDeadlineRepository) *strawpollDeadlineCommandFactory {
+func NewCommandFactory(session DiscordSession, strawpollClient strawpollClient, repo StrawpollDeadlineRepository) *strawpollDeadlineCommandFactory {
 	return &strawpollDeadlineCommandFactory{
 		strawpollClient: strawpollClient,
 		repo:            repo,
@@ -45,38 +45,69 @@ type strawpollDeadlineCommand struct {
 func (c *strawpollDeadlineCommand) ExecuteMessageCreateCommand() {
 	msg := c.data.Message
 
-	split := strings.Split(msg.Content, " ")
+	split := strings.Fields(msg.Content)
+	if len(split) > 0 {
+		switch strings.ToLower(split[0]) {
+		case "list":
+			c.listDeadlines()
+			return
+		case "cancel":
+			c.cancelDeadline(split[1:])
+			return
+		}
+	}
+
+	split, flags := parseFlags(split, "pin")
+	standings, err := parseStandingsFlags(flags)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not set standings updates, "+err.Error()+". Use a duration like 30m, 6h or 1d.")
+		return
+	}
+
 	if len(split) < 3 {
-		c.session.SendSimpleMessage(msg.ChannelID, "Incorrect number of arguments for command.")
+		c.session.SendSimpleMessage(msg.ChannelID, "Incorrect number of arguments for command.\n"+c.PrintHelp())
 		return
 	}
 
-	u, err := url.Parse(split[0])
+	pollID, err := strawpoll.PollIDFromURL(split[0])
 	if err != nil {
-		c.session.SendSimpleMessage(msg.ChannelID, "Error processing strawpoll url.")
+		c.session.SendSimpleMessage(msg.ChannelID, "Error processing strawpoll url. Links should look like https://strawpoll.com/polls/{id}")
 		return
 	}
 
-	pollID := u.Path[7:]
-	poll, _ := c.strawpollClient.GetPoll(pollID)
+	poll, err := c.strawpollClient.GetPoll(pollID)
+	if err != nil {
+		log.WithField("pollid", pollID).Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not find strawpoll "+pollID+".")
+		return
+	}
 
-	now := time.Now()
+	if poll.Poll.PollConfig.DeadlineAt == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not set timer for poll. The poll has no deadline.")
+		return
+	}
 	pollDeadline := time.Unix(poll.Poll.PollConfig.DeadlineAt, 0)
-	if now.After(pollDeadline) {
-		c.session.SendSimpleMessage(msg.ChannelID, "Could not set timer for poll. Deadline either missing or deadline has passed.")
+	if time.Now().After(pollDeadline) {
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not set timer for poll. The deadline has already passed.")
 		return
 	}
 
 	channelName := split[1]
 	guild := c.session.Guild(msg.GuildID)
 	channel := FindChannelByName(channelName, guild)
+	if channel == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Channel "+channelName+" not found.")
+		return
+	}
 
-	roleName := strings.Join(split[2:]," ")
-	roles, _ := c.session.Guild(msg.GuildID).GetRoles()
+	roleName := strings.Join(split[2:], " ")
+	roles, _ := guild.GetRoles()
 	role := FindRoleByName(roleName, roles)
+	if role == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Role "+roleName+" not found.")
+		return
+	}
 
-	deadlineDuration := pollDeadline.Sub(now)
-	timeToWait := time.NewTimer(deadlineDuration)
 	strawpollDeadline := &StrawpollDeadline{
 		User:        c.user.UsersID,
 		Guild:       msg.GuildID,
@@ -84,67 +115,144 @@ func (c *strawpollDeadlineCommand) ExecuteMessageCreateCommand() {
 		Role:        role.ID,
 		StrawpollID: pollID,
 	}
-	c.repo.SaveStrawpollDeadline(strawpollDeadline)
+	err = c.repo.SaveStrawpollDeadline(strawpollDeadline)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, strawpoll deadline unable to be saved.")
+		return
+	}
+	err = saveStrawpollStandings(c.repo, standings, strawpollDeadline.StrawpollDeadlineID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Deadline saved but standings updates could not be set.")
+	}
+	waitForStrawpollDeadline(c.session, c.repo, c.strawpollClient, *strawpollDeadline, pollDeadline)
+
+	c.session.ReactToMessage(msg.ID, msg.ChannelID, "👍")
+}
+
+func (c *strawpollDeadlineCommand) listDeadlines() {
+	msg := c.data.Message
+
+	deadlines, err := c.repo.GetStrawpollDeadlinesByGuild(msg.GuildID)
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+
+	if len(deadlines) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "No strawpoll deadlines pending.")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Pending strawpoll deadlines:")
+	for _, d := range deadlines {
+		b.WriteString(fmt.Sprintf("\n%d - %s in <#%s>", d.StrawpollDeadlineID, strawpoll.PollURL(d.StrawpollID), d.Channel))
+		poll, err := c.strawpollClient.GetPoll(d.StrawpollID)
+		if err != nil {
+			log.WithField("pollid", d.StrawpollID).Error(err)
+			continue
+		}
+		b.WriteString(fmt.Sprintf(" %s closes <t:%d:R>", poll.Poll.Title, poll.Poll.PollConfig.DeadlineAt))
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, b.String())
+}
+
+func (c *strawpollDeadlineCommand) cancelDeadline(args []string) {
+	msg := c.data.Message
+
+	if len(args) != 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+StrawPollDeadlineString+" cancel {id}")
+		return
+	}
+
+	ID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Deadline id should be a number. See "+CommandPrefix+StrawPollDeadlineString+" list")
+		return
+	}
+
+	d, err := c.repo.GetStrawpollDeadlineByID(ID)
+	if err != nil || d.Guild != msg.GuildID {
+		c.session.SendSimpleMessage(msg.ChannelID, "No strawpoll deadline with id "+args[0]+" in this server.")
+		return
+	}
+
+	err = c.repo.DeleteStrawpollDeadlineByID(ID)
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+
+	c.session.ReactWithThumbsUp(msg)
+}
+
+//waitForStrawpollDeadline announces the top answer of the poll once the deadline passes unless it was cancelled
+func waitForStrawpollDeadline(s DiscordSession, repo StrawpollDeadlineRepository, client strawpoll.StrawPollGetClient, strawpollDeadline StrawpollDeadline, pollDeadline time.Time) {
+	timeToWait := time.NewTimer(time.Until(pollDeadline))
 	go func() {
 		<-timeToWait.C
-		poll, err := c.strawpollClient.GetPoll(pollID)
+		//Ids are reused once a deadline is cancelled, so a different deadline under this id is not ours to announce
+		current, err := repo.GetStrawpollDeadlineByID(strawpollDeadline.StrawpollDeadlineID)
+		if err != nil || current != strawpollDeadline {
+			return
+		}
+		poll, err := client.GetPoll(strawpollDeadline.StrawpollID)
 		if err != nil {
-			log.WithField("pollid",pollID).Error("Error fetching strawpoll ", err)
+			log.WithField("pollid", strawpollDeadline.StrawpollID).Error("Error fetching strawpoll ", err)
 			return
 		}
-		pollAnswers := poll.Poll.PollOptions
-		topAnswer := pollAnswers[0]
-		for _, answer := range pollAnswers {
-			if answer.VoteCount > topAnswer.VoteCount {
-				topAnswer = answer
-			}
+		result := formatStrawpollResults(strawpollDeadline, poll.Poll)
+		s.SendSimpleMessage(strawpollDeadline.Channel, result)
+		if standings, err := repo.GetStrawpollStandings(strawpollDeadline.StrawpollDeadlineID); err == nil && standings.Message != 0 {
+			s.EditMessage(strawpollDeadline.Channel, standings.Message, result)
 		}
-		result := fmt.Sprintf("%s Strawpoll has closed. The top vote for %s is %s with %d votes.", role.Mention(), poll.Poll.Title, topAnswer.Value, topAnswer.VoteCount)
-		c.session.SendSimpleMessage(channel.ID, result)
-		err = c.repo.DeleteStrawpollDeadlineByID(strawpollDeadline.StrawpollDeadlineID)
+		err = repo.DeleteStrawpollDeadlineByID(strawpollDeadline.StrawpollDeadlineID)
 		if err != nil {
 			log.WithField("strawpoll", strawpollDeadline).Error(err)
 		}
 	}()
+}
 
-	c.session.ReactToMessage(msg.ID, msg.ChannelID, "👍")
+func formatStrawpollResults(strawpollDeadline StrawpollDeadline, poll strawpoll.Poll) string {
+	result := ""
+	if strawpollDeadline.Role != 0 {
+		result = createMention(strawpollDeadline.Role) + " "
+	}
+	if len(poll.PollOptions) == 0 {
+		return result + fmt.Sprintf("Strawpoll %s has closed with no options to vote on.", poll.Title)
+	}
+	topAnswer := poll.PollOptions[0]
+	for _, answer := range poll.PollOptions {
+		if answer.VoteCount > topAnswer.VoteCount {
+			topAnswer = answer
+		}
+	}
+	return result + fmt.Sprintf("Strawpoll has closed. The top vote for %s is %s with %d votes.", poll.Title, topAnswer.Value, topAnswer.VoteCount)
 }
 
-func RestartStrawpollDeadlines(client disgord.Session, dbClient StrawpollDeadlineRepository, strawpollClient *strawpoll.Client) {
-	strawpolls, err := dbClient.GetAllStrawpollDeadlines()
+//RestartStrawpollDeadlines resumes deadlines after a restart. Polls that closed while offline are announced immediately.
+func RestartStrawpollDeadlines(s DiscordSession, repo StrawpollDeadlineRepository, client strawpoll.StrawPollGetClient) {
+	strawpolls, err := repo.GetAllStrawpollDeadlines()
 	if err != nil {
 		log.Error(err)
 		return
 	}
-	for _, strawpoll := range strawpolls {
-
-		poll, err := strawpollClient.GetPoll(strawpoll.StrawpollID)
+	for _, strawpollDeadline := range strawpolls {
+		poll, err := client.GetPoll(strawpollDeadline.StrawpollID)
 		if err != nil {
-			dbClient.DeleteStrawpollDeadlineByID(strawpoll.StrawpollDeadlineID)
+			log.WithField("pollid", strawpollDeadline.StrawpollID).Error("Error fetching strawpoll ", err)
 			continue
 		}
 
-		now := time.Now()
-		pollDeadline := time.Unix(poll.Poll.PollConfig.DeadlineAt, 0)
-		if now.After(pollDeadline) {
-			dbClient.DeleteStrawpollDeadlineByID(strawpoll.StrawpollDeadlineID)
+		if poll.Poll.PollConfig.DeadlineAt == 0 {
+			repo.DeleteStrawpollDeadlineByID(strawpollDeadline.StrawpollDeadlineID)
 			continue
 		}
 
-		timeToWait := time.NewTimer(pollDeadline.Sub(now))
-		go func(strawpoll StrawpollDeadline) {
-			<-timeToWait.C
-			poll, _ := strawpollClient.GetPoll(strawpoll.StrawpollID)
-			pollAnswers := poll.Poll.PollOptions
-			topAnswer := pollAnswers[0]
-			for _, answer := range pollAnswers {
-				if answer.VoteCount > topAnswer.VoteCount {
-					topAnswer = answer
-				}
-			}
-			result := fmt.Sprintf("<@&%s> Strawpoll has closed. The top vote for %s is %s with %d votes.", strawpoll.Role, poll.Poll.Title, topAnswer.Value, topAnswer.VoteCount)
-			client.Channel(strawpoll.Channel).CreateMessage(&disgord.CreateMessageParams{Content: result})
-			dbClient.DeleteStrawpollDeadlineByID(strawpoll.StrawpollDeadlineID)
-		}(strawpoll)
+		waitForStrawpollDeadline(s, repo, client, strawpollDeadline, time.Unix(poll.Poll.PollConfig.DeadlineAt, 0))
 	}
 }
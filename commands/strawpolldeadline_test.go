@@ -0,0 +1,191 @@
+package commands_test
+
+import (
+	"discordbot/commands"
+	"discordbot/strawpoll"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+var deadlineMockGuild = mockGuild{
+	channels: []*disgord.Channel{{Name: "polls", ID: 55}},
+	roles:    []*disgord.Role{{Name: "poll voters", ID: 66}},
+}
+
+func newStrawpollResults(title string, deadline time.Time, options ...strawpoll.PollOptions) *strawpoll.StrawPollResults {
+	return &strawpoll.StrawPollResults{Poll: strawpoll.Poll{
+		Title:       title,
+		PollConfig:  strawpoll.PollConfig{DeadlineAt: deadline.Unix()},
+		PollOptions: options,
+	}}
+}
+
+func runStrawpollDeadlineCommand(content string, client *mockStrawpollClient, repo *mockStrawpollDeadlineRepo) *mockSession {
+	s := &mockSession{guild: &deadlineMockGuild}
+	msg := &disgord.MessageCreate{Message: &disgord.Message{
+		ID:        1,
+		Content:   content,
+		ChannelID: 10,
+		GuildID:   20,
+	}}
+	factory := commands.NewCommandFactory(s, client, repo)
+	c := factory.CreateRequest(msg, &commands.Users{UsersID: 1})
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestStrawpollDeadline(t *testing.T) {
+	//Given: A strawpoll closing in an hour
+	client := &mockStrawpollClient{polls: map[string]*strawpoll.StrawPollResults{
+		"abc": newStrawpollResults("Best game", time.Now().Add(time.Hour)),
+	}}
+	repo := &mockStrawpollDeadlineRepo{}
+
+	//When: A deadline is set for the poll
+	s := runStrawpollDeadlineCommand("https://strawpoll.com/polls/abc polls poll voters", client, repo)
+
+	//Then: The deadline is saved with the channel and role
+	if len(repo.deadlines) != 1 {
+		t.Fatal("Deadline not saved. Message: ", s.message)
+	}
+	d := repo.deadlines[0]
+	if d.StrawpollID != "abc" || d.Channel != 55 || d.Role != 66 || d.Guild != 20 {
+		log.Println("Deadline saved incorrectly ", d)
+		t.Fail()
+	}
+	if s.getReactedMessage() != 1 {
+		log.Println("Command not acknowledged.")
+		t.Fail()
+	}
+}
+
+func TestStrawpollDeadlineInvalidInput(t *testing.T) {
+	client := &mockStrawpollClient{polls: map[string]*strawpoll.StrawPollResults{
+		"open":   newStrawpollResults("Open", time.Now().Add(time.Hour)),
+		"closed": newStrawpollResults("Closed", time.Now().Add(-time.Hour)),
+		"nodate": {Poll: strawpoll.Poll{Title: "No deadline"}},
+	}}
+	cases := map[string]string{
+		"https://strawpoll.com/polls/open polls":                "Incorrect number of arguments",
+		"https://strawpoll.com/polls/ polls poll voters":        "Error processing strawpoll url",
+		"https://strawpoll.com/polls/missing polls poll voters": "Could not find strawpoll",
+		"https://strawpoll.com/polls/closed polls poll voters":  "deadline has already passed",
+		"https://strawpoll.com/polls/nodate polls poll voters":  "no deadline",
+		"https://strawpoll.com/polls/open nowhere poll voters":  "Channel nowhere not found",
+		"https://strawpoll.com/polls/open polls nobody":         "Role nobody not found",
+	}
+	for content, expected := range cases {
+		repo := &mockStrawpollDeadlineRepo{}
+		s := runStrawpollDeadlineCommand(content, client, repo)
+
+		if !strings.Contains(s.message, expected) {
+			t.Error("For ", content, " expected message containing ", expected, " got ", s.message)
+		}
+		if len(repo.deadlines) != 0 {
+			t.Error("Deadline saved for invalid input ", content)
+		}
+	}
+}
+
+func TestListStrawpollDeadlines(t *testing.T) {
+	deadline := time.Now().Add(time.Hour)
+	client := &mockStrawpollClient{polls: map[string]*strawpoll.StrawPollResults{
+		"abc": newStrawpollResults("Best game", deadline),
+	}}
+	repo := &mockStrawpollDeadlineRepo{}
+	repo.SaveStrawpollDeadline(&commands.StrawpollDeadline{StrawpollID: "abc", Guild: 20, Channel: 55})
+	repo.SaveStrawpollDeadline(&commands.StrawpollDeadline{StrawpollID: "other", Guild: 30, Channel: 55})
+
+	s := runStrawpollDeadlineCommand("list", client, repo)
+
+	if !strings.Contains(s.message, "1 - https://strawpoll.com/polls/abc in <#55> Best game closes <t:") {
+		t.Error("Deadline not listed. Got ", s.message)
+	}
+	if strings.Contains(s.message, "other") {
+		t.Error("Deadline from another server listed. Got ", s.message)
+	}
+}
+
+func TestCancelStrawpollDeadline(t *testing.T) {
+	client := &mockStrawpollClient{}
+	repo := &mockStrawpollDeadlineRepo{}
+	repo.SaveStrawpollDeadline(&commands.StrawpollDeadline{StrawpollID: "abc", Guild: 20})
+	repo.SaveStrawpollDeadline(&commands.StrawpollDeadline{StrawpollID: "other", Guild: 30})
+
+	s := runStrawpollDeadlineCommand("cancel 2", client, repo)
+	if len(repo.deadlines) != 2 || !strings.HasPrefix(s.message, "No strawpoll deadline with id 2") {
+		t.Error("Deadline from another server cancelled.")
+	}
+
+	runStrawpollDeadlineCommand("cancel 1", client, repo)
+	if len(repo.deadlines) != 1 || repo.deadlines[0].StrawpollID != "other" {
+		t.Error("Deadline not cancelled. Got ", repo.deadlines)
+	}
+}
+
+func TestRestartStrawpollDeadlineWithoutOptions(t *testing.T) {
+	//Given: A saved deadline for a poll that closed while offline with no options
+	client := &mockStrawpollClient{polls: map[string]*strawpoll.StrawPollResults{
+		"abc": newStrawpollResults("Empty", time.Now().Add(-time.Minute)),
+	}}
+	repo := &mockStrawpollDeadlineRepo{}
+	repo.SaveStrawpollDeadline(&commands.StrawpollDeadline{StrawpollID: "abc", Guild: 20, Channel: 55, Role: 66})
+	s := &mockSession{}
+
+	//When: Deadlines are restarted
+	commands.RestartStrawpollDeadlines(s, repo, client)
+	time.Sleep(50 * time.Millisecond)
+
+	//Then: The poll is announced without panicking and removed
+	if s.message != "<@&66> Strawpoll Empty has closed with no options to vote on." {
+		t.Error("Unexpected announcement ", s.message)
+	}
+	if len(repo.deadlines) != 0 {
+		t.Error("Deadline not removed after announcement.")
+	}
+}
+
+func TestRestartStrawpollDeadlineAnnouncesTopVote(t *testing.T) {
+	client := &mockStrawpollClient{polls: map[string]*strawpoll.StrawPollResults{
+		"abc": newStrawpollResults("Lunch", time.Now().Add(-time.Minute),
+			strawpoll.PollOptions{Value: "pizza", VoteCount: 2},
+			strawpoll.PollOptions{Value: "sushi", VoteCount: 5}),
+	}}
+	repo := &mockStrawpollDeadlineRepo{}
+	repo.SaveStrawpollDeadline(&commands.StrawpollDeadline{StrawpollID: "abc", Guild: 20, Channel: 55, Role: 66})
+	s := &mockSession{}
+
+	commands.RestartStrawpollDeadlines(s, repo, client)
+	time.Sleep(50 * time.Millisecond)
+
+	if s.message != "<@&66> Strawpoll has closed. The top vote for Lunch is sushi with 5 votes." {
+		t.Error("Unexpected announcement ", s.message)
+	}
+}
+
+func TestCancelledStrawpollDeadlineIgnoresReusedID(t *testing.T) {
+	client := &mockStrawpollClient{polls: map[string]*strawpoll.StrawPollResults{
+		"abc":   newStrawpollResults("Lunch", time.Now().Add(30*time.Millisecond), strawpoll.PollOptions{Value: "pizza", VoteCount: 2}),
+		"other": newStrawpollResults("Dinner", time.Now().Add(time.Hour), strawpoll.PollOptions{Value: "soup", VoteCount: 1}),
+	}}
+	repo := &mockStrawpollDeadlineRepo{}
+	repo.SaveStrawpollDeadline(&commands.StrawpollDeadline{StrawpollID: "abc", Guild: 20, Channel: 55})
+	s := &mockSession{}
+	commands.RestartStrawpollDeadlines(s, repo, client)
+
+	//The deadline is cancelled and its id given to a new one before the timer fires
+	repo.DeleteStrawpollDeadlineByID(1)
+	repo.SaveStrawpollDeadline(&commands.StrawpollDeadline{StrawpollID: "other", Guild: 20, Channel: 55})
+	time.Sleep(80 * time.Millisecond)
+
+	if s.message != "" {
+		t.Error("Cancelled deadline announced ", s.message)
+	}
+	if len(repo.deadlines) != 1 || repo.deadlines[0].StrawpollID != "other" {
+		t.Error("New deadline removed ", repo.deadlines)
+	}
+}
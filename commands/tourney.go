@@ -2,21 +2,29 @@ package commands
 
 import (
 	"discordbot/challonge"
+	"fmt"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/andersfylling/disgord"
 )
 
 type tourneyCommandRequestFactory struct {
 	repo            TournamentRepository
+	usersRepo       UsersRepository
 	session         DiscordSession
 	challongeClient challongeClient
 }
 
-func NewTourneyCommandRequestFactory(s DiscordSession, repo TournamentRepository, client challongeClient) *tourneyCommandRequestFactory {
+//challongeUnavailable - sent when the bracket could not be read from challonge, the error is logged
+const challongeUnavailable = "Could not get the bracket from challonge, try again later."
+
+func NewTourneyCommandRequestFactory(s DiscordSession, repo TournamentRepository, usersRepo UsersRepository, client challongeClient) *tourneyCommandRequestFactory {
 	return &tourneyCommandRequestFactory{
 		session:         s,
 		repo:            repo,
+		usersRepo:       usersRepo,
 		challongeClient: client,
 	}
 }
@@ -69,10 +77,21 @@ type tourneyCommand struct {
 	*tourneyCommandRequestFactory
 	data *disgord.MessageCreate
 	user *Users
+	//tourneyName - tournament picked with --tourney
+	tourneyName string
 }
 
 func (c *tourneyCommand) ExecuteMessageCreateCommand() {
-	con := c.data.Message.Content
+	var con string
+	con, c.tourneyName = takeTourneyName(c.data.Message.Content)
+	args, flags := parseFlags(splitArguments(con))
+	if len(args) > 0 {
+		if sub, ok := tourneySubcommands[strings.ToLower(args[0])]; ok {
+			sub(c, args[1:], flags)
+			return
+		}
+	}
+
 	u, err := url.Parse(con)
 	if err != nil {
 		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Unable to parse url")
@@ -80,8 +99,21 @@ func (c *tourneyCommand) ExecuteMessageCreateCommand() {
 		return
 	}
 
-	tourneyID := u.Path[1:]
-	ps := c.challongeClient.GetParticipants(tourneyID)
+	tourneyID := strings.TrimPrefix(u.Path, "/")
+	name := c.tourneyName
+	if name == "" {
+		name = tourneyID
+	}
+	if !c.isNameFree(name) {
+		return
+	}
+
+	ps, err := c.challongeClient.GetParticipants(tourneyID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, challongeUnavailable)
+		return
+	}
 	var tourneyParticipants []TournamentParticipant
 	for _, p := range ps {
 		participant := TournamentParticipant{
@@ -94,7 +126,9 @@ func (c *tourneyCommand) ExecuteMessageCreateCommand() {
 		DiscordServerID: c.data.Message.GuildID,
 		User:            c.user.UsersID,
 		ChallongeID:     tourneyID,
+		Name:            name,
 		Participants:    tourneyParticipants,
+		Organizers:      []Users{*c.user},
 	}
 	err = c.repo.SaveTourney(&t)
 	if err != nil {
@@ -112,21 +146,33 @@ type addOrganizerCommand struct {
 }
 
 func (c *addOrganizerCommand) ExecuteMessageCreateCommand() {
-	t, err := c.repo.GetTourneyByServer(c.data.Message.GuildID)
+	t, content, ok := c.findTourney(c.data.Message)
+	if !ok {
+		return
+	}
+	if !c.checkOrganizer(&t, c.user, c.data.Message.ChannelID, "add organizers") {
+		return
+	}
 
-	if t.DiscordServerID == 0 || err != nil {
-		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Command unable to be used. Tournament not started in this server.")
-		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Command unable to be used. Tournament not started in this server.")
+	u, ok := c.mentionedUser(c.data.Message, content)
+	if !ok {
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Usage: "+CommandPrefix+TournamentAddOrganizerString+" @user")
 		return
 	}
+	for _, o := range t.Organizers {
+		if o.UsersID == u.UsersID {
+			c.session.SendSimpleMessage(c.data.Message.ChannelID, createUserMention(u.DiscordUsersID)+" is already an organizer.")
+			return
+		}
+	}
 
-	err = c.repo.AddTourneyOrganizer(c.user.UsersID, t.TournamentID)
+	err := c.repo.AddTourneyOrganizer(u.UsersID, t.TournamentID)
 	if err != nil {
 		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Something went wrong, organizer unable to be added.")
 		log.Error(err)
 		return
 	}
-	c.session.ReactToMessage(c.data.Message.ID, c.data.Message.ChannelID, "👍" )
+	c.session.ReactToMessage(c.data.Message.ID, c.data.Message.ChannelID, "👍")
 }
 
 type nextLosersMatchCommand struct {
@@ -136,27 +182,27 @@ type nextLosersMatchCommand struct {
 }
 
 func (c *nextLosersMatchCommand) ExecuteMessageCreateCommand() {
-	t, err := c.repo.GetTourneyByServer(c.data.Message.GuildID)
-
-	if t.DiscordServerID == 0 || err != nil {
-		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Command unable to be used. Tournament not started in this server.")
+	t, _, ok := c.findTourney(c.data.Message)
+	if !ok {
+		return
+	}
+	if !c.checkOrganizer(&t, c.user, c.data.Message.ChannelID, "call matches") {
 		return
 	}
 
-	mcontainer := c.challongeClient.GetMatches(t.ChallongeID)
+	matches, err := c.challongeClient.GetMatches(t.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, challongeUnavailable)
+		return
+	}
 
 	var nextMatch challonge.Match
-	for _, m := range mcontainer {
-		if m.Round > 0 || m.WinnerID != 0 {
-			continue
+	for _, m := range matchQueue(&t, matches) {
+		if m.Round <= 0 {
+			nextMatch = m
+			break
 		}
-
-		if m.Player1ID == 0 || m.Player2ID == 0 {
-			continue
-		}
-
-		nextMatch = m
-		break
 	}
 
 	if nextMatch.ID == 0 {
@@ -167,10 +213,7 @@ func (c *nextLosersMatchCommand) ExecuteMessageCreateCommand() {
 	t.CurrentMatch = nextMatch.ID
 	c.repo.SaveTourney(&t)
 
-	p1 := findParticipant(&t.Participants, nextMatch.Player1ID)
-	p2 := findParticipant(&t.Participants, nextMatch.Player2ID)
-
-	c.session.SendSimpleMessage(c.data.Message.ChannelID, p1.Name+" vs "+p2.Name)
+	c.session.SendSimpleMessage(c.data.Message.ChannelID, formatMatch(&t, nextMatch))
 }
 
 func findParticipant(ps *[]TournamentParticipant, id int) *TournamentParticipant {
@@ -198,31 +241,66 @@ type matchWinnerCommand struct {
 }
 
 func (c *matchWinnerCommand) ExecuteMessageCreateCommand() {
-	t, err := c.repo.GetTourneyByServer(c.data.Message.GuildID)
-
-	if t.DiscordServerID == 0 || err != nil || t.CurrentMatch == 0 {
-		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Command unable to be used. Tournament not started in this server.")
-		return
+	var t Tournament
+	var err error
+	content := strings.TrimSpace(c.data.Message.Content)
+	if c.data.Message.GuildID == 0 {
+		t, err = c.findReportingTourney()
+	} else {
+		var ok bool
+		if t, content, ok = c.findTourney(c.data.Message); !ok {
+			return
+		}
 	}
 
-	if c.data.Message.Content == "" {
-		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Missing winner's name.")
+	called := calledMatches(&t)
+	if t.DiscordServerID == 0 || err != nil || len(called) == 0 {
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, tourneyNotStarted)
 		return
 	}
 
-	w := findParticipantByName(&t.Participants, c.data.Message.Content)
+	//Organizers playing in the tournament can report their own win with no name
+	var w *TournamentParticipant
+	if content == "" {
+		w = findParticipantByDiscordUser(&t.Participants, c.user.DiscordUsersID)
+		if w == nil {
+			c.session.SendSimpleMessage(c.data.Message.ChannelID, "Missing winner's name.")
+			return
+		}
+	} else {
+		w = findParticipantByArgument(&t.Participants, content)
+	}
 
 	if w == nil {
 		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Winner's name not found.")
 		return
 	}
 
-	m := c.challongeClient.GetMatch(t.ChallongeID, t.CurrentMatch)
+	//Only organizers record results directly, players report through $report so their opponent can confirm
+	if !isTourneyOrganizer(&t, c.user) {
+		isWinner := w.DiscordUserID != 0 && w.DiscordUserID == c.user.DiscordUsersID
+		if isWinner && t.SelfReporting {
+			c.session.SendSimpleMessage(c.data.Message.ChannelID, "Report your match with "+CommandPrefix+TournamentReportString+" 2-1 in the server so your opponent can confirm it.")
+			return
+		}
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Only tournament organizers can report other players' wins.")
+		return
+	}
+
+	//The winner can only be playing one of the called matches
+	var m challonge.Match
+	for matchID := range called {
+		cm := c.challongeClient.GetMatch(t.ChallongeID, matchID)
+		if w.ChallongeID == cm.Player1ID || w.ChallongeID == cm.Player2ID {
+			m = cm
+			break
+		}
+	}
 
 	var score challonge.MatchScore
-	if w.ChallongeID == m.Player1ID {
+	if m.ID != 0 && w.ChallongeID == m.Player1ID {
 		score = challonge.MatchScore{Player1Score: 1}
-	} else if w.ChallongeID == m.Player2ID {
+	} else if m.ID != 0 && w.ChallongeID == m.Player2ID {
 		score = challonge.MatchScore{Player2Score: 1}
 	} else {
 		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Winner not found?")
@@ -232,15 +310,41 @@ func (c *matchWinnerCommand) ExecuteMessageCreateCommand() {
 
 	q := challonge.MatchQueryParams{WinnerID: w.ChallongeID, MatchScore: score}
 
-	c.challongeClient.UpdateMatch(t.ChallongeID, t.CurrentMatch, q)
+	err = c.challongeClient.UpdateMatch(t.ChallongeID, m.ID, q)
+	if err != nil {
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Something went wrong, match unable to be reported.")
+		log.Error(err)
+		return
+	}
 	c.session.ReactToMessage(c.data.Message.ID, c.data.Message.ChannelID, "👍")
-	t.CurrentMatch = 0
+	rateTourneyMatch(c.repo, &t, m, w.ChallongeID)
+	clearMatch(&t, m.ID)
+	t.LastReportedMatch = m.ID
 	err = c.repo.SaveTourney(&t)
 	if err != nil {
 		log.Error(err)
 	}
 }
 
+//findReportingTourney finds the tournament a player is reporting from direct messages,
+//the one where they are in a called match
+func (c *matchWinnerCommand) findReportingTourney() (Tournament, error) {
+	ts, err := c.repo.GetTourneysByParticipant(c.user.DiscordUsersID)
+	if err != nil {
+		return Tournament{}, err
+	}
+	for _, t := range ts {
+		p := findParticipantByDiscordUser(&t.Participants, c.user.DiscordUsersID)
+		for matchID := range calledMatches(&t) {
+			m := c.challongeClient.GetMatch(t.ChallongeID, matchID)
+			if m.Player1ID == p.ChallongeID || m.Player2ID == p.ChallongeID {
+				return t, nil
+			}
+		}
+	}
+	return Tournament{}, nil
+}
+
 type closeTourney struct {
 	*tourneyCommandRequestFactory
 	data *disgord.MessageCreate
@@ -248,19 +352,47 @@ type closeTourney struct {
 }
 
 func (c *closeTourney) ExecuteMessageCreateCommand() {
-	t, err := c.repo.GetTourneyByServer(c.data.Message.GuildID)
+	t, content, ok := c.findTourney(c.data.Message)
+	if !ok {
+		return
+	}
+	if !c.checkOrganizer(&t, c.user, c.data.Message.ChannelID, "end the tournament") {
+		return
+	}
+	_, flags := parseFlags(splitArguments(content), "force")
+	_, force := flags["force"]
 
-	if t.DiscordServerID == 0 || err != nil {
-		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Command unable to be used. Tournament not started in this server.")
+	//Results are kept after the running tournament is removed
+	result, err := archiveTourney(&t, c.challongeClient, time.Now())
+	if err != nil && force {
+		//The bracket is gone or challonge is down, the tournament is removed without its results
+		log.Error(err)
+		c.removeTourney(&t, "Tournament ended without keeping its results.")
+		return
+	}
+	if err != nil {
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Something went wrong, results could not be read from challonge, tournament not ended. Add --force to end it without keeping its results.")
+		log.Error(err)
 		return
 	}
+	err = c.repo.SaveTournamentResult(&result)
 
-	err = c.repo.RemoveTourney(c.data.Message.GuildID)
+	if err != nil {
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, "An error occurred saving the results, tournament not ended.")
+		log.Error(err)
+		return
+	}
+
+	c.removeTourney(&t, fmt.Sprintf("Tournament ended. See the results with %s%s %d", CommandPrefix, TournamentResultsString, result.TournamentResultID))
+}
+
+func (c *closeTourney) removeTourney(t *Tournament, done string) {
+	err := c.repo.RemoveTourney(t.TournamentID)
 
 	if err != nil {
 		c.session.SendSimpleMessage(c.data.Message.ChannelID, "An error occurred ending tournament.")
 		log.Error(err)
 		return
 	}
-	c.session.ReactToMessage(c.data.Message.ID, c.data.Message.ChannelID, "👍")
+	c.session.SendSimpleMessage(c.data.Message.ChannelID, done)
 }
\ No newline at end of file
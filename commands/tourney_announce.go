@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"discordbot/challonge"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	announceMatchOpen     = "open"
+	announceMatchComplete = "complete"
+	announceTourneyDone   = "finished"
+)
+
+//AnnounceTournamentMatches posts newly playable matches, results and finished brackets for tournaments with announcements on
+func AnnounceTournamentMatches(repo TournamentRepository, client challongeClient, s DiscordSession) {
+	ts, err := repo.GetAnnouncedTourneys()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	for _, t := range ts {
+		announceTourney(repo, client, s, &t, true)
+	}
+}
+
+//announceTourney compares the bracket with what was already announced.
+//When post is false everything is only recorded, so turning announcements on does not repeat the whole bracket.
+func announceTourney(repo TournamentRepository, client challongeClient, s DiscordSession, t *Tournament, post bool) {
+	as, err := repo.GetMatchAnnouncements(t.TournamentID)
+	if err != nil {
+		log.WithField("tournament", t.TournamentID).Error(err)
+		return
+	}
+	matches, err := client.GetMatches(t.ChallongeID)
+	if err != nil {
+		log.WithField("tournament", t.TournamentID).Error(err)
+		return
+	}
+	announced := make(map[string]bool)
+	undone := undoneAnnouncements(as, matches)
+	for _, a := range as {
+		if undone[a] {
+			err := repo.RemoveMatchAnnouncement(&a)
+			if err == nil {
+				continue
+			}
+			log.WithField("tournament", t.TournamentID).Error(err)
+		}
+		announced[announcementKey(a.MatchID, a.Event)] = true
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matchProgression(matches[i]) < matchProgression(matches[j])
+	})
+
+	announce := func(matchID int, event string, content string) {
+		if announced[announcementKey(matchID, event)] {
+			return
+		}
+		if post {
+			if _, err := s.SendSimpleMessage(t.AnnounceChannel, content); err != nil {
+				log.WithField("tournament", t.TournamentID).Error(err)
+				return
+			}
+		}
+		err := repo.SaveMatchAnnouncement(&MatchAnnouncement{TournamentID: t.TournamentID, MatchID: matchID, Event: event})
+		if err != nil {
+			log.WithField("tournament", t.TournamentID).Error(err)
+		}
+	}
+
+	//Results go out before the matches they open up
+	for _, m := range matches {
+		if m.WinnerID != 0 {
+			announce(m.ID, announceMatchComplete, formatResult(t, m, matches))
+		}
+	}
+	called := calledMatches(t)
+	for _, m := range matches {
+		if isMatchPlayable(m) {
+			content := "Ready to play: " + formatMatch(t, m) + " (" + roundName(m, matches) + ")"
+			if station, ok := called[m.ID]; ok {
+				content += " on " + strings.ToLower(stationLabel(station))
+			}
+			announce(m.ID, announceMatchOpen, content)
+		}
+	}
+
+	if isBracketComplete(matches) {
+		announce(0, announceTourneyDone, "The bracket is complete! Final results at "+challongeURL+t.ChallongeID)
+	}
+}
+
+//undoneAnnouncements finds announcements a reopened match took back, whether by undo or on challonge, so they are made again.
+//A reopened match loses its result and is ready to play again, the matches after it lose their players and the bracket its finish.
+func undoneAnnouncements(as []MatchAnnouncement, matches []challonge.Match) map[MatchAnnouncement]bool {
+	byID := make(map[int]challonge.Match)
+	for _, m := range matches {
+		byID[m.ID] = m
+	}
+	reopened := make(map[int]bool)
+	for _, a := range as {
+		if m, ok := byID[a.MatchID]; ok && a.Event == announceMatchComplete && m.WinnerID == 0 {
+			reopened[a.MatchID] = true
+		}
+	}
+
+	undone := make(map[MatchAnnouncement]bool)
+	for _, a := range as {
+		m, ok := byID[a.MatchID]
+		switch {
+		case a.Event == announceTourneyDone:
+			undone[a] = !isBracketComplete(matches)
+		case reopened[a.MatchID]:
+			undone[a] = true
+		case ok && a.Event == announceMatchOpen:
+			undone[a] = m.WinnerID == 0 && !isMatchPlayable(m)
+		}
+	}
+	return undone
+}
+
+func announcementKey(matchID int, event string) string {
+	return strconv.Itoa(matchID) + event
+}
+
+func isBracketComplete(matches []challonge.Match) bool {
+	for _, m := range matches {
+		if m.WinnerID == 0 {
+			return false
+		}
+	}
+	return len(matches) > 0
+}
+
+//formatResult describes a finished match with the winner first
+func formatResult(t *Tournament, m challonge.Match, matches []challonge.Match) string {
+	winner := findParticipant(&t.Participants, m.WinnerID)
+	loser := findParticipant(&t.Participants, opponentID(m, m.WinnerID))
+	if winner == nil || loser == nil {
+		return "Unknown participant"
+	}
+
+	result := participantLabel(winner) + " beat " + participantLabel(loser)
+	if score := winnerScore(m); score != "" {
+		result += " " + score
+	}
+	return result + " (" + roundName(m, matches) + ")"
+}
+
+//winnerScore turns challonge's player 1 first score into the winner's score first.
+//Scores with more than one set are left as they are.
+func winnerScore(m challonge.Match) string {
+	parts := strings.Split(m.ScoresCsv, "-")
+	if len(parts) != 2 {
+		return m.ScoresCsv
+	}
+	p1, err1 := strconv.Atoi(parts[0])
+	p2, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return m.ScoresCsv
+	}
+	if m.WinnerID == m.Player2ID {
+		p1, p2 = p2, p1
+	}
+	return fmt.Sprintf("%d-%d", p1, p2)
+}
+
+//announce turns match announcements on for a channel or off
+func (c *tourneyCommand) announce(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) > 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentCommandString+" announce [#channel|off]")
+		return
+	}
+	t, ok := c.organizerTourney("change announcements")
+	if !ok {
+		return
+	}
+
+	if len(args) == 1 && strings.EqualFold(args[0], "off") {
+		t.AnnounceChannel = 0
+		c.saveAndReact(&t)
+		return
+	}
+
+	channel := msg.ChannelID
+	if len(args) == 1 {
+		var ok bool
+		channel, ok = parseChannelMention(args[0])
+		if !ok {
+			c.session.SendSimpleMessage(msg.ChannelID, "Could not find channel "+args[0]+". Mention it like #channel.")
+			return
+		}
+		//A mention can name a channel of any server the bot is in
+		if FindTargetChannel(channel, c.session.Guild(msg.GuildID)) == nil {
+			c.session.SendSimpleMessage(msg.ChannelID, "Could not find channel "+args[0]+" in this server.")
+			return
+		}
+	}
+
+	t.AnnounceChannel = channel
+	err := c.repo.SaveTourney(&t)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, tournament unable to be saved.")
+		log.Error(err)
+		return
+	}
+	announceTourney(c.repo, c.challongeClient, c.session, &t, false)
+	c.session.SendSimpleMessage(msg.ChannelID, "Match announcements will be posted in <#"+channel.String()+">.")
+}
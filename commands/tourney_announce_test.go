@@ -0,0 +1,110 @@
+package commands_test
+
+import (
+	"discordbot/challonge"
+	"discordbot/commands"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+)
+
+func setMatch(cclient *mockChallongeClient, m challonge.Match) {
+	for i := range cclient.matches {
+		if cclient.matches[i].ID == m.ID {
+			cclient.matches[i] = m
+		}
+	}
+}
+
+func TestAnnounceTurnedOnRecordsCurrentBracket(t *testing.T) {
+	//Given: A bracket with an open match
+	cclient, repo := newLinkedMatchTourney()
+
+	//When: Announcements are turned on
+	guild := &mockGuild{channels: []*disgord.Channel{{ID: 55, Name: "brackets"}}}
+	s := runTourneyCommandAs("announce <#55>", &commands.Users{UsersID: 1}, guild, cclient, repo)
+
+	//Then: Only the confirmation is sent and the open match is not announced later
+	if s.message != "Match announcements will be posted in <#55>." || repo.tourneys[1].AnnounceChannel != 55 {
+		t.Error("Announcements not turned on ", s.message)
+	}
+	watcher := &mockSession{}
+	commands.AnnounceTournamentMatches(repo, cclient, watcher)
+	if len(watcher.sentMessages) != 0 {
+		t.Error("Existing match announced ", watcher.sentMessages)
+	}
+}
+
+func TestAnnounceChannelInAnotherServer(t *testing.T) {
+	cclient, repo := newLinkedMatchTourney()
+	guild := &mockGuild{channels: []*disgord.Channel{{ID: 55, Name: "brackets"}}}
+
+	s := runTourneyCommandAs("announce <#66>", &commands.Users{UsersID: 1}, guild, cclient, repo)
+
+	if s.message != "Could not find channel <#66> in this server." || repo.tourneys[1].AnnounceChannel != 0 {
+		t.Error("Channel from another server used ", s.message)
+	}
+}
+
+func TestAnnounceResultsAndNewMatches(t *testing.T) {
+	//Given: Announcements on for a bracket whose first match is under way
+	cclient, repo := newLinkedMatchTourney()
+	cclient.addMatches(challonge.Match{ID: 2, Round: 2, Player1ID: 0, Player2ID: 3, State: "pending"})
+	tourney := repo.tourneys[1]
+	tourney.Participants = append(tourney.Participants, commands.TournamentParticipant{Name: "c", ChallongeID: 3})
+	repo.tourneys[1] = tourney
+	runTourneyCommandAs("announce", &commands.Users{UsersID: 1}, &commonMockGuild, cclient, repo)
+
+	//When: b wins and the next match opens
+	setMatch(cclient, challonge.Match{ID: 1, Round: 1, Player1ID: 1, Player2ID: 2, WinnerID: 2, ScoresCsv: "1-2", State: "complete"})
+	setMatch(cclient, challonge.Match{ID: 2, Round: 2, Player1ID: 2, Player2ID: 3, State: "open"})
+	s := &mockSession{}
+	commands.AnnounceTournamentMatches(repo, cclient, s)
+
+	//Then: The result is posted winner first before the new match
+	if len(s.sentMessages) != 2 || s.sentMessages[0] != "<@801> beat <@800> 2-1 (Round 1)" || s.sentMessages[1] != "Ready to play: <@801> vs c (Round 2)" {
+		t.Fatal("Unexpected announcements ", s.sentMessages)
+	}
+
+	//And: Nothing is posted twice
+	s = &mockSession{}
+	commands.AnnounceTournamentMatches(repo, cclient, s)
+	if len(s.sentMessages) != 0 {
+		t.Error("Announcements repeated ", s.sentMessages)
+	}
+
+	//When: The last match finishes
+	setMatch(cclient, challonge.Match{ID: 2, Round: 2, Player1ID: 2, Player2ID: 3, WinnerID: 3, ScoresCsv: "0-3", State: "complete"})
+	commands.AnnounceTournamentMatches(repo, cclient, s)
+
+	//Then: The bracket is announced as complete
+	if len(s.sentMessages) != 2 || s.sentMessages[1] != "The bracket is complete! Final results at https://challonge.com/test" {
+		t.Error("Bracket completion not announced ", s.sentMessages)
+	}
+}
+
+func TestAnnounceReopenedMatch(t *testing.T) {
+	//Given: A finished bracket whose only match was announced
+	cclient, repo := newLinkedMatchTourney()
+	runTourneyCommandAs("announce", &commands.Users{UsersID: 1}, &commonMockGuild, cclient, repo)
+	setMatch(cclient, challonge.Match{ID: 1, Round: 1, Player1ID: 1, Player2ID: 2, WinnerID: 2, ScoresCsv: "1-2", State: "complete"})
+	commands.AnnounceTournamentMatches(repo, cclient, &mockSession{})
+
+	//When: The match is reopened
+	setMatch(cclient, challonge.Match{ID: 1, Round: 1, Player1ID: 1, Player2ID: 2, State: "open"})
+	s := &mockSession{}
+	commands.AnnounceTournamentMatches(repo, cclient, s)
+
+	//Then: It is ready to play again
+	if len(s.sentMessages) != 1 || s.sentMessages[0] != "Ready to play: <@800> vs <@801> (Round 1)" {
+		t.Fatal("Reopened match not announced ", s.sentMessages)
+	}
+
+	//And: The corrected result and the finished bracket are announced again
+	setMatch(cclient, challonge.Match{ID: 1, Round: 1, Player1ID: 1, Player2ID: 2, WinnerID: 1, ScoresCsv: "2-0", State: "complete"})
+	s = &mockSession{}
+	commands.AnnounceTournamentMatches(repo, cclient, s)
+	if len(s.sentMessages) != 2 || s.sentMessages[0] != "<@800> beat <@801> 2-0 (Round 1)" {
+		t.Error("Corrected result not announced ", s.sentMessages)
+	}
+}
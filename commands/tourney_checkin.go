@@ -0,0 +1,324 @@
+package commands
+
+import (
+	"discordbot/challonge"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+const checkInEmoji = "✅"
+
+//checkInReminder - how long before check in closes the players who have not checked in are reminded
+const checkInReminder = 10 * time.Minute
+
+const checkInUsage = "Usage: " + CommandPrefix + TournamentCheckInString + " open 30m|close|status"
+
+func (c *tourneyCommandRequestFactory) CreateCheckInCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &checkInCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+type checkInCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *checkInCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	content, name := takeTourneyName(msg.Content)
+	args := splitArguments(content)
+	if len(args) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, checkInUsage)
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "open":
+		if len(args) != 2 {
+			c.session.SendSimpleMessage(msg.ChannelID, checkInUsage)
+			return
+		}
+		d, err := parseDuration(args[1])
+		if err != nil || d < time.Minute {
+			c.session.SendSimpleMessage(msg.ChannelID, "Could not read check in duration "+args[1]+". Use a duration like 15m or 1h.")
+			return
+		}
+		t, ok := c.selectTourney(msg, name)
+		if !ok || !c.checkOrganizer(&t, c.user, msg.ChannelID, "open check in") {
+			return
+		}
+		c.startCheckIn(msg, &t, d)
+	case "close":
+		t, ok := c.selectTourney(msg, name)
+		if !ok || !c.checkOrganizer(&t, c.user, msg.ChannelID, "close check in") {
+			return
+		}
+		if t.CheckInMessage == 0 {
+			c.session.SendSimpleMessage(msg.ChannelID, "Check in is not open.")
+			return
+		}
+		closeCheckIn(c.repo, c.challongeClient, c.session, &t)
+	case "status":
+		t, ok := c.selectTourney(msg, name)
+		if !ok {
+			return
+		}
+		c.status(&t)
+	default:
+		c.session.SendSimpleMessage(msg.ChannelID, checkInUsage)
+	}
+}
+
+func (c *checkInCommand) status(t *Tournament) {
+	msg := c.data.Message
+	if t.CheckInMessage == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Check in is not open.")
+		return
+	}
+
+	ps, err := c.challongeClient.GetParticipants(t.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return
+	}
+	var checkedIn []string
+	for _, p := range ps {
+		if p.CheckedIn {
+			checkedIn = append(checkedIn, p.Name)
+		}
+	}
+	status := fmt.Sprintf("Checked in (%d of %d), closing <t:%d:R>: %s", len(checkedIn), len(ps), t.CheckInEnds.Unix(), strings.Join(checkedIn, ", "))
+	if missing := notCheckedIn(t, ps); len(missing) > 0 {
+		status += "\nNot checked in: " + strings.Join(missing, ", ")
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, status)
+}
+
+//startCheckIn opens check in on challonge and posts the message players react to
+func (c *tourneyCommandRequestFactory) startCheckIn(msg *disgord.Message, t *Tournament, d time.Duration) {
+	if t.CheckInMessage != 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("Check in is already open, closing <t:%d:R>.", t.CheckInEnds.Unix()))
+		return
+	}
+
+	err := c.challongeClient.OpenCheckIn(t.ChallongeID, d)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, check in unable to be opened.")
+		log.Error(err)
+		return
+	}
+
+	ends := time.Now().Add(d)
+	m, err := c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("Check in for %s is open for %s, closing <t:%d:R>. React with %s to check in.",
+		t.Name, formatDuration(d), ends.Unix(), checkInEmoji))
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	c.session.ReactToMessage(m.ID, m.ChannelID, checkInEmoji)
+
+	t.CheckInMessage = m.ID
+	t.CheckInChannel = m.ChannelID
+	t.CheckInEnds = ends
+	t.CheckInReminded = false
+	if err := c.repo.SaveTourney(t); err != nil {
+		log.Error(err)
+	}
+}
+
+//notCheckedIn names the participants who have not checked in, mentioning the ones linked to discord
+func notCheckedIn(t *Tournament, ps []challonge.Participant) []string {
+	var missing []string
+	for _, p := range ps {
+		if p.CheckedIn {
+			continue
+		}
+		tp := findParticipant(&t.Participants, p.ID)
+		if tp == nil {
+			tp = &TournamentParticipant{Name: p.Name}
+		}
+		missing = append(missing, participantLabel(tp))
+	}
+	return missing
+}
+
+//RunTournamentCheckIns reminds players who have not checked in and closes check in once its time is up
+func RunTournamentCheckIns(repo TournamentRepository, client challongeClient, s DiscordSession) {
+	ts, err := repo.GetCheckInTourneys()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	now := time.Now()
+	for _, t := range ts {
+		checkInTick(repo, client, s, &t, now)
+	}
+}
+
+func checkInTick(repo TournamentRepository, client challongeClient, s DiscordSession, t *Tournament, now time.Time) {
+	if !now.Before(t.CheckInEnds) {
+		closeCheckIn(repo, client, s, t)
+		return
+	}
+	if t.CheckInReminded || t.CheckInEnds.Sub(now) > checkInReminder {
+		return
+	}
+
+	ps, err := client.GetParticipants(t.ChallongeID)
+	if err != nil {
+		log.WithField("tournament", t.TournamentID).Error(err)
+		return
+	}
+	if missing := notCheckedIn(t, ps); len(missing) > 0 {
+		s.SendSimpleMessage(t.CheckInChannel, fmt.Sprintf("Check in closes <t:%d:R>. %s react with %s to keep your spot.",
+			t.CheckInEnds.Unix(), strings.Join(missing, ", "), checkInEmoji))
+	}
+	t.CheckInReminded = true
+	if err := repo.SaveTourney(t); err != nil {
+		log.WithField("tournament", t.TournamentID).Error(err)
+	}
+}
+
+//closeCheckIn has challonge remove the no-shows and drops them from the tournament
+func closeCheckIn(repo TournamentRepository, client challongeClient, s DiscordSession, t *Tournament) {
+	channel := t.CheckInChannel
+	ps, err := client.GetParticipants(t.ChallongeID)
+	if err != nil {
+		log.WithField("tournament", t.TournamentID).Error(err)
+	}
+	missing := notCheckedIn(t, ps)
+
+	t.CheckInMessage = 0
+	t.CheckInChannel = 0
+	t.CheckInEnds = time.Time{}
+	t.CheckInReminded = false
+
+	//Check in is closed either way so the watcher does not keep retrying every minute
+	if err := client.ProcessCheckIns(t.ChallongeID); err != nil {
+		log.WithField("tournament", t.TournamentID).Error(err)
+		if err := repo.SaveTourney(t); err != nil {
+			log.WithField("tournament", t.TournamentID).Error(err)
+		}
+		s.SendSimpleMessage(channel, "Check in closed but challonge could not remove the players who did not check in.")
+		return
+	}
+
+	//Without the participant list nobody is known to have missed check in, so nobody is removed here
+	if err != nil {
+		if err := repo.SaveTourney(t); err != nil {
+			log.WithField("tournament", t.TournamentID).Error(err)
+		}
+		s.SendSimpleMessage(channel, "Check in closed. Could not get the players who did not check in from challonge.")
+		return
+	}
+
+	removed := make(map[int]bool)
+	for _, p := range ps {
+		if !p.CheckedIn {
+			removed[p.ID] = true
+		}
+	}
+	var remaining []TournamentParticipant
+	for _, tp := range t.Participants {
+		if !removed[tp.ChallongeID] {
+			remaining = append(remaining, tp)
+		}
+	}
+	t.Participants = remaining
+	if err := repo.SaveTourney(t); err != nil {
+		log.WithField("tournament", t.TournamentID).Error(err)
+	}
+
+	if len(missing) == 0 {
+		s.SendSimpleMessage(channel, "Check in closed. Everyone checked in.")
+		return
+	}
+	s.SendSimpleMessage(channel, "Check in closed. Removed for not checking in: "+strings.Join(missing, ", "))
+}
+
+type checkInReact struct {
+	repo    TournamentRepository
+	session DiscordSession
+	client  challongeClient
+	data    *disgord.MessageReactionAdd
+}
+
+func NewCheckInReact(r TournamentRepository, s DiscordSession, client challongeClient, d *disgord.MessageReactionAdd) *checkInReact {
+	return &checkInReact{
+		repo:    r,
+		session: s,
+		client:  client,
+		data:    d,
+	}
+}
+
+//OnReactionAdd - a player checks in by reacting to the check in message
+func (c *checkInReact) OnReactionAdd() {
+	if c.data.PartialEmoji.Name != checkInEmoji {
+		return
+	}
+	t, err := c.repo.GetTourneyByCheckInMessage(c.data.MessageID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	p := findParticipantByDiscordUser(&t.Participants, c.data.UserID)
+	if p == nil {
+		c.session.RemoveUserReaction(c.data.MessageID, c.data.ChannelID, checkInEmoji, c.data.UserID)
+		c.session.SendSimpleMessage(c.data.ChannelID, createUserMention(c.data.UserID)+" you are not in "+t.Name+
+			". Join with "+CommandPrefix+TournamentCommandString+" join or ask an organizer to link you.")
+		return
+	}
+
+	err = c.client.CheckInParticipant(t.ChallongeID, p.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.RemoveUserReaction(c.data.MessageID, c.data.ChannelID, checkInEmoji, c.data.UserID)
+		c.session.SendSimpleMessage(c.data.ChannelID, "Something went wrong, "+createUserMention(c.data.UserID)+" could not be checked in.")
+	}
+}
+
+type checkOutReact struct {
+	repo    TournamentRepository
+	session DiscordSession
+	client  challongeClient
+	data    *disgord.MessageReactionRemove
+}
+
+func NewCheckOutReact(r TournamentRepository, s DiscordSession, client challongeClient, d *disgord.MessageReactionRemove) *checkOutReact {
+	return &checkOutReact{
+		repo:    r,
+		session: s,
+		client:  client,
+		data:    d,
+	}
+}
+
+//OnReactionRemove - taking the reaction back undoes the check in
+func (c *checkOutReact) OnReactionRemove() {
+	if c.data.PartialEmoji.Name != checkInEmoji {
+		return
+	}
+	t, err := c.repo.GetTourneyByCheckInMessage(c.data.MessageID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	p := findParticipantByDiscordUser(&t.Participants, c.data.UserID)
+	if p == nil {
+		return
+	}
+	if err := c.client.UndoCheckInParticipant(t.ChallongeID, p.ChallongeID); err != nil {
+		log.Error(err)
+	}
+}
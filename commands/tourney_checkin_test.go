@@ -0,0 +1,165 @@
+package commands_test
+
+import (
+	"discordbot/challonge"
+	"discordbot/commands"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+func newCheckInTourney() (*mockChallongeClient, *mockTourneyDB) {
+	cclient, repo := newLinkedMatchTourney()
+	cclient.matches = nil
+	cclient.addParticipant(challonge.Participant{ID: 1, Name: "a"})
+	cclient.addParticipant(challonge.Participant{ID: 2, Name: "b"})
+	cclient.addParticipant(challonge.Participant{ID: 3, Name: "c"})
+	t := repo.tourneys[1]
+	t.Name = "weekly"
+	t.Participants = append(t.Participants, commands.TournamentParticipant{Name: "c", ChallongeID: 3})
+	repo.tourneys[1] = t
+	return cclient, repo
+}
+
+func runCheckInCommand(content string, user *commands.Users, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{ID: 60, Content: content, GuildID: 123, ChannelID: 10}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	c := factory.CreateCheckInCommand(&msg, user)
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func reactToCheckIn(user commands.Snowflake, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	s := &mockSession{}
+	e := &disgord.MessageReactionAdd{MessageID: 999, ChannelID: 10, UserID: user, PartialEmoji: &disgord.Emoji{Name: "✅"}}
+	commands.NewCheckInReact(repo, s, cclient, e).OnReactionAdd()
+	return s
+}
+
+func TestOpenCheckIn(t *testing.T) {
+	cclient, repo := newCheckInTourney()
+
+	//A player can not open check in
+	s := runCheckInCommand("open 30m", organizerPlayer, cclient, repo)
+	if s.message != "Only tournament organizers can open check in." || len(cclient.actions) != 0 {
+		t.Error("Player opened check in ", s.message)
+	}
+
+	s = runCheckInCommand("open 30m", organizerCreator, cclient, repo)
+
+	if cclient.checkIn != 30*time.Minute || !strings.HasPrefix(s.message, "Check in for weekly is open for 30 minutes") || len(s.reactions) != 1 || s.reactions[0] != "✅" {
+		t.Error("Check in not opened ", s.message)
+	}
+	tourney := repo.tourneys[1]
+	if tourney.CheckInMessage != 999 || tourney.CheckInChannel != 10 || tourney.CheckInEnds.Sub(time.Now()) < 29*time.Minute {
+		t.Error("Check in not saved ", tourney)
+	}
+}
+
+func TestCheckInByReaction(t *testing.T) {
+	cclient, repo := newCheckInTourney()
+	runCheckInCommand("open 30m", organizerCreator, cclient, repo)
+
+	//A linked player checks in by reacting
+	reactToCheckIn(800, cclient, repo)
+	if !cclient.participants[0].CheckedIn {
+		t.Error("Player not checked in ", cclient.participants)
+	}
+
+	//Someone who is not in the tournament is told so
+	s := reactToCheckIn(555, cclient, repo)
+	if len(s.removedReactions) != 1 || !strings.HasPrefix(s.message, "<@555> you are not in weekly.") {
+		t.Error("Stranger checked in ", s.message)
+	}
+
+	//Taking the reaction back undoes the check in
+	e := &disgord.MessageReactionRemove{MessageID: 999, ChannelID: 10, UserID: 800, PartialEmoji: &disgord.Emoji{Name: "✅"}}
+	commands.NewCheckOutReact(repo, &mockSession{}, cclient, e).OnReactionRemove()
+	if cclient.participants[0].CheckedIn {
+		t.Error("Check in not undone ", cclient.participants)
+	}
+}
+
+func TestCheckInStatus(t *testing.T) {
+	cclient, repo := newCheckInTourney()
+	runCheckInCommand("open 30m", organizerCreator, cclient, repo)
+	reactToCheckIn(801, cclient, repo)
+
+	s := runCheckInCommand("status", organizerPlayer, cclient, repo)
+
+	if !strings.HasPrefix(s.message, "Checked in (1 of 3), closing") || !strings.HasSuffix(s.message, ": b\nNot checked in: <@800>, c") {
+		t.Error("Status incorrect ", s.message)
+	}
+}
+
+func TestCheckInReminderAndClose(t *testing.T) {
+	//Given: Check in closing in 5 minutes with only b checked in
+	cclient, repo := newCheckInTourney()
+	runCheckInCommand("open 30m", organizerCreator, cclient, repo)
+	reactToCheckIn(801, cclient, repo)
+	tourney := repo.tourneys[1]
+	tourney.CheckInEnds = time.Now().Add(5 * time.Minute)
+	repo.tourneys[1] = tourney
+
+	//When: The watcher runs twice
+	s := &mockSession{}
+	commands.RunTournamentCheckIns(repo, cclient, s)
+	commands.RunTournamentCheckIns(repo, cclient, s)
+
+	//Then: The players who have not checked in are reminded once
+	if len(s.sentMessages) != 1 || !strings.HasSuffix(s.message, "<@800>, c react with ✅ to keep your spot.") {
+		t.Error("Reminder incorrect ", s.sentMessages)
+	}
+
+	//When: Check in runs out
+	tourney = repo.tourneys[1]
+	tourney.CheckInEnds = time.Now().Add(-time.Second)
+	repo.tourneys[1] = tourney
+	commands.RunTournamentCheckIns(repo, cclient, s)
+
+	//Then: The no-shows are removed on challonge and from the tournament
+	if s.message != "Check in closed. Removed for not checking in: <@800>, c" {
+		t.Error("Close message incorrect ", s.message)
+	}
+	tourney = repo.tourneys[1]
+	if len(cclient.participants) != 1 || len(tourney.Participants) != 1 || tourney.Participants[0].Name != "b" || tourney.CheckInMessage != 0 {
+		t.Error("No-shows not removed ", tourney.Participants)
+	}
+}
+
+func TestCloseCheckInKeepsPlayersWhenChallongeFails(t *testing.T) {
+	cclient, repo := newCheckInTourney()
+	runCheckInCommand("open 30m", organizerCreator, cclient, repo)
+	tourney := repo.tourneys[1]
+	tourney.CheckInEnds = time.Now().Add(-time.Second)
+	repo.tourneys[1] = tourney
+	cclient.participantsErr = errors.New("error status code 500")
+
+	s := &mockSession{}
+	commands.RunTournamentCheckIns(repo, cclient, s)
+
+	tourney = repo.tourneys[1]
+	if len(tourney.Participants) != 3 || tourney.CheckInMessage != 0 {
+		t.Error("Players removed without knowing who checked in ", tourney.Participants)
+	}
+	if s.message != "Check in closed. Could not get the players who did not check in from challonge." {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestStartClosesCheckIn(t *testing.T) {
+	cclient, repo := newCheckInTourney()
+	runCheckInCommand("open 30m", organizerCreator, cclient, repo)
+	reactToCheckIn(800, cclient, repo)
+	reactToCheckIn(801, cclient, repo)
+
+	runOrganizerCommand(commands.TournamentCommandString, "start", organizerCreator, cclient, repo)
+
+	if strings.Join(cclient.actions, ",") != "checkin,process_check_ins,start" || len(repo.tourneys[1].Participants) != 2 {
+		t.Error("Check in not closed before start ", cclient.actions)
+	}
+}
@@ -0,0 +1,326 @@
+package commands
+
+import (
+	"discordbot/challonge"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tourneyCreateUsage = "Usage: " + CommandPrefix + TournamentCommandString + " create \"Tournament name\" [--type single|double|roundrobin|swiss] [--game \"Game name\"] [--url challonge_url] [--tourney short_name]"
+
+const challongeURL = "https://challonge.com/"
+
+var tourneySubcommands = map[string]func(*tourneyCommand, []string, map[string]string){
+	"create":     (*tourneyCommand).create,
+	"add":        (*tourneyCommand).addParticipant,
+	"remove":     (*tourneyCommand).removeParticipant,
+	"seed":       (*tourneyCommand).seedParticipant,
+	"checkin":    (*tourneyCommand).openCheckIn,
+	"start":      (*tourneyCommand).start,
+	"reset":      (*tourneyCommand).reset,
+	"finalize":   (*tourneyCommand).finalize,
+	"join":       (*tourneyCommand).join,
+	"link":       (*tourneyCommand).link,
+	"selfreport": (*tourneyCommand).setSelfReporting,
+	"list":       (*tourneyCommand).list,
+	"announce":   (*tourneyCommand).announce,
+	"bind":       (*tourneyCommand).bind,
+	"unbind":     (*tourneyCommand).unbind,
+	"seeds":      (*tourneyCommand).seeds,
+	"game":       (*tourneyCommand).setGame,
+}
+
+var tourneyTypes = map[string]string{
+	"single":     challonge.SingleElimination,
+	"double":     challonge.DoubleElimination,
+	"roundrobin": challonge.RoundRobin,
+	"swiss":      challonge.Swiss,
+}
+
+var nonURLCharacters = regexp.MustCompile(`[^a-z0-9_]+`)
+
+//tourneySlug turns a tournament name into something usable in urls and commands
+func tourneySlug(name string) string {
+	slug := strings.Trim(nonURLCharacters.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if len(slug) > 40 {
+		slug = slug[:40]
+	}
+	return slug
+}
+
+//createTourneyURL builds a challonge url from the tournament name. Challonge urls are global so a timestamp keeps it unique.
+func createTourneyURL(name string, now time.Time) string {
+	return tourneySlug(name) + "_" + strconv.FormatInt(now.Unix(), 36)
+}
+
+func (c *tourneyCommand) create(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) != 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, tourneyCreateUsage)
+		return
+	}
+
+	name := c.tourneyName
+	if name == "" {
+		name = tourneySlug(args[0])
+	}
+	if !c.isNameFree(name) {
+		return
+	}
+
+	tourneyType := challonge.SingleElimination
+	if name, ok := flags["type"]; ok {
+		tourneyType, ok = tourneyTypes[strings.ToLower(name)]
+		if !ok {
+			c.session.SendSimpleMessage(msg.ChannelID, "Unknown tournament type "+name+". Use single, double, roundrobin or swiss.")
+			return
+		}
+	}
+
+	tourneyURL, ok := flags["url"]
+	if !ok {
+		tourneyURL = createTourneyURL(args[0], time.Now())
+	}
+
+	tourney, err := c.challongeClient.CreateTournament(challonge.TournamentParams{
+		Name:           args[0],
+		TournamentType: tourneyType,
+		URL:            tourneyURL,
+		GameName:       flags["game"],
+	})
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, tournament unable to be created.")
+		log.Error(err)
+		return
+	}
+
+	t := Tournament{
+		DiscordServerID: msg.GuildID,
+		User:            c.user.UsersID,
+		ChallongeID:     tourney.URL,
+		Name:            name,
+		Organizers:      []Users{*c.user},
+		Game:            flags["game"],
+	}
+	err = c.repo.SaveTourney(&t)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Tournament created on challonge but could not be saved.")
+		log.Error(err)
+		return
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, args[0]+" created "+challongeURL+tourney.URL+" as "+name)
+}
+
+//isNameFree checks no other tournament in the server already uses the short name
+func (c *tourneyCommand) isNameFree(name string) bool {
+	msg := c.data.Message
+	ts, err := c.repo.GetTourneysByServer(msg.GuildID)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, tournament unable to start.")
+		log.Error(err)
+		return false
+	}
+	for _, t := range ts {
+		if strings.EqualFold(t.Name, name) {
+			c.session.SendSimpleMessage(msg.ChannelID, "A tournament named "+name+" is already running in this server. End it with "+CommandPrefix+TournamentFinishString+" --tourney "+name+" or pick another name with --tourney.")
+			return false
+		}
+	}
+	return true
+}
+
+//currentTourney fetches the tournament the command is for, sending an error message when there is none
+func (c *tourneyCommand) currentTourney() (Tournament, bool) {
+	return c.selectTourney(c.data.Message, c.tourneyName)
+}
+
+//organizerTourney fetches the tournament for the server when the user is allowed to change it
+func (c *tourneyCommand) organizerTourney(action string) (Tournament, bool) {
+	t, ok := c.currentTourney()
+	if !ok {
+		return t, false
+	}
+	return t, c.checkOrganizer(&t, c.user, c.data.Message.ChannelID, action)
+}
+
+func (c *tourneyCommand) addParticipant(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) != 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentCommandString+" add \"participant name\"")
+		return
+	}
+	t, ok := c.organizerTourney("add participants")
+	if !ok {
+		return
+	}
+	if findParticipantByName(&t.Participants, args[0]) != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, args[0]+" is already in the tournament.")
+		return
+	}
+
+	p, err := c.challongeClient.AddParticipant(t.ChallongeID, args[0])
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, participant unable to be added.")
+		log.Error(err)
+		return
+	}
+
+	t.Participants = append(t.Participants, TournamentParticipant{Name: p.Name, ChallongeID: p.ID})
+	c.saveAndReact(&t)
+}
+
+func (c *tourneyCommand) removeParticipant(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) != 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentCommandString+" remove \"participant name\"")
+		return
+	}
+	t, ok := c.organizerTourney("remove participants")
+	if !ok {
+		return
+	}
+	p := findParticipantByName(&t.Participants, args[0])
+	if p == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Participant's name not found.")
+		return
+	}
+
+	err := c.challongeClient.RemoveParticipant(t.ChallongeID, p.ChallongeID)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, participant unable to be removed.")
+		log.Error(err)
+		return
+	}
+
+	var remaining []TournamentParticipant
+	for _, tp := range t.Participants {
+		if tp.ChallongeID != p.ChallongeID {
+			remaining = append(remaining, tp)
+		}
+	}
+	t.Participants = remaining
+	c.saveAndReact(&t)
+}
+
+func (c *tourneyCommand) seedParticipant(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) != 2 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentCommandString+" seed \"participant name\" seed")
+		return
+	}
+	seed, err := strconv.Atoi(args[1])
+	if err != nil || seed < 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Seed must be a number 1 or higher.")
+		return
+	}
+	t, ok := c.organizerTourney("seed participants")
+	if !ok {
+		return
+	}
+	p := findParticipantByName(&t.Participants, args[0])
+	if p == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Participant's name not found.")
+		return
+	}
+
+	err = c.challongeClient.SeedParticipant(t.ChallongeID, p.ChallongeID, seed)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, participant unable to be seeded.")
+		log.Error(err)
+		return
+	}
+	c.session.ReactToMessage(msg.ID, msg.ChannelID, "👍")
+}
+
+func (c *tourneyCommand) openCheckIn(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) != 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentCommandString+" checkin 30m")
+		return
+	}
+	d, err := parseDuration(args[0])
+	if err != nil || d < time.Minute {
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not read check in duration "+args[0]+". Use a duration like 15m or 1h.")
+		return
+	}
+	t, ok := c.organizerTourney("open check in")
+	if !ok {
+		return
+	}
+	c.startCheckIn(msg, &t, d)
+}
+
+func (c *tourneyCommand) start(args []string, flags map[string]string) {
+	//No-shows are removed before the bracket is made
+	ts, err := c.repo.GetTourneysByServer(c.data.Message.GuildID)
+	if err != nil {
+		log.Error(err)
+	}
+	if t, problem := pickTourney(ts, c.tourneyName, c.data.Message.ChannelID); problem == "" && t.CheckInMessage != 0 && isTourneyOrganizer(&t, c.user) {
+		closeCheckIn(c.repo, c.challongeClient, c.session, &t)
+	}
+	c.runTourneyAction(c.challongeClient.StartTournament, "start", "started")
+}
+
+func (c *tourneyCommand) reset(args []string, flags map[string]string) {
+	c.runTourneyAction(c.challongeClient.ResetTournament, "reset", "reset")
+}
+
+func (c *tourneyCommand) finalize(args []string, flags map[string]string) {
+	c.runTourneyAction(c.challongeClient.FinalizeTournament, "finalize", "finalized")
+}
+
+func (c *tourneyCommand) runTourneyAction(action func(string) error, verb string, done string) {
+	msg := c.data.Message
+	t, ok := c.organizerTourney(verb + " the tournament")
+	if !ok {
+		return
+	}
+
+	err := action(t.ChallongeID)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, tournament could not be "+done+".")
+		log.Error(err)
+		return
+	}
+
+	//Matches called before are not called any more once the bracket is reset or started
+	changed := t.CurrentMatch != 0
+	t.CurrentMatch = 0
+	for i := range t.Stations {
+		changed = changed || t.Stations[i].CurrentMatch != 0
+		t.Stations[i].CurrentMatch = 0
+	}
+	if changed {
+		if err := c.repo.SaveTourney(&t); err != nil {
+			log.Error(err)
+		}
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, "Tournament "+done+". "+challongeURL+t.ChallongeID)
+}
+
+func (c *tourneyCommand) saveAndReact(t *Tournament) {
+	err := c.repo.SaveTourney(t)
+	if err != nil {
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Something went wrong, tournament unable to be saved.")
+		log.Error(err)
+		return
+	}
+	c.session.ReactToMessage(c.data.Message.ID, c.data.Message.ChannelID, "👍")
+}
+
+func (c *tourneyCommand) setSelfReporting(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentCommandString+" selfreport on|off")
+		return
+	}
+	t, ok := c.organizerTourney("change self reporting")
+	if !ok {
+		return
+	}
+	t.SelfReporting = args[0] == "on"
+	c.saveAndReact(&t)
+}
@@ -0,0 +1,184 @@
+package commands_test
+
+import (
+	"discordbot/challonge"
+	"discordbot/commands"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+func runTourneyCommand(content string, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{
+		ID:        77,
+		Content:   content,
+		GuildID:   123,
+		ChannelID: 10,
+	}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	c := factory.CreateRequest(&msg, &commands.Users{UsersID: 1, DiscordUsersID: 1})
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func newRunningTourney() (*mockChallongeClient, *mockTourneyDB) {
+	cclient := &mockChallongeClient{}
+	cclient.setTourneyID("weekly")
+	cclient.addParticipant(challonge.Participant{ID: 1, Name: "Mang0"})
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+	repo.SaveTourney(&commands.Tournament{
+		TournamentID:    1,
+		ChallongeID:     "weekly",
+		Name:            "weekly",
+		User:            1,
+		DiscordServerID: 123,
+		Participants:    []commands.TournamentParticipant{{Name: "Mang0", ChallongeID: 1}},
+	})
+	return cclient, repo
+}
+
+func TestCreateTourney(t *testing.T) {
+	//Given: No tournament in the server
+	cclient := &mockChallongeClient{}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+
+	//When: A double elimination tournament is created
+	s := runTourneyCommand(`create "Weekly #1" --type double --game "Melee"`, cclient, repo)
+
+	//Then: Challonge is asked to create it
+	if len(cclient.created) != 1 {
+		t.Fatal("Tournament not created.")
+	}
+	p := cclient.created[0]
+	if p.Name != "Weekly #1" || p.TournamentType != challonge.DoubleElimination || p.GameName != "Melee" {
+		t.Error("Tournament params parsed incorrectly ", p)
+	}
+	if !strings.HasPrefix(p.URL, "weekly_1_") {
+		t.Error("Url not generated from the name ", p.URL)
+	}
+	//And: It is saved for the server
+	if repo.tourneys[1].ChallongeID != p.URL {
+		t.Error("Tournament not saved ", repo.tourneys[1])
+	}
+	if s.message != "Weekly #1 created https://challonge.com/"+p.URL+" as weekly_1" || repo.tourneys[1].Name != "weekly_1" {
+		t.Error("Link not posted ", s.message)
+	}
+}
+
+func TestCreateTourneyBadInput(t *testing.T) {
+	inputs := []string{
+		`create`,
+		`create "Weekly" --type ladder`,
+	}
+	for _, input := range inputs {
+		cclient := &mockChallongeClient{}
+		repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+
+		s := runTourneyCommand(input, cclient, repo)
+
+		if len(cclient.created) != 0 || s.message == "" {
+			t.Error("Tournament created for bad input ", input)
+		}
+	}
+
+	//A second tournament cannot use the name of one that is running
+	cclient, repo := newRunningTourney()
+	s := runTourneyCommand(`create "Weekly"`, cclient, repo)
+	if len(cclient.created) != 0 || !strings.HasPrefix(s.message, "A tournament named weekly is already running") {
+		t.Error("Created tournament over a running one. Got ", s.message)
+	}
+}
+
+func TestAddAndRemoveParticipant(t *testing.T) {
+	cclient, repo := newRunningTourney()
+
+	runTourneyCommand(`add "Hungrybox"`, cclient, repo)
+
+	ps := repo.tourneys[1].Participants
+	if len(ps) != 2 || ps[1].Name != "Hungrybox" || ps[1].ChallongeID == 0 {
+		t.Fatal("Participant not added ", ps)
+	}
+
+	s := runTourneyCommand(`remove Mang0`, cclient, repo)
+
+	ps = repo.tourneys[1].Participants
+	if len(ps) != 1 || ps[0].Name != "Hungrybox" || len(cclient.participants) != 1 {
+		t.Error("Participant not removed ", ps)
+	}
+	if s.getReactedMessage() != 77 {
+		t.Error("Message not given a reaction.")
+	}
+}
+
+func TestAddDuplicateParticipant(t *testing.T) {
+	cclient, repo := newRunningTourney()
+
+	s := runTourneyCommand(`add Mang0`, cclient, repo)
+
+	if len(cclient.participants) != 1 || s.message != "Mang0 is already in the tournament." {
+		t.Error("Duplicate participant added. Got ", s.message)
+	}
+}
+
+func TestSeedParticipant(t *testing.T) {
+	cclient, repo := newRunningTourney()
+
+	runTourneyCommand(`seed Mang0 3`, cclient, repo)
+
+	if cclient.seeds[1] != 3 {
+		t.Error("Participant not seeded ", cclient.seeds)
+	}
+
+	s := runTourneyCommand(`seed Mang0 first`, cclient, repo)
+	if s.message != "Seed must be a number 1 or higher." {
+		t.Error("Bad seed accepted. Got ", s.message)
+	}
+}
+
+func TestTourneyActions(t *testing.T) {
+	cclient, repo := newRunningTourney()
+
+	runTourneyCommand(`checkin 30m`, cclient, repo)
+	runTourneyCommand(`start`, cclient, repo)
+	runTourneyCommand(`reset`, cclient, repo)
+	s := runTourneyCommand(`finalize`, cclient, repo)
+
+	if strings.Join(cclient.actions, ",") != "checkin,process_check_ins,start,reset,finalize" {
+		t.Error("Actions not sent to challonge ", cclient.actions)
+	}
+	if cclient.checkIn != 30*time.Minute {
+		t.Error("Check in duration incorrect ", cclient.checkIn)
+	}
+	if s.message != "Tournament finalized. https://challonge.com/weekly" {
+		t.Error("Finalize message not sent ", s.message)
+	}
+}
+
+func TestResetClearsCalledMatches(t *testing.T) {
+	cclient, repo := newRunningTourney()
+	tourney := repo.tourneys[1]
+	tourney.CurrentMatch = 1
+	tourney.Stations = []commands.TournamentStation{{Name: "2", CurrentMatch: 2}, {Name: "3"}}
+	repo.tourneys[1] = tourney
+
+	runTourneyCommand(`reset`, cclient, repo)
+
+	tourney = repo.tourneys[1]
+	if tourney.CurrentMatch != 0 || len(tourney.Stations) != 2 || tourney.Stations[0].CurrentMatch != 0 {
+		t.Error("Called matches kept after reset ", tourney.CurrentMatch, " ", tourney.Stations)
+	}
+}
+
+func TestTourneyActionWithoutTourney(t *testing.T) {
+	cclient := &mockChallongeClient{}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+
+	s := runTourneyCommand(`start`, cclient, repo)
+
+	if len(cclient.actions) != 0 || s.message != "Command unable to be used. Tournament not started in this server." {
+		t.Error("Action run without a tournament. Got ", s.message)
+	}
+}
@@ -0,0 +1,267 @@
+package commands
+
+import (
+	"discordbot/challonge"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const matchQueueLength = 10
+
+func (c *tourneyCommandRequestFactory) CreateNextMatchCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &nextMatchCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+func (c *tourneyCommandRequestFactory) CreateMatchQueueCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &matchQueueCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+//calledMatches - match ids currently being played on any setup
+func calledMatches(t *Tournament) map[int]string {
+	called := make(map[int]string)
+	if t.CurrentMatch != 0 {
+		called[t.CurrentMatch] = ""
+	}
+	for _, s := range t.Stations {
+		if s.CurrentMatch != 0 {
+			called[s.CurrentMatch] = s.Name
+		}
+	}
+	return called
+}
+
+func stationMatch(t *Tournament, station string) int {
+	if station == "" {
+		return t.CurrentMatch
+	}
+	for _, s := range t.Stations {
+		if strings.EqualFold(s.Name, station) {
+			return s.CurrentMatch
+		}
+	}
+	return 0
+}
+
+func setStationMatch(t *Tournament, station string, matchID int) {
+	if station == "" {
+		t.CurrentMatch = matchID
+		return
+	}
+	for i, s := range t.Stations {
+		if strings.EqualFold(s.Name, station) {
+			t.Stations[i].CurrentMatch = matchID
+			return
+		}
+	}
+	t.Stations = append(t.Stations, TournamentStation{Name: station, CurrentMatch: matchID})
+}
+
+//clearMatch takes a finished match off whichever setup it was called on
+func clearMatch(t *Tournament, matchID int) {
+	if t.CurrentMatch == matchID {
+		t.CurrentMatch = 0
+	}
+	for i, s := range t.Stations {
+		if s.CurrentMatch == matchID {
+			t.Stations[i].CurrentMatch = 0
+		}
+	}
+}
+
+func isMatchPlayable(m challonge.Match) bool {
+	return m.WinnerID == 0 && m.Player1ID != 0 && m.Player2ID != 0 && m.State != "complete" && m.State != "pending"
+}
+
+//matchProgression orders rounds the way a double elimination bracket is played.
+//Winners round n feeds losers round 2n-2, so W1, L1, W2, L2, L3, W3, L4, L5, W4 ...
+//Round robin and swiss only have positive rounds and are ordered by round.
+func matchProgression(m challonge.Match) int {
+	if m.Round < 0 {
+		return -m.Round
+	}
+	return 2*m.Round - 2
+}
+
+//matchQueue returns the playable matches that are not already called, in the order they should be played.
+//Matches with a player who is busy on another setup are left out.
+func matchQueue(t *Tournament, matches []challonge.Match) []challonge.Match {
+	called := calledMatches(t)
+	busy := make(map[int]bool)
+	for _, m := range matches {
+		if _, ok := called[m.ID]; ok && m.WinnerID == 0 {
+			busy[m.Player1ID] = true
+			busy[m.Player2ID] = true
+		}
+	}
+
+	var queue []challonge.Match
+	for _, m := range matches {
+		if _, ok := called[m.ID]; ok || !isMatchPlayable(m) {
+			continue
+		}
+		if busy[m.Player1ID] || busy[m.Player2ID] {
+			continue
+		}
+		queue = append(queue, m)
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		pi, pj := matchProgression(queue[i]), matchProgression(queue[j])
+		if pi != pj {
+			return pi < pj
+		}
+		if queue[i].Round > 0 != (queue[j].Round > 0) {
+			return queue[i].Round > 0
+		}
+		return queue[i].SuggestedPlayOrder < queue[j].SuggestedPlayOrder
+	})
+	return queue
+}
+
+func roundName(m challonge.Match, matches []challonge.Match) string {
+	hasLosers := false
+	maxRound := 0
+	for _, o := range matches {
+		if o.Round < 0 {
+			hasLosers = true
+		}
+		if o.Round > maxRound {
+			maxRound = o.Round
+		}
+	}
+
+	switch {
+	case m.Round < 0:
+		return fmt.Sprintf("Losers Round %d", -m.Round)
+	case hasLosers && m.Round == maxRound:
+		return "Grand Finals"
+	case hasLosers:
+		return fmt.Sprintf("Winners Round %d", m.Round)
+	default:
+		return fmt.Sprintf("Round %d", m.Round)
+	}
+}
+
+func formatMatch(t *Tournament, m challonge.Match) string {
+	p1 := findParticipant(&t.Participants, m.Player1ID)
+	p2 := findParticipant(&t.Participants, m.Player2ID)
+	if p1 == nil || p2 == nil {
+		return "Unknown participant"
+	}
+	return participantLabel(p1) + " vs " + participantLabel(p2)
+}
+
+func findMatch(matches []challonge.Match, ID int) *challonge.Match {
+	for i := range matches {
+		if matches[i].ID == ID {
+			return &matches[i]
+		}
+	}
+	return nil
+}
+
+type nextMatchCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *nextMatchCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	t, station, ok := c.findTourney(msg)
+	if !ok {
+		return
+	}
+	if !c.checkOrganizer(&t, c.user, msg.ChannelID, "call matches") {
+		return
+	}
+
+	matches, err := c.challongeClient.GetMatches(t.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return
+	}
+
+	if current := findMatch(matches, stationMatch(&t, station)); current != nil && current.WinnerID == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, stationLabel(station)+" is still playing "+formatMatch(&t, *current)+". Report the winner with "+CommandPrefix+TournamentMatchWinString+" first.")
+		return
+	}
+
+	queue := matchQueue(&t, matches)
+	if len(queue) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "No match is ready to be played yet.")
+		return
+	}
+
+	next := queue[0]
+	setStationMatch(&t, station, next.ID)
+	err = c.repo.SaveTourney(&t)
+	if err != nil {
+		log.Error(err)
+	}
+
+	c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("%s: %s (%s)", stationLabel(station), formatMatch(&t, next), roundName(next, matches)))
+}
+
+func stationLabel(station string) string {
+	if station == "" {
+		return "Main setup"
+	}
+	return "Station " + station
+}
+
+type matchQueueCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *matchQueueCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	t, _, ok := c.findTourney(msg)
+	if !ok {
+		return
+	}
+
+	matches, err := c.challongeClient.GetMatches(t.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return
+	}
+
+	var b strings.Builder
+	stations := append([]TournamentStation{{CurrentMatch: t.CurrentMatch}}, t.Stations...)
+	for _, s := range stations {
+		if m := findMatch(matches, s.CurrentMatch); m != nil && m.WinnerID == 0 {
+			b.WriteString(fmt.Sprintf("%s: %s (%s)\n", stationLabel(s.Name), formatMatch(&t, *m), roundName(*m, matches)))
+		}
+	}
+
+	queue := matchQueue(&t, matches)
+	if len(queue) == 0 {
+		b.WriteString("No matches waiting to be called.")
+	} else {
+		b.WriteString("Up next:")
+	}
+	for i, m := range queue {
+		if i == matchQueueLength {
+			b.WriteString(fmt.Sprintf("\n... and %d more", len(queue)-matchQueueLength))
+			break
+		}
+		b.WriteString(fmt.Sprintf("\n%d. %s (%s)", i+1, formatMatch(&t, m), roundName(m, matches)))
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, b.String())
+}
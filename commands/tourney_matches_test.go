@@ -0,0 +1,169 @@
+package commands_test
+
+import (
+	"discordbot/challonge"
+	"discordbot/commands"
+	"strings"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+)
+
+func newDoubleElimTourney() (*mockChallongeClient, *mockTourneyDB) {
+	cclient := &mockChallongeClient{}
+	cclient.setTourneyID("test")
+	cclient.addMatches(
+		challonge.Match{ID: 1, Round: 1, Player1ID: 1, Player2ID: 2, WinnerID: 1},
+		challonge.Match{ID: 2, Round: 1, Player1ID: 3, Player2ID: 4, WinnerID: 3},
+		challonge.Match{ID: 3, Round: 2, Player1ID: 1, Player2ID: 3, SuggestedPlayOrder: 3},
+		challonge.Match{ID: 4, Round: -1, Player1ID: 2, Player2ID: 4, SuggestedPlayOrder: 2},
+		challonge.Match{ID: 5, Round: -2, Player1ID: 0, Player2ID: 0},
+		challonge.Match{ID: 6, Round: 3},
+	)
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+	repo.SaveTourney(&commands.Tournament{
+		TournamentID:    1,
+		ChallongeID:     "test",
+		User:            1,
+		DiscordServerID: 123,
+		Participants: []commands.TournamentParticipant{
+			{Name: "a", ChallongeID: 1},
+			{Name: "b", ChallongeID: 2},
+			{Name: "c", ChallongeID: 3},
+			{Name: "d", ChallongeID: 4},
+		},
+	})
+	return cclient, repo
+}
+
+func runMatchCommand(command string, content string, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{ID: 50, Content: content, GuildID: 123}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	var c interface{}
+	switch command {
+	case commands.TournamentNextMatchString:
+		c = factory.CreateNextMatchCommand(&msg, &commands.Users{UsersID: 1})
+	case commands.TournamentMatchWinString:
+		c = factory.CreateWinnerCommand(&msg, &commands.Users{UsersID: 1})
+	case commands.TournamentMatchQueueString:
+		c = factory.CreateMatchQueueCommand(&msg, &commands.Users{UsersID: 1})
+	}
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestNextMatchOrdersByBracketProgression(t *testing.T) {
+	//Given: Winners round 2 and losers round 1 are both ready
+	cclient, repo := newDoubleElimTourney()
+
+	//When: The next match is called without a station
+	s := runMatchCommand(commands.TournamentNextMatchString, "", cclient, repo)
+
+	//Then: Losers round 1 is called first since it comes earlier in the bracket
+	if s.message != "Main setup: b vs d (Losers Round 1)" {
+		t.Error("Wrong match called ", s.message)
+	}
+	if repo.tourneys[1].CurrentMatch != 4 {
+		t.Error("Current match not set ", repo.tourneys[1].CurrentMatch)
+	}
+}
+
+func TestNextMatchMultipleStations(t *testing.T) {
+	cclient, repo := newDoubleElimTourney()
+
+	s1 := runMatchCommand(commands.TournamentNextMatchString, "1", cclient, repo)
+	s2 := runMatchCommand(commands.TournamentNextMatchString, "2", cclient, repo)
+	s3 := runMatchCommand(commands.TournamentNextMatchString, "3", cclient, repo)
+
+	if s1.message != "Station 1: b vs d (Losers Round 1)" {
+		t.Error("Station 1 called incorrectly ", s1.message)
+	}
+	if s2.message != "Station 2: a vs c (Winners Round 2)" {
+		t.Error("Station 2 called incorrectly ", s2.message)
+	}
+	if s3.message != "No match is ready to be played yet." {
+		t.Error("Match called twice ", s3.message)
+	}
+	st := repo.tourneys[1].Stations
+	if len(st) != 2 || st[0].CurrentMatch != 4 || st[1].CurrentMatch != 3 {
+		t.Error("Stations not saved ", st)
+	}
+}
+
+func TestNextMatchStationStillPlaying(t *testing.T) {
+	cclient, repo := newDoubleElimTourney()
+	runMatchCommand(commands.TournamentNextMatchString, "1", cclient, repo)
+
+	s := runMatchCommand(commands.TournamentNextMatchString, "1", cclient, repo)
+
+	if !strings.HasPrefix(s.message, "Station 1 is still playing b vs d") {
+		t.Error("Station given a second match ", s.message)
+	}
+}
+
+func TestMatchWinFindsStation(t *testing.T) {
+	//Given: Two stations playing
+	cclient, repo := newDoubleElimTourney()
+	runMatchCommand(commands.TournamentNextMatchString, "1", cclient, repo)
+	runMatchCommand(commands.TournamentNextMatchString, "2", cclient, repo)
+
+	//When: The winner of station 2's match is reported
+	runMatchCommand(commands.TournamentMatchWinString, "c", cclient, repo)
+
+	//Then: The match on station 2 is updated and the station is freed
+	if cclient.query.WinnerID != 3 || cclient.query.MatchScore.Player2Score != 1 {
+		t.Error("Winner not reported ", cclient.query)
+	}
+	st := repo.tourneys[1].Stations
+	if st[0].CurrentMatch != 4 || st[1].CurrentMatch != 0 {
+		t.Error("Wrong station cleared ", st)
+	}
+}
+
+func TestMatchQueue(t *testing.T) {
+	cclient, repo := newDoubleElimTourney()
+	runMatchCommand(commands.TournamentNextMatchString, "1", cclient, repo)
+
+	s := runMatchCommand(commands.TournamentMatchQueueString, "", cclient, repo)
+
+	if s.message != "Station 1: b vs d (Losers Round 1)\nUp next:\n1. a vs c (Winners Round 2)" {
+		t.Error("Queue formatted incorrectly ", s.message)
+	}
+}
+
+func TestNextMatchRoundRobin(t *testing.T) {
+	//Given: A round robin where player 1 is in two open matches
+	cclient := &mockChallongeClient{}
+	cclient.setTourneyID("rr")
+	cclient.addMatches(
+		challonge.Match{ID: 1, Round: 2, Player1ID: 1, Player2ID: 2},
+		challonge.Match{ID: 2, Round: 1, Player1ID: 1, Player2ID: 3},
+		challonge.Match{ID: 3, Round: 1, Player1ID: 2, Player2ID: 4},
+	)
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+	repo.SaveTourney(&commands.Tournament{
+		TournamentID:    1,
+		ChallongeID:     "rr",
+		User:            1,
+		DiscordServerID: 123,
+		Participants: []commands.TournamentParticipant{
+			{Name: "a", ChallongeID: 1},
+			{Name: "b", ChallongeID: 2},
+			{Name: "c", ChallongeID: 3},
+			{Name: "d", ChallongeID: 4},
+		},
+	})
+
+	s1 := runMatchCommand(commands.TournamentNextMatchString, "1", cclient, repo)
+	s2 := runMatchCommand(commands.TournamentNextMatchString, "2", cclient, repo)
+	s3 := runMatchCommand(commands.TournamentNextMatchString, "3", cclient, repo)
+
+	if s1.message != "Station 1: a vs c (Round 1)" || s2.message != "Station 2: b vs d (Round 1)" {
+		t.Error("Round robin matches called incorrectly ", s1.message, s2.message)
+	}
+	//Then: A player already on a station is not called again
+	if s3.message != "No match is ready to be played yet." {
+		t.Error("Busy player called to a second station ", s3.message)
+	}
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+func (c *tourneyCommandRequestFactory) CreateRemoveOrganizerCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &removeOrganizerCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+func (c *tourneyCommandRequestFactory) CreateOrganizerListCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &organizerListCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+//checkOrganizer tells the user off when they are not allowed to run an organizer only command
+func (c *tourneyCommandRequestFactory) checkOrganizer(t *Tournament, user *Users, channel Snowflake, action string) bool {
+	if isTourneyOrganizer(t, user) {
+		return true
+	}
+	c.session.SendSimpleMessage(channel, "Only tournament organizers can "+action+".")
+	return false
+}
+
+//mentionedUser finds or creates the user mentioned in a command
+func (c *tourneyCommandRequestFactory) mentionedUser(msg *disgord.Message, content string) (Users, bool) {
+	ID, ok := parseUserMention(content)
+	if !ok {
+		return Users{}, false
+	}
+
+	if !c.usersRepo.DoesUserExist(ID) {
+		u := Users{DiscordUsersID: ID}
+		for _, m := range msg.Mentions {
+			if m.ID == ID {
+				u.UserName = m.Username
+			}
+		}
+		if err := c.usersRepo.SaveUser(&u); err != nil {
+			log.Error(err)
+			return Users{}, false
+		}
+	}
+
+	u, err := c.usersRepo.GetUserByDiscordId(ID)
+	if err != nil || u.UsersID == 0 {
+		log.Error(err)
+		return Users{}, false
+	}
+	return u, true
+}
+
+type removeOrganizerCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *removeOrganizerCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	t, content, ok := c.findTourney(msg)
+	if !ok {
+		return
+	}
+	if !c.checkOrganizer(&t, c.user, msg.ChannelID, "remove organizers") {
+		return
+	}
+
+	ID, ok := parseUserMention(content)
+	if !ok {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentRemoveOrganizerString+" @user")
+		return
+	}
+
+	var organizer *Users
+	for i, o := range t.Organizers {
+		if o.DiscordUsersID == ID {
+			organizer = &t.Organizers[i]
+		}
+	}
+	if organizer == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, createUserMention(ID)+" is not an organizer.")
+		return
+	}
+	if organizer.UsersID == t.User {
+		c.session.SendSimpleMessage(msg.ChannelID, "The tournament creator can not be removed.")
+		return
+	}
+
+	err := c.repo.RemoveTourneyOrganizer(organizer.UsersID, t.TournamentID)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, organizer unable to be removed.")
+		log.Error(err)
+		return
+	}
+	c.session.ReactToMessage(msg.ID, msg.ChannelID, "👍")
+}
+
+type organizerListCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *organizerListCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	t, _, ok := c.findTourney(msg)
+	if !ok {
+		return
+	}
+
+	if len(t.Organizers) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "This tournament has no organizers. Add one with "+CommandPrefix+TournamentAddOrganizerString+" @user")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Organizers:")
+	for _, o := range t.Organizers {
+		b.WriteString("\n" + createUserMention(o.DiscordUsersID))
+		if o.UsersID == t.User {
+			b.WriteString(" (creator)")
+		}
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, b.String())
+}
@@ -0,0 +1,134 @@
+package commands_test
+
+import (
+	"discordbot/commands"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+)
+
+var (
+	organizerCreator = &commands.Users{UsersID: 1, DiscordUsersID: 1}
+	organizerHelper  = &commands.Users{UsersID: 5, DiscordUsersID: 900}
+	organizerPlayer  = &commands.Users{UsersID: 3, DiscordUsersID: 800}
+)
+
+func newOrganizedTourney() (*mockChallongeClient, *mockTourneyDB) {
+	cclient, repo := newLinkedMatchTourney()
+	t := repo.tourneys[1]
+	t.Organizers = []commands.Users{*organizerCreator, *organizerHelper}
+	repo.tourneys[1] = t
+	return cclient, repo
+}
+
+func runOrganizerCommand(command string, content string, user *commands.Users, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{ID: 40, Content: content, GuildID: 123, ChannelID: 10}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	var c interface{}
+	switch command {
+	case commands.TournamentAddOrganizerString:
+		c = factory.CreateAddOrganizerCommand(&msg, user)
+	case commands.TournamentRemoveOrganizerString:
+		c = factory.CreateRemoveOrganizerCommand(&msg, user)
+	case commands.TournamentOrganizerListString:
+		c = factory.CreateOrganizerListCommand(&msg, user)
+	case commands.TournamentNextMatchString:
+		c = factory.CreateNextMatchCommand(&msg, user)
+	case commands.TournamentMatchWinString:
+		c = factory.CreateWinnerCommand(&msg, user)
+	case commands.TournamentFinishString:
+		c = factory.CreateTourneyCloseCommand(&msg, user)
+	case commands.TournamentCommandString:
+		c = factory.CreateRequest(&msg, user)
+	}
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestOrganizerList(t *testing.T) {
+	cclient, repo := newOrganizedTourney()
+
+	s := runOrganizerCommand(commands.TournamentOrganizerListString, "", organizerPlayer, cclient, repo)
+
+	if s.message != "Organizers:\n<@1> (creator)\n<@900>" {
+		t.Error("Organizers listed incorrectly ", s.message)
+	}
+}
+
+func TestPlayerCanNotAddOrganizer(t *testing.T) {
+	cclient, repo := newOrganizedTourney()
+
+	s := runOrganizerCommand(commands.TournamentAddOrganizerString, "<@800>", organizerPlayer, cclient, repo)
+
+	if s.message != "Only tournament organizers can add organizers." || len(repo.organizers) != 0 {
+		t.Error("Player added an organizer ", s.message)
+	}
+}
+
+func TestRemoveOrganizer(t *testing.T) {
+	//Given: A tournament with a creator and a helper
+	cclient, repo := newOrganizedTourney()
+
+	//When: The helper tries to remove the creator
+	s := runOrganizerCommand(commands.TournamentRemoveOrganizerString, "<@1>", organizerHelper, cclient, repo)
+
+	//Then: The creator stays
+	if s.message != "The tournament creator can not be removed." || len(repo.tourneys[1].Organizers) != 2 {
+		t.Error("Creator removed ", s.message)
+	}
+
+	//When: The creator removes the helper
+	runOrganizerCommand(commands.TournamentRemoveOrganizerString, "<@!900>", organizerCreator, cclient, repo)
+
+	//Then: Only the creator is left
+	os := repo.tourneys[1].Organizers
+	if len(os) != 1 || os[0].UsersID != 1 {
+		t.Error("Organizer not removed ", os)
+	}
+
+	//And: Removing someone who is not an organizer is reported
+	s = runOrganizerCommand(commands.TournamentRemoveOrganizerString, "<@800>", organizerCreator, cclient, repo)
+	if s.message != "<@800> is not an organizer." {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestMutatingCommandsRequireOrganizer(t *testing.T) {
+	inputs := []struct {
+		command  string
+		content  string
+		expected string
+	}{
+		{commands.TournamentNextMatchString, "", "Only tournament organizers can call matches."},
+		{commands.TournamentMatchWinString, "b", "Only tournament organizers can report other players' wins."},
+		{commands.TournamentFinishString, "", "Only tournament organizers can end the tournament."},
+		{commands.TournamentCommandString, "start", "Only tournament organizers can start the tournament."},
+		{commands.TournamentCommandString, `add "Someone"`, "Only tournament organizers can add participants."},
+	}
+	for _, input := range inputs {
+		cclient, repo := newOrganizedTourney()
+		tourney := repo.tourneys[1]
+		tourney.CurrentMatch = 1
+		repo.tourneys[1] = tourney
+
+		s := runOrganizerCommand(input.command, input.content, organizerPlayer, cclient, repo)
+
+		if s.message != input.expected {
+			t.Error("Unexpected response for ", input.command, " ", input.content, ": ", s.message)
+		}
+		if _, ok := repo.tourneys[1]; !ok || cclient.query.WinnerID != 0 || len(cclient.actions) != 0 {
+			t.Error("Command ran for a player ", input.command)
+		}
+	}
+}
+
+func TestHelperOrganizerCanCallMatches(t *testing.T) {
+	cclient, repo := newOrganizedTourney()
+
+	s := runOrganizerCommand(commands.TournamentNextMatchString, "", organizerHelper, cclient, repo)
+
+	if s.message != "Main setup: <@800> vs <@801> (Round 1)" {
+		t.Error("Organizer could not call a match ", s.message)
+	}
+}
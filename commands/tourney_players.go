@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const tourneyLinkUsage = "Usage: " + CommandPrefix + TournamentCommandString + " link \"participant name\" @user, or " + CommandPrefix + TournamentCommandString + " link auto to match participants to server members by name"
+
+func findParticipantByDiscordUser(ps *[]TournamentParticipant, user Snowflake) *TournamentParticipant {
+	if user == 0 {
+		return nil
+	}
+	for i := range *ps {
+		if (*ps)[i].DiscordUserID == user {
+			return &(*ps)[i]
+		}
+	}
+	return nil
+}
+
+//findParticipantByNameFold finds a participant ignoring case, for names typed by players
+func findParticipantByNameFold(ps *[]TournamentParticipant, name string) *TournamentParticipant {
+	for i := range *ps {
+		if strings.EqualFold((*ps)[i].Name, name) {
+			return &(*ps)[i]
+		}
+	}
+	return nil
+}
+
+//findParticipantByArgument accepts either an @mention or a participant name
+func findParticipantByArgument(ps *[]TournamentParticipant, arg string) *TournamentParticipant {
+	if id, ok := parseUserMention(arg); ok {
+		return findParticipantByDiscordUser(ps, id)
+	}
+	if p := findParticipantByName(ps, arg); p != nil {
+		return p
+	}
+	return findParticipantByNameFold(ps, arg)
+}
+
+//participantLabel mentions linked participants so they are pinged when their match is called
+func participantLabel(p *TournamentParticipant) string {
+	if p.DiscordUserID != 0 {
+		return createUserMention(p.DiscordUserID)
+	}
+	return p.Name
+}
+
+func isTourneyOrganizer(t *Tournament, user *Users) bool {
+	if t.User == user.UsersID || user.IsAdmin {
+		return true
+	}
+	for _, o := range t.Organizers {
+		if o.UsersID == user.UsersID {
+			return true
+		}
+	}
+	return false
+}
+
+func authorName(msg *disgord.Message, user *Users) string {
+	if msg.Member != nil && msg.Member.Nick != "" {
+		return msg.Member.Nick
+	}
+	if msg.Author != nil && msg.Author.Username != "" {
+		return msg.Author.Username
+	}
+	return user.UserName
+}
+
+//isAuthorName says whether a name is the author's server nickname or username
+func isAuthorName(msg *disgord.Message, user *Users, name string) bool {
+	names := []string{user.UserName}
+	if msg.Member != nil {
+		names = append(names, msg.Member.Nick)
+	}
+	if msg.Author != nil {
+		names = append(names, msg.Author.Username)
+	}
+	for _, n := range names {
+		if n != "" && strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+//join links the author to a participant with their name, or signs them up when there is none.
+//Only organizers link someone to a participant going by another name.
+func (c *tourneyCommand) join(args []string, flags map[string]string) {
+	msg := c.data.Message
+	t, ok := c.currentTourney()
+	if !ok {
+		return
+	}
+	if p := findParticipantByDiscordUser(&t.Participants, c.user.DiscordUsersID); p != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "You are already in the tournament as "+p.Name+".")
+		return
+	}
+
+	name := authorName(msg, c.user)
+	if len(args) > 0 {
+		name = strings.Join(args, " ")
+	}
+
+	if p := findParticipantByNameFold(&t.Participants, name); p != nil {
+		if p.DiscordUserID != 0 {
+			c.session.SendSimpleMessage(msg.ChannelID, p.Name+" is already linked to another user.")
+			return
+		}
+		if !isAuthorName(msg, c.user, name) {
+			c.session.SendSimpleMessage(msg.ChannelID, p.Name+" is already signed up. Ask an organizer to link you with "+CommandPrefix+TournamentCommandString+" link.")
+			return
+		}
+		p.DiscordUserID = c.user.DiscordUsersID
+		c.saveAndReact(&t)
+		return
+	}
+
+	cp, err := c.challongeClient.AddParticipant(t.ChallongeID, name)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, unable to join the tournament.")
+		log.Error(err)
+		return
+	}
+	t.Participants = append(t.Participants, TournamentParticipant{Name: cp.Name, ChallongeID: cp.ID, DiscordUserID: c.user.DiscordUsersID})
+	c.saveAndReact(&t)
+}
+
+//link lets organizers assign a participant to a user or match everyone up by name
+func (c *tourneyCommand) link(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, tourneyLinkUsage)
+		return
+	}
+	t, ok := c.organizerTourney("link participants")
+	if !ok {
+		return
+	}
+
+	if len(args) == 1 && strings.EqualFold(args[0], "auto") {
+		c.autoLink(&t)
+		return
+	}
+	if len(args) != 2 {
+		c.session.SendSimpleMessage(msg.ChannelID, tourneyLinkUsage)
+		return
+	}
+
+	p := findParticipantByNameFold(&t.Participants, args[0])
+	if p == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Participant's name not found.")
+		return
+	}
+	user, ok := parseUserMention(args[1])
+	if !ok {
+		c.session.SendSimpleMessage(msg.ChannelID, tourneyLinkUsage)
+		return
+	}
+	if other := findParticipantByDiscordUser(&t.Participants, user); other != nil && other != p {
+		other.DiscordUserID = 0
+	}
+	p.DiscordUserID = user
+	c.saveAndReact(&t)
+}
+
+func (c *tourneyCommand) autoLink(t *Tournament) {
+	msg := c.data.Message
+	members, err := c.session.Guild(msg.GuildID).GetMembers(nil)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Unable to fetch server members.")
+		log.Error(err)
+		return
+	}
+
+	linked := 0
+	for i := range t.Participants {
+		p := &t.Participants[i]
+		if p.DiscordUserID != 0 {
+			continue
+		}
+		for _, m := range members {
+			if m.User == nil || findParticipantByDiscordUser(&t.Participants, m.User.ID) != nil {
+				continue
+			}
+			if strings.EqualFold(p.Name, m.User.Username) || (m.Nick != "" && strings.EqualFold(p.Name, m.Nick)) {
+				p.DiscordUserID = m.User.ID
+				linked++
+				break
+			}
+		}
+	}
+
+	err = c.repo.SaveTourney(t)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, tournament unable to be saved.")
+		log.Error(err)
+		return
+	}
+
+	unlinked := 0
+	for _, p := range t.Participants {
+		if p.DiscordUserID == 0 {
+			unlinked++
+		}
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("Linked %d participants. %d still have no Discord user.", linked, unlinked))
+}
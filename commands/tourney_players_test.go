@@ -0,0 +1,184 @@
+package commands_test
+
+import (
+	"discordbot/challonge"
+	"discordbot/commands"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+)
+
+func runTourneyCommandAs(content string, user *commands.Users, guild *mockGuild, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{
+		ID:        77,
+		Content:   content,
+		GuildID:   123,
+		ChannelID: 10,
+		Author:    &disgord.User{ID: user.DiscordUsersID, Username: user.UserName},
+	}}
+	s := &mockSession{guild: guild}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	c := factory.CreateRequest(&msg, user)
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestJoinClaimsParticipantByName(t *testing.T) {
+	//Given: A participant with the same name as the user
+	cclient, repo := newRunningTourney()
+	player := &commands.Users{UsersID: 2, DiscordUsersID: 500, UserName: "mang0"}
+
+	//When: The user joins
+	runTourneyCommandAs("join", player, &commonMockGuild, cclient, repo)
+
+	//Then: The participant is linked instead of a new one being added
+	ps := repo.tourneys[1].Participants
+	if len(ps) != 1 || ps[0].DiscordUserID != 500 {
+		t.Error("Participant not linked ", ps)
+	}
+
+	//And: Joining again does nothing
+	s := runTourneyCommandAs("join", player, &commonMockGuild, cclient, repo)
+	if s.message != "You are already in the tournament as Mang0." || len(cclient.participants) != 1 {
+		t.Error("Joined twice ", s.message)
+	}
+}
+
+func TestJoinSignsUpNewParticipant(t *testing.T) {
+	cclient, repo := newRunningTourney()
+	player := &commands.Users{UsersID: 2, DiscordUsersID: 501, UserName: "hbox"}
+
+	runTourneyCommandAs(`join "Hungrybox"`, player, &commonMockGuild, cclient, repo)
+
+	ps := repo.tourneys[1].Participants
+	if len(ps) != 2 || ps[1].Name != "Hungrybox" || ps[1].DiscordUserID != 501 || ps[1].ChallongeID == 0 {
+		t.Error("Participant not signed up ", ps)
+	}
+}
+
+func TestJoinByNameOnlyClaimsOwnName(t *testing.T) {
+	cclient, repo := newRunningTourney()
+	player := &commands.Users{UsersID: 2, DiscordUsersID: 502, UserName: "hbox"}
+
+	//Someone else's slot is left for an organizer to link
+	s := runTourneyCommandAs(`join "mang0"`, player, &commonMockGuild, cclient, repo)
+
+	ps := repo.tourneys[1].Participants
+	if len(ps) != 1 || ps[0].DiscordUserID != 0 || len(cclient.participants) != 1 {
+		t.Error("Participant claimed by another user ", ps)
+	}
+	if s.message != "Mang0 is already signed up. Ask an organizer to link you with $tournament link." {
+		t.Error("Unexpected message ", s.message)
+	}
+
+	//Their own name is still claimed
+	player.UserName = "MANG0"
+	runTourneyCommandAs(`join "mang0"`, player, &commonMockGuild, cclient, repo)
+	if ps := repo.tourneys[1].Participants; ps[0].DiscordUserID != 502 {
+		t.Error("Own participant not linked ", ps)
+	}
+}
+
+func TestLinkParticipant(t *testing.T) {
+	cclient, repo := newRunningTourney()
+	organizer := &commands.Users{UsersID: 1, DiscordUsersID: 1}
+	player := &commands.Users{UsersID: 2, DiscordUsersID: 2}
+
+	//Players can not link other people
+	s := runTourneyCommandAs("link Mang0 <@!600>", player, &commonMockGuild, cclient, repo)
+	if s.message != "Only tournament organizers can link participants." {
+		t.Error("Non organizer linked a participant ", s.message)
+	}
+
+	runTourneyCommandAs("link mang0 <@!600>", organizer, &commonMockGuild, cclient, repo)
+
+	if repo.tourneys[1].Participants[0].DiscordUserID != 600 {
+		t.Error("Participant not linked ", repo.tourneys[1].Participants)
+	}
+}
+
+func TestAutoLinkParticipants(t *testing.T) {
+	cclient, repo := newRunningTourney()
+	tourney := repo.tourneys[1]
+	tourney.Participants = append(tourney.Participants,
+		commands.TournamentParticipant{Name: "Armada", ChallongeID: 2},
+		commands.TournamentParticipant{Name: "Nobody", ChallongeID: 3})
+	repo.tourneys[1] = tourney
+	guild := &mockGuild{members: []*disgord.Member{
+		{User: &disgord.User{ID: 700, Username: "mang0"}},
+		{User: &disgord.User{ID: 701, Username: "adam"}, Nick: "Armada"},
+	}}
+
+	s := runTourneyCommandAs("link auto", &commands.Users{UsersID: 1}, guild, cclient, repo)
+
+	ps := repo.tourneys[1].Participants
+	if ps[0].DiscordUserID != 700 || ps[1].DiscordUserID != 701 || ps[2].DiscordUserID != 0 {
+		t.Error("Participants not matched by name ", ps)
+	}
+	if s.message != "Linked 2 participants. 1 still have no Discord user." {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func newLinkedMatchTourney() (*mockChallongeClient, *mockTourneyDB) {
+	cclient := &mockChallongeClient{}
+	cclient.setTourneyID("test")
+	cclient.addMatches(challonge.Match{ID: 1, Round: 1, Player1ID: 1, Player2ID: 2})
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+	repo.SaveTourney(&commands.Tournament{
+		TournamentID:    1,
+		ChallongeID:     "test",
+		User:            1,
+		DiscordServerID: 123,
+		Participants: []commands.TournamentParticipant{
+			{Name: "a", ChallongeID: 1, DiscordUserID: 800},
+			{Name: "b", ChallongeID: 2, DiscordUserID: 801},
+		},
+	})
+	return cclient, repo
+}
+
+func TestNextMatchMentionsLinkedPlayers(t *testing.T) {
+	cclient, repo := newLinkedMatchTourney()
+
+	s := runMatchCommand(commands.TournamentNextMatchString, "", cclient, repo)
+
+	if s.message != "Main setup: <@800> vs <@801> (Round 1)" {
+		t.Error("Players not mentioned ", s.message)
+	}
+}
+
+func TestMatchWinByMention(t *testing.T) {
+	cclient, repo := newLinkedMatchTourney()
+	runMatchCommand(commands.TournamentNextMatchString, "", cclient, repo)
+
+	runMatchCommand(commands.TournamentMatchWinString, "<@801>", cclient, repo)
+
+	if cclient.query.WinnerID != 2 {
+		t.Error("Winner not found by mention ", cclient.query)
+	}
+}
+
+func TestMatchWinFromPlayerPointsToReport(t *testing.T) {
+	//Given: A called match with linked players who report their own matches
+	cclient, repo := newLinkedMatchTourney()
+	tourney := repo.tourneys[1]
+	tourney.SelfReporting = true
+	repo.tourneys[1] = tourney
+	runMatchCommand(commands.TournamentNextMatchString, "", cclient, repo)
+
+	//When: A player reports their own win from direct messages
+	msg := disgord.MessageCreate{Message: &disgord.Message{ID: 60, Content: ""}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	c := factory.CreateWinnerCommand(&msg, &commands.Users{UsersID: 3, DiscordUsersID: 800})
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+	//Then: Nothing is recorded without their opponent confirming through $report
+	if cclient.query.WinnerID != 0 || repo.tourneys[1].CurrentMatch == 0 {
+		t.Error("Player recorded their own win ", cclient.query)
+	}
+	if s.message != "Report your match with $report 2-1 in the server so your opponent can confirm it." {
+		t.Error("Unexpected message ", s.message)
+	}
+}
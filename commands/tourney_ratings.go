@@ -0,0 +1,472 @@
+package commands
+
+import (
+	"discordbot/challonge"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const defaultRating = 1500.0
+
+//ratingK - the most a rating can move in one set
+const ratingK = 32.0
+
+const leaderboardLength = 10
+
+const ratingUsage = "Usage: " + CommandPrefix + TournamentRatingString + " [@user] [--game name], " + CommandPrefix + TournamentRatingString + " import challonge_url [--game name]"
+
+func (c *tourneyCommandRequestFactory) CreateRatingCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &ratingCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+func (c *tourneyCommandRequestFactory) CreateLeaderboardCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &leaderboardCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+//expectedScore is the elo chance of a player beating their opponent
+func expectedScore(rating float64, opponent float64) float64 {
+	return 1 / (1 + math.Pow(10, (opponent-rating)/400))
+}
+
+//findRating gets a player's rating on a ladder, starting players new to it at the default rating.
+//A linked player takes over the rating imported for their name before they were linked.
+func findRating(repo TournamentRepository, server Snowflake, game string, p TournamentParticipant) (PlayerRating, error) {
+	r, err := repo.GetPlayerRating(server, game, p.DiscordUserID, p.Name)
+	if err != nil {
+		return r, err
+	}
+	if r.PlayerRatingID == 0 && p.DiscordUserID != 0 {
+		r, err = repo.GetPlayerRating(server, game, 0, p.Name)
+		if err != nil {
+			return r, err
+		}
+	}
+	if r.PlayerRatingID == 0 {
+		r = PlayerRating{DiscordServerID: server, Game: game, Rating: defaultRating}
+	}
+	if p.DiscordUserID != 0 {
+		r.DiscordUserID = p.DiscordUserID
+	}
+	r.Name = p.Name
+	return r, nil
+}
+
+//rateMatch moves the winner's and loser's ratings. A match reported again replaces its earlier result.
+func rateMatch(repo TournamentRepository, server Snowflake, game string, challongeID string, matchID int, winner TournamentParticipant, loser TournamentParticipant) error {
+	if err := unrateMatch(repo, server, challongeID, matchID); err != nil {
+		return err
+	}
+
+	w, err := findRating(repo, server, game, winner)
+	if err != nil {
+		return err
+	}
+	l, err := findRating(repo, server, game, loser)
+	if err != nil {
+		return err
+	}
+
+	change := ratingK * (1 - expectedScore(w.Rating, l.Rating))
+	w.Rating += change
+	w.Wins++
+	l.Rating -= change
+	l.Losses++
+
+	if err := repo.SavePlayerRating(&w); err != nil {
+		return err
+	}
+	if err := repo.SavePlayerRating(&l); err != nil {
+		return err
+	}
+	return repo.SaveRatedMatch(&RatedMatch{
+		DiscordServerID: server,
+		ChallongeID:     challongeID,
+		MatchID:         matchID,
+		WinnerRatingID:  w.PlayerRatingID,
+		LoserRatingID:   l.PlayerRatingID,
+		Change:          change,
+	})
+}
+
+//unrateMatch takes back the rating change of a match, nothing happens when it was never rated
+func unrateMatch(repo TournamentRepository, server Snowflake, challongeID string, matchID int) error {
+	m, err := repo.GetRatedMatch(server, challongeID, matchID)
+	if err != nil || m.MatchID == 0 {
+		return err
+	}
+
+	w, err := repo.GetPlayerRatingByID(m.WinnerRatingID)
+	if err != nil {
+		return err
+	}
+	l, err := repo.GetPlayerRatingByID(m.LoserRatingID)
+	if err != nil {
+		return err
+	}
+
+	w.Rating -= m.Change
+	w.Wins--
+	l.Rating += m.Change
+	l.Losses--
+
+	if err := repo.SavePlayerRating(&w); err != nil {
+		return err
+	}
+	if err := repo.SavePlayerRating(&l); err != nil {
+		return err
+	}
+	return repo.RemoveRatedMatch(server, challongeID, matchID)
+}
+
+//rateTourneyMatch counts a reported match in the ratings. The report already went through so a failure is only logged.
+func rateTourneyMatch(repo TournamentRepository, t *Tournament, m challonge.Match, winnerID int) {
+	winner := findParticipant(&t.Participants, winnerID)
+	loser := findParticipant(&t.Participants, opponentID(m, winnerID))
+	if winner == nil || loser == nil {
+		return
+	}
+	if err := rateMatch(repo, t.DiscordServerID, t.Game, t.ChallongeID, m.ID, *winner, *loser); err != nil {
+		log.WithField("tournament", t.TournamentID).Error(err)
+	}
+}
+
+func ladderName(game string) string {
+	if game == "" {
+		return "General"
+	}
+	return game
+}
+
+func ratingPlayer(r PlayerRating) string {
+	if r.DiscordUserID != 0 {
+		return createUserMention(r.DiscordUserID)
+	}
+	return r.Name
+}
+
+func formatRating(r PlayerRating) string {
+	return fmt.Sprintf("%.0f (%d-%d)", r.Rating, r.Wins, r.Losses)
+}
+
+type ratingCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *ratingCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	args, flags := parseFlags(splitArguments(msg.Content))
+	if len(args) > 0 && strings.EqualFold(args[0], "import") {
+		c.importHistory(args[1:], flags)
+		return
+	}
+	if len(args) > 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, ratingUsage)
+		return
+	}
+
+	ID := c.user.DiscordUsersID
+	if len(args) == 1 {
+		var ok bool
+		ID, ok = parseUserMention(args[0])
+		if !ok {
+			c.session.SendSimpleMessage(msg.ChannelID, ratingUsage)
+			return
+		}
+	}
+
+	rs, err := c.repo.GetPlayerRatingsByServer(msg.GuildID)
+	if err != nil {
+		log.Error(err)
+	}
+	game, filtered := flags["game"]
+
+	var b strings.Builder
+	for _, r := range rs {
+		if r.DiscordUserID != ID || (filtered && !strings.EqualFold(r.Game, game)) {
+			continue
+		}
+		b.WriteString("\n" + ladderName(r.Game) + ": " + formatRating(r))
+	}
+	if b.Len() == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, createUserMention(ID)+" has no rating yet.")
+		return
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, "Ratings for "+createUserMention(ID)+":"+b.String())
+}
+
+//importHistory rates the finished matches of a challonge tournament that was run without the bot
+func (c *ratingCommand) importHistory(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) != 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, ratingUsage)
+		return
+	}
+	if !c.user.IsAdmin {
+		c.session.SendSimpleMessage(msg.ChannelID, "Only admins can import tournament history.")
+		return
+	}
+
+	u, err := url.Parse(args[0])
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Unable to parse url")
+		return
+	}
+	challongeID := strings.TrimPrefix(u.Path, "/")
+
+	//A tournament still running in the server knows who the players are on discord
+	t := Tournament{DiscordServerID: msg.GuildID, ChallongeID: challongeID}
+	ts, err := c.repo.GetTourneysByServer(msg.GuildID)
+	if err != nil {
+		log.Error(err)
+	}
+	for _, rt := range ts {
+		if rt.ChallongeID == challongeID {
+			t = rt
+		}
+	}
+	if game, ok := flags["game"]; ok {
+		t.Game = game
+	}
+	ps, err := c.challongeClient.GetParticipants(challongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return
+	}
+	for _, p := range ps {
+		if findParticipant(&t.Participants, p.ID) == nil {
+			t.Participants = append(t.Participants, TournamentParticipant{Name: p.Name, ChallongeID: p.ID})
+		}
+	}
+
+	matches, err := c.challongeClient.GetMatches(challongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matchProgression(matches[i]) < matchProgression(matches[j])
+	})
+
+	rated := 0
+	for _, m := range matches {
+		if m.WinnerID == 0 {
+			continue
+		}
+		if rm, err := c.repo.GetRatedMatch(msg.GuildID, challongeID, m.ID); err != nil || rm.MatchID != 0 {
+			continue
+		}
+		winner := findParticipant(&t.Participants, m.WinnerID)
+		loser := findParticipant(&t.Participants, opponentID(m, m.WinnerID))
+		if winner == nil || loser == nil {
+			continue
+		}
+		if err := rateMatch(c.repo, msg.GuildID, t.Game, challongeID, m.ID, *winner, *loser); err != nil {
+			log.Error(err)
+			continue
+		}
+		rated++
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("Rated %d matches from %s on the %s ladder.", rated, challongeURL+challongeID, ladderName(t.Game)))
+}
+
+type leaderboardCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *leaderboardCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	args := splitArguments(msg.Content)
+	if len(args) > 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentLeaderboardString+" [game]")
+		return
+	}
+
+	rs, err := c.repo.GetPlayerRatingsByServer(msg.GuildID)
+	if err != nil {
+		log.Error(err)
+	}
+	if len(rs) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Nobody has a rating yet. Ratings change as matches are reported.")
+		return
+	}
+
+	var games []string
+	ladders := make(map[string][]PlayerRating)
+	for _, r := range rs {
+		key := strings.ToLower(r.Game)
+		if _, ok := ladders[key]; !ok {
+			games = append(games, ladderName(r.Game))
+		}
+		ladders[key] = append(ladders[key], r)
+	}
+
+	var ladder []PlayerRating
+	switch {
+	case len(args) == 1:
+		ladder = ladders[strings.ToLower(args[0])]
+		if strings.EqualFold(args[0], ladderName("")) {
+			ladder = ladders[""]
+		}
+	case len(games) == 1:
+		ladder = rs
+	default:
+		c.session.SendSimpleMessage(msg.ChannelID, "Pick a ladder: "+strings.Join(games, ", "))
+		return
+	}
+	if len(ladder) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "No ladder for "+args[0]+". Ladders: "+strings.Join(games, ", "))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Leaderboard for " + ladderName(ladder[0].Game) + ":")
+	for i, r := range ladder {
+		if i == leaderboardLength {
+			break
+		}
+		b.WriteString(fmt.Sprintf("\n%d. %s %s", i+1, ratingPlayer(r), formatRating(r)))
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, b.String())
+}
+
+//seedRating - a participant with their rating, rated is false for players new to the ladder
+type seedRating struct {
+	participant challonge.Participant
+	rating      float64
+	rated       bool
+}
+
+//suggestedSeeds orders participants by rating. Players without a rating go last in their current seed order.
+func (c *tourneyCommand) suggestedSeeds(t *Tournament) ([]seedRating, error) {
+	ps, err := c.challongeClient.GetParticipants(t.ChallongeID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		return ps[i].Seed < ps[j].Seed
+	})
+
+	var seeds []seedRating
+	for _, p := range ps {
+		tp := TournamentParticipant{Name: p.Name}
+		if linked := findParticipant(&t.Participants, p.ID); linked != nil {
+			tp.DiscordUserID = linked.DiscordUserID
+		}
+		r, err := findRating(c.repo, t.DiscordServerID, t.Game, tp)
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, seedRating{participant: p, rating: r.Rating, rated: r.PlayerRatingID != 0})
+	}
+
+	sort.SliceStable(seeds, func(i, j int) bool {
+		if seeds[i].rated != seeds[j].rated {
+			return seeds[i].rated
+		}
+		return seeds[i].rated && seeds[i].rating > seeds[j].rating
+	})
+	return seeds, nil
+}
+
+//seeds suggests seeds from the ladder and pushes them to challonge with apply
+func (c *tourneyCommand) seeds(args []string, flags map[string]string) {
+	msg := c.data.Message
+	apply := len(args) == 1 && strings.EqualFold(args[0], "apply")
+	if len(args) > 1 || (len(args) == 1 && !apply) {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentCommandString+" seeds [apply]")
+		return
+	}
+
+	var t Tournament
+	var ok bool
+	if apply {
+		t, ok = c.organizerTourney("seed participants")
+	} else {
+		t, ok = c.currentTourney()
+	}
+	if !ok {
+		return
+	}
+
+	seeds, err := c.suggestedSeeds(&t)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, ratings unable to be read.")
+		log.Error(err)
+		return
+	}
+	if len(seeds) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Nobody has signed up yet.")
+		return
+	}
+
+	if !apply {
+		var b strings.Builder
+		b.WriteString("Suggested seeds from the " + ladderName(t.Game) + " ladder:")
+		for i, s := range seeds {
+			rating := "unrated"
+			if s.rated {
+				rating = fmt.Sprintf("%.0f", s.rating)
+			}
+			b.WriteString(fmt.Sprintf("\n%d. %s - %s", i+1, s.participant.Name, rating))
+		}
+		b.WriteString("\nSend them to challonge with " + CommandPrefix + TournamentCommandString + " seeds apply")
+		c.session.SendSimpleMessage(msg.ChannelID, b.String())
+		return
+	}
+
+	//Challonge only has matches once the tournament has started
+	matches, err := c.challongeClient.GetMatches(t.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return
+	}
+	if len(matches) > 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Seeds can only be changed before the tournament starts.")
+		return
+	}
+
+	for i, s := range seeds {
+		err := c.challongeClient.SeedParticipant(t.ChallongeID, s.participant.ID, i+1)
+		if err != nil {
+			c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, participant unable to be seeded.")
+			log.Error(err)
+			return
+		}
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, "Seeds updated. "+challongeURL+t.ChallongeID)
+}
+
+//setGame picks the ladder the tournament's matches are rated on
+func (c *tourneyCommand) setGame(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) != 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentCommandString+" game \"Game name\"")
+		return
+	}
+	t, ok := c.organizerTourney("change the game")
+	if !ok {
+		return
+	}
+	t.Game = args[0]
+	c.saveAndReact(&t)
+}
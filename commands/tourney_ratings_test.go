@@ -0,0 +1,175 @@
+package commands_test
+
+import (
+	"discordbot/challonge"
+	"discordbot/commands"
+	"math"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+)
+
+func runRatingCommand(command string, content string, user *commands.Users, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{ID: 90, Content: content, GuildID: 123, ChannelID: 10}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	var c interface{}
+	switch command {
+	case commands.TournamentRatingString:
+		c = factory.CreateRatingCommand(&msg, user)
+	case commands.TournamentLeaderboardString:
+		c = factory.CreateLeaderboardCommand(&msg, user)
+	case commands.TournamentCommandString:
+		c = factory.CreateRequest(&msg, user)
+	}
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestReportedMatchUpdatesRatings(t *testing.T) {
+	//Given: Two new players on the melee ladder
+	cclient, repo := newReportTourney(false)
+	tourney := repo.tourneys[1]
+	tourney.Game = "Melee"
+	repo.tourneys[1] = tourney
+
+	//When: b beats a
+	runReportCommand("<@801> 2-0", reportOrganizer, cclient, repo)
+
+	//Then: b gains what a loses
+	if len(repo.ratings) != 2 || repo.ratings[0].Rating != 1516 || repo.ratings[1].Rating != 1484 {
+		t.Fatal("Ratings not updated ", repo.ratings)
+	}
+	if repo.ratings[0].DiscordUserID != 801 || repo.ratings[0].Wins != 1 || repo.ratings[1].Losses != 1 || repo.ratings[0].Game != "Melee" {
+		t.Error("Ratings saved incorrectly ", repo.ratings)
+	}
+
+	//When: The report is undone
+	runReportCommand("undo", reportOrganizer, cclient, repo)
+
+	//Then: The ratings go back
+	if repo.ratings[0].Rating != 1500 || repo.ratings[0].Wins != 0 || repo.ratings[1].Losses != 0 || len(repo.ratedMatches) != 0 {
+		t.Error("Ratings not reverted ", repo.ratings)
+	}
+}
+
+func newRatedLadder() *mockTourneyDB {
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+	repo.SavePlayerRating(&commands.PlayerRating{DiscordServerID: 123, Game: "Melee", DiscordUserID: 801, Name: "b", Rating: 1600, Wins: 4, Losses: 1})
+	repo.SavePlayerRating(&commands.PlayerRating{DiscordServerID: 123, Game: "Melee", Name: "c", Rating: 1550, Wins: 2})
+	repo.SavePlayerRating(&commands.PlayerRating{DiscordServerID: 123, Game: "Melee", DiscordUserID: 800, Name: "a", Rating: 1400.4, Losses: 5})
+	repo.SavePlayerRating(&commands.PlayerRating{DiscordServerID: 123, Game: "Ultimate", DiscordUserID: 800, Name: "a", Rating: 1520, Wins: 1})
+	return repo
+}
+
+func TestLeaderboard(t *testing.T) {
+	repo := newRatedLadder()
+
+	s := runRatingCommand(commands.TournamentLeaderboardString, "melee", &commands.Users{}, &mockChallongeClient{}, repo)
+	if s.message != "Leaderboard for Melee:\n1. <@801> 1600 (4-1)\n2. c 1550 (2-0)\n3. <@800> 1400 (0-5)" {
+		t.Error("Leaderboard incorrect ", s.message)
+	}
+
+	s = runRatingCommand(commands.TournamentLeaderboardString, "", &commands.Users{}, &mockChallongeClient{}, repo)
+	if s.message != "Pick a ladder: Melee, Ultimate" {
+		t.Error("Ladder not asked for ", s.message)
+	}
+}
+
+func TestRating(t *testing.T) {
+	repo := newRatedLadder()
+	user := &commands.Users{DiscordUsersID: 800}
+
+	s := runRatingCommand(commands.TournamentRatingString, "", user, &mockChallongeClient{}, repo)
+	if s.message != "Ratings for <@800>:\nUltimate: 1520 (1-0)\nMelee: 1400 (0-5)" {
+		t.Error("Ratings incorrect ", s.message)
+	}
+
+	s = runRatingCommand(commands.TournamentRatingString, "<@801> --game ultimate", user, &mockChallongeClient{}, repo)
+	if s.message != "<@801> has no rating yet." {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestImportHistory(t *testing.T) {
+	//Given: A finished challonge bracket run without the bot
+	cclient := &mockChallongeClient{}
+	cclient.setTourneyID("old")
+	cclient.addParticipant(challonge.Participant{ID: 1, Name: "a"})
+	cclient.addParticipant(challonge.Participant{ID: 2, Name: "b"})
+	cclient.addMatches(
+		challonge.Match{ID: 2, Round: 2, Player1ID: 2, Player2ID: 1, WinnerID: 1},
+		challonge.Match{ID: 1, Round: 1, Player1ID: 1, Player2ID: 2, WinnerID: 2},
+		challonge.Match{ID: 3, Round: 3, Player1ID: 1, Player2ID: 2},
+	)
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+
+	//When: A player tries to import it
+	s := runRatingCommand(commands.TournamentRatingString, "import https://challonge.com/old", &commands.Users{DiscordUsersID: 800}, cclient, repo)
+	if s.message != "Only admins can import tournament history." || len(repo.ratings) != 0 {
+		t.Error("Player imported history ", s.message)
+	}
+
+	//When: An admin imports it twice
+	admin := &commands.Users{DiscordUsersID: 1, IsAdmin: true}
+	runRatingCommand(commands.TournamentRatingString, "import https://challonge.com/old --game Melee", admin, cclient, repo)
+	s = runRatingCommand(commands.TournamentRatingString, "import https://challonge.com/old --game Melee", admin, cclient, repo)
+
+	//Then: The finished matches are rated once, round 1 first, by name
+	if s.message != "Rated 0 matches from https://challonge.com/old on the Melee ladder." || len(repo.ratedMatches) != 2 {
+		t.Error("Matches imported again ", s.message)
+	}
+	b, a := repo.ratings[0], repo.ratings[1]
+	if b.Name != "b" || a.Name != "a" || a.DiscordUserID != 0 || a.Wins != 1 || b.Wins != 1 || math.Round(a.Rating) != 1501 {
+		t.Error("History rated incorrectly ", repo.ratings)
+	}
+
+	//And: A linked player takes over the rating imported for their name
+	tourney := commands.Tournament{ChallongeID: "old", DiscordServerID: 123, Game: "melee",
+		Participants: []commands.TournamentParticipant{{Name: "A", ChallongeID: 1, DiscordUserID: 800}, {Name: "b", ChallongeID: 2}}}
+	repo.SaveTourney(&tourney)
+	cclient.matches[2].WinnerID = 1
+	cclient.id = "old"
+	runRatingCommand(commands.TournamentRatingString, "import https://challonge.com/old", admin, cclient, repo)
+	if len(repo.ratings) != 2 || repo.ratings[1].DiscordUserID != 800 || repo.ratings[1].Wins != 2 {
+		t.Error("Imported rating not linked ", repo.ratings)
+	}
+}
+
+func TestSeedsByRating(t *testing.T) {
+	//Given: A tournament on the melee ladder where the best rated player is seeded last
+	cclient, repo := newLinkedMatchTourney()
+	cclient.matches = nil
+	cclient.addParticipant(challonge.Participant{ID: 1, Name: "a", Seed: 1})
+	cclient.addParticipant(challonge.Participant{ID: 3, Name: "new", Seed: 2})
+	cclient.addParticipant(challonge.Participant{ID: 2, Name: "b", Seed: 3})
+	tourney := repo.tourneys[1]
+	tourney.Game = "melee"
+	repo.tourneys[1] = tourney
+	ladder := newRatedLadder()
+	repo.ratings = ladder.ratings
+
+	//When: Anyone asks for suggested seeds
+	s := runRatingCommand(commands.TournamentCommandString, "seeds", organizerPlayer, cclient, repo)
+
+	//Then: Rated players go first, best first
+	expected := "Suggested seeds from the melee ladder:\n1. b - 1600\n2. a - 1400\n3. new - unrated\nSend them to challonge with $tournament seeds apply"
+	if s.message != expected || len(cclient.seeds) != 0 {
+		t.Error("Seeds suggested incorrectly ", s.message)
+	}
+
+	//When: An organizer applies them
+	s = runRatingCommand(commands.TournamentCommandString, "seeds apply", organizerCreator, cclient, repo)
+
+	//Then: Challonge gets the new seeds
+	if cclient.seeds[2] != 1 || cclient.seeds[1] != 2 || cclient.seeds[3] != 3 {
+		t.Error("Seeds not applied ", cclient.seeds, s.message)
+	}
+
+	//And: Seeds can not change once the bracket has started
+	cclient.addMatches(challonge.Match{ID: 1, Round: 1, Player1ID: 2, Player2ID: 3})
+	s = runRatingCommand(commands.TournamentCommandString, "seeds apply", organizerCreator, cclient, repo)
+	if s.message != "Seeds can only be changed before the tournament starts." {
+		t.Error("Seeds applied after start ", s.message)
+	}
+}
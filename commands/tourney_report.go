@@ -0,0 +1,437 @@
+package commands
+
+import (
+	"discordbot/challonge"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const reportUsage = "Usage: " + CommandPrefix + TournamentReportString + " 2-1, " + CommandPrefix + TournamentReportString + " @winner 3-2, " + CommandPrefix + TournamentReportString + " confirm|dispute|undo"
+
+const confirmReportEmoji = "✅"
+const disputeReportEmoji = "❌"
+
+func (c *tourneyCommandRequestFactory) CreateReportCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &reportCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+type reportCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+//parseSetScore reads a set score like 2-1. Sets can not end in a tie.
+func parseSetScore(s string) (int, int, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return 0, 0, errors.New("score must look like 2-1")
+	}
+	a, errA := strconv.Atoi(parts[0])
+	b, errB := strconv.Atoi(parts[1])
+	if errA != nil || errB != nil || a < 0 || b < 0 {
+		return 0, 0, errors.New("score must look like 2-1")
+	}
+	if a == b {
+		return 0, 0, errors.New("a set can not end in a tie")
+	}
+	return a, b, nil
+}
+
+func isPlayerInMatch(m challonge.Match, p *TournamentParticipant) bool {
+	return p != nil && (m.Player1ID == p.ChallongeID || m.Player2ID == p.ChallongeID)
+}
+
+func opponentID(m challonge.Match, participantID int) int {
+	if m.Player1ID == participantID {
+		return m.Player2ID
+	}
+	return m.Player1ID
+}
+
+//findPlayerMatch finds the match a participant is playing, preferring one called to a setup
+func findPlayerMatch(t *Tournament, matches []challonge.Match, p *TournamentParticipant) *challonge.Match {
+	called := calledMatches(t)
+	var open *challonge.Match
+	for i, m := range matches {
+		if !isPlayerInMatch(m, p) || !isMatchPlayable(m) {
+			continue
+		}
+		if _, ok := called[m.ID]; ok {
+			return &matches[i]
+		}
+		if open == nil {
+			open = &matches[i]
+		}
+	}
+	return open
+}
+
+//findPlayerTourney finds the tournament a player is in when they use a command from direct messages
+func findPlayerTourney(repo TournamentRepository, client challongeClient, user Snowflake) (Tournament, error) {
+	ts, err := repo.GetTourneysByParticipant(user)
+	if err != nil {
+		return Tournament{}, err
+	}
+	for _, t := range ts {
+		p := findParticipantByDiscordUser(&t.Participants, user)
+		matches, err := client.GetMatches(t.ChallongeID)
+		if err != nil {
+			return Tournament{}, err
+		}
+		if findPlayerMatch(&t, matches, p) != nil {
+			return t, nil
+		}
+	}
+	return Tournament{}, nil
+}
+
+//submitMatchReport sends the set score to challonge with the scores in player order
+func submitMatchReport(s DiscordSession, repo TournamentRepository, client challongeClient, t *Tournament, m challonge.Match, winnerID int, winnerScore int, loserScore int, channel Snowflake) error {
+	score := challonge.MatchScore{Player1Score: winnerScore, Player2Score: loserScore}
+	if m.Player2ID == winnerID {
+		score = challonge.MatchScore{Player1Score: loserScore, Player2Score: winnerScore}
+	}
+
+	err := client.UpdateMatch(t.ChallongeID, m.ID, challonge.MatchQueryParams{WinnerID: winnerID, MatchScore: score})
+	if err != nil {
+		return err
+	}
+	rateTourneyMatch(repo, t, m, winnerID)
+
+	if report, err := repo.GetMatchReport(t.TournamentID, m.ID); err == nil {
+		if err := repo.RemoveMatchReport(report.MatchReportID); err != nil {
+			log.Error(err)
+		}
+	}
+
+	clearMatch(t, m.ID)
+	t.LastReportedMatch = m.ID
+	if err := repo.SaveTourney(t); err != nil {
+		log.Error(err)
+	}
+
+	winner := findParticipant(&t.Participants, winnerID)
+	loser := findParticipant(&t.Participants, opponentID(m, winnerID))
+	if winner != nil && loser != nil {
+		s.SendSimpleMessage(channel, fmt.Sprintf("Reported: %s %d-%d %s", winner.Name, winnerScore, loserScore, loser.Name))
+	}
+	return nil
+}
+
+func disputeMatchReport(s DiscordSession, repo TournamentRepository, t *Tournament, report MatchReport, m challonge.Match) {
+	report.Disputed = true
+	if err := repo.SaveMatchReport(&report); err != nil {
+		log.Error(err)
+	}
+
+	var mentions []string
+	for _, o := range t.Organizers {
+		mentions = append(mentions, createUserMention(o.DiscordUsersID))
+	}
+	if len(mentions) == 0 {
+		mentions = append(mentions, "Organizers")
+	}
+
+	s.SendSimpleMessage(report.Channel, fmt.Sprintf("%s the result of %s was disputed. Report the correct score with %s%s @winner 2-1.",
+		strings.Join(mentions, " "), formatMatch(t, m), CommandPrefix, TournamentReportString))
+}
+
+func (c *reportCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	content, name := takeTourneyName(msg.Content)
+	args := splitArguments(content)
+	if len(args) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, reportUsage)
+		return
+	}
+
+	var t Tournament
+	if msg.GuildID == 0 {
+		var err error
+		t, err = findPlayerTourney(c.repo, c.challongeClient, c.user.DiscordUsersID)
+		if t.DiscordServerID == 0 || err != nil {
+			c.session.SendSimpleMessage(msg.ChannelID, tourneyNotStarted)
+			return
+		}
+	} else {
+		var ok bool
+		if t, ok = c.selectTourney(msg, name); !ok {
+			return
+		}
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "confirm":
+		c.confirm(&t)
+	case "dispute":
+		c.dispute(&t)
+	case "undo":
+		c.undo(&t)
+	default:
+		c.report(&t, args)
+	}
+}
+
+func (c *reportCommand) report(t *Tournament, args []string) {
+	msg := c.data.Message
+	if len(args) > 2 {
+		c.session.SendSimpleMessage(msg.ChannelID, reportUsage)
+		return
+	}
+	first, second, err := parseSetScore(args[len(args)-1])
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Could not read score, "+err.Error()+".")
+		return
+	}
+
+	reporter := findParticipantByDiscordUser(&t.Participants, c.user.DiscordUsersID)
+	matches, err := c.challongeClient.GetMatches(t.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return
+	}
+
+	//The score is from the named winner's side, or the reporter's side when nobody is named
+	var anchor *TournamentParticipant
+	if len(args) == 2 {
+		anchor = findParticipantByArgument(&t.Participants, args[0])
+		if anchor == nil {
+			c.session.SendSimpleMessage(msg.ChannelID, "Winner's name not found.")
+			return
+		}
+		if first < second {
+			c.session.SendSimpleMessage(msg.ChannelID, "Put the winner's score first, like 2-1.")
+			return
+		}
+	} else {
+		anchor = reporter
+		if anchor == nil {
+			c.session.SendSimpleMessage(msg.ChannelID, "Mention the winner, like "+CommandPrefix+TournamentReportString+" @winner 2-1.")
+			return
+		}
+	}
+
+	m := findPlayerMatch(t, matches, anchor)
+	if m == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "No open match found for "+anchor.Name+".")
+		return
+	}
+
+	winnerID, winnerScore, loserScore := anchor.ChallongeID, first, second
+	if first < second {
+		winnerID, winnerScore, loserScore = opponentID(*m, anchor.ChallongeID), second, first
+	}
+
+	if isTourneyOrganizer(t, c.user) {
+		err = submitMatchReport(c.session, c.repo, c.challongeClient, t, *m, winnerID, winnerScore, loserScore, msg.ChannelID)
+		if err != nil {
+			c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, match unable to be reported.")
+			log.Error(err)
+		}
+		return
+	}
+
+	if !isPlayerInMatch(*m, reporter) {
+		c.session.SendSimpleMessage(msg.ChannelID, "Only organizers or the players in the match can report it.")
+		return
+	}
+	if !t.SelfReporting {
+		c.session.SendSimpleMessage(msg.ChannelID, "Self reporting is off. Ask an organizer to report the match.")
+		return
+	}
+	c.requestConfirmation(t, *m, reporter, winnerID, winnerScore, loserScore)
+}
+
+func (c *reportCommand) requestConfirmation(t *Tournament, m challonge.Match, reporter *TournamentParticipant, winnerID int, winnerScore int, loserScore int) {
+	msg := c.data.Message
+	winner := findParticipant(&t.Participants, winnerID)
+	loser := findParticipant(&t.Participants, opponentID(m, winnerID))
+	opponent := findParticipant(&t.Participants, opponentID(m, reporter.ChallongeID))
+	if winner == nil || loser == nil || opponent == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Unknown participant in match.")
+		return
+	}
+
+	prompt, err := c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("%s reported %s %d-%d %s. %s react %s to confirm or %s to dispute.",
+		reporter.Name, winner.Name, winnerScore, loserScore, loser.Name, participantLabel(opponent), confirmReportEmoji, disputeReportEmoji))
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	report := MatchReport{
+		TournamentID: t.TournamentID,
+		Guild:        t.DiscordServerID,
+		MatchID:      m.ID,
+		Reporter:     c.user.DiscordUsersID,
+		WinnerID:     winnerID,
+		Player1Score: winnerScore,
+		Player2Score: loserScore,
+		Channel:      msg.ChannelID,
+		Message:      prompt.ID,
+	}
+	if m.Player2ID == winnerID {
+		report.Player1Score, report.Player2Score = loserScore, winnerScore
+	}
+	err = c.repo.SaveMatchReport(&report)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, report unable to be saved.")
+		log.Error(err)
+		return
+	}
+	c.session.ReactToMessage(prompt.ID, msg.ChannelID, confirmReportEmoji)
+	c.session.ReactToMessage(prompt.ID, msg.ChannelID, disputeReportEmoji)
+}
+
+//pendingReport finds the unconfirmed report for the author's match
+func (c *reportCommand) pendingReport(t *Tournament) (MatchReport, *challonge.Match, bool) {
+	msg := c.data.Message
+	p := findParticipantByDiscordUser(&t.Participants, c.user.DiscordUsersID)
+	matches, err := c.challongeClient.GetMatches(t.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return MatchReport{}, nil, false
+	}
+	m := findPlayerMatch(t, matches, p)
+	if m == nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "You have no open match.")
+		return MatchReport{}, nil, false
+	}
+	report, err := c.repo.GetMatchReport(t.TournamentID, m.ID)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Nothing has been reported for your match yet.")
+		return MatchReport{}, nil, false
+	}
+	if report.Reporter == c.user.DiscordUsersID {
+		c.session.SendSimpleMessage(msg.ChannelID, "Your opponent has to respond to your report.")
+		return MatchReport{}, nil, false
+	}
+	//Reactions on a disputed report are ignored the same way
+	if report.Disputed {
+		c.session.SendSimpleMessage(msg.ChannelID, "This report was disputed. An organizer has to report the correct score.")
+		return MatchReport{}, nil, false
+	}
+	return report, m, true
+}
+
+func (c *reportCommand) confirm(t *Tournament) {
+	report, m, ok := c.pendingReport(t)
+	if !ok {
+		return
+	}
+	winnerScore, loserScore := report.Player1Score, report.Player2Score
+	if m.Player2ID == report.WinnerID {
+		winnerScore, loserScore = loserScore, winnerScore
+	}
+	err := submitMatchReport(c.session, c.repo, c.challongeClient, t, *m, report.WinnerID, winnerScore, loserScore, c.data.Message.ChannelID)
+	if err != nil {
+		c.session.SendSimpleMessage(c.data.Message.ChannelID, "Something went wrong, match unable to be reported.")
+		log.Error(err)
+	}
+}
+
+func (c *reportCommand) dispute(t *Tournament) {
+	report, m, ok := c.pendingReport(t)
+	if !ok {
+		return
+	}
+	disputeMatchReport(c.session, c.repo, t, report, *m)
+}
+
+func (c *reportCommand) undo(t *Tournament) {
+	msg := c.data.Message
+	if !isTourneyOrganizer(t, c.user) {
+		c.session.SendSimpleMessage(msg.ChannelID, "Only tournament organizers can undo a report.")
+		return
+	}
+	if t.LastReportedMatch == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "No reported match to undo.")
+		return
+	}
+
+	m := c.challongeClient.GetMatch(t.ChallongeID, t.LastReportedMatch)
+	err := c.challongeClient.ReopenMatch(t.ChallongeID, t.LastReportedMatch)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, match unable to be reopened.")
+		log.Error(err)
+		return
+	}
+	if err := unrateMatch(c.repo, t.DiscordServerID, t.ChallongeID, t.LastReportedMatch); err != nil {
+		log.Error(err)
+	}
+
+	t.LastReportedMatch = 0
+	if err := c.repo.SaveTourney(t); err != nil {
+		log.Error(err)
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, "Reopened "+formatMatch(t, m)+".")
+}
+
+type matchReportReact struct {
+	repo    TournamentRepository
+	session DiscordSession
+	client  challongeClient
+	data    *disgord.MessageReactionAdd
+}
+
+func NewMatchReportReact(r TournamentRepository, s DiscordSession, client challongeClient, d *disgord.MessageReactionAdd) *matchReportReact {
+	return &matchReportReact{
+		repo:    r,
+		session: s,
+		client:  client,
+		data:    d,
+	}
+}
+
+//OnReactionAdd - the reporter's opponent confirms or disputes the report
+func (c *matchReportReact) OnReactionAdd() {
+	emoji := c.data.PartialEmoji.Name
+	if emoji != confirmReportEmoji && emoji != disputeReportEmoji {
+		return
+	}
+
+	report, err := c.repo.GetMatchReportByMessage(c.data.MessageID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if report.Disputed || c.data.UserID == report.Reporter {
+		return
+	}
+
+	t, err := c.repo.GetTourneyByID(report.TournamentID)
+	if err != nil || t.DiscordServerID == 0 {
+		log.Error(err)
+		return
+	}
+	m := c.client.GetMatch(t.ChallongeID, report.MatchID)
+	if !isPlayerInMatch(m, findParticipantByDiscordUser(&t.Participants, c.data.UserID)) {
+		return
+	}
+
+	if emoji == disputeReportEmoji {
+		disputeMatchReport(c.session, c.repo, &t, report, m)
+		return
+	}
+
+	winnerScore, loserScore := report.Player1Score, report.Player2Score
+	if m.Player2ID == report.WinnerID {
+		winnerScore, loserScore = loserScore, winnerScore
+	}
+	err = submitMatchReport(c.session, c.repo, c.client, &t, m, report.WinnerID, winnerScore, loserScore, report.Channel)
+	if err != nil {
+		c.session.SendSimpleMessage(report.Channel, "Something went wrong, match unable to be reported.")
+		log.Error(err)
+	}
+}
@@ -0,0 +1,172 @@
+package commands_test
+
+import (
+	"discordbot/commands"
+	"strings"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+)
+
+var (
+	reportOrganizer = &commands.Users{UsersID: 1, DiscordUsersID: 1}
+	reportPlayerA   = &commands.Users{UsersID: 2, DiscordUsersID: 800}
+	reportPlayerB   = &commands.Users{UsersID: 3, DiscordUsersID: 801}
+)
+
+func newReportTourney(selfReporting bool) (*mockChallongeClient, *mockTourneyDB) {
+	cclient, repo := newLinkedMatchTourney()
+	t := repo.tourneys[1]
+	t.User = 1
+	t.SelfReporting = selfReporting
+	t.Organizers = []commands.Users{{UsersID: 5, DiscordUsersID: 900}}
+	repo.tourneys[1] = t
+	return cclient, repo
+}
+
+func runReportCommand(content string, user *commands.Users, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{ID: 70, Content: content, GuildID: 123, ChannelID: 10}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	c := factory.CreateReportCommand(&msg, user)
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestOrganizerReportsScore(t *testing.T) {
+	//Given: A match between a (player 1) and b (player 2)
+	cclient, repo := newReportTourney(false)
+
+	//When: An organizer reports b winning 3-2
+	s := runReportCommand("<@801> 3-2", reportOrganizer, cclient, repo)
+
+	//Then: The score is sent in player order
+	q := cclient.query
+	if q.WinnerID != 2 || q.MatchScore.Player1Score != 2 || q.MatchScore.Player2Score != 3 {
+		t.Error("Score reported incorrectly ", q)
+	}
+	if s.message != "Reported: b 3-2 a" {
+		t.Error("Report not announced ", s.message)
+	}
+	if repo.tourneys[1].LastReportedMatch != 1 {
+		t.Error("Last reported match not saved.")
+	}
+}
+
+func TestReportBadScores(t *testing.T) {
+	inputs := map[string]string{
+		"2-2":        "Could not read score, a set can not end in a tie.",
+		"two-one":    "Could not read score, score must look like 2-1.",
+		"<@801> 1-3": "Put the winner's score first, like 2-1.",
+		"nobody 2-1": "Winner's name not found.",
+	}
+	for input, expected := range inputs {
+		cclient, repo := newReportTourney(true)
+
+		s := runReportCommand(input, reportPlayerA, cclient, repo)
+
+		if s.message != expected || cclient.query.WinnerID != 0 {
+			t.Error("Unexpected response for ", input, ": ", s.message)
+		}
+	}
+}
+
+func TestSelfReportOff(t *testing.T) {
+	cclient, repo := newReportTourney(false)
+
+	s := runReportCommand("2-1", reportPlayerA, cclient, repo)
+
+	if s.message != "Self reporting is off. Ask an organizer to report the match." || len(repo.reports) != 0 {
+		t.Error("Self report accepted while off ", s.message)
+	}
+}
+
+func TestSelfReportConfirmed(t *testing.T) {
+	//Given: Player a reports losing 1-2
+	cclient, repo := newReportTourney(true)
+	s := runReportCommand("1-2", reportPlayerA, cclient, repo)
+
+	//Then: Nothing is sent until the opponent confirms
+	if cclient.query.WinnerID != 0 || len(repo.reports) != 1 {
+		t.Fatal("Report sent without confirmation.")
+	}
+	if !strings.HasPrefix(s.message, "a reported b 2-1 a. <@801> react ✅") || len(s.reactions) != 2 {
+		t.Error("Confirmation not requested ", s.message)
+	}
+
+	//And: The reporter can not confirm their own report
+	s = runReportCommand("confirm", reportPlayerA, cclient, repo)
+	if s.message != "Your opponent has to respond to your report." {
+		t.Error("Reporter confirmed their own report ", s.message)
+	}
+
+	//When: The opponent confirms
+	runReportCommand("confirm", reportPlayerB, cclient, repo)
+
+	//Then: The score is sent and the pending report removed
+	q := cclient.query
+	if q.WinnerID != 2 || q.MatchScore.Player1Score != 1 || q.MatchScore.Player2Score != 2 {
+		t.Error("Confirmed score incorrect ", q)
+	}
+	if len(repo.reports) != 0 {
+		t.Error("Pending report not removed.")
+	}
+}
+
+func TestSelfReportConfirmedByReaction(t *testing.T) {
+	cclient, repo := newReportTourney(true)
+	runReportCommand("2-1", reportPlayerA, cclient, repo)
+	s := &mockSession{}
+
+	//Reactions from the reporter are ignored
+	commands.NewMatchReportReact(repo, s, cclient, &disgord.MessageReactionAdd{MessageID: 999, UserID: 800, PartialEmoji: &disgord.Emoji{Name: "✅"}}).OnReactionAdd()
+	if cclient.query.WinnerID != 0 {
+		t.Fatal("Reporter confirmed by reaction.")
+	}
+
+	commands.NewMatchReportReact(repo, s, cclient, &disgord.MessageReactionAdd{MessageID: 999, UserID: 801, PartialEmoji: &disgord.Emoji{Name: "✅"}}).OnReactionAdd()
+
+	if cclient.query.WinnerID != 1 || cclient.query.MatchScore.Player1Score != 2 {
+		t.Error("Report not confirmed by reaction ", cclient.query)
+	}
+}
+
+func TestSelfReportDisputed(t *testing.T) {
+	cclient, repo := newReportTourney(true)
+	runReportCommand("2-1", reportPlayerA, cclient, repo)
+	s := &mockSession{}
+
+	commands.NewMatchReportReact(repo, s, cclient, &disgord.MessageReactionAdd{MessageID: 999, UserID: 801, PartialEmoji: &disgord.Emoji{Name: "❌"}}).OnReactionAdd()
+
+	if cclient.query.WinnerID != 0 || !repo.reports[0].Disputed {
+		t.Error("Disputed report sent to challonge.")
+	}
+	if !strings.HasPrefix(s.message, "<@900> the result of <@800> vs <@801> was disputed.") {
+		t.Error("Organizers not notified ", s.message)
+	}
+
+	//A disputed report can not be confirmed by command either
+	s = runReportCommand("confirm", reportPlayerB, cclient, repo)
+	if cclient.query.WinnerID != 0 || s.message != "This report was disputed. An organizer has to report the correct score." {
+		t.Error("Disputed report confirmed ", s.message)
+	}
+}
+
+func TestUndoReport(t *testing.T) {
+	cclient, repo := newReportTourney(false)
+	runReportCommand("<@800> 2-0", reportOrganizer, cclient, repo)
+
+	s := runReportCommand("undo", reportPlayerA, cclient, repo)
+	if s.message != "Only tournament organizers can undo a report." {
+		t.Error("Player undid a report ", s.message)
+	}
+
+	s = runReportCommand("undo", reportOrganizer, cclient, repo)
+
+	if len(cclient.reopened) != 1 || cclient.reopened[0] != 1 {
+		t.Error("Match not reopened ", cclient.reopened)
+	}
+	if repo.tourneys[1].LastReportedMatch != 0 || s.message != "Reopened <@800> vs <@801>." {
+		t.Error("Undo not recorded ", s.message)
+	}
+}
@@ -0,0 +1,362 @@
+package commands
+
+import (
+	"bytes"
+	"discordbot/challonge"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+const bracketCompletedLength = 10
+const resultsListLength = 10
+
+func (c *tourneyCommandRequestFactory) CreateBracketCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &bracketCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+func (c *tourneyCommandRequestFactory) CreateStandingsCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &standingsCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+func (c *tourneyCommandRequestFactory) CreateResultsCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &resultsCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+//placementsFromChallonge orders participants by final rank then seed, unranked participants last
+func placementsFromChallonge(t *Tournament, ps []challonge.Participant) []TournamentPlacement {
+	var result []TournamentPlacement
+	for _, p := range ps {
+		placement := TournamentPlacement{Name: p.Name, Seed: p.Seed, Rank: p.FinalRank}
+		if tp := findParticipant(&t.Participants, p.ID); tp != nil {
+			placement.DiscordUserID = tp.DiscordUserID
+		}
+		result = append(result, placement)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		ri, rj := result[i].Rank, result[j].Rank
+		if ri != rj {
+			return rj == 0 || (ri != 0 && ri < rj)
+		}
+		return result[i].Seed < result[j].Seed
+	})
+	return result
+}
+
+//archiveTourney keeps the placements and played matches of a tournament that is ending.
+//Nothing is kept when challonge can not be read, so the tournament is not removed without its history.
+func archiveTourney(t *Tournament, client challongeClient, now time.Time) (TournamentResult, error) {
+	ps, err := client.GetParticipants(t.ChallongeID)
+	if err != nil {
+		return TournamentResult{}, err
+	}
+	matches, err := client.GetMatches(t.ChallongeID)
+	if err != nil {
+		return TournamentResult{}, err
+	}
+	result := TournamentResult{
+		DiscordServerID: t.DiscordServerID,
+		Name:            t.Name,
+		ChallongeID:     t.ChallongeID,
+		EndedAt:         now,
+		Placements:      placementsFromChallonge(t, ps),
+	}
+
+	for _, m := range matches {
+		if m.WinnerID == 0 {
+			continue
+		}
+		p1 := archivedPlayer(t, ps, m.Player1ID)
+		p2 := archivedPlayer(t, ps, m.Player2ID)
+		rm := TournamentResultMatch{
+			MatchID:          m.ID,
+			Round:            m.Round,
+			Player1:          p1.Name,
+			Player1DiscordID: p1.DiscordUserID,
+			Player2:          p2.Name,
+			Player2DiscordID: p2.DiscordUserID,
+			Winner:           1,
+			ScoresCsv:        m.ScoresCsv,
+		}
+		if m.WinnerID == m.Player2ID {
+			rm.Winner = 2
+		}
+		result.Matches = append(result.Matches, rm)
+	}
+	return result, nil
+}
+
+//archivedPlayer names a match player, using challonge for those who were never linked here
+func archivedPlayer(t *Tournament, ps []challonge.Participant, ID int) TournamentParticipant {
+	if p := findParticipant(&t.Participants, ID); p != nil {
+		return *p
+	}
+	for _, p := range ps {
+		if p.ID == ID {
+			return TournamentParticipant{Name: p.Name, ChallongeID: p.ID}
+		}
+	}
+	return TournamentParticipant{ChallongeID: ID}
+}
+
+func formatPlacements(ps []TournamentPlacement) string {
+	var b strings.Builder
+	for _, p := range ps {
+		rank := "-"
+		if p.Rank != 0 {
+			rank = strconv.Itoa(p.Rank)
+		}
+		name := p.Name
+		if p.DiscordUserID != 0 {
+			name += " (" + createUserMention(p.DiscordUserID) + ")"
+		}
+		b.WriteString(fmt.Sprintf("\n%s. %s - seed %d", rank, name, p.Seed))
+	}
+	return b.String()
+}
+
+type bracketCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *bracketCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	t, _, ok := c.findTourney(msg)
+	if !ok {
+		return
+	}
+
+	matches, err := c.challongeClient.GetMatches(t.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return
+	}
+	if len(matches) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "The bracket has not started yet. "+challongeURL+t.ChallongeID)
+		return
+	}
+
+	var completed []challonge.Match
+	for _, m := range matches {
+		if m.WinnerID != 0 {
+			completed = append(completed, m)
+		}
+	}
+	sort.SliceStable(completed, func(i, j int) bool {
+		return matchProgression(completed[i]) > matchProgression(completed[j])
+	})
+
+	var b strings.Builder
+	b.WriteString(challongeURL + t.ChallongeID)
+
+	stations := append([]TournamentStation{{CurrentMatch: t.CurrentMatch}}, t.Stations...)
+	var playing []string
+	for _, s := range stations {
+		if m := findMatch(matches, s.CurrentMatch); m != nil && m.WinnerID == 0 {
+			playing = append(playing, fmt.Sprintf("%s: %s (%s)", stationLabel(s.Name), formatMatch(&t, *m), roundName(*m, matches)))
+		}
+	}
+	if len(playing) > 0 {
+		b.WriteString("\n\nPlaying:\n" + strings.Join(playing, "\n"))
+	}
+
+	if queue := matchQueue(&t, matches); len(queue) > 0 {
+		b.WriteString("\n\nUp next:")
+		for i, m := range queue {
+			if i == matchQueueLength {
+				b.WriteString(fmt.Sprintf("\n... and %d more", len(queue)-matchQueueLength))
+				break
+			}
+			b.WriteString(fmt.Sprintf("\n%s (%s)", formatMatch(&t, m), roundName(m, matches)))
+		}
+	}
+
+	if len(completed) > 0 {
+		b.WriteString(fmt.Sprintf("\n\nCompleted (%d of %d):", len(completed), len(matches)))
+		for i, m := range completed {
+			if i == bracketCompletedLength {
+				break
+			}
+			b.WriteString("\n" + formatResult(&t, m, matches))
+		}
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, b.String())
+}
+
+type standingsCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *standingsCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	t, _, ok := c.findTourney(msg)
+	if !ok {
+		return
+	}
+
+	participants, err := c.challongeClient.GetParticipants(t.ChallongeID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, challongeUnavailable)
+		return
+	}
+	ps := placementsFromChallonge(&t, participants)
+	if len(ps) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Nobody has signed up yet.")
+		return
+	}
+	if ps[0].Rank == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Final placements are posted once the tournament is finalized. Seeding:"+formatPlacements(ps))
+		return
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, "Standings for "+t.Name+":"+formatPlacements(ps))
+}
+
+type resultsCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *resultsCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	args, flags := parseFlags(splitArguments(msg.Content))
+
+	if len(args) == 0 {
+		c.listResults()
+		return
+	}
+
+	ID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentResultsString+" [id] [--format csv|json]")
+		return
+	}
+	result, err := c.repo.GetTournamentResult(ID)
+	if err != nil || result.DiscordServerID != msg.GuildID {
+		c.session.SendSimpleMessage(msg.ChannelID, "No results found with id "+args[0]+".")
+		return
+	}
+
+	format, export := flags["format"]
+	if !export {
+		c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("%s - ended %s %s%s", result.Name, result.EndedAt.UTC().Format("2006-01-02"),
+			challongeURL+result.ChallongeID, formatPlacements(result.Placements)))
+		return
+	}
+
+	format = strings.ToLower(format)
+	if format != "csv" && format != "json" {
+		c.session.SendSimpleMessage(msg.ChannelID, "Unknown format "+format+", use csv or json.")
+		return
+	}
+	files, err := exportResult(result, format)
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+	_, err = c.session.SendMessage(msg.ChannelID, &disgord.CreateMessageParams{Files: files})
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+	}
+}
+
+func (c *resultsCommand) listResults() {
+	msg := c.data.Message
+	rs, err := c.repo.GetTournamentResultsByServer(msg.GuildID)
+	if err != nil {
+		log.Error(err)
+	}
+	if len(rs) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "No tournaments have ended in this server yet.")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Past tournaments:")
+	for i, r := range rs {
+		if i == resultsListLength {
+			break
+		}
+		b.WriteString(fmt.Sprintf("\n#%d %s - ended %s", r.TournamentResultID, r.Name, r.EndedAt.UTC().Format("2006-01-02")))
+		if len(r.Placements) > 0 && r.Placements[0].Rank == 1 {
+			b.WriteString(" - won by " + r.Placements[0].Name)
+		}
+	}
+	b.WriteString("\nSee one with " + CommandPrefix + TournamentResultsString + " id, export it with --format csv or --format json.")
+	c.session.SendSimpleMessage(msg.ChannelID, b.String())
+}
+
+//exportResult writes the archived results as a json file or csv files of the placements and matches
+func exportResult(r TournamentResult, format string) ([]disgord.CreateMessageFileParams, error) {
+	name := fmt.Sprintf("results_%d_%s", r.TournamentResultID, tourneySlug(r.Name))
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return []disgord.CreateMessageFileParams{{Reader: bytes.NewReader(b), FileName: name + ".json"}}, nil
+	case "csv":
+		placements := [][]string{{"rank", "seed", "name", "discord_user_id"}}
+		for _, p := range r.Placements {
+			placements = append(placements, []string{strconv.Itoa(p.Rank), strconv.Itoa(p.Seed), p.Name, p.DiscordUserID.String()})
+		}
+		matches := [][]string{{"match_id", "round", "player1", "player2", "winner", "scores"}}
+		for _, m := range r.Matches {
+			winner := m.Player1
+			if m.Winner == 2 {
+				winner = m.Player2
+			}
+			matches = append(matches, []string{strconv.Itoa(m.MatchID), strconv.Itoa(m.Round), m.Player1, m.Player2, winner, m.ScoresCsv})
+		}
+
+		pb, err := writeCSV(placements)
+		if err != nil {
+			return nil, err
+		}
+		mb, err := writeCSV(matches)
+		if err != nil {
+			return nil, err
+		}
+		return []disgord.CreateMessageFileParams{
+			{Reader: bytes.NewReader(pb), FileName: name + "_placements.csv"},
+			{Reader: bytes.NewReader(mb), FileName: name + "_matches.csv"},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %s", format)
+}
+
+func writeCSV(rows [][]string) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
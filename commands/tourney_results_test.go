@@ -0,0 +1,181 @@
+package commands_test
+
+import (
+	"discordbot/challonge"
+	"discordbot/commands"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+func newFinishedTourney() (*mockChallongeClient, *mockTourneyDB) {
+	cclient, repo := newLinkedMatchTourney()
+	cclient.matches[0].WinnerID = 2
+	cclient.matches[0].ScoresCsv = "1-3"
+	cclient.addParticipant(challonge.Participant{ID: 1, Name: "a", Seed: 1, FinalRank: 2})
+	cclient.addParticipant(challonge.Participant{ID: 2, Name: "b", Seed: 2, FinalRank: 1})
+	t := repo.tourneys[1]
+	t.Name = "weekly"
+	repo.tourneys[1] = t
+	return cclient, repo
+}
+
+func runResultsCommand(command string, content string, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	return runResultsCommandAs(command, content, &commands.Users{UsersID: 1, DiscordUsersID: 1}, cclient, repo)
+}
+
+func runResultsCommandAs(command string, content string, user *commands.Users, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{ID: 20, Content: content, GuildID: 123, ChannelID: 10}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	var c interface{}
+	switch command {
+	case commands.TournamentFinishString:
+		c = factory.CreateTourneyCloseCommand(&msg, user)
+	case commands.TournamentBracketString:
+		c = factory.CreateBracketCommand(&msg, user)
+	case commands.TournamentStandingsString:
+		c = factory.CreateStandingsCommand(&msg, user)
+	case commands.TournamentResultsString:
+		c = factory.CreateResultsCommand(&msg, user)
+	}
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestEndTourneyArchivesResults(t *testing.T) {
+	cclient, repo := newFinishedTourney()
+
+	s := runResultsCommand(commands.TournamentFinishString, "", cclient, repo)
+
+	if len(repo.results) != 1 || len(repo.tourneys) != 0 {
+		t.Fatal("Results not archived ", repo.results)
+	}
+	r := repo.results[0]
+	if r.Name != "weekly" || len(r.Placements) != 2 || r.Placements[0].Name != "b" || r.Placements[0].DiscordUserID != 801 {
+		t.Error("Placements archived incorrectly ", r.Placements)
+	}
+	if len(r.Matches) != 1 || r.Matches[0].Winner != 2 || r.Matches[0].Player1 != "a" || r.Matches[0].ScoresCsv != "1-3" {
+		t.Error("Matches archived incorrectly ", r.Matches)
+	}
+	if s.message != "Tournament ended. See the results with $results 1" {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestEndTourneyKeptWhenChallongeFails(t *testing.T) {
+	cclient, repo := newFinishedTourney()
+	cclient.setTourneyID("gone")
+
+	s := runResultsCommand(commands.TournamentFinishString, "", cclient, repo)
+
+	if len(repo.results) != 0 || len(repo.tourneys) != 1 {
+		t.Fatal("Tournament removed without its results ", repo.results)
+	}
+	if s.message != "Something went wrong, results could not be read from challonge, tournament not ended. Add --force to end it without keeping its results." {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestForceEndTourneyWithoutChallonge(t *testing.T) {
+	cclient, repo := newFinishedTourney()
+	cclient.setTourneyID("gone")
+
+	//Players can not force it
+	s := runResultsCommandAs(commands.TournamentFinishString, "--force", &commands.Users{UsersID: 2, DiscordUsersID: 800}, cclient, repo)
+	if len(repo.tourneys) != 1 || s.message != "Only tournament organizers can end the tournament." {
+		t.Fatal("Tournament forced to end by a player ", s.message)
+	}
+
+	s = runResultsCommand(commands.TournamentFinishString, "--force", cclient, repo)
+
+	if len(repo.tourneys) != 0 || len(repo.results) != 0 {
+		t.Error("Tournament not removed ", repo.tourneys, repo.results)
+	}
+	if s.message != "Tournament ended without keeping its results." {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestEndTourneyArchivesUnlinkedPlayers(t *testing.T) {
+	cclient, repo := newFinishedTourney()
+	cclient.addParticipant(challonge.Participant{ID: 3, Name: "c", Seed: 3, FinalRank: 3})
+	cclient.addMatches(challonge.Match{ID: 2, Round: 1, Player1ID: 3, Player2ID: 2, WinnerID: 3, ScoresCsv: "2-0"})
+
+	runResultsCommand(commands.TournamentFinishString, "", cclient, repo)
+
+	if len(repo.results) != 1 || len(repo.results[0].Matches) != 2 {
+		t.Fatal("Match with an unlinked player dropped ", repo.results)
+	}
+	m := repo.results[0].Matches[1]
+	if m.Player1 != "c" || m.Player1DiscordID != 0 || m.Winner != 1 {
+		t.Error("Unlinked player archived incorrectly ", m)
+	}
+}
+
+func TestStandings(t *testing.T) {
+	cclient, repo := newFinishedTourney()
+
+	s := runResultsCommand(commands.TournamentStandingsString, "", cclient, repo)
+
+	if s.message != "Standings for weekly:\n1. b (<@801>) - seed 2\n2. a (<@800>) - seed 1" {
+		t.Error("Standings incorrect ", s.message)
+	}
+
+	//Before the tournament is finalized only seeding is known
+	cclient.participants[0].FinalRank = 0
+	cclient.participants[1].FinalRank = 0
+	s = runResultsCommand(commands.TournamentStandingsString, "", cclient, repo)
+	if !strings.HasPrefix(s.message, "Final placements are posted once the tournament is finalized. Seeding:\n-. a (<@800>) - seed 1") {
+		t.Error("Unfinished standings incorrect ", s.message)
+	}
+}
+
+func TestBracket(t *testing.T) {
+	cclient, repo := newFinishedTourney()
+	cclient.addMatches(challonge.Match{ID: 2, Round: 2, Player1ID: 2, Player2ID: 1, State: "open"})
+
+	s := runResultsCommand(commands.TournamentBracketString, "", cclient, repo)
+
+	expected := "https://challonge.com/test\n\nUp next:\n<@801> vs <@800> (Round 2)\n\nCompleted (1 of 2):\n<@801> beat <@800> 3-1 (Round 1)"
+	if s.message != expected {
+		t.Error("Bracket incorrect ", s.message)
+	}
+}
+
+func TestResultsListAndExport(t *testing.T) {
+	cclient, repo := newFinishedTourney()
+	runResultsCommand(commands.TournamentFinishString, "", cclient, repo)
+	repo.results[0].EndedAt = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	s := runResultsCommand(commands.TournamentResultsString, "", cclient, repo)
+	if !strings.HasPrefix(s.message, "Past tournaments:\n#1 weekly - ended 2026-10-01 - won by b") {
+		t.Error("Results not listed ", s.message)
+	}
+
+	s = runResultsCommand(commands.TournamentResultsString, "1 --format json", cclient, repo)
+	if len(s.sentParams) != 1 || s.sentParams[0].Files[0].FileName != "results_1_weekly.json" {
+		t.Fatal("Json not exported ", s.sentParams)
+	}
+	b, _ := ioutil.ReadAll(s.sentParams[0].Files[0].Reader)
+	var exported commands.TournamentResult
+	if err := json.Unmarshal(b, &exported); err != nil || exported.Placements[0].Name != "b" {
+		t.Error("Json export incorrect ", string(b))
+	}
+
+	s = runResultsCommand(commands.TournamentResultsString, "1 --format csv", cclient, repo)
+	files := s.sentParams[0].Files
+	b, _ = ioutil.ReadAll(files[1].Reader)
+	if len(files) != 2 || string(b) != "match_id,round,player1,player2,winner,scores\n1,1,a,b,b,1-3\n" {
+		t.Error("Csv export incorrect ", string(b))
+	}
+
+	s = runResultsCommand(commands.TournamentResultsString, "1 --format xml", cclient, repo)
+	if s.message != "Unknown format xml, use csv or json." {
+		t.Error("Unknown format accepted ", s.message)
+	}
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const tourneyNotStarted = "Command unable to be used. Tournament not started in this server."
+
+var tourneyNameFlag = regexp.MustCompile(`(?:^|\s)--(?:tourney|t)\s+("[^"]*"|\S+)`)
+
+//takeTourneyName pulls "--tourney name" out of a command so the rest of it can be read as before
+func takeTourneyName(content string) (string, string) {
+	m := tourneyNameFlag.FindStringSubmatchIndex(content)
+	if m == nil {
+		return strings.TrimSpace(content), ""
+	}
+	name := strings.Trim(content[m[2]:m[3]], `"`)
+	return strings.TrimSpace(content[:m[0]] + content[m[1]:]), name
+}
+
+//pickTourney chooses between the tournaments running in a server.
+//A name picks that tournament, then a tournament bound to the channel, then the only tournament running.
+func pickTourney(ts []Tournament, name string, channel Snowflake) (Tournament, string) {
+	if name != "" {
+		for _, t := range ts {
+			if strings.EqualFold(t.Name, name) {
+				return t, ""
+			}
+		}
+		return Tournament{}, "No tournament named " + name + " in this server."
+	}
+
+	for _, t := range ts {
+		if t.ChannelID != 0 && t.ChannelID == channel {
+			return t, ""
+		}
+	}
+
+	switch len(ts) {
+	case 0:
+		return Tournament{}, tourneyNotStarted
+	case 1:
+		return ts[0], ""
+	}
+	return Tournament{}, "More than one tournament is running: " + tourneyNames(ts) + ". Pick one with --tourney name or bind one to this channel with " +
+		CommandPrefix + TournamentCommandString + " bind name"
+}
+
+func tourneyNames(ts []Tournament) string {
+	names := make([]string, len(ts))
+	for i, t := range ts {
+		names[i] = t.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+//selectTourney finds the tournament a command is for, telling the user when it can not be worked out
+func (c *tourneyCommandRequestFactory) selectTourney(msg *disgord.Message, name string) (Tournament, bool) {
+	ts, err := c.repo.GetTourneysByServer(msg.GuildID)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, tourneyNotStarted)
+		return Tournament{}, false
+	}
+
+	t, problem := pickTourney(ts, name, msg.ChannelID)
+	if problem != "" {
+		c.session.SendSimpleMessage(msg.ChannelID, problem)
+		return t, false
+	}
+	return t, true
+}
+
+//findTourney selects the tournament for a command and returns the command's content without the tournament name
+func (c *tourneyCommandRequestFactory) findTourney(msg *disgord.Message) (Tournament, string, bool) {
+	content, name := takeTourneyName(msg.Content)
+	t, ok := c.selectTourney(msg, name)
+	return t, content, ok
+}
+
+//list shows the tournaments running in the server and the channels they are bound to
+func (c *tourneyCommand) list(args []string, flags map[string]string) {
+	msg := c.data.Message
+	ts, err := c.repo.GetTourneysByServer(msg.GuildID)
+	if err != nil || len(ts) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, "No tournaments are running in this server.")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Tournaments:")
+	for _, t := range ts {
+		b.WriteString("\n" + t.Name + " - " + challongeURL + t.ChallongeID)
+		if t.ChannelID != 0 {
+			b.WriteString(" in <#" + t.ChannelID.String() + ">")
+		}
+	}
+	c.session.SendSimpleMessage(msg.ChannelID, b.String())
+}
+
+//bind sends commands in this channel to a tournament without needing --tourney
+func (c *tourneyCommand) bind(args []string, flags map[string]string) {
+	msg := c.data.Message
+	if len(args) == 1 {
+		c.tourneyName = args[0]
+	}
+	if len(args) > 1 || c.tourneyName == "" {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentCommandString+" bind name")
+		return
+	}
+	t, ok := c.organizerTourney("bind the tournament")
+	if !ok {
+		return
+	}
+
+	//A channel only goes to one tournament
+	ts, err := c.repo.GetTourneysByServer(msg.GuildID)
+	if err != nil {
+		log.Error(err)
+	}
+	for _, o := range ts {
+		if o.TournamentID != t.TournamentID && o.ChannelID == msg.ChannelID {
+			o.ChannelID = 0
+			if err := c.repo.SaveTourney(&o); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+
+	t.ChannelID = msg.ChannelID
+	c.saveAndReact(&t)
+}
+
+func (c *tourneyCommand) unbind(args []string, flags map[string]string) {
+	if len(args) == 1 {
+		c.tourneyName = args[0]
+	}
+	t, ok := c.organizerTourney("unbind the tournament")
+	if !ok {
+		return
+	}
+	t.ChannelID = 0
+	c.saveAndReact(&t)
+}
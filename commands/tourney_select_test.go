@@ -0,0 +1,133 @@
+package commands_test
+
+import (
+	"discordbot/challonge"
+	"discordbot/commands"
+	"strings"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+)
+
+//newTwoTourneys - a melee and an ultimate bracket running in the same server
+func newTwoTourneys() (*mockChallongeClient, *mockTourneyDB) {
+	cclient := &mockChallongeClient{}
+	cclient.setTourneyID("melee_weekly")
+	cclient.addMatches(challonge.Match{ID: 1, Round: 1, Player1ID: 1, Player2ID: 2})
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+	repo.SaveTourney(&commands.Tournament{
+		TournamentID:    1,
+		ChallongeID:     "melee_weekly",
+		Name:            "melee",
+		User:            1,
+		DiscordServerID: 123,
+		Participants:    []commands.TournamentParticipant{{Name: "a", ChallongeID: 1}, {Name: "b", ChallongeID: 2}},
+	})
+	repo.SaveTourney(&commands.Tournament{
+		TournamentID:    2,
+		ChallongeID:     "ult_weekly",
+		Name:            "ult",
+		User:            1,
+		DiscordServerID: 123,
+		ChannelID:       20,
+	})
+	return cclient, repo
+}
+
+func runSelectCommand(command string, content string, channel commands.Snowflake, cclient *mockChallongeClient, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{ID: 30, Content: content, GuildID: 123, ChannelID: channel}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, cclient)
+	user := &commands.Users{UsersID: 1, DiscordUsersID: 1}
+	var c interface{}
+	switch command {
+	case commands.TournamentNextMatchString:
+		c = factory.CreateNextMatchCommand(&msg, user)
+	case commands.TournamentFinishString:
+		c = factory.CreateTourneyCloseCommand(&msg, user)
+	case commands.TournamentCommandString:
+		c = factory.CreateRequest(&msg, user)
+	}
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestCommandNeedsTourneyWhenSeveralRunning(t *testing.T) {
+	cclient, repo := newTwoTourneys()
+
+	s := runSelectCommand(commands.TournamentNextMatchString, "", 10, cclient, repo)
+
+	if !strings.HasPrefix(s.message, "More than one tournament is running: melee, ult.") {
+		t.Error("Ambiguous tournament not reported ", s.message)
+	}
+}
+
+func TestCommandPicksTourneyByName(t *testing.T) {
+	cclient, repo := newTwoTourneys()
+
+	//The tournament name is taken out before the station is read
+	s := runSelectCommand(commands.TournamentNextMatchString, "2 --tourney Melee", 10, cclient, repo)
+
+	if s.message != "Station 2: a vs b (Round 1)" {
+		t.Error("Match not called for the named tournament ", s.message)
+	}
+	if repo.tourneys[1].Stations[0].CurrentMatch != 1 {
+		t.Error("Match saved to the wrong tournament.")
+	}
+
+	s = runSelectCommand(commands.TournamentNextMatchString, "--t smash4", 10, cclient, repo)
+	if s.message != "No tournament named smash4 in this server." {
+		t.Error("Unknown tournament not reported ", s.message)
+	}
+}
+
+func TestCommandUsesChannelTourney(t *testing.T) {
+	cclient, repo := newTwoTourneys()
+	cclient.setTourneyID("ult_weekly")
+
+	runSelectCommand(commands.TournamentFinishString, "", 20, cclient, repo)
+
+	if _, ok := repo.tourneys[2]; ok {
+		t.Error("Bound tournament not ended.")
+	}
+	if _, ok := repo.tourneys[1]; !ok {
+		t.Error("Other tournament ended.")
+	}
+}
+
+func TestBindTourneyToChannel(t *testing.T) {
+	cclient, repo := newTwoTourneys()
+
+	//Binding melee to the ultimate channel moves the channel over
+	runSelectCommand(commands.TournamentCommandString, "bind melee", 20, cclient, repo)
+
+	if repo.tourneys[1].ChannelID != 20 || repo.tourneys[2].ChannelID != 0 {
+		t.Error("Channel not bound ", repo.tourneys[1].ChannelID, repo.tourneys[2].ChannelID)
+	}
+
+	runSelectCommand(commands.TournamentCommandString, "unbind", 20, cclient, repo)
+	if repo.tourneys[1].ChannelID != 0 {
+		t.Error("Channel not unbound.")
+	}
+}
+
+func TestListTourneys(t *testing.T) {
+	cclient, repo := newTwoTourneys()
+
+	s := runSelectCommand(commands.TournamentCommandString, "list", 10, cclient, repo)
+
+	if s.message != "Tournaments:\nmelee - https://challonge.com/melee_weekly\nult - https://challonge.com/ult_weekly in <#20>" {
+		t.Error("Tournaments listed incorrectly ", s.message)
+	}
+}
+
+func TestCreateSecondTourney(t *testing.T) {
+	cclient, repo := newTwoTourneys()
+
+	runSelectCommand(commands.TournamentCommandString, `create "Rivals Weekly" --tourney rivals`, 10, cclient, repo)
+
+	ts, _ := repo.GetTourneysByServer(123)
+	if len(ts) != 3 || ts[2].Name != "rivals" {
+		t.Error("Second tournament not created ", ts)
+	}
+}
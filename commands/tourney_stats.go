@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+const statsRecentLength = 5
+
+func (c *tourneyCommandRequestFactory) CreatePlayerStatsCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &playerStatsCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+func (c *tourneyCommandRequestFactory) CreateHeadToHeadCommand(data *disgord.MessageCreate, user *Users) interface{} {
+	return &headToHeadCommand{
+		tourneyCommandRequestFactory: c,
+		data:                         data,
+		user:                         user,
+	}
+}
+
+//playerRecord adds up the sets and games a player won and lost
+type playerRecord struct {
+	setWins    int
+	setLosses  int
+	gameWins   int
+	gameLosses int
+}
+
+func (r *playerRecord) add(m TournamentResultMatch, side int) {
+	if m.Winner == side {
+		r.setWins++
+	} else {
+		r.setLosses++
+	}
+	won, lost := gameCount(m, side)
+	r.gameWins += won
+	r.gameLosses += lost
+}
+
+//matchSide tells which player of an archived match a user was, 0 when they did not play in it
+func matchSide(m TournamentResultMatch, discordUserID Snowflake) int {
+	switch discordUserID {
+	case m.Player1DiscordID:
+		return 1
+	case m.Player2DiscordID:
+		return 2
+	}
+	return 0
+}
+
+//gameCount reads challonge's scores, player 1 first and one comma separated entry per set, from one side of the match
+func gameCount(m TournamentResultMatch, side int) (int, int) {
+	won, lost := 0, 0
+	for _, set := range strings.Split(m.ScoresCsv, ",") {
+		parts := strings.Split(strings.TrimSpace(set), "-")
+		if len(parts) != 2 {
+			continue
+		}
+		p1, err1 := strconv.Atoi(parts[0])
+		p2, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if side == 2 {
+			p1, p2 = p2, p1
+		}
+		won += p1
+		lost += p2
+	}
+	return won, lost
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+func formatEnded(r TournamentResult) string {
+	return r.EndedAt.UTC().Format("2006-01-02")
+}
+
+type playerStatsCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *playerStatsCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	args := splitArguments(msg.Content)
+	if len(args) > 1 {
+		c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentPlayerStatsString+" [@user]")
+		return
+	}
+
+	ID := c.user.DiscordUsersID
+	if len(args) == 1 {
+		var ok bool
+		ID, ok = parseUserMention(args[0])
+		if !ok {
+			c.session.SendSimpleMessage(msg.ChannelID, "Usage: "+CommandPrefix+TournamentPlayerStatsString+" [@user]")
+			return
+		}
+	}
+
+	rs, err := c.repo.GetTournamentResultsByPlayer(msg.GuildID, ID)
+	if err != nil {
+		log.Error(err)
+	}
+	if len(rs) == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, createUserMention(ID)+" has not played in any tournaments here yet.")
+		return
+	}
+
+	record := playerRecord{}
+	placed, placementTotal := 0, 0
+	var recent []string
+	for _, r := range rs {
+		for _, m := range r.Matches {
+			if side := matchSide(m, ID); side != 0 {
+				record.add(m, side)
+			}
+		}
+
+		placement := "unranked"
+		for _, p := range r.Placements {
+			if p.DiscordUserID == ID && p.Rank != 0 {
+				placed++
+				placementTotal += p.Rank
+				placement = ordinal(p.Rank)
+			}
+		}
+		if len(recent) < statsRecentLength {
+			recent = append(recent, fmt.Sprintf("%s of %d - %s (%s)", placement, len(r.Placements), r.Name, formatEnded(r)))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Stats for %s across %d tournaments:", createUserMention(ID), len(rs)))
+	b.WriteString(fmt.Sprintf("\nSets: %d-%d", record.setWins, record.setLosses))
+	if sets := record.setWins + record.setLosses; sets > 0 {
+		b.WriteString(fmt.Sprintf(" (%d%% won)", record.setWins*100/sets))
+	}
+	b.WriteString(fmt.Sprintf("\nGames: %d-%d", record.gameWins, record.gameLosses))
+	if placed > 0 {
+		b.WriteString(fmt.Sprintf("\nAverage placement: %.1f", float64(placementTotal)/float64(placed)))
+	}
+	b.WriteString("\nRecent results:\n" + strings.Join(recent, "\n"))
+	c.session.SendSimpleMessage(msg.ChannelID, b.String())
+}
+
+type headToHeadCommand struct {
+	*tourneyCommandRequestFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *headToHeadCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+	args := splitArguments(msg.Content)
+	usage := "Usage: " + CommandPrefix + TournamentHeadToHeadString + " @user @user"
+	if len(args) != 2 {
+		c.session.SendSimpleMessage(msg.ChannelID, usage)
+		return
+	}
+	a, okA := parseUserMention(args[0])
+	b, okB := parseUserMention(args[1])
+	if !okA || !okB || a == b {
+		c.session.SendSimpleMessage(msg.ChannelID, usage)
+		return
+	}
+
+	rs, err := c.repo.GetTournamentResultsByPlayer(msg.GuildID, a)
+	if err != nil {
+		log.Error(err)
+	}
+
+	record := playerRecord{}
+	tourneys := 0
+	var recent []string
+	for _, r := range rs {
+		played := false
+		for _, m := range r.Matches {
+			side := matchSide(m, a)
+			if side == 0 || matchSide(m, b) == 0 {
+				continue
+			}
+			played = true
+			record.add(m, side)
+
+			if len(recent) < statsRecentLength {
+				outcome := "lost"
+				if m.Winner == side {
+					outcome = "won"
+				}
+				won, lost := gameCount(m, side)
+				recent = append(recent, fmt.Sprintf("%s (%s): %s %d-%d", r.Name, formatEnded(r), outcome, won, lost))
+			}
+		}
+		if played {
+			tourneys++
+		}
+	}
+
+	if tourneys == 0 {
+		c.session.SendSimpleMessage(msg.ChannelID, createUserMention(a)+" and "+createUserMention(b)+" have not played each other yet.")
+		return
+	}
+
+	c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("%s vs %s across %d tournaments:\nSets: %d-%d\nGames: %d-%d\nRecent sets for %s:\n%s",
+		createUserMention(a), createUserMention(b), tourneys, record.setWins, record.setLosses, record.gameWins, record.gameLosses,
+		createUserMention(a), strings.Join(recent, "\n")))
+}
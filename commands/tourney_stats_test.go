@@ -0,0 +1,83 @@
+package commands_test
+
+import (
+	"discordbot/commands"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+func newTourneyHistory() *mockTourneyDB {
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
+	repo.SaveTournamentResult(&commands.TournamentResult{DiscordServerID: 123, Name: "week1", EndedAt: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		Placements: []commands.TournamentPlacement{{Name: "a", DiscordUserID: 800, Rank: 1}, {Name: "b", DiscordUserID: 801, Rank: 2}, {Name: "c", Rank: 3}},
+		Matches: []commands.TournamentResultMatch{
+			{MatchID: 1, Player1: "a", Player1DiscordID: 800, Player2: "b", Player2DiscordID: 801, Winner: 1, ScoresCsv: "2-1"},
+			{MatchID: 2, Player1: "c", Player2: "a", Player2DiscordID: 800, Winner: 2, ScoresCsv: "0-2"},
+		}})
+	repo.SaveTournamentResult(&commands.TournamentResult{DiscordServerID: 123, Name: "week2", EndedAt: time.Date(2026, 9, 8, 0, 0, 0, 0, time.UTC),
+		Placements: []commands.TournamentPlacement{{Name: "b", DiscordUserID: 801, Rank: 1}, {Name: "a", DiscordUserID: 800, Rank: 2}},
+		Matches: []commands.TournamentResultMatch{
+			{MatchID: 1, Player1: "b", Player1DiscordID: 801, Player2: "a", Player2DiscordID: 800, Winner: 1, ScoresCsv: "3-2,1-3,3-0"},
+		}})
+	repo.SaveTournamentResult(&commands.TournamentResult{DiscordServerID: 456, Name: "elsewhere",
+		Placements: []commands.TournamentPlacement{{Name: "a", DiscordUserID: 800, Rank: 4}}})
+	return repo
+}
+
+func runStatsCommand(command string, content string, repo *mockTourneyDB) *mockSession {
+	msg := disgord.MessageCreate{Message: &disgord.Message{ID: 20, Content: content, GuildID: 123, ChannelID: 10}}
+	s := &mockSession{}
+	factory := commands.NewTourneyCommandRequestFactory(s, repo, &mockUsersDB{}, &mockChallongeClient{})
+	user := &commands.Users{UsersID: 3, DiscordUsersID: 800}
+	var c interface{}
+	switch command {
+	case commands.TournamentPlayerStatsString:
+		c = factory.CreatePlayerStatsCommand(&msg, user)
+	case commands.TournamentHeadToHeadString:
+		c = factory.CreateHeadToHeadCommand(&msg, user)
+	}
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+	return s
+}
+
+func TestPlayerStats(t *testing.T) {
+	repo := newTourneyHistory()
+
+	expected := "Stats for <@800> across 2 tournaments:\nSets: 2-1 (66% won)\nGames: 9-8\nAverage placement: 1.5\n" +
+		"Recent results:\n2nd of 2 - week2 (2026-09-08)\n1st of 3 - week1 (2026-09-01)"
+	if s := runStatsCommand(commands.TournamentPlayerStatsString, "<@800>", repo); s.message != expected {
+		t.Error("Stats incorrect ", s.message)
+	}
+
+	//Without a mention the stats are for whoever asked
+	if s := runStatsCommand(commands.TournamentPlayerStatsString, "", repo); s.message != expected {
+		t.Error("Own stats incorrect ", s.message)
+	}
+
+	if s := runStatsCommand(commands.TournamentPlayerStatsString, "<@999>", repo); s.message != "<@999> has not played in any tournaments here yet." {
+		t.Error("Unexpected message ", s.message)
+	}
+}
+
+func TestHeadToHead(t *testing.T) {
+	repo := newTourneyHistory()
+
+	s := runStatsCommand(commands.TournamentHeadToHeadString, "<@801> <@!800>", repo)
+
+	expected := "<@801> vs <@800> across 2 tournaments:\nSets: 1-1\nGames: 8-7\nRecent sets for <@801>:\nweek2 (2026-09-08): won 7-5\nweek1 (2026-09-01): lost 1-2"
+	if s.message != expected {
+		t.Error("Head to head incorrect ", s.message)
+	}
+
+	s = runStatsCommand(commands.TournamentHeadToHeadString, "<@800> <@999>", repo)
+	if s.message != "<@800> and <@999> have not played each other yet." {
+		t.Error("Unexpected message ", s.message)
+	}
+
+	s = runStatsCommand(commands.TournamentHeadToHeadString, "<@800> <@800>", repo)
+	if s.message != "Usage: $head-to-head @user @user" {
+		t.Error("Same player accepted ", s.message)
+	}
+}
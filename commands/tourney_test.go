@@ -3,27 +3,290 @@ package commands_test
 import (
 	"discordbot/challonge"
 	"discordbot/commands"
+	"errors"
 	"log"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/andersfylling/disgord"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type mockTourneyDB struct {
-	tourneys   map[commands.Snowflake]commands.Tournament
-	organizers map[int64]int64
+	tourneys      map[int64]commands.Tournament
+	organizers    map[int64]int64
+	reports       []commands.MatchReport
+	announcements []commands.MatchAnnouncement
+	results       []commands.TournamentResult
+	ratings       []commands.PlayerRating
+	ratedMatches  []commands.RatedMatch
+}
+
+func (r *mockTourneyDB) SavePlayerRating(p *commands.PlayerRating) error {
+	if p.PlayerRatingID == 0 {
+		p.PlayerRatingID = int64(len(r.ratings) + 1)
+		r.ratings = append(r.ratings, *p)
+		return nil
+	}
+	r.ratings[p.PlayerRatingID-1] = *p
+	return nil
+}
+
+func (r *mockTourneyDB) GetPlayerRating(discordServerID commands.Snowflake, game string, discordUserID commands.Snowflake, name string) (commands.PlayerRating, error) {
+	for _, p := range r.ratings {
+		if p.DiscordServerID != discordServerID || !strings.EqualFold(p.Game, game) || p.DiscordUserID != discordUserID {
+			continue
+		}
+		if discordUserID != 0 || strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return commands.PlayerRating{}, nil
+}
+
+func (r *mockTourneyDB) GetPlayerRatingByID(ID int64) (commands.PlayerRating, error) {
+	return r.ratings[ID-1], nil
+}
+
+func (r *mockTourneyDB) GetPlayerRatingsByServer(discordServerID commands.Snowflake) ([]commands.PlayerRating, error) {
+	var result []commands.PlayerRating
+	for _, p := range r.ratings {
+		if p.DiscordServerID == discordServerID {
+			result = append(result, p)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Rating > result[j].Rating
+	})
+	return result, nil
+}
+
+func (r *mockTourneyDB) SaveRatedMatch(m *commands.RatedMatch) error {
+	r.RemoveRatedMatch(m.DiscordServerID, m.ChallongeID, m.MatchID)
+	r.ratedMatches = append(r.ratedMatches, *m)
+	return nil
+}
+
+func (r *mockTourneyDB) GetRatedMatch(discordServerID commands.Snowflake, challongeID string, matchID int) (commands.RatedMatch, error) {
+	for _, m := range r.ratedMatches {
+		if m.DiscordServerID == discordServerID && m.ChallongeID == challongeID && m.MatchID == matchID {
+			return m, nil
+		}
+	}
+	return commands.RatedMatch{}, nil
+}
+
+func (r *mockTourneyDB) RemoveRatedMatch(discordServerID commands.Snowflake, challongeID string, matchID int) error {
+	var remaining []commands.RatedMatch
+	for _, m := range r.ratedMatches {
+		if m.DiscordServerID != discordServerID || m.ChallongeID != challongeID || m.MatchID != matchID {
+			remaining = append(remaining, m)
+		}
+	}
+	r.ratedMatches = remaining
+	return nil
+}
+
+func (r *mockTourneyDB) SaveTournamentResult(t *commands.TournamentResult) error {
+	t.TournamentResultID = int64(len(r.results) + 1)
+	r.results = append(r.results, *t)
+	return nil
+}
+
+func (r *mockTourneyDB) GetTournamentResult(ID int64) (commands.TournamentResult, error) {
+	for _, t := range r.results {
+		if t.TournamentResultID == ID {
+			return t, nil
+		}
+	}
+	return commands.TournamentResult{}, errors.New("sql: no rows in result set")
+}
+
+func (r *mockTourneyDB) GetTournamentResultsByServer(discordServerID commands.Snowflake) ([]commands.TournamentResult, error) {
+	var result []commands.TournamentResult
+	for i := len(r.results) - 1; i >= 0; i-- {
+		if r.results[i].DiscordServerID == discordServerID {
+			result = append(result, r.results[i])
+		}
+	}
+	return result, nil
+}
+
+func (r *mockTourneyDB) GetTournamentResultsByPlayer(discordServerID commands.Snowflake, discordUserID commands.Snowflake) ([]commands.TournamentResult, error) {
+	var result []commands.TournamentResult
+	rs, _ := r.GetTournamentResultsByServer(discordServerID)
+	for _, t := range rs {
+		played := false
+		for _, p := range t.Placements {
+			played = played || p.DiscordUserID == discordUserID
+		}
+		for _, m := range t.Matches {
+			played = played || m.Player1DiscordID == discordUserID || m.Player2DiscordID == discordUserID
+		}
+		if played {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (r *mockTourneyDB) GetCheckInTourneys() ([]commands.Tournament, error) {
+	var result []commands.Tournament
+	for _, t := range r.tourneys {
+		if t.CheckInMessage != 0 {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (r *mockTourneyDB) GetTourneyByCheckInMessage(msg commands.Snowflake) (commands.Tournament, error) {
+	for _, t := range r.tourneys {
+		if t.CheckInMessage == msg {
+			return t, nil
+		}
+	}
+	return commands.Tournament{}, errors.New("no tournament")
+}
+
+func (r *mockTourneyDB) IsCheckInMessage(msg commands.Snowflake) (bool, error) {
+	_, err := r.GetTourneyByCheckInMessage(msg)
+	return err == nil, nil
+}
+
+func (r *mockTourneyDB) GetAnnouncedTourneys() ([]commands.Tournament, error) {
+	var result []commands.Tournament
+	for _, t := range r.tourneys {
+		if t.AnnounceChannel != 0 {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (r *mockTourneyDB) GetMatchAnnouncements(tournamentID int64) ([]commands.MatchAnnouncement, error) {
+	var result []commands.MatchAnnouncement
+	for _, a := range r.announcements {
+		if a.TournamentID == tournamentID {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (r *mockTourneyDB) SaveMatchAnnouncement(a *commands.MatchAnnouncement) error {
+	r.announcements = append(r.announcements, *a)
+	return nil
+}
+
+func (r *mockTourneyDB) RemoveMatchAnnouncement(a *commands.MatchAnnouncement) error {
+	for i, o := range r.announcements {
+		if o == *a {
+			r.announcements = append(r.announcements[:i], r.announcements[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *mockTourneyDB) SaveMatchReport(m *commands.MatchReport) error {
+	for i, o := range r.reports {
+		if o.TournamentID == m.TournamentID && o.MatchID == m.MatchID {
+			m.MatchReportID = o.MatchReportID
+			r.reports[i] = *m
+			return nil
+		}
+	}
+	m.MatchReportID = int64(len(r.reports) + 1)
+	r.reports = append(r.reports, *m)
+	return nil
+}
+
+func (r *mockTourneyDB) GetMatchReport(tournamentID int64, matchID int) (commands.MatchReport, error) {
+	for _, o := range r.reports {
+		if o.TournamentID == tournamentID && o.MatchID == matchID {
+			return o, nil
+		}
+	}
+	return commands.MatchReport{}, errors.New("sql: no rows in result set")
+}
+
+func (r *mockTourneyDB) GetMatchReportByMessage(msg commands.Snowflake) (commands.MatchReport, error) {
+	for _, o := range r.reports {
+		if o.Message == msg {
+			return o, nil
+		}
+	}
+	return commands.MatchReport{}, errors.New("sql: no rows in result set")
+}
+
+func (r *mockTourneyDB) IsMatchReportMessage(msg commands.Snowflake) (bool, error) {
+	_, err := r.GetMatchReportByMessage(msg)
+	return err == nil, nil
+}
+
+func (r *mockTourneyDB) RemoveMatchReport(ID int64) error {
+	for i, o := range r.reports {
+		if o.MatchReportID == ID {
+			r.reports = append(r.reports[:i], r.reports[i+1:]...)
+			return nil
+		}
+	}
+	return nil
 }
 
 func (r *mockTourneyDB) SaveTourney(t *commands.Tournament) error {
-	r.tourneys[t.DiscordServerID] = *t
+	if t.TournamentID == 0 {
+		for ID := range r.tourneys {
+			if ID > t.TournamentID {
+				t.TournamentID = ID
+			}
+		}
+		t.TournamentID++
+	}
+	r.tourneys[t.TournamentID] = *t
 	return nil
 }
 
-func (r *mockTourneyDB) GetTourneyByServer(ID commands.Snowflake) (commands.Tournament, error) {
+func (r *mockTourneyDB) GetTourneyByID(ID int64) (commands.Tournament, error) {
 	return r.tourneys[ID], nil
 }
 
+func (r *mockTourneyDB) GetTourneysByServer(ID commands.Snowflake) ([]commands.Tournament, error) {
+	var result []commands.Tournament
+	for _, t := range r.tourneys {
+		if t.DiscordServerID == ID {
+			result = append(result, t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].TournamentID < result[j].TournamentID })
+	return result, nil
+}
+
+//GetTourneyByServer - the first tournament in a server, for tests that only run one
+func (r *mockTourneyDB) GetTourneyByServer(ID commands.Snowflake) (commands.Tournament, error) {
+	ts, _ := r.GetTourneysByServer(ID)
+	if len(ts) == 0 {
+		return commands.Tournament{}, nil
+	}
+	return ts[0], nil
+}
+
+func (r *mockTourneyDB) GetTourneysByParticipant(discordUserID commands.Snowflake) ([]commands.Tournament, error) {
+	var result []commands.Tournament
+	for _, t := range r.tourneys {
+		for _, p := range t.Participants {
+			if p.DiscordUserID == discordUserID {
+				result = append(result, t)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
 func (r *mockTourneyDB) AddTourneyOrganizer(userID int64, tourneyID int64) error {
 	if r.organizers == nil {
 		r.organizers = make(map[int64]int64)
@@ -32,6 +295,48 @@ func (r *mockTourneyDB) AddTourneyOrganizer(userID int64, tourneyID int64) error
 	return nil
 }
 
+func (r *mockTourneyDB) RemoveTourneyOrganizer(userID int64, tourneyID int64) error {
+	delete(r.organizers, userID)
+	for k, t := range r.tourneys {
+		if t.TournamentID != tourneyID {
+			continue
+		}
+		var os []commands.Users
+		for _, o := range t.Organizers {
+			if o.UsersID != userID {
+				os = append(os, o)
+			}
+		}
+		t.Organizers = os
+		r.tourneys[k] = t
+	}
+	return nil
+}
+
+type mockUsersDB struct {
+	users []commands.Users
+}
+
+func (r *mockUsersDB) GetUserByDiscordId(user commands.Snowflake) (commands.Users, error) {
+	for _, u := range r.users {
+		if u.DiscordUsersID == user {
+			return u, nil
+		}
+	}
+	return commands.Users{}, nil
+}
+
+func (r *mockUsersDB) DoesUserExist(user commands.Snowflake) bool {
+	u, _ := r.GetUserByDiscordId(user)
+	return u.UsersID != 0
+}
+
+func (r *mockUsersDB) SaveUser(u *commands.Users) error {
+	u.UsersID = int64(len(r.users) + 100)
+	r.users = append(r.users, *u)
+	return nil
+}
+
 func (r *mockTourneyDB) IsUserTourneyOrganizer(ID int64, tournamentID int64) (bool, error) {
 	_, ok := r.organizers[ID]
 	return ok, nil
@@ -41,8 +346,8 @@ func (r *mockTourneyDB) HasMatchInProgress(ID int64) (bool, error) {
 	return false, nil
 }
 
-func (r *mockTourneyDB) RemoveTourney(discordServerID commands.Snowflake) error {
-	delete(r.tourneys, discordServerID)
+func (r *mockTourneyDB) RemoveTourney(ID int64) error {
+	delete(r.tourneys, ID)
 	return nil
 }
 
@@ -52,20 +357,60 @@ type mockChallongeClient struct {
 	matches      []challonge.Match
 	WinnerID     int
 	query        challonge.MatchQueryParams
+	created      []challonge.TournamentParams
+	actions      []string
+	seeds        map[int]int
+	checkIn      time.Duration
+	reopened     []int
+
+	//participantsErr - challonge failing to list the participants
+	participantsErr error
+}
+
+func (c *mockChallongeClient) CheckInParticipant(tourneyID string, participantID int) error {
+	return c.setCheckedIn(participantID, true)
+}
+
+func (c *mockChallongeClient) UndoCheckInParticipant(tourneyID string, participantID int) error {
+	return c.setCheckedIn(participantID, false)
+}
+
+func (c *mockChallongeClient) setCheckedIn(participantID int, checkedIn bool) error {
+	for i := range c.participants {
+		if c.participants[i].ID == participantID {
+			c.participants[i].CheckedIn = checkedIn
+			return nil
+		}
+	}
+	return errors.New("error status code 404")
+}
+
+func (c *mockChallongeClient) ProcessCheckIns(tourneyID string) error {
+	var remaining []challonge.Participant
+	for _, p := range c.participants {
+		if p.CheckedIn {
+			remaining = append(remaining, p)
+		}
+	}
+	c.participants = remaining
+	return c.tourneyAction(tourneyID, "process_check_ins")
 }
 
-func (c *mockChallongeClient) GetParticipants(tourneyID string) []challonge.Participant {
+func (c *mockChallongeClient) GetParticipants(tourneyID string) ([]challonge.Participant, error) {
 	if tourneyID != c.id {
-		return nil
+		return nil, errors.New("error status code 404")
+	}
+	if c.participantsErr != nil {
+		return nil, c.participantsErr
 	}
-	return c.participants
+	return c.participants, nil
 }
 
-func (c *mockChallongeClient) GetMatches(tourneyID string) []challonge.Match {
+func (c *mockChallongeClient) GetMatches(tourneyID string) ([]challonge.Match, error) {
 	if tourneyID != c.id {
-		return nil
+		return nil, errors.New("error status code 404")
 	}
-	return c.matches
+	return c.matches, nil
 }
 
 func (c *mockChallongeClient) GetMatch(tourneyID string, matchID int) challonge.Match {
@@ -81,11 +426,86 @@ func (c *mockChallongeClient) GetMatch(tourneyID string, matchID int) challonge.
 	return challonge.Match{}
 }
 
-func (c *mockChallongeClient) UpdateMatch(tourneyID string, matchID int, params challonge.MatchQueryParams) {
+func (c *mockChallongeClient) UpdateMatch(tourneyID string, matchID int, params challonge.MatchQueryParams) error {
 	if tourneyID != c.id {
-		return
+		return errors.New("error status code 404")
 	}
 	c.query = params
+	for i := range c.matches {
+		if c.matches[i].ID == matchID {
+			c.matches[i].WinnerID = params.WinnerID
+		}
+	}
+	return nil
+}
+
+func (c *mockChallongeClient) ReopenMatch(tourneyID string, matchID int) error {
+	for i := range c.matches {
+		if c.matches[i].ID == matchID {
+			c.matches[i].WinnerID = 0
+			c.reopened = append(c.reopened, matchID)
+			return nil
+		}
+	}
+	return errors.New("error status code 404")
+}
+
+func (c *mockChallongeClient) CreateTournament(params challonge.TournamentParams) (challonge.Tournament, error) {
+	c.created = append(c.created, params)
+	c.id = params.URL
+	return challonge.Tournament{ID: 1, Name: params.Name, URL: params.URL, TournamentType: params.TournamentType}, nil
+}
+
+func (c *mockChallongeClient) tourneyAction(tourneyID string, action string) error {
+	if tourneyID != c.id {
+		return errors.New("error status code 404")
+	}
+	c.actions = append(c.actions, action)
+	return nil
+}
+
+func (c *mockChallongeClient) StartTournament(tourneyID string) error {
+	return c.tourneyAction(tourneyID, "start")
+}
+
+func (c *mockChallongeClient) ResetTournament(tourneyID string) error {
+	return c.tourneyAction(tourneyID, "reset")
+}
+
+func (c *mockChallongeClient) FinalizeTournament(tourneyID string) error {
+	return c.tourneyAction(tourneyID, "finalize")
+}
+
+func (c *mockChallongeClient) OpenCheckIn(tourneyID string, duration time.Duration) error {
+	c.checkIn = duration
+	return c.tourneyAction(tourneyID, "checkin")
+}
+
+func (c *mockChallongeClient) AddParticipant(tourneyID string, name string) (challonge.Participant, error) {
+	if tourneyID != c.id {
+		return challonge.Participant{}, errors.New("error status code 404")
+	}
+	p := challonge.Participant{ID: 1000 + len(c.participants), Name: name}
+	c.participants = append(c.participants, p)
+	return p, nil
+}
+
+func (c *mockChallongeClient) RemoveParticipant(tourneyID string, participantID int) error {
+	for i, p := range c.participants {
+		if p.ID == participantID {
+			c.participants = append(c.participants[:i], c.participants[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("error status code 404")
+}
+
+func (c *mockChallongeClient) SeedParticipant(tourneyID string, participantID int, seed int) error {
+	if c.seeds == nil {
+		c.seeds = make(map[int]int)
+	}
+	c.seeds[participantID] = seed
+	return nil
 }
 
 func (c *mockChallongeClient) setTourneyID(tourneyID string) {
@@ -116,9 +536,9 @@ func TestCreateTourneyFromChallongeLink(t *testing.T) {
 		GuildID: 123,
 	}}
 	user := commands.Users{UsersID: 1, DiscordUsersID: 1}
-	repo := &mockTourneyDB{tourneys: make(map[commands.Snowflake]commands.Tournament)}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
 	s := mockSession{}
-	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &challongeClient)
+	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &mockUsersDB{}, &challongeClient)
 	c := factory.CreateRequest(&msg, &user)
 	//When: The command is executed
 	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
@@ -153,9 +573,9 @@ func TestRunCommandsWithoutTourneyStart(t *testing.T) {
 	}}
 	challongeClient := mockChallongeClient{}
 	user := commands.Users{UsersID: 1, DiscordUsersID: 1}
-	repo := &mockTourneyDB{tourneys: make(map[commands.Snowflake]commands.Tournament)}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
 	s := mockSession{}
-	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &challongeClient)
+	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &mockUsersDB{}, &challongeClient)
 
 	c := factory.CreateAddOrganizerCommand(&msg, &user)
 
@@ -169,32 +589,40 @@ func TestRunCommandsWithoutTourneyStart(t *testing.T) {
 }
 
 func TestAddTourneyOrganizer(t *testing.T) {
-	//Given: A request to add a tourney organizer
+	//Given: A request to add a mentioned user as a tourney organizer
 	msg := disgord.MessageCreate{Message: &disgord.Message{
-		Content: "testUser",
+		Content: "<@!55>",
 		GuildID: 123,
 	}}
 	challongeClient := mockChallongeClient{}
 	user := commands.Users{UsersID: 1, DiscordUsersID: 1}
-	repo := &mockTourneyDB{tourneys: make(map[commands.Snowflake]commands.Tournament)}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
 	repo.SaveTourney(&commands.Tournament{
 		TournamentID:    1,
 		User:            1,
 		DiscordServerID: 123,
 	})
+	users := &mockUsersDB{}
 	s := mockSession{}
-	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &challongeClient)
+	factory := commands.NewTourneyCommandRequestFactory(&s, repo, users, &challongeClient)
 
 	c := factory.CreateAddOrganizerCommand(&msg, &user)
 	//When: The command is executed
 	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
 
-	//Then: The user is found as a tourney organizer
-	b, _ := repo.IsUserTourneyOrganizer(user.UsersID, 1)
+	//Then: The mentioned user is saved and found as a tourney organizer
+	if len(users.users) != 1 || users.users[0].DiscordUsersID != 55 {
+		t.Fatal("Mentioned user not saved ", users.users)
+	}
+	b, _ := repo.IsUserTourneyOrganizer(users.users[0].UsersID, 1)
 
 	if !b {
 		t.Fail()
 	}
+	//And: The caller is not added
+	if b, _ = repo.IsUserTourneyOrganizer(user.UsersID, 1); b {
+		t.Error("Caller added as organizer.")
+	}
 }
 
 func TestNextLosersMatch(t *testing.T) {
@@ -212,7 +640,7 @@ func TestNextLosersMatch(t *testing.T) {
 	cclient.setTourneyID("test")
 
 	user := commands.Users{UsersID: 1, DiscordUsersID: 1}
-	repo := &mockTourneyDB{tourneys: make(map[commands.Snowflake]commands.Tournament)}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
 	//And: A tourney saved with matching participants
 	repo.SaveTourney(&commands.Tournament{
 		TournamentID:    1,
@@ -229,7 +657,7 @@ func TestNextLosersMatch(t *testing.T) {
 		},
 	})
 	s := mockSession{}
-	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &cclient)
+	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &mockUsersDB{}, &cclient)
 
 	c := factory.CreateNextLosersCommnad(&msg, &user)
 
@@ -265,7 +693,7 @@ func TestNextLosersMatchMultipleLosers(t *testing.T) {
 	cclient.setTourneyID("test")
 
 	user := commands.Users{UsersID: 1, DiscordUsersID: 1}
-	repo := &mockTourneyDB{tourneys: make(map[commands.Snowflake]commands.Tournament)}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
 	//And: A tourney saved with matching participants
 	repo.SaveTourney(&commands.Tournament{
 		TournamentID:    1,
@@ -282,14 +710,14 @@ func TestNextLosersMatchMultipleLosers(t *testing.T) {
 		},
 	})
 	s := mockSession{}
-	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &cclient)
+	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &mockUsersDB{}, &cclient)
 
 	c := factory.CreateNextLosersCommnad(&msg, &user)
 
 	//When: The command is executed
 	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
 
-	tour := repo.tourneys[123]
+	tour := repo.tourneys[1]
 	//Then: The correct match message is sent
 	if s.message != "user1 vs test user" {
 		log.Println("Message not sent")
@@ -317,7 +745,7 @@ func TestNextLosersNoMatchReady(t *testing.T) {
 	cclient.setTourneyID("test")
 
 	user := commands.Users{UsersID: 1, DiscordUsersID: 1}
-	repo := &mockTourneyDB{tourneys: make(map[commands.Snowflake]commands.Tournament)}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
 	//And: A tourney saved with matching participants
 	repo.SaveTourney(&commands.Tournament{
 		TournamentID:    1,
@@ -334,7 +762,7 @@ func TestNextLosersNoMatchReady(t *testing.T) {
 		},
 	})
 	s := mockSession{}
-	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &cclient)
+	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &mockUsersDB{}, &cclient)
 
 	c := factory.CreateNextLosersCommnad(&msg, &user)
 
@@ -364,7 +792,7 @@ func TestWinCommand(t *testing.T) {
 	cclient.setTourneyID("test")
 
 	user := commands.Users{UsersID: 1, DiscordUsersID: 1}
-	repo := &mockTourneyDB{tourneys: make(map[commands.Snowflake]commands.Tournament)}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
 	//And: A tourney saved with matching participants
 	repo.SaveTourney(&commands.Tournament{
 		TournamentID:    1,
@@ -382,7 +810,7 @@ func TestWinCommand(t *testing.T) {
 		CurrentMatch: 1,
 	})
 	s := mockSession{}
-	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &cclient)
+	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &mockUsersDB{}, &cclient)
 
 	c := factory.CreateWinnerCommand(&msg, &user)
 
@@ -419,7 +847,7 @@ func TestFinishTourney(t *testing.T) {
 	cclient.setTourneyID("test")
 
 	user := commands.Users{UsersID: 1, DiscordUsersID: 1}
-	repo := &mockTourneyDB{tourneys: make(map[commands.Snowflake]commands.Tournament)}
+	repo := &mockTourneyDB{tourneys: make(map[int64]commands.Tournament)}
 	//And: A tourney saved with matching participants
 	repo.SaveTourney(&commands.Tournament{
 		TournamentID:    1,
@@ -437,7 +865,7 @@ func TestFinishTourney(t *testing.T) {
 		CurrentMatch: 1,
 	})
 	s := mockSession{}
-	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &cclient)
+	factory := commands.NewTourneyCommandRequestFactory(&s, repo, &mockUsersDB{}, &cclient)
 
 	c := factory.CreateTourneyCloseCommand(&msg, &user)
 
@@ -1,11 +1,11 @@
 package commands
 
 import (
-	"bytes"
-	"io"
-	"io/ioutil"
-	"net/http"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/andersfylling/disgord"
 )
@@ -77,24 +77,149 @@ func createMention(s Snowflake) string {
 	return "<@&" + s.String() + ">"
 }
 
-func doHttpGetRequest(link string) io.Reader {
-	req, err := http.NewRequest("GET", link, nil)
-	if err != nil {
-		log.Error(err)
-		return nil
+func createUserMention(s Snowflake) string {
+	return "<@" + s.String() + ">"
+}
+
+//parseUserMention reads a user id out of <@id> or <@!id>
+func parseUserMention(s string) (Snowflake, bool) {
+	if !strings.HasPrefix(s, "<@") || !strings.HasSuffix(s, ">") {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(s[2:len(s)-1], "!"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
 	}
+	return Snowflake(id), true
+}
 
-	r, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Error(err)
-		return nil
+//parseChannelMention reads a channel id out of <#id>
+func parseChannelMention(s string) (Snowflake, bool) {
+	if !strings.HasPrefix(s, "<#") || !strings.HasSuffix(s, ">") {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(s[2:len(s)-1], 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
 	}
-	defer r.Body.Close()
+	return Snowflake(id), true
+}
 
-	result, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Error(err)
-		return nil
+//splitArguments splits command content on whitespace, keeping "quoted text" together
+func splitArguments(content string) []string {
+	var args []string
+	var current strings.Builder
+	inQuotes := false
+	hasArg := false
+	for _, r := range content {
+		switch {
+		case r == '"' || r == '“' || r == '”':
+			inQuotes = !inQuotes
+			hasArg = true
+		case unicode.IsSpace(r) && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, current.String())
 	}
-	return bytes.NewReader(result)
-}
\ No newline at end of file
+	return args
+}
+
+//parseFlags separates "--name value" pairs from the positional arguments. Switches are flags that take no value.
+func parseFlags(args []string, switches ...string) ([]string, map[string]string) {
+	var positional []string
+	flags := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			positional = append(positional, args[i])
+			continue
+		}
+		name := strings.ToLower(args[i][2:])
+		if isSwitch(name, switches) {
+			flags[name] = ""
+		} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			flags[name] = args[i+1]
+			i++
+		} else {
+			flags[name] = ""
+		}
+	}
+	return positional, flags
+}
+
+func isSwitch(name string, switches []string) bool {
+	for _, s := range switches {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+//parseDuration extends time.ParseDuration with day (d) and week (w) units
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	unit := s[len(s)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		d := time.Duration(n) * 24 * time.Hour
+		if unit == 'w' {
+			d *= 7
+		}
+		return d, nil
+	}
+	return time.ParseDuration(s)
+}
+
+//formatDuration writes a duration in the largest whole unit, e.g. 2 days, 6 hours or 45 minutes
+func formatDuration(d time.Duration) string {
+	plural := func(n int64, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return plural(int64(d/(24*time.Hour)), "day")
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int64(d/time.Hour), "hour")
+	default:
+		return plural(int64(d/time.Minute), "minute")
+	}
+}
+
+//hasGuildPermission adds up the permissions of a member's roles, @everyone included. The owner and administrators have every permission.
+func hasGuildPermission(guild *disgord.Guild, roles []*disgord.Role, user Snowflake, member *disgord.Member, permission disgord.PermissionBit) bool {
+	if !user.IsZero() && user == guild.OwnerID {
+		return true
+	}
+	if member == nil {
+		return false
+	}
+	var permissions disgord.PermissionBit
+	for _, role := range roles {
+		if role.ID == guild.ID {
+			permissions |= role.Permissions
+		}
+		for _, ID := range member.Roles {
+			if role.ID == ID {
+				permissions |= role.Permissions
+			}
+		}
+	}
+	return permissions.Contains(disgord.PermissionAdministrator) || permissions.Contains(permission)
+}
@@ -0,0 +1,360 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+const votePollDefaultDeadline = 24 * time.Hour
+const voteUsage = "Usage: " + CommandPrefix + VoteString + " \"Question\" \"option 1\" \"option 2\" ... [--deadline 1d] [--multi] [--anonymous] [--channel channel_name] [--role role_name]"
+
+var voteOptionEmojis = []string{"1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣", "6️⃣", "7️⃣", "8️⃣", "9️⃣", "🔟"}
+
+type votePollCommandFactory struct {
+	repo    VotePollRepository
+	session DiscordSession
+}
+
+func NewVotePollCommandFactory(s DiscordSession, repo VotePollRepository) *votePollCommandFactory {
+	return &votePollCommandFactory{
+		session: s,
+		repo:    repo,
+	}
+}
+
+func (c *votePollCommandFactory) PrintHelp() string {
+	return CommandPrefix + VoteString + " {question} {options...} - Post a poll voted on with reactions. Results are announced at the deadline."
+}
+
+func (c *votePollCommandFactory) CreateRequest(data *disgord.MessageCreate, user *Users) interface{} {
+	return &votePollCommand{
+		votePollCommandFactory: c,
+		data:                   data,
+		user:                   user,
+	}
+}
+
+type votePollCommand struct {
+	*votePollCommandFactory
+	data *disgord.MessageCreate
+	user *Users
+}
+
+func (c *votePollCommand) ExecuteMessageCreateCommand() {
+	msg := c.data.Message
+
+	args, flags := parseFlags(splitArguments(msg.Content), "multi", "anonymous")
+	if len(args) < 3 {
+		c.session.SendSimpleMessage(msg.ChannelID, "A poll needs a question and at least two options.\n"+voteUsage)
+		return
+	}
+	if len(args)-1 > len(voteOptionEmojis) {
+		c.session.SendSimpleMessage(msg.ChannelID, fmt.Sprintf("A poll can have at most %d options.", len(voteOptionEmojis)))
+		return
+	}
+
+	deadline := time.Now().Add(votePollDefaultDeadline)
+	if d, ok := flags["deadline"]; ok {
+		duration, err := parseDuration(d)
+		if err != nil || duration <= 0 {
+			c.session.SendSimpleMessage(msg.ChannelID, "Could not read deadline "+d+". Use a duration like 30m, 12h or 2d.")
+			return
+		}
+		deadline = time.Now().Add(duration)
+	}
+
+	channelID := msg.ChannelID
+	if name, ok := flags["channel"]; ok {
+		channel := FindChannelByName(name, c.session.Guild(msg.GuildID))
+		if channel == nil {
+			c.session.SendSimpleMessage(msg.ChannelID, "Channel name not found")
+			return
+		}
+		channelID = channel.ID
+	}
+
+	var roleID Snowflake
+	if name, ok := flags["role"]; ok {
+		roles, _ := c.session.Guild(msg.GuildID).GetRoles()
+		role := FindRoleByName(name, roles)
+		if role == nil {
+			c.session.SendSimpleMessage(msg.ChannelID, "Role name not found")
+			return
+		}
+		roleID = role.ID
+	}
+
+	_, multiChoice := flags["multi"]
+	_, anonymous := flags["anonymous"]
+	poll := VotePoll{
+		User:        c.user.UsersID,
+		Guild:       msg.GuildID,
+		Channel:     channelID,
+		Role:        roleID,
+		Title:       args[0],
+		Options:     args[1:],
+		MultiChoice: multiChoice,
+		Anonymous:   anonymous,
+		Deadline:    deadline,
+	}
+
+	pollMsg, err := c.session.SendSimpleMessage(channelID, formatVotePoll(poll))
+	if err != nil {
+		log.Error(err)
+		c.session.ReactWithThumbsDown(msg)
+		return
+	}
+	poll.Message = pollMsg.ID
+
+	err = c.repo.SaveVotePoll(&poll)
+	if err != nil {
+		log.Error(err)
+		c.session.SendSimpleMessage(msg.ChannelID, "Something went wrong, poll unable to be saved.")
+		return
+	}
+
+	for i := range poll.Options {
+		c.session.ReactToMessage(poll.Message, poll.Channel, voteOptionEmojis[i])
+	}
+
+	waitForVotePollDeadline(c.session, c.repo, poll)
+}
+
+func formatVotePoll(poll VotePoll) string {
+	var b strings.Builder
+	b.WriteString("**" + poll.Title + "**\n")
+	for i, o := range poll.Options {
+		b.WriteString(voteOptionEmojis[i] + " " + o + "\n")
+	}
+	b.WriteString("\n")
+	if poll.MultiChoice {
+		b.WriteString("Vote for as many options as you like.")
+	} else {
+		b.WriteString("One vote per person.")
+	}
+	if poll.Anonymous {
+		b.WriteString(" Votes are anonymous.")
+	}
+	b.WriteString(fmt.Sprintf(" Closes <t:%d:R>.", poll.Deadline.Unix()))
+	return b.String()
+}
+
+func voteOptionIndex(emoji *disgord.Emoji) int {
+	if emoji == nil {
+		return -1
+	}
+	for i, e := range voteOptionEmojis {
+		if e == emoji.Name {
+			return i
+		}
+	}
+	return -1
+}
+
+//waitForVotePollDeadline announces the results of the poll once the deadline passes
+func waitForVotePollDeadline(s DiscordSession, repo VotePollRepository, poll VotePoll) {
+	timeToWait := time.NewTimer(time.Until(poll.Deadline))
+	go func() {
+		<-timeToWait.C
+		closeVotePoll(s, repo, poll)
+	}()
+}
+
+func closeVotePoll(s DiscordSession, repo VotePollRepository, poll VotePoll) {
+	votes, err := repo.GetVotes(poll.VotePollID)
+	if err != nil {
+		log.WithField("votepoll", poll.VotePollID).Error(err)
+		return
+	}
+
+	s.SendSimpleMessage(poll.Channel, formatVotePollResults(poll, votes))
+
+	err = repo.DeleteVotePollByID(poll.VotePollID)
+	if err != nil {
+		log.WithField("votepoll", poll).Error(err)
+	}
+}
+
+func formatVotePollResults(poll VotePoll, votes []VotePollVote) string {
+	voters := make([][]Snowflake, len(poll.Options))
+	for _, v := range votes {
+		if v.Option >= 0 && v.Option < len(voters) {
+			voters[v.Option] = append(voters[v.Option], v.User)
+		}
+	}
+
+	top := 0
+	for i := range voters {
+		if len(voters[i]) > len(voters[top]) {
+			top = i
+		}
+	}
+
+	var b strings.Builder
+	if poll.Role != 0 {
+		b.WriteString(createMention(poll.Role) + " ")
+	}
+	if len(voters[top]) == 0 {
+		b.WriteString(fmt.Sprintf("Poll has closed. Nobody voted on %s.", poll.Title))
+		return b.String()
+	}
+
+	var winners []string
+	for i := range voters {
+		if len(voters[i]) == len(voters[top]) {
+			winners = append(winners, poll.Options[i])
+		}
+	}
+	if len(winners) > 1 {
+		b.WriteString(fmt.Sprintf("Poll has closed. The top vote for %s is a tie between %s with %d votes.", poll.Title, strings.Join(winners, ", "), len(voters[top])))
+	} else {
+		b.WriteString(fmt.Sprintf("Poll has closed. The top vote for %s is %s with %d votes.", poll.Title, winners[0], len(voters[top])))
+	}
+
+	for i, o := range poll.Options {
+		b.WriteString(fmt.Sprintf("\n%s %s - %d", voteOptionEmojis[i], o, len(voters[i])))
+		if !poll.Anonymous && len(voters[i]) > 0 {
+			var mentions []string
+			for _, u := range voters[i] {
+				mentions = append(mentions, createUserMention(u))
+			}
+			b.WriteString(" (" + strings.Join(mentions, ", ") + ")")
+		}
+	}
+	return b.String()
+}
+
+//RestartVotePolls resumes deadlines after a restart. Polls that closed while offline are announced immediately.
+func RestartVotePolls(s DiscordSession, repo VotePollRepository) {
+	polls, err := repo.GetAllVotePolls()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	for _, poll := range polls {
+		waitForVotePollDeadline(s, repo, poll)
+	}
+}
+
+type addVoteReact struct {
+	repo    VotePollRepository
+	session DiscordSession
+	data    *disgord.MessageReactionAdd
+}
+
+func NewAddVoteReact(r VotePollRepository, s DiscordSession, d *disgord.MessageReactionAdd) *addVoteReact {
+	return &addVoteReact{
+		repo:    r,
+		session: s,
+		data:    d,
+	}
+}
+
+func (c *addVoteReact) OnReactionAdd() {
+	poll, err := c.repo.GetVotePollByMessage(c.data.MessageID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	option := voteOptionIndex(c.data.PartialEmoji)
+	if option < 0 || option >= len(poll.Options) || time.Now().After(poll.Deadline) {
+		return
+	}
+
+	if poll.Anonymous {
+		//Reactions are removed so nobody can see who voted for what
+		c.session.RemoveUserReaction(poll.Message, poll.Channel, c.data.PartialEmoji.Name, c.data.UserID)
+	}
+
+	votes, err := c.repo.GetVotes(poll.VotePollID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	var previous []int
+	for _, v := range votes {
+		if v.User == c.data.UserID {
+			previous = append(previous, v.Option)
+		}
+	}
+
+	vote := VotePollVote{VotePollID: poll.VotePollID, Option: option, User: c.data.UserID}
+	if poll.Anonymous && containsOption(previous, option) {
+		//Reacting again to an anonymous poll takes the vote back
+		err = c.repo.RemoveVote(&vote)
+		if err != nil {
+			log.Error(err)
+		}
+		return
+	}
+
+	if !poll.MultiChoice {
+		err = c.repo.RemoveUserVotes(poll.VotePollID, c.data.UserID)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		if !poll.Anonymous {
+			for _, p := range previous {
+				if p != option {
+					c.session.RemoveUserReaction(poll.Message, poll.Channel, voteOptionEmojis[p], c.data.UserID)
+				}
+			}
+		}
+	}
+
+	err = c.repo.AddVote(&vote)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func containsOption(options []int, option int) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+type removeVoteReact struct {
+	repo    VotePollRepository
+	session DiscordSession
+	data    *disgord.MessageReactionRemove
+}
+
+func NewRemoveVoteReact(r VotePollRepository, s DiscordSession, d *disgord.MessageReactionRemove) *removeVoteReact {
+	return &removeVoteReact{
+		repo:    r,
+		session: s,
+		data:    d,
+	}
+}
+
+func (c *removeVoteReact) OnReactionRemove() {
+	poll, err := c.repo.GetVotePollByMessage(c.data.MessageID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	//Anonymous poll reactions are removed by the bot itself
+	if poll.Anonymous || time.Now().After(poll.Deadline) {
+		return
+	}
+
+	option := voteOptionIndex(c.data.PartialEmoji)
+	if option < 0 {
+		return
+	}
+
+	err = c.repo.RemoveVote(&VotePollVote{VotePollID: poll.VotePollID, Option: option, User: c.data.UserID})
+	if err != nil {
+		log.Error(err)
+	}
+}
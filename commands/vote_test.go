@@ -0,0 +1,243 @@
+package commands_test
+
+import (
+	"discordbot/commands"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+type mockVotePollRepo struct {
+	polls []commands.VotePoll
+	votes []commands.VotePollVote
+}
+
+func (r *mockVotePollRepo) SaveVotePoll(p *commands.VotePoll) error {
+	p.VotePollID = int64(len(r.polls) + 1)
+	r.polls = append(r.polls, *p)
+	return nil
+}
+
+func (r *mockVotePollRepo) GetVotePollByMessage(msg commands.Snowflake) (commands.VotePoll, error) {
+	for _, p := range r.polls {
+		if p.Message == msg {
+			return p, nil
+		}
+	}
+	return commands.VotePoll{}, nil
+}
+
+func (r *mockVotePollRepo) GetAllVotePolls() ([]commands.VotePoll, error) {
+	return r.polls, nil
+}
+
+func (r *mockVotePollRepo) IsVotePollMessage(msg commands.Snowflake) (bool, error) {
+	p, _ := r.GetVotePollByMessage(msg)
+	return p.VotePollID != 0, nil
+}
+
+func (r *mockVotePollRepo) DeleteVotePollByID(ID int64) error {
+	for i, p := range r.polls {
+		if p.VotePollID == ID {
+			r.polls = append(r.polls[:i], r.polls[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *mockVotePollRepo) AddVote(v *commands.VotePollVote) error {
+	for _, existing := range r.votes {
+		if existing == *v {
+			return nil
+		}
+	}
+	r.votes = append(r.votes, *v)
+	return nil
+}
+
+func (r *mockVotePollRepo) RemoveVote(v *commands.VotePollVote) error {
+	for i, existing := range r.votes {
+		if existing == *v {
+			r.votes = append(r.votes[:i], r.votes[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *mockVotePollRepo) RemoveUserVotes(pollID int64, user commands.Snowflake) error {
+	var kept []commands.VotePollVote
+	for _, v := range r.votes {
+		if v.VotePollID != pollID || v.User != user {
+			kept = append(kept, v)
+		}
+	}
+	r.votes = kept
+	return nil
+}
+
+func (r *mockVotePollRepo) GetVotes(pollID int64) ([]commands.VotePollVote, error) {
+	var votes []commands.VotePollVote
+	for _, v := range r.votes {
+		if v.VotePollID == pollID {
+			votes = append(votes, v)
+		}
+	}
+	return votes, nil
+}
+
+func newVoteReaction(user commands.Snowflake, emoji string) *disgord.MessageReactionAdd {
+	return &disgord.MessageReactionAdd{
+		UserID:       user,
+		MessageID:    999,
+		PartialEmoji: &disgord.Emoji{Name: emoji},
+	}
+}
+
+func TestCreateVotePoll(t *testing.T) {
+	//Given: A vote command with three options
+	s := &mockSession{guild: &commonMockGuild}
+	repo := &mockVotePollRepo{}
+	msg := &disgord.MessageCreate{Message: &disgord.Message{
+		Content:   `"Best game" Melee Ultimate "Project M" --multi --deadline 2h`,
+		ChannelID: 10,
+		GuildID:   20,
+	}}
+	factory := commands.NewVotePollCommandFactory(s, repo)
+	c := factory.CreateRequest(msg, &commands.Users{UsersID: 1})
+
+	//When: The command is executed
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+	//Then: The poll is saved against the posted message
+	if len(repo.polls) != 1 {
+		t.Fatal("Poll not saved.")
+	}
+	p := repo.polls[0]
+	if p.Title != "Best game" || len(p.Options) != 3 || !p.MultiChoice || p.Anonymous {
+		log.Println("Poll saved incorrectly ", p)
+		t.Fail()
+	}
+	if p.Message != 999 || p.Channel != 10 {
+		log.Println("Poll message not tracked ", p)
+		t.Fail()
+	}
+	if time.Until(p.Deadline) < time.Hour || time.Until(p.Deadline) > 2*time.Hour {
+		log.Println("Deadline not set ", p.Deadline)
+		t.Fail()
+	}
+	//And: A numbered reaction is added for each option
+	if len(s.reactions) != 3 || s.reactions[2] != "3️⃣" {
+		log.Println("Option reactions not added ", s.reactions)
+		t.Fail()
+	}
+}
+
+func TestCreateVotePollTooFewOptions(t *testing.T) {
+	s := &mockSession{guild: &commonMockGuild}
+	repo := &mockVotePollRepo{}
+	msg := &disgord.MessageCreate{Message: &disgord.Message{Content: `"Question?" yes`}}
+	factory := commands.NewVotePollCommandFactory(s, repo)
+	c := factory.CreateRequest(msg, &commands.Users{UsersID: 1})
+
+	c.(onMessageCreateCommand).ExecuteMessageCreateCommand()
+
+	if len(repo.polls) != 0 || !strings.HasPrefix(s.message, "A poll needs") {
+		t.Error("Poll with one option should be rejected.")
+	}
+}
+
+func TestSingleChoiceVoteReplacesPrevious(t *testing.T) {
+	//Given: A single choice public poll
+	s := &mockSession{}
+	repo := &mockVotePollRepo{}
+	repo.SaveVotePoll(&commands.VotePoll{Message: 999, Options: []string{"a", "b"}, Deadline: time.Now().Add(time.Hour)})
+
+	//When: A user votes for option 1 then option 2
+	commands.NewAddVoteReact(repo, s, newVoteReaction(5, "1️⃣")).OnReactionAdd()
+	commands.NewAddVoteReact(repo, s, newVoteReaction(5, "2️⃣")).OnReactionAdd()
+
+	//Then: Only the last vote is kept
+	if len(repo.votes) != 1 || repo.votes[0].Option != 1 {
+		t.Error("Expected single vote for option 2. Got ", repo.votes)
+	}
+	//And: The old reaction is removed
+	if len(s.removedReactions) != 1 || s.removedReactions[0] != "1️⃣" {
+		t.Error("Previous reaction not removed ", s.removedReactions)
+	}
+}
+
+func TestMultiChoiceVotes(t *testing.T) {
+	s := &mockSession{}
+	repo := &mockVotePollRepo{}
+	repo.SaveVotePoll(&commands.VotePoll{Message: 999, Options: []string{"a", "b"}, MultiChoice: true, Deadline: time.Now().Add(time.Hour)})
+
+	commands.NewAddVoteReact(repo, s, newVoteReaction(5, "1️⃣")).OnReactionAdd()
+	commands.NewAddVoteReact(repo, s, newVoteReaction(5, "2️⃣")).OnReactionAdd()
+	commands.NewAddVoteReact(repo, s, newVoteReaction(5, "🤔")).OnReactionAdd()
+
+	if len(repo.votes) != 2 {
+		t.Error("Expected two votes. Got ", repo.votes)
+	}
+
+	remove := &disgord.MessageReactionRemove{UserID: 5, MessageID: 999, PartialEmoji: &disgord.Emoji{Name: "1️⃣"}}
+	commands.NewRemoveVoteReact(repo, s, remove).OnReactionRemove()
+
+	if len(repo.votes) != 1 || repo.votes[0].Option != 1 {
+		t.Error("Vote not removed with reaction. Got ", repo.votes)
+	}
+}
+
+func TestAnonymousVoteHidesReaction(t *testing.T) {
+	s := &mockSession{}
+	repo := &mockVotePollRepo{}
+	repo.SaveVotePoll(&commands.VotePoll{Message: 999, Options: []string{"a", "b"}, Anonymous: true, Deadline: time.Now().Add(time.Hour)})
+
+	commands.NewAddVoteReact(repo, s, newVoteReaction(5, "2️⃣")).OnReactionAdd()
+
+	if len(repo.votes) != 1 || len(s.removedReactions) != 1 {
+		t.Error("Anonymous vote not recorded and hidden.")
+	}
+
+	//Removing the reaction is done by the bot so it should not remove the vote
+	remove := &disgord.MessageReactionRemove{UserID: 5, MessageID: 999, PartialEmoji: &disgord.Emoji{Name: "2️⃣"}}
+	commands.NewRemoveVoteReact(repo, s, remove).OnReactionRemove()
+	if len(repo.votes) != 1 {
+		t.Error("Anonymous vote removed by reaction removal.")
+	}
+
+	//Reacting again takes the vote back
+	commands.NewAddVoteReact(repo, s, newVoteReaction(5, "2️⃣")).OnReactionAdd()
+	if len(repo.votes) != 0 {
+		t.Error("Anonymous vote not toggled off.")
+	}
+}
+
+func TestVotePollResultsAnnounced(t *testing.T) {
+	//Given: A poll that closed while the bot was offline
+	s := &mockSession{}
+	repo := &mockVotePollRepo{}
+	repo.SaveVotePoll(&commands.VotePoll{Message: 999, Channel: 10, Role: 7, Title: "Lunch", Options: []string{"pizza", "sushi"}, Deadline: time.Now().Add(-time.Minute)})
+	repo.AddVote(&commands.VotePollVote{VotePollID: 1, Option: 1, User: 5})
+	repo.AddVote(&commands.VotePollVote{VotePollID: 1, Option: 1, User: 6})
+	repo.AddVote(&commands.VotePollVote{VotePollID: 1, Option: 0, User: 8})
+
+	//When: Polls are restarted
+	commands.RestartVotePolls(s, repo)
+	time.Sleep(50 * time.Millisecond)
+
+	//Then: The results are announced and the poll is removed
+	if !strings.HasPrefix(s.message, "<@&7> Poll has closed. The top vote for Lunch is sushi with 2 votes.") {
+		t.Error("Results not announced. Got ", s.message)
+	}
+	if !strings.Contains(s.message, "2️⃣ sushi - 2 (<@5>, <@6>)") {
+		t.Error("Voters not listed. Got ", s.message)
+	}
+	if len(repo.polls) != 0 {
+		t.Error("Closed poll not removed.")
+	}
+}
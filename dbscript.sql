@@ -44,19 +44,41 @@ CREATE TABLE IF NOT EXISTS strawpoll_deadline(
     role BIG INTEGER,
     FOREIGN KEY(author) REFERENCES users(users_id));
 
+CREATE TABLE IF NOT EXISTS strawpoll_standings(
+    strawpoll_deadline_id INTEGER PRIMARY KEY,
+    update_interval INTEGER,
+    reminder INTEGER,
+    pinned BOOLEAN DEFAULT FALSE,
+    msg BIG INTEGER,
+    last_update INTEGER,
+    reminder_sent BOOLEAN DEFAULT FALSE,
+    FOREIGN KEY(strawpoll_deadline_id) REFERENCES strawpoll_deadline(strawpoll_deadline_id) ON DELETE CASCADE);
+
 CREATE TABLE IF NOT EXISTS tournament(
     tournament_id INTEGER PRIMARY KEY,
     author INTEGER,
     challonge_id TEXT,
-    discord_server_id BIG INTEGER UNIQUE,
+    discord_server_id BIG INTEGER,
+    name TEXT DEFAULT '',
+    game TEXT DEFAULT '',
+    check_in_message BIG INTEGER DEFAULT 0,
+    check_in_channel BIG INTEGER DEFAULT 0,
+    check_in_ends INTEGER DEFAULT 0,
+    check_in_reminded INTEGER DEFAULT 0,
+    channel_id BIG INTEGER DEFAULT 0,
+    announce_channel BIG INTEGER DEFAULT 0,
     current_match INTEGER,
-    FOREIGN KEY(author) REFERENCES users(users_id)
+    self_reporting INTEGER DEFAULT 0,
+    last_reported_match INTEGER DEFAULT 0,
+    FOREIGN KEY(author) REFERENCES users(users_id),
+    UNIQUE(discord_server_id, name)
 );
 
 CREATE TABLE IF NOT EXISTS tournament_participant(
     tournament_participant_id INTEGER PRIMARY KEY,
     name TEXT,
-    challonge_id INTEGER UNIQUE
+    challonge_id INTEGER UNIQUE,
+    discord_user_id BIG INTEGER DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS tournament_organizer_xref(
@@ -75,6 +97,92 @@ CREATE TABLE IF NOT EXISTS tournament_participant_xref(
     PRIMARY KEY(tournament_id, tournament_participant_id)
 );
 
+CREATE TABLE IF NOT EXISTS tournament_station(
+    tournament_id INTEGER,
+    name TEXT,
+    current_match INTEGER,
+    FOREIGN KEY(tournament_id) REFERENCES tournament(tournament_id) ON DELETE CASCADE,
+    PRIMARY KEY(tournament_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS tournament_match_report(
+    tournament_match_report_id INTEGER PRIMARY KEY,
+    tournament_id INTEGER,
+    guild BIG INTEGER,
+    match_id INTEGER,
+    reporter BIG INTEGER,
+    winner_id INTEGER,
+    player1_score INTEGER,
+    player2_score INTEGER,
+    channel BIG INTEGER,
+    msg BIG INTEGER,
+    disputed INTEGER DEFAULT 0,
+    FOREIGN KEY(tournament_id) REFERENCES tournament(tournament_id) ON DELETE CASCADE,
+    UNIQUE(tournament_id, match_id)
+);
+
+CREATE TABLE IF NOT EXISTS tournament_match_announcement(
+    tournament_id INTEGER,
+    match_id INTEGER,
+    event TEXT,
+    FOREIGN KEY(tournament_id) REFERENCES tournament(tournament_id) ON DELETE CASCADE,
+    PRIMARY KEY(tournament_id, match_id, event)
+);
+
+CREATE TABLE IF NOT EXISTS tournament_result(
+    tournament_result_id INTEGER PRIMARY KEY,
+    discord_server_id BIG INTEGER,
+    name TEXT,
+    challonge_id TEXT,
+    ended_at INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS tournament_result_placement(
+    tournament_result_id INTEGER,
+    name TEXT,
+    discord_user_id BIG INTEGER DEFAULT 0,
+    seed INTEGER,
+    final_rank INTEGER,
+    FOREIGN KEY(tournament_result_id) REFERENCES tournament_result(tournament_result_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS tournament_result_match(
+    tournament_result_id INTEGER,
+    match_id INTEGER,
+    round INTEGER,
+    player1_name TEXT,
+    player1_discord_id BIG INTEGER DEFAULT 0,
+    player2_name TEXT,
+    player2_discord_id BIG INTEGER DEFAULT 0,
+    winner INTEGER,
+    scores_csv TEXT,
+    FOREIGN KEY(tournament_result_id) REFERENCES tournament_result(tournament_result_id) ON DELETE CASCADE,
+    PRIMARY KEY(tournament_result_id, match_id)
+);
+
+CREATE TABLE IF NOT EXISTS player_rating(
+    player_rating_id INTEGER PRIMARY KEY,
+    discord_server_id BIG INTEGER,
+    game TEXT DEFAULT '',
+    discord_user_id BIG INTEGER DEFAULT 0,
+    name TEXT,
+    rating REAL,
+    wins INTEGER DEFAULT 0,
+    losses INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS rated_match(
+    discord_server_id BIG INTEGER,
+    challonge_id TEXT,
+    match_id INTEGER,
+    winner_rating_id INTEGER,
+    loser_rating_id INTEGER,
+    change REAL,
+    FOREIGN KEY(winner_rating_id) REFERENCES player_rating(player_rating_id),
+    FOREIGN KEY(loser_rating_id) REFERENCES player_rating(player_rating_id),
+    PRIMARY KEY(discord_server_id, challonge_id, match_id)
+);
+
 CREATE TABLE IF NOT EXISTS manga_notification(
     manga_notification_id INTEGER PRIMARY KEY,
     author INTEGER,
@@ -82,12 +190,15 @@ CREATE TABLE IF NOT EXISTS manga_notification(
     guild BIG INTEGER,
     channel BIG INTEGER,
     role BIG INTEGER,
+    discord_user BIG INTEGER DEFAULT 0,
     FOREIGN KEY(author) REFERENCES users(users_id)
 );
 
 CREATE TABLE IF NOT EXISTS manga_links(
     manga_link_id INTEGER PRIMARY KEY,
-    manga_link TEXT UNIQUE
+    manga_link TEXT UNIQUE,
+    last_chapter REAL DEFAULT 0,
+    last_chapter_url TEXT DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS manga_notification_links(
@@ -98,9 +209,92 @@ CREATE TABLE IF NOT EXISTS manga_notification_links(
     PRIMARY KEY(manga_notification_id, manga_link_id)
 );
 
+CREATE TABLE IF NOT EXISTS manga_search(
+    manga_search_id INTEGER PRIMARY KEY,
+    guild BIG INTEGER,
+    channel BIG INTEGER,
+    msg BIG INTEGER UNIQUE,
+    created INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS manga_search_result(
+    manga_search_id INTEGER,
+    position INTEGER,
+    title TEXT,
+    manga_link TEXT,
+    FOREIGN KEY(manga_search_id) REFERENCES manga_search(manga_search_id) ON DELETE CASCADE,
+    PRIMARY KEY(manga_search_id, position)
+);
+
 -- INSERT INTO manga_links(manga_link)
 -- SELECT DISTINCT manga_url from manga_notification;
 -- INSERT INTO manga_notification_links (manga_notification_id, manga_link_id)
 -- SELECT mn.manga_notification_id, ml.manga_link_id FROM manga_notification as mn
 -- JOIN  manga_links AS ml ON mn.manga_url = ml.manga_link;
--- ALTER TABLE manga_notification DROP COLUMgoN manga_url;
\ No newline at end of file
+-- ALTER TABLE manga_notification DROP COLUMgoN manga_url;
+CREATE TABLE IF NOT EXISTS vote_poll(
+    vote_poll_id INTEGER PRIMARY KEY,
+    author INTEGER,
+    guild BIG INTEGER,
+    channel BIG INTEGER,
+    msg BIG INTEGER UNIQUE,
+    role BIG INTEGER,
+    title TEXT,
+    multi_choice BOOLEAN DEFAULT FALSE,
+    anonymous BOOLEAN DEFAULT FALSE,
+    deadline INTEGER,
+    FOREIGN KEY(author) REFERENCES users(users_id)
+);
+
+CREATE TABLE IF NOT EXISTS vote_poll_option(
+    vote_poll_id INTEGER,
+    position INTEGER,
+    value TEXT,
+    FOREIGN KEY(vote_poll_id) REFERENCES vote_poll(vote_poll_id) ON DELETE CASCADE,
+    PRIMARY KEY(vote_poll_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS vote_poll_vote(
+    vote_poll_id INTEGER,
+    position INTEGER,
+    discord_users_id BIG INTEGER,
+    FOREIGN KEY(vote_poll_id) REFERENCES vote_poll(vote_poll_id) ON DELETE CASCADE,
+    PRIMARY KEY(vote_poll_id, position, discord_users_id)
+);
+
+-- Existing databases are upgraded by hand, run whatever is missing in order:
+-- ALTER TABLE tournament_participant ADD COLUMN discord_user_id BIG INTEGER DEFAULT 0;
+-- ALTER TABLE tournament ADD COLUMN self_reporting INTEGER DEFAULT 0;
+-- ALTER TABLE tournament ADD COLUMN last_reported_match INTEGER DEFAULT 0;
+-- tournament is rebuilt to add name and channel_id and make names unique per server instead of one tournament per server.
+-- Foreign keys are off so dropping the old table does not cascade to its participants, stations and reports.
+-- PRAGMA foreign_keys = OFF;
+-- BEGIN TRANSACTION;
+-- CREATE TABLE tournament_new(
+--     tournament_id INTEGER PRIMARY KEY,
+--     author INTEGER,
+--     challonge_id TEXT,
+--     discord_server_id BIG INTEGER,
+--     name TEXT DEFAULT '',
+--     channel_id BIG INTEGER DEFAULT 0,
+--     current_match INTEGER,
+--     self_reporting INTEGER DEFAULT 0,
+--     last_reported_match INTEGER DEFAULT 0,
+--     FOREIGN KEY(author) REFERENCES users(users_id),
+--     UNIQUE(discord_server_id, name)
+-- );
+-- INSERT INTO tournament_new (tournament_id, author, challonge_id, discord_server_id, current_match, self_reporting, last_reported_match)
+-- SELECT tournament_id, author, challonge_id, discord_server_id, current_match, self_reporting, last_reported_match FROM tournament;
+-- DROP TABLE tournament;
+-- ALTER TABLE tournament_new RENAME TO tournament;
+-- COMMIT;
+-- PRAGMA foreign_keys = ON;
+-- ALTER TABLE tournament ADD COLUMN announce_channel BIG INTEGER DEFAULT 0;
+-- ALTER TABLE tournament ADD COLUMN game TEXT DEFAULT '';
+-- ALTER TABLE tournament ADD COLUMN check_in_message BIG INTEGER DEFAULT 0;
+-- ALTER TABLE tournament ADD COLUMN check_in_channel BIG INTEGER DEFAULT 0;
+-- ALTER TABLE tournament ADD COLUMN check_in_ends INTEGER DEFAULT 0;
+-- ALTER TABLE tournament ADD COLUMN check_in_reminded INTEGER DEFAULT 0;
+-- ALTER TABLE manga_links ADD COLUMN last_chapter REAL DEFAULT 0;
+-- ALTER TABLE manga_links ADD COLUMN last_chapter_url TEXT DEFAULT '';
+-- ALTER TABLE manga_notification ADD COLUMN discord_user BIG INTEGER DEFAULT 0;
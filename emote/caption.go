@@ -0,0 +1,152 @@
+package emote
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	"github.com/disintegration/gift"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	fx "golang.org/x/image/math/fixed"
+)
+
+const (
+	//captionHeight - tallest a line of text is next to the image height
+	captionHeight = 0.2
+	//captionBlock - most of the image height one caption covers, however many lines it takes
+	captionBlock = 0.4
+	//captionWidth - widest a line of text is next to the image width
+	captionWidth = 0.94
+	//captionShrink - how much smaller the text is made each time it does not fit
+	captionShrink = 0.9
+	//minCaptionSize - smallest text is drawn at before it is scaled down instead
+	minCaptionSize = 8
+	//captionOutline - thickness of the black around the letters next to the text size
+	captionOutline = 0.06
+)
+
+//captionFont - Go Bold, bundled with x/image, covers Latin, Greek and Cyrillic
+var captionFont, _ = truetype.Parse(gobold.TTF)
+
+//Caption writes meme style text along the top and bottom of every frame. Either can be empty.
+func Caption(src []byte, top string, bottom string) ([]byte, string, error) {
+	a, err := decodeAnimation(src)
+	if err != nil {
+		return nil, "", err
+	}
+	bounds := a.frames[0].Bounds()
+	top, bottom = strings.ToUpper(strings.TrimSpace(top)), strings.ToUpper(strings.TrimSpace(bottom))
+
+	var texts []*image.NRGBA
+	var places []image.Point
+	if top != "" {
+		text := captionText(top, bounds)
+		texts = append(texts, text)
+		places = append(places, image.Pt((bounds.Dx()-text.Bounds().Dx())/2, captionMargin(bounds)))
+	}
+	if bottom != "" {
+		text := captionText(bottom, bounds)
+		texts = append(texts, text)
+		places = append(places, image.Pt((bounds.Dx()-text.Bounds().Dx())/2, bounds.Dy()-text.Bounds().Dy()-captionMargin(bounds)))
+	}
+
+	for _, frame := range a.frames {
+		for i, text := range texts {
+			gift.New().DrawAt(frame, text, places[i], gift.OverOperator)
+		}
+	}
+	return a.encodeToFit()
+}
+
+func captionMargin(bounds image.Rectangle) int {
+	return int(math.Ceil(float64(bounds.Dy()) / 40))
+}
+
+//captionText wraps the text onto as many lines as it needs, smaller until it fits the image
+func captionText(text string, bounds image.Rectangle) *image.NRGBA {
+	maxWidth := fx.I(int(float64(bounds.Dx()) * captionWidth))
+	maxHeight := int(float64(bounds.Dy()) * captionBlock)
+	size := float64(bounds.Dy()) * captionHeight
+	for {
+		face := truetype.NewFace(captionFont, &truetype.Options{Size: size, Hinting: font.HintingFull})
+		lines := wrapCaption(face, text, maxWidth)
+		fits := len(lines)*lineHeight(face) <= maxHeight
+		for _, line := range lines {
+			fits = fits && font.MeasureString(face, line) <= maxWidth
+		}
+		if fits || size*captionShrink < minCaptionSize {
+			return fitCaption(drawCaption(face, lines, size), bounds)
+		}
+		size *= captionShrink
+	}
+}
+
+//wrapCaption breaks the text between words, a word too long for a line is left on its own
+func wrapCaption(face font.Face, text string, maxWidth fx.Int26_6) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && font.MeasureString(face, line+" "+word) > maxWidth {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
+
+func lineHeight(face font.Face) int {
+	m := face.Metrics()
+	return (m.Ascent + m.Descent).Ceil()
+}
+
+//drawCaption draws centered white lines with a black outline
+func drawCaption(face font.Face, lines []string, size float64) *image.NRGBA {
+	outline := int(math.Max(1, math.Round(size*captionOutline)))
+	width := 0
+	for _, line := range lines {
+		width = int(math.Max(float64(width), float64(font.MeasureString(face, line).Ceil())))
+	}
+	height := lineHeight(face)
+	bounds := image.Rect(0, 0, width+2*outline, height*len(lines)+2*outline)
+
+	//The letters are drawn once and stamped around for the outline
+	mask := image.NewAlpha(bounds)
+	d := &font.Drawer{Dst: mask, Face: face, Src: image.Opaque}
+	for i, line := range lines {
+		x := outline + (width-font.MeasureString(face, line).Ceil())/2
+		d.Dot = fx.P(x, outline+i*height+face.Metrics().Ascent.Ceil())
+		d.DrawString(line)
+	}
+	dst := image.NewNRGBA(bounds)
+	black := image.NewUniform(color.Black)
+	for dy := -outline; dy <= outline; dy++ {
+		for dx := -outline; dx <= outline; dx++ {
+			if dx*dx+dy*dy <= outline*outline {
+				draw.DrawMask(dst, bounds.Add(image.Pt(dx, dy)), black, image.Point{}, mask, image.Point{}, draw.Over)
+			}
+		}
+	}
+	draw.DrawMask(dst, bounds, image.White, image.Point{}, mask, image.Point{}, draw.Over)
+	return dst
+}
+
+//fitCaption scales down text that is still too big at the smallest size
+func fitCaption(text *image.NRGBA, bounds image.Rectangle) *image.NRGBA {
+	size := text.Bounds().Size()
+	scale := math.Min(float64(bounds.Dx())*captionWidth/float64(size.X), float64(bounds.Dy())*captionBlock/float64(size.Y))
+	if scale >= 1 {
+		return text
+	}
+	g := gift.New(gift.Resize(int(math.Max(1, float64(size.X)*scale)), int(math.Max(1, float64(size.Y)*scale)), gift.LinearResampling))
+	dst := image.NewNRGBA(g.Bounds(text.Bounds()))
+	g.Draw(dst, text)
+	return dst
+}
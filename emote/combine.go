@@ -0,0 +1,147 @@
+package emote
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/disintegration/gift"
+)
+
+const (
+	LayoutSide    = "side"
+	LayoutStack   = "stack"
+	LayoutOverlay = "overlay"
+	MaxCombined   = 4
+)
+
+//Layouts - how Combine can place images
+var Layouts = []string{LayoutSide, LayoutStack, LayoutOverlay}
+
+type placement struct {
+	at   image.Point
+	size image.Point
+}
+
+//Combine puts images side by side, stacked, or each drawn over the first, made the same height or width.
+//Animated images keep playing, shorter ones looping, and set the timing by the one with the most frames.
+//Long results skip frames and big ones are made smaller until they fit in an upload.
+func Combine(layout string, srcs ...[]byte) ([]byte, string, error) {
+	if len(srcs) < 2 || len(srcs) > MaxCombined {
+		return nil, "", fmt.Errorf("combine takes 2 to %d images, got %d", MaxCombined, len(srcs))
+	}
+	//Every frame of every image is held at once, so they share one limit
+	total := 0
+	for _, src := range srcs {
+		pixels, err := decodedPixels(src)
+		if err != nil {
+			return nil, "", err
+		}
+		total += pixels
+	}
+	if total > maxAnimationPixels {
+		return nil, "", ErrImageTooBig
+	}
+	var as []*animation
+	for _, src := range srcs {
+		a, err := decodeAnimation(src)
+		if err != nil {
+			return nil, "", err
+		}
+		as = append(as, a)
+	}
+
+	var canvas image.Rectangle
+	var places []placement
+	switch layout {
+	case LayoutSide, LayoutStack:
+		canvas, places = lineUp(as, layout == LayoutStack)
+	case LayoutOverlay:
+		canvas, places = overlay(as)
+	default:
+		return nil, "", fmt.Errorf("combine goes %s, %s or %s, got %s", LayoutSide, LayoutStack, LayoutOverlay, layout)
+	}
+
+	result := &animation{}
+	clock := as[0]
+	for _, a := range as {
+		if len(a.frames) > len(clock.frames) || (!clock.animated() && a.animated()) {
+			clock = a
+		}
+	}
+	filters := make([]*gift.GIFT, len(as))
+	for i := range as {
+		filters[i] = gift.New(gift.Resize(places[i].size.X, places[i].size.Y, gift.LinearResampling))
+	}
+	//Frames are skipped past maxFrames or when the result would not fit in the limit, the ones kept shown for as long as those skipped
+	frames := float64(len(clock.frames))
+	step := int(math.Max(math.Ceil(frames/maxFrames), math.Ceil(frames*float64(canvas.Dx()*canvas.Dy())/maxAnimationPixels)))
+	for f := 0; f < len(clock.frames); f += step {
+		dst := image.NewNRGBA(canvas)
+		for i, a := range as {
+			filters[i].DrawAt(dst, a.frame(f), places[i].at, gift.OverOperator)
+		}
+		result.frames = append(result.frames, dst)
+		if clock.animated() {
+			delay := 0
+			for d := f; d < f+step && d < len(clock.delays); d++ {
+				delay += clock.delays[d]
+			}
+			result.delays = append(result.delays, delay)
+		}
+	}
+	return result.encodeToFit()
+}
+
+//lineUp makes every image as tall as the tallest, or as wide as the widest when stacked, shrinking the lot to fit MaxSize
+func lineUp(as []*animation, stacked bool) (image.Rectangle, []placement) {
+	across := func(p image.Point) (int, int) {
+		if stacked {
+			return p.Y, p.X
+		}
+		return p.X, p.Y
+	}
+	point := func(along int, side int) image.Point {
+		if stacked {
+			return image.Pt(side, along)
+		}
+		return image.Pt(along, side)
+	}
+
+	side := 0
+	for _, a := range as {
+		_, s := across(a.frames[0].Bounds().Size())
+		side = int(math.Max(float64(side), float64(s)))
+	}
+	var lengths []float64
+	total := 0.0
+	for _, a := range as {
+		l, s := across(a.frames[0].Bounds().Size())
+		lengths = append(lengths, float64(l)*float64(side)/float64(s))
+		total += lengths[len(lengths)-1]
+	}
+	shrink := math.Min(1, MaxSize/math.Max(total, float64(side)))
+
+	var places []placement
+	along := 0
+	height := int(math.Max(1, math.Round(float64(side)*shrink)))
+	for _, l := range lengths {
+		length := int(math.Max(1, math.Round(l*shrink)))
+		places = append(places, placement{at: point(along, 0), size: point(length, height)})
+		along += length
+	}
+	return image.Rectangle{Max: point(along, height)}, places
+}
+
+//overlay draws the others over the first, centred and shrunk to fit inside it
+func overlay(as []*animation) (image.Rectangle, []placement) {
+	canvas := as[0].frames[0].Bounds()
+	places := []placement{{size: canvas.Size()}}
+	for _, a := range as[1:] {
+		size := a.frames[0].Bounds().Size()
+		scale := math.Min(float64(canvas.Dx())/float64(size.X), float64(canvas.Dy())/float64(size.Y))
+		fit := image.Pt(int(math.Max(1, math.Round(float64(size.X)*scale))), int(math.Max(1, math.Round(float64(size.Y)*scale))))
+		places = append(places, placement{at: image.Pt((canvas.Dx()-fit.X)/2, (canvas.Dy()-fit.Y)/2), size: fit})
+	}
+	return canvas, places
+}
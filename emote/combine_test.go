@@ -0,0 +1,221 @@
+package emote_test
+
+import (
+	"bytes"
+	"discordbot/emote"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func solidPNG(t *testing.T, width int, height int, c color.Color) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return encodePNG(t, img)
+}
+
+//blinkingGIF - a 4x4 gif switching between black and white
+func blinkingGIF(t *testing.T, frames int) []byte {
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+		for p := range frame.Pix {
+			frame.Pix[p] = uint8(i % 2)
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 7)
+	}
+	buff := new(bytes.Buffer)
+	if err := gif.EncodeAll(buff, g); err != nil {
+		t.Fatal(err)
+	}
+	return buff.Bytes()
+}
+
+//checkGolden compares against testdata, or rewrites it with -update
+func checkGolden(t *testing.T, name string, b []byte) {
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		if err := ioutil.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := png.Decode(bytes.NewReader(expected))
+	got, _ := png.Decode(bytes.NewReader(b))
+	if !sameImage(got, want) {
+		t.Error("Result does not match ", path)
+	}
+}
+
+func TestCombineLayouts(t *testing.T) {
+	red := solidPNG(t, 8, 8, color.NRGBA{R: 255, A: 255})
+	blue := solidPNG(t, 4, 2, color.NRGBA{B: 255, A: 255})
+	inputs := []struct {
+		layout string
+		width  int
+		height int
+		//A point on the second image
+		second image.Point
+	}{
+		{emote.LayoutSide, 24, 8, image.Pt(20, 4)},
+		{emote.LayoutStack, 8, 12, image.Pt(4, 10)},
+		{emote.LayoutOverlay, 8, 8, image.Pt(4, 4)},
+	}
+	for _, input := range inputs {
+		b, extension, err := emote.Combine(input.layout, red, blue)
+		if err != nil || extension != emote.ExtensionPNG {
+			t.Fatal(input.layout, ": ", err, " ", extension)
+		}
+		img, _ := png.Decode(bytes.NewReader(b))
+		if img.Bounds().Dx() != input.width || img.Bounds().Dy() != input.height {
+			t.Error(input.layout, ": unexpected size ", img.Bounds())
+		}
+		if _, _, b, _ := img.At(input.second.X, input.second.Y).RGBA(); b>>8 != 255 {
+			t.Error(input.layout, ": second image not drawn at ", input.second)
+		}
+		if r, _, _, _ := img.At(1, 1).RGBA(); r>>8 != 255 {
+			t.Error(input.layout, ": first image not drawn")
+		}
+	}
+
+	//Overlays keep the shape of what is drawn over the first
+	b, _, _ := emote.Combine(emote.LayoutOverlay, red, blue)
+	img, _ := png.Decode(bytes.NewReader(b))
+	if _, _, b, _ := img.At(4, 1).RGBA(); b>>8 == 255 {
+		t.Error("Overlay stretched")
+	}
+}
+
+func TestCombineAnimated(t *testing.T) {
+	red := solidPNG(t, 4, 4, color.NRGBA{R: 255, A: 255})
+
+	b, extension, err := emote.Combine(emote.LayoutSide, red, blinkingGIF(t, 2), blinkingGIF(t, 3))
+	if err != nil || extension != emote.ExtensionGIF {
+		t.Fatal(err, " ", extension)
+	}
+	anim, _ := gif.DecodeAll(bytes.NewReader(b))
+	if len(anim.Image) != 3 || anim.Delay[0] != 7 || anim.Config.Width != 12 {
+		t.Fatal("Unexpected animation ", len(anim.Image), " ", anim.Delay, " ", anim.Config.Width)
+	}
+	//The two frame gif loops while the three frame one plays through
+	if r, _, _, _ := anim.Image[2].At(5, 1).RGBA(); r != 0 {
+		t.Error("Shorter animation not looped")
+	}
+	if r, _, _, _ := anim.Image[2].At(9, 1).RGBA(); r != 0 {
+		t.Error("Unexpected last frame")
+	}
+	if r, _, _, _ := anim.Image[1].At(9, 1).RGBA(); r>>8 != 255 {
+		t.Error("Unexpected second frame")
+	}
+}
+
+func TestCombineLimits(t *testing.T) {
+	red := solidPNG(t, 4, 4, color.NRGBA{R: 255, A: 255})
+
+	//Long animations keep every third frame, shown three times as long
+	b, _, err := emote.Combine(emote.LayoutSide, red, blinkingGIF(t, 300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	anim, _ := gif.DecodeAll(bytes.NewReader(b))
+	if len(anim.Image) != 100 || anim.Delay[0] != 21 {
+		t.Error("Frames not skipped ", len(anim.Image), " ", anim.Delay[0])
+	}
+
+	//Every image together is held to one limit, even when each is small enough alone
+	wide := blinkingGIF(t, 10)
+	wide[6], wide[7], wide[8], wide[9] = 0xd0, 0x07, 0xd0, 0x07
+	if _, _, err := emote.Combine(emote.LayoutSide, wide, wide); err != emote.ErrImageTooBig {
+		t.Error("Unexpected error ", err)
+	}
+}
+
+func TestCombineErrors(t *testing.T) {
+	red := solidPNG(t, 4, 4, color.NRGBA{R: 255, A: 255})
+	if _, _, err := emote.Combine(emote.LayoutSide, red); err == nil || err.Error() != "combine takes 2 to 4 images, got 1" {
+		t.Error("Unexpected error ", err)
+	}
+	if _, _, err := emote.Combine("diagonal", red, red); err == nil || err.Error() != "combine goes side, stack or overlay, got diagonal" {
+		t.Error("Unexpected error ", err)
+	}
+}
+
+func TestCaption(t *testing.T) {
+	p, _ := emote.Parse("resize(64x64)")
+	src, _, _ := p.Render(encodePNG(t, sourceEmoji()))
+
+	b, extension, err := emote.Caption(src, "top text", "bottom")
+	if err != nil || extension != emote.ExtensionPNG {
+		t.Fatal(err, " ", extension)
+	}
+	checkGolden(t, "caption", b)
+
+	//Long text wraps onto more lines
+	b, _, err = emote.Caption(src, "", "a caption far too long to fit at full size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "caption_long", b)
+}
+
+func TestCaptionFitsUpload(t *testing.T) {
+	src := noiseGIF(t, 37, 480)
+	if len(src) <= emote.MaxUploadSize {
+		t.Fatal("Source already fits ", len(src))
+	}
+
+	b, extension, err := emote.Caption(src, "top text", "")
+	if err != nil || extension != emote.ExtensionGIF {
+		t.Fatal(err, " ", extension)
+	}
+	if len(b) > emote.MaxUploadSize {
+		t.Error("Too big to upload ", len(b))
+	}
+}
+
+func TestCaptionNonASCII(t *testing.T) {
+	p, _ := emote.Parse("resize(64x64)")
+	src, _, _ := p.Render(encodePNG(t, sourceEmoji()))
+
+	b, _, err := emote.Caption(src, "ЖАРА", "ÉTÉ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "caption_unicode", b)
+
+	//Letters the font has are drawn as themselves rather than as the same missing glyph box
+	other, _, _ := emote.Caption(src, "ЯЯЯЯ", "ÉTÉ")
+	got, _ := png.Decode(bytes.NewReader(b))
+	want, _ := png.Decode(bytes.NewReader(other))
+	if sameImage(got, want) {
+		t.Error("Cyrillic letters not drawn")
+	}
+}
+
+func TestCaptionAnimated(t *testing.T) {
+	b, extension, err := emote.Caption(blinkingGIF(t, 2), "hi", "")
+	if err != nil || extension != emote.ExtensionGIF {
+		t.Fatal(err, " ", extension)
+	}
+	anim, _ := gif.DecodeAll(bytes.NewReader(b))
+	if len(anim.Image) != 2 {
+		t.Fatal("Frames lost ", len(anim.Image))
+	}
+	if nearImage(anim.Image[0], anim.Image[1], 0) {
+		t.Error("Frames are the same")
+	}
+}
@@ -0,0 +1,276 @@
+package emote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"math"
+	"strings"
+
+	"github.com/disintegration/gift"
+)
+
+const (
+	defaultFPS      = 20
+	defaultDuration = 1.0
+	maxFrames       = 120
+	//MaxUploadSize - Discord refuses files bigger than this without boosts
+	MaxUploadSize = 8 << 20
+	//shrinkStep - how much smaller an animation is made each time it is too big to upload
+	shrinkStep = 0.75
+)
+
+var ErrTooLarge = errors.New("the animation is too big to upload, try a shorter duration or a lower fps")
+
+//effect draws one frame of an animation at t, from 0 at the start to just under 1 at the end
+type effect func(src image.Image, t float64) *image.NRGBA
+
+var effects = map[string]filterSpec{
+	"spin":    effectFilter("spin(turns)", numberParam{def: 1, min: -10, max: 10}, spin),
+	"shake":   effectFilter("shake(pixels)", numberParam{def: 4, min: 1, max: 64, suffix: "px"}, shake),
+	"pulse":   effectFilter("pulse(scale)", numberParam{def: 1.2, min: 0.1, max: 4, suffix: "x"}, pulse),
+	"zoom":    effectFilter("zoom(scale)", numberParam{def: 2, min: 0.1, max: 8, suffix: "x"}, zoom),
+	"rainbow": effectFilter("rainbow(cycles)", numberParam{def: 1, min: -10, max: 10}, rainbow),
+	"party":   effectFilter("party(flashes)", numberParam{def: 8, min: 1, max: 30}, party),
+	"slide":   {usage: "slide(left|right|up|down)", build: buildSlide},
+	"fps": {usage: "fps(frames)", build: func(p *Pipeline, name string, args []string) error {
+		n, err := numberArgs(name, args, numberParam{def: defaultFPS, min: 1, max: 50})
+		if err != nil {
+			return err
+		}
+		p.fps = n[0]
+		return nil
+	}},
+	"duration": {usage: "duration(seconds)", build: func(p *Pipeline, name string, args []string) error {
+		n, err := numberArgs(name, args, numberParam{def: defaultDuration, min: 0.1, max: 10, suffix: "s"})
+		if err != nil {
+			return err
+		}
+		p.duration = n[0]
+		return nil
+	}},
+}
+
+func effectFilter(usage string, param numberParam, build func(float64) effect) filterSpec {
+	return filterSpec{usage: usage, build: func(p *Pipeline, name string, args []string) error {
+		n, err := numberArgs(name, args, param)
+		if err != nil {
+			return err
+		}
+		p.effects = append(p.effects, build(n[0]))
+		return nil
+	}}
+}
+
+//Animated says whether the pipeline turns a still image into a gif
+func (p *Pipeline) Animated() bool {
+	return len(p.effects) > 0
+}
+
+//frameCount is the frames and delay between them in hundredths of a second
+func (p *Pipeline) frameCount() (int, int) {
+	fps, duration := float64(defaultFPS), defaultDuration
+	if p.fps != 0 {
+		fps = p.fps
+	}
+	if p.duration != 0 {
+		duration = p.duration
+	}
+	frames := int(math.Min(maxFrames, math.Max(1, math.Round(fps*duration))))
+	return frames, p.frameDelay(int(math.Round(100 / fps)))
+}
+
+//renderEffects animates the filtered image, only using the first frame of a gif.
+//It is made smaller until it fits in an upload.
+func (p *Pipeline) renderEffects(src []byte) ([]byte, error) {
+	if _, err := checkPixels(src); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	g := p.gift(img)
+	base := image.NewNRGBA(g.Bounds(img.Bounds()))
+	g.Draw(base, img)
+
+	for size := 1.0; ; size *= shrinkStep {
+		frame := image.Image(base)
+		if size < 1 {
+			frame = resized(base, size)
+			if frame.Bounds().Dx() < 16 {
+				return nil, ErrTooLarge
+			}
+		}
+		b, err := p.encodeAnimation(frame)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) <= MaxUploadSize {
+			return b, nil
+		}
+	}
+}
+
+func resized(img image.Image, scale float64) image.Image {
+	g := gift.New(gift.Resize(int(float64(img.Bounds().Dx())*scale), 0, gift.BoxResampling))
+	dst := image.NewNRGBA(g.Bounds(img.Bounds()))
+	g.Draw(dst, img)
+	return dst
+}
+
+func (p *Pipeline) encodeAnimation(base image.Image) ([]byte, error) {
+	frames, delay := p.frameCount()
+	a := &animation{delays: make([]int, frames)}
+	for i := 0; i < frames; i++ {
+		t := float64(i) / float64(frames)
+		frame := base
+		var drawn *image.NRGBA
+		for _, e := range p.effects {
+			drawn = e(frame, t)
+			frame = drawn
+		}
+		a.frames = append(a.frames, drawn)
+		a.delays[i] = delay
+	}
+	b, _, err := a.encode()
+	return b, err
+}
+
+//quantizer maps colours onto the plan9 palette with a transparent first entry, remembering the colours it has seen
+type quantizer struct {
+	palette color.Palette
+	seen    map[color.RGBA]uint8
+}
+
+func newQuantizer() *quantizer {
+	//A pale yellow makes room for transparency
+	p := color.Palette{color.Transparent}
+	p = append(p, palette.Plan9[:254]...)
+	p = append(p, palette.Plan9[255])
+	return &quantizer{palette: p, seen: make(map[color.RGBA]uint8)}
+}
+
+func (q *quantizer) paletted(img image.Image) *image.Paletted {
+	b := img.Bounds()
+	dst := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), q.palette)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if c.A < 0x80 {
+				continue
+			}
+			//Partly see-through edges are made solid against black
+			c.A = 0xff
+			i, ok := q.seen[c]
+			if !ok {
+				i = uint8(q.palette[1:].Index(c) + 1)
+				q.seen[c] = i
+			}
+			dst.SetColorIndex(x-b.Min.X, y-b.Min.Y, i)
+		}
+	}
+	return dst
+}
+
+//onCanvas draws the filtered image centred and moved by offset on a canvas the size of src, cutting off what falls outside
+func onCanvas(src image.Image, g *gift.GIFT, offset image.Point) *image.NRGBA {
+	dst := image.NewNRGBA(src.Bounds())
+	size := g.Bounds(src.Bounds())
+	at := src.Bounds().Min.Add(image.Pt((src.Bounds().Dx()-size.Dx())/2, (src.Bounds().Dy()-size.Dy())/2)).Add(offset)
+	g.DrawAt(dst, src, at, gift.OverOperator)
+	return dst
+}
+
+func spin(turns float64) effect {
+	return func(src image.Image, t float64) *image.NRGBA {
+		return onCanvas(src, gift.New(gift.Rotate(float32(-360*turns*t), color.Transparent, gift.LinearInterpolation)), image.Point{})
+	}
+}
+
+//shake moves the image around a path that comes back to where it started
+func shake(pixels float64) effect {
+	return func(src image.Image, t float64) *image.NRGBA {
+		offset := image.Pt(int(math.Round(pixels*math.Sin(2*math.Pi*3*t))), int(math.Round(pixels*math.Sin(2*math.Pi*5*t))))
+		return onCanvas(src, gift.New(), offset)
+	}
+}
+
+func scaled(src image.Image, factor float64) *image.NRGBA {
+	width := int(math.Max(1, math.Round(float64(src.Bounds().Dx())*factor)))
+	height := int(math.Max(1, math.Round(float64(src.Bounds().Dy())*factor)))
+	return onCanvas(src, gift.New(gift.Resize(width, height, gift.LinearResampling)), image.Point{})
+}
+
+//pulse grows to scale and back
+func pulse(scale float64) effect {
+	return func(src image.Image, t float64) *image.NRGBA {
+		return scaled(src, 1+(scale-1)*(1-math.Cos(2*math.Pi*t))/2)
+	}
+}
+
+//zoom grows steadily to scale
+func zoom(scale float64) effect {
+	return func(src image.Image, t float64) *image.NRGBA {
+		return scaled(src, 1+(scale-1)*t)
+	}
+}
+
+func rainbow(cycles float64) effect {
+	return func(src image.Image, t float64) *image.NRGBA {
+		shift := math.Mod(360*cycles*t, 360)
+		if shift > 180 {
+			shift -= 360
+		} else if shift < -180 {
+			shift += 360
+		}
+		return onCanvas(src, gift.New(gift.Hue(float32(shift))), image.Point{})
+	}
+}
+
+//partyColours - hues flashed through in order
+var partyColours = []float32{0, 30, 60, 120, 180, 240, 280, 320}
+
+func party(flashes float64) effect {
+	return func(src image.Image, t float64) *image.NRGBA {
+		hue := partyColours[int(t*flashes)%len(partyColours)]
+		return onCanvas(src, gift.New(gift.Colorize(hue, 100, 60)), image.Point{})
+	}
+}
+
+//slide moves the image across, coming back in from the other side
+func buildSlide(p *Pipeline, name string, args []string) error {
+	direction := "right"
+	if len(args) == 1 {
+		direction = strings.ToLower(args[0])
+	}
+	var dx, dy int
+	switch {
+	case len(args) > 1:
+		return fmt.Errorf("%s takes at most 1 argument(s), got %d", name, len(args))
+	case direction == "right":
+		dx = 1
+	case direction == "left":
+		dx = -1
+	case direction == "down":
+		dy = 1
+	case direction == "up":
+		dy = -1
+	default:
+		return fmt.Errorf("%s goes left, right, up or down, got %s", name, args[0])
+	}
+
+	p.effects = append(p.effects, func(src image.Image, t float64) *image.NRGBA {
+		b := src.Bounds()
+		offset := image.Pt(int(float64(dx*b.Dx())*t), int(float64(dy*b.Dy())*t))
+		dst := image.NewNRGBA(b)
+		draw.Draw(dst, b.Add(offset), src, b.Min, draw.Over)
+		draw.Draw(dst, b.Add(offset).Sub(image.Pt(dx*b.Dx(), dy*b.Dy())), src, b.Min, draw.Over)
+		return dst
+	})
+	return nil
+}
@@ -0,0 +1,129 @@
+package emote_test
+
+import (
+	"bytes"
+	"discordbot/emote"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+)
+
+func renderAnimation(t *testing.T, expr string) *gif.GIF {
+	p, err := emote.Parse(expr)
+	if err != nil {
+		t.Fatal(expr, ": ", err)
+	}
+	if !p.Animated() {
+		t.Fatal(expr, " not animated")
+	}
+	b, extension, err := p.Render(encodePNG(t, sourceEmoji()))
+	if err != nil || extension != emote.ExtensionGIF {
+		t.Fatal(expr, ": ", err, " ", extension)
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return anim
+}
+
+func framesDiffer(anim *gif.GIF) bool {
+	for _, frame := range anim.Image[1:] {
+		if !sameImage(frame, anim.Image[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEffects(t *testing.T) {
+	for _, expr := range []string{"spin", "spin(-2)", "shake(3px)", "pulse(1.5x)", "zoom", "rainbow", "party(4)", "slide(up)", "spin rainbow"} {
+		anim := renderAnimation(t, expr)
+
+		if len(anim.Image) != 20 || anim.Delay[0] != 5 {
+			t.Error(expr, ": unexpected frames ", len(anim.Image), " ", anim.Delay[0])
+		}
+		if anim.Config.Width != 48 || anim.Config.Height != 32 {
+			t.Error(expr, ": unexpected size ", anim.Config.Width, "x", anim.Config.Height)
+		}
+		if !framesDiffer(anim) {
+			t.Error(expr, ": frames do not move")
+		}
+	}
+}
+
+func TestEffectStartsFromFilteredImage(t *testing.T) {
+	//Given: The image the filters make without an effect
+	p, _ := emote.Parse("mirror")
+	b, _, _ := p.Render(encodePNG(t, sourceEmoji()))
+	still, _ := png.Decode(bytes.NewReader(b))
+
+	//When: It spins
+	anim := renderAnimation(t, "mirror spin")
+
+	//Then: The first frame has not turned yet and keeps the transparent corner, give or take the gif's colours
+	if !nearImage(anim.Image[0], still, 48) {
+		t.Error("First frame does not match the filtered image")
+	}
+	if _, _, _, a := anim.Image[0].At(47, 0).RGBA(); a != 0 {
+		t.Error("Transparency lost")
+	}
+}
+
+func TestEffectTiming(t *testing.T) {
+	inputs := []struct {
+		expr   string
+		frames int
+		delay  int
+	}{
+		{"spin fps(10) duration(2s)", 20, 10},
+		{"spin fps(50) duration(10)", 120, 2},
+		{"spin duration(0.5s) speed(2x)", 10, 3},
+		{"spin speed(4x)", 20, 2},
+	}
+	for _, input := range inputs {
+		anim := renderAnimation(t, input.expr)
+		if len(anim.Image) != input.frames || anim.Delay[len(anim.Delay)-1] != input.delay {
+			t.Error(input.expr, ": unexpected frames ", len(anim.Image), " ", anim.Delay[0])
+		}
+	}
+}
+
+func TestEffectErrors(t *testing.T) {
+	inputs := []struct {
+		expr     string
+		expected string
+	}{
+		{"spin fps(60)", "fps takes a number from 1 to 50, got 60"},
+		{"spin duration(1m)", "duration takes a number from 0.1 to 10, got 1m"},
+		{"slide(diagonal)", "slide goes left, right, up or down, got diagonal"},
+		{"pulse(10x)", "pulse takes a number from 0.1 to 4, got 10x"},
+	}
+	for _, input := range inputs {
+		_, err := emote.Parse(input.expr)
+		if err == nil || err.Error() != input.expected {
+			t.Error("Unexpected error for ", input.expr, ": ", err)
+		}
+	}
+}
+
+func TestEffectUsesFirstGifFrame(t *testing.T) {
+	src := &gif.GIF{Image: []*image.Paletted{
+		image.NewPaletted(image.Rect(0, 0, 4, 4), []color.Color{color.White}),
+		image.NewPaletted(image.Rect(0, 0, 4, 4), []color.Color{color.Black}),
+	}, Delay: []int{10, 10}}
+	buff := new(bytes.Buffer)
+	gif.EncodeAll(buff, src)
+
+	p, _ := emote.Parse("pulse")
+	b, _, err := p.Render(buff.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	anim, _ := gif.DecodeAll(bytes.NewReader(b))
+	if r, _, _, _ := anim.Image[0].At(4, 4).RGBA(); r>>8 != 0xff {
+		t.Error("First frame not used ", anim.Image[0].At(4, 4))
+	}
+}
@@ -0,0 +1,72 @@
+package emote
+
+import (
+	"errors"
+	"image"
+
+	"github.com/disintegration/gift"
+)
+
+const (
+	//EmojiSize - Discord shows custom emoji no bigger than this, anything larger only costs upload size
+	EmojiSize = 128
+	//MaxEmojiUpload - Discord refuses emoji files bigger than this
+	MaxEmojiUpload = 256 << 10
+	//minEmojiSize - smallest an emoji is shrunk to before frames are dropped instead
+	minEmojiSize = 48
+)
+
+var ErrTooLargeForEmoji = errors.New("the image is too big for an emoji even when shrunk, try a shorter animation")
+
+//FitEmoji makes an image small enough to upload as a custom emoji.
+//It is scaled to EmojiSize, then smaller, then animations lose every other frame until it fits.
+func FitEmoji(src []byte) ([]byte, string, error) {
+	a, err := decodeAnimation(src)
+	if err != nil {
+		return nil, "", err
+	}
+	size := EmojiSize
+	for {
+		b, extension, err := a.fit(size).encode()
+		if err != nil {
+			return nil, "", err
+		}
+		if len(b) <= MaxEmojiUpload {
+			return b, extension, nil
+		}
+		switch {
+		case size > minEmojiSize:
+			size = int(float64(size) * shrinkStep)
+		case len(a.frames) > 1:
+			a = a.halved()
+		default:
+			return nil, "", ErrTooLargeForEmoji
+		}
+	}
+}
+
+//fit shrinks every frame to fit in a square of size, smaller images are left alone
+func (a *animation) fit(size int) *animation {
+	g := gift.New(gift.ResizeToFit(size, size, gift.LanczosResampling))
+	fitted := &animation{delays: a.delays}
+	for _, frame := range a.frames {
+		dst := image.NewNRGBA(g.Bounds(frame.Bounds()))
+		g.Draw(dst, frame)
+		fitted.frames = append(fitted.frames, dst)
+	}
+	return fitted
+}
+
+//halved drops every other frame, showing the ones kept for as long as both were
+func (a *animation) halved() *animation {
+	h := &animation{}
+	for i := 0; i < len(a.frames); i += 2 {
+		h.frames = append(h.frames, a.frames[i])
+		delay := a.delays[i]
+		if i+1 < len(a.delays) {
+			delay += a.delays[i+1]
+		}
+		h.delays = append(h.delays, delay)
+	}
+	return h
+}
@@ -0,0 +1,70 @@
+package emote_test
+
+import (
+	"bytes"
+	"discordbot/emote"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math/rand"
+	"testing"
+)
+
+//noiseGIF - random pixels, which gif compression can do nothing with
+func noiseGIF(t *testing.T, frames int, size int) []byte {
+	r := rand.New(rand.NewSource(1))
+	palette := make(color.Palette, 256)
+	for c := range palette {
+		palette[c] = color.RGBA{R: uint8(c), G: uint8(c * 7), B: uint8(c * 13), A: 255}
+	}
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+		r.Read(frame.Pix)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 5)
+	}
+	buff := new(bytes.Buffer)
+	if err := gif.EncodeAll(buff, g); err != nil {
+		t.Fatal(err)
+	}
+	return buff.Bytes()
+}
+
+func TestFitEmojiStill(t *testing.T) {
+	b, extension, err := emote.FitEmoji(solidPNG(t, 300, 200, color.NRGBA{R: 255, A: 255}))
+	if err != nil || extension != emote.ExtensionPNG {
+		t.Fatal(err, " ", extension)
+	}
+	img, _ := png.Decode(bytes.NewReader(b))
+	if img.Bounds().Dx() != emote.EmojiSize || img.Bounds().Dy() != 85 {
+		t.Error("Unexpected size ", img.Bounds())
+	}
+
+	//Small images are not made bigger
+	b, _, _ = emote.FitEmoji(solidPNG(t, 32, 32, color.NRGBA{B: 255, A: 255}))
+	img, _ = png.Decode(bytes.NewReader(b))
+	if img.Bounds().Dx() != 32 {
+		t.Error("Small image resized to ", img.Bounds())
+	}
+}
+
+func TestFitEmojiAnimated(t *testing.T) {
+	//Noise does not compress, so this is far over the limit at any size worth showing
+	b, extension, err := emote.FitEmoji(noiseGIF(t, 40, 256))
+	if err != nil || extension != emote.ExtensionGIF {
+		t.Fatal(err, " ", extension)
+	}
+	if len(b) > emote.MaxEmojiUpload {
+		t.Error("Too big for an emoji ", len(b))
+	}
+	fitted, _ := gif.DecodeAll(bytes.NewReader(b))
+	total := 0
+	for _, d := range fitted.Delay {
+		total += d
+	}
+	if total != 200 || fitted.Config.Width > emote.EmojiSize {
+		t.Error("Unexpected animation ", len(fitted.Image), " frames lasting ", total, " at ", fitted.Config.Width)
+	}
+}
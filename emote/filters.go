@@ -0,0 +1,355 @@
+package emote
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/disintegration/gift"
+)
+
+const (
+	//defaultScale - images are made bigger when no resize is asked for so the emoji is readable
+	defaultScale = 2
+	//MaxSize - longest side of a result in pixels, bigger resizes are scaled down to fit
+	MaxSize = 1024
+)
+
+//Filter - built for the size the image has by the time it runs, so every frame of a gif gets the same filter
+type Filter func(bounds image.Rectangle) gift.Filter
+
+//Pipeline - what an expression like "blur(5) hue(-45) flip resize(3x)" asks for
+type Pipeline struct {
+	Filters []Filter
+	//Speed - multiplies how fast gif frames play
+	Speed   float64
+	resized bool
+	//scaled - the default scale was added because no resize was asked for
+	scaled  bool
+	effects []effect
+	//fps and duration of animations, 0 for the defaults
+	fps      float64
+	duration float64
+}
+
+type filterSpec struct {
+	usage string
+	build func(p *Pipeline, name string, args []string) error
+}
+
+var filters = map[string]filterSpec{
+	"blur":       numberFilter("blur(sigma)", numberParam{def: 3, min: 0.1, max: 20}, func(n []float64) Filter { return fixed(gift.GaussianBlur(float32(n[0]))) }),
+	"pixelate":   numberFilter("pixelate(size)", numberParam{def: 5, min: 1, max: 64}, func(n []float64) Filter { return fixed(gift.Pixelate(int(n[0]))) }),
+	"hue":        numberFilter("hue(degrees)", numberParam{def: 90, min: -180, max: 180, suffix: "deg"}, func(n []float64) Filter { return fixed(gift.Hue(float32(n[0]))) }),
+	"sepia":      numberFilter("sepia(percent)", numberParam{def: 100, min: 0, max: 100, suffix: "%"}, func(n []float64) Filter { return fixed(gift.Sepia(float32(n[0]))) }),
+	"contrast":   numberFilter("contrast(percent)", numberParam{def: 30, min: -100, max: 100, suffix: "%"}, func(n []float64) Filter { return fixed(gift.Contrast(float32(n[0]))) }),
+	"brightness": numberFilter("brightness(percent)", numberParam{def: 20, min: -100, max: 100, suffix: "%"}, func(n []float64) Filter { return fixed(gift.Brightness(float32(n[0]))) }),
+	"saturation": numberFilter("saturation(percent)", numberParam{def: 50, min: -100, max: 500, suffix: "%"}, func(n []float64) Filter { return fixed(gift.Saturation(float32(n[0]))) }),
+	"sharpen":    numberFilter("sharpen(amount)", numberParam{def: 1, min: 0.1, max: 10}, func(n []float64) Filter { return fixed(gift.UnsharpMask(1, float32(n[0]), 0)) }),
+	"rotate":     numberFilter("rotate(degrees)", numberParam{def: 90, min: -360, max: 360, suffix: "deg"}, func(n []float64) Filter { return rotate(n[0]) }),
+	"grayscale":  plainFilter("grayscale", gift.Grayscale()),
+	"invert":     plainFilter("invert", gift.Invert()),
+	"flip":       plainFilter("flip", gift.FlipVertical()),
+	"mirror":     plainFilter("mirror", gift.FlipHorizontal()),
+	"crop":       {usage: "crop", build: buildCrop},
+	"resize":     {usage: "resize(2x|width|widthxheight)", build: buildResize},
+	"speed":      {usage: "speed(2x)", build: buildSpeed},
+}
+
+//aliases - other names filters are known by
+var aliases = map[string]string{
+	"greyscale": "grayscale",
+	"gray":      "grayscale",
+	"grey":      "grayscale",
+}
+
+//legacyFlags - the single letters $emote took before filters had names
+var legacyFlags = map[rune]Filter{
+	'p': fixed(gift.Pixelate(5)),
+	'i': fixed(gift.Invert()),
+	'r': fixed(gift.Rotate90()),
+	'b': fixed(gift.GaussianBlur(3)),
+	'h': fixed(gift.Hue(90)),
+	'c': randomCrop,
+}
+
+//Usage lists every filter with its parameters
+func Usage() string {
+	return usage(filters)
+}
+
+//EffectUsage lists the effects that animate a still image and their options
+func EffectUsage() string {
+	return usage(effects)
+}
+
+func usage(specs map[string]filterSpec) string {
+	var usages []string
+	for _, f := range specs {
+		usages = append(usages, f.usage)
+	}
+	sort.Strings(usages)
+	return strings.Join(usages, " ")
+}
+
+//Parse reads filters separated by spaces, each a name optionally followed by arguments in brackets.
+//Errors are meant to be shown to whoever wrote the expression.
+func Parse(expr string) (*Pipeline, error) {
+	p := &Pipeline{Speed: 1}
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		name, args := token, []string(nil)
+		if open := strings.Index(token, "("); open >= 0 {
+			name = token[:open]
+			inner := strings.TrimSpace(token[open+1 : len(token)-1])
+			if inner != "" {
+				for _, a := range strings.Split(inner, ",") {
+					args = append(args, strings.TrimSpace(a))
+				}
+			}
+		}
+		name = strings.ToLower(name)
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+
+		f, ok := filters[name]
+		if !ok {
+			f, ok = effects[name]
+		}
+		if !ok {
+			if legacy, ok := legacyFilters(name); ok && args == nil {
+				p.Filters = append(p.Filters, legacy...)
+				continue
+			}
+			return nil, fmt.Errorf("unknown filter %s, filters are: %s, effects are: %s", name, Usage(), EffectUsage())
+		}
+		if err := f.build(p, name, args); err != nil {
+			return nil, err
+		}
+	}
+	if !p.resized {
+		p.Filters = append(p.Filters, scale(defaultScale, defaultScale))
+		p.scaled = true
+	}
+	return p, nil
+}
+
+//KeepSize drops the default scale, for images already made as big as they should be
+func (p *Pipeline) KeepSize() {
+	if p.scaled {
+		p.Filters = p.Filters[:len(p.Filters)-1]
+		p.scaled = false
+	}
+}
+
+//tokenize splits on spaces outside of brackets
+func tokenize(expr string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	depth := 0
+	for _, r := range expr {
+		switch {
+		case r == '(':
+			if depth > 0 || current.Len() == 0 {
+				return nil, fmt.Errorf("unexpected ( in %s", expr)
+			}
+			depth++
+		case r == ')':
+			if depth == 0 {
+				return nil, fmt.Errorf("unexpected ) in %s", expr)
+			}
+			depth--
+		case unicode.IsSpace(r) && depth == 0:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+		//Anything straight after a closing bracket starts a new filter
+		if r == ')' {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("missing ) in %s", expr)
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+func legacyFilters(flags string) ([]Filter, bool) {
+	var fs []Filter
+	for _, r := range flags {
+		f, ok := legacyFlags[r]
+		if !ok {
+			return nil, false
+		}
+		fs = append(fs, f)
+	}
+	return fs, len(fs) > 0
+}
+
+type numberParam struct {
+	def, min, max float64
+	//suffix - unit the number may be written with, like 45deg
+	suffix string
+}
+
+func numberFilter(usage string, param numberParam, build func([]float64) Filter) filterSpec {
+	return filterSpec{usage: usage, build: func(p *Pipeline, name string, args []string) error {
+		n, err := numberArgs(name, args, param)
+		if err != nil {
+			return err
+		}
+		p.Filters = append(p.Filters, build(n))
+		return nil
+	}}
+}
+
+func plainFilter(usage string, f gift.Filter) filterSpec {
+	return filterSpec{usage: usage, build: func(p *Pipeline, name string, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("%s does not take arguments", name)
+		}
+		p.Filters = append(p.Filters, fixed(f))
+		return nil
+	}}
+}
+
+//numberArgs checks the arguments against their ranges, using the defaults for the ones left out
+func numberArgs(name string, args []string, params ...numberParam) ([]float64, error) {
+	if len(args) > len(params) {
+		return nil, fmt.Errorf("%s takes at most %d argument(s), got %d", name, len(params), len(args))
+	}
+	result := make([]float64, len(params))
+	for i, param := range params {
+		result[i] = param.def
+		if i >= len(args) {
+			continue
+		}
+		arg := args[i]
+		if param.suffix != "" {
+			arg = strings.TrimSuffix(strings.ToLower(arg), param.suffix)
+		}
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil || math.IsNaN(n) || n < param.min || n > param.max {
+			return nil, fmt.Errorf("%s takes a number from %v to %v, got %s", name, param.min, param.max, args[i])
+		}
+		result[i] = n
+	}
+	return result, nil
+}
+
+func fixed(f gift.Filter) Filter {
+	return func(image.Rectangle) gift.Filter { return f }
+}
+
+//rotate turns clockwise, keeping right angles sharp
+func rotate(degrees float64) Filter {
+	switch math.Mod(degrees+360, 360) {
+	case 0:
+		return fixed(gift.Rotate(0, color.Transparent, gift.NearestNeighborInterpolation))
+	case 90:
+		return fixed(gift.Rotate270())
+	case 180:
+		return fixed(gift.Rotate180())
+	case 270:
+		return fixed(gift.Rotate90())
+	}
+	return fixed(gift.Rotate(float32(-degrees), color.Transparent, gift.CubicInterpolation))
+}
+
+func randomCrop(bounds image.Rectangle) gift.Filter {
+	return gift.CropToSize(rand.Intn(bounds.Dx())+1, rand.Intn(bounds.Dy())+1, randomAnchor())
+}
+
+func randomAnchor() gift.Anchor {
+	anchors := []gift.Anchor{
+		gift.CenterAnchor, gift.TopLeftAnchor, gift.TopAnchor, gift.TopRightAnchor, gift.LeftAnchor,
+		gift.RightAnchor, gift.BottomLeftAnchor, gift.BottomAnchor, gift.BottomRightAnchor,
+	}
+	return anchors[rand.Intn(len(anchors))]
+}
+
+func buildCrop(p *Pipeline, name string, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("%s does not take arguments", name)
+	}
+	p.Filters = append(p.Filters, randomCrop)
+	return nil
+}
+
+//scale multiplies the size, keeping the result within MaxSize
+func scale(x float64, y float64) Filter {
+	return func(bounds image.Rectangle) gift.Filter {
+		return fitResize(float64(bounds.Dx())*x, float64(bounds.Dy())*y)
+	}
+}
+
+func fitResize(width float64, height float64) gift.Filter {
+	if longest := math.Max(width, height); longest > MaxSize {
+		width, height = width*MaxSize/longest, height*MaxSize/longest
+	}
+	return gift.Resize(int(math.Max(1, math.Round(width))), int(math.Max(1, math.Round(height))), gift.BoxResampling)
+}
+
+//buildResize takes a multiplier like 3x, a width keeping the shape, or a width and height like 64x32
+func buildResize(p *Pipeline, name string, args []string) error {
+	usage := fmt.Errorf("%s takes a multiplier from 0.1x to 8x, a width or a size like 64x32, up to %d pixels", name, MaxSize)
+	if len(args) != 1 {
+		return usage
+	}
+	arg := strings.ToLower(args[0])
+	p.resized = true
+
+	if strings.HasSuffix(arg, "x") {
+		n, err := strconv.ParseFloat(strings.TrimSuffix(arg, "x"), 64)
+		if err != nil || n < 0.1 || n > 8 {
+			return usage
+		}
+		p.Filters = append(p.Filters, scale(n, n))
+		return nil
+	}
+
+	sizes := strings.Split(strings.TrimSuffix(arg, "px"), "x")
+	if len(sizes) > 2 {
+		return usage
+	}
+	var size [2]int
+	for i, s := range sizes {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxSize {
+			return usage
+		}
+		size[i] = n
+	}
+	p.Filters = append(p.Filters, func(image.Rectangle) gift.Filter {
+		//A height of 0 keeps the shape
+		return gift.Resize(size[0], size[1], gift.BoxResampling)
+	})
+	return nil
+}
+
+func buildSpeed(p *Pipeline, name string, args []string) error {
+	n, err := numberArgs(name, args, numberParam{def: 2, min: 0.1, max: 10, suffix: "x"})
+	if err != nil {
+		return err
+	}
+	p.Speed *= n[0]
+	return nil
+}
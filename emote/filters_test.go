@@ -0,0 +1,302 @@
+package emote_test
+
+import (
+	"bytes"
+	"discordbot/emote"
+	"encoding/binary"
+	"flag"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+//sourceEmoji - a small picture with colours, edges and transparency for the filters to work on
+func sourceEmoji() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 24, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 24; x++ {
+			c := color.NRGBA{R: uint8(x * 10), G: uint8(y * 15), B: 200, A: 255}
+			if x > 8 && x < 14 && y > 4 && y < 12 {
+				c = color.NRGBA{R: 250, G: 220, B: 20, A: 255}
+			}
+			if x+y < 4 {
+				c = color.NRGBA{}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	buff := new(bytes.Buffer)
+	if err := png.Encode(buff, img); err != nil {
+		t.Fatal(err)
+	}
+	return buff.Bytes()
+}
+
+//sameImage allows for rounding differences between platforms
+func sameImage(a image.Image, b image.Image) bool {
+	return nearImage(a, b, 2)
+}
+
+//nearImage - no channel of any pixel differs by more than tolerance out of 255
+func nearImage(a image.Image, b image.Image, tolerance uint32) bool {
+	if a.Bounds() != b.Bounds() {
+		return false
+	}
+	near := func(x uint32, y uint32) bool {
+		return x>>8 <= y>>8+tolerance && y>>8 <= x>>8+tolerance
+	}
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			r1, g1, b1, a1 := a.At(x, y).RGBA()
+			r2, g2, b2, a2 := b.At(x, y).RGBA()
+			if !near(r1, r2) || !near(g1, g2) || !near(b1, b2) || !near(a1, a2) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestGoldenImages(t *testing.T) {
+	inputs := []struct {
+		golden string
+		expr   string
+	}{
+		{"default", ""},
+		{"blur", "blur(2)"},
+		{"pixelate", "pixelate(4)"},
+		{"hue", "hue(-45)"},
+		{"flip", "flip"},
+		{"mirror", "mirror"},
+		{"grayscale", "grayscale"},
+		{"sepia", "sepia(80%)"},
+		{"sharpen", "sharpen(3)"},
+		{"contrast", "contrast(50)"},
+		{"rotate90", "rotate(90)"},
+		{"rotate45", "rotate(45deg)"},
+		{"resize", "resize(3x)"},
+		{"resize_width", "resize(12)"},
+		{"chain", "grayscale  flip resize( 1.5x ) invert"},
+		{"legacy", "ib"},
+	}
+	src := encodePNG(t, sourceEmoji())
+	for _, input := range inputs {
+		p, err := emote.Parse(input.expr)
+		if err != nil {
+			t.Error(input.expr, ": ", err)
+			continue
+		}
+		b, extension, err := p.Render(src)
+		if err != nil || extension != emote.ExtensionPNG {
+			t.Error(input.expr, ": ", err, " ", extension)
+			continue
+		}
+
+		path := filepath.Join("testdata", input.golden+".png")
+		if *update {
+			if err := ioutil.WriteFile(path, b, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		expected, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := png.Decode(bytes.NewReader(expected))
+		got, _ := png.Decode(bytes.NewReader(b))
+		if !sameImage(got, want) {
+			t.Error("Result of ", input.expr, " does not match ", path)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	inputs := []struct {
+		expr     string
+		expected string
+	}{
+		{"blur(50)", "blur takes a number from 0.1 to 20, got 50"},
+		{"hue(left)", "hue takes a number from -180 to 180, got left"},
+		{"blur(1, 2)", "blur takes at most 1 argument(s), got 2"},
+		{"flip(2)", "flip does not take arguments"},
+		{"resize(20x)", "resize takes a multiplier from 0.1x to 8x, a width or a size like 64x32, up to 1024 pixels"},
+		{"resize(2000)", "resize takes a multiplier from 0.1x to 8x, a width or a size like 64x32, up to 1024 pixels"},
+		{"speed(0)", "speed takes a number from 0.1 to 10, got 0"},
+		{"blur(5", "missing ) in blur(5"},
+		{"blur)", "unexpected ) in blur)"},
+		{"(5)", "unexpected ( in (5)"},
+	}
+	for _, input := range inputs {
+		_, err := emote.Parse(input.expr)
+		if err == nil || err.Error() != input.expected {
+			t.Error("Unexpected error for ", input.expr, ": ", err)
+		}
+	}
+
+	_, err := emote.Parse("blur sparkle")
+	if err == nil || !strings.HasPrefix(err.Error(), "unknown filter sparkle, filters are: blur(sigma) brightness(percent)") {
+		t.Error("Unexpected error for an unknown filter ", err)
+	}
+}
+
+func TestParseChaining(t *testing.T) {
+	//Brackets end a filter even without a space after them
+	p, err := emote.Parse("blur(5)hue(-45) GRAYSCALE resize(64x32)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Filters) != 4 {
+		t.Error("Unexpected filters ", len(p.Filters))
+	}
+	b, _, err := p.Render(encodePNG(t, sourceEmoji()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _ := png.Decode(bytes.NewReader(b))
+	if img.Bounds().Dx() != 64 || img.Bounds().Dy() != 32 {
+		t.Error("Unexpected size ", img.Bounds())
+	}
+}
+
+func TestKeepSize(t *testing.T) {
+	src := encodePNG(t, sourceEmoji())
+	inputs := []struct {
+		expr  string
+		width int
+	}{
+		{"flip", sourceEmoji().Bounds().Dx()},
+		{"flip resize(64x32)", 64},
+	}
+	for _, input := range inputs {
+		p, _ := emote.Parse(input.expr)
+		p.KeepSize()
+		b, _, err := p.Render(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, _ := png.Decode(bytes.NewReader(b))
+		if img.Bounds().Dx() != input.width {
+			t.Error("Unexpected size for ", input.expr, ": ", img.Bounds())
+		}
+	}
+}
+
+func TestResizeLimited(t *testing.T) {
+	p, _ := emote.Parse("resize(8x) resize(8x)")
+	b, _, err := p.Render(encodePNG(t, sourceEmoji()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _ := png.Decode(bytes.NewReader(b))
+	if img.Bounds().Dx() != emote.MaxSize || img.Bounds().Dy() != 683 {
+		t.Error("Resize not limited ", img.Bounds())
+	}
+}
+
+func TestGifSpeed(t *testing.T) {
+	palette := color.Palette{color.Transparent, color.White, color.Black}
+	src := &gif.GIF{
+		Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 4, 4), palette), image.NewPaletted(image.Rect(0, 0, 4, 4), palette)},
+		Delay: []int{10, 3},
+	}
+	buff := new(bytes.Buffer)
+	if err := gif.EncodeAll(buff, src); err != nil {
+		t.Fatal(err)
+	}
+
+	p, _ := emote.Parse("speed(2x) flip")
+	b, extension, err := p.Render(buff.Bytes())
+	if err != nil || extension != emote.ExtensionGIF {
+		t.Fatal(err, extension)
+	}
+	result, err := gif.DecodeAll(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//Frames are kept at the shortest delay browsers play at full speed
+	if result.Delay[0] != 5 || result.Delay[1] != 2 || result.Config.Width != 8 {
+		t.Error("Unexpected gif ", result.Delay, " ", result.Config.Width)
+	}
+}
+
+func TestOtherFormats(t *testing.T) {
+	webp, err := ioutil.ReadFile(filepath.Join("testdata", "source.webp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jpg := new(bytes.Buffer)
+	if err := jpeg.Encode(jpg, sourceEmoji(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, src := range map[string][]byte{"webp": webp, "jpeg": jpg.Bytes()} {
+		p, _ := emote.Parse("resize(32)")
+		b, extension, err := p.Render(src)
+		if err != nil || extension != emote.ExtensionPNG {
+			t.Error(name, " not rendered ", err, " ", extension)
+			continue
+		}
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil || img.Bounds().Dx() != 32 {
+			t.Error(name, " not resized ", err)
+		}
+	}
+}
+
+//hugePNG - a tiny file whose header claims a huge image
+func hugePNG(t *testing.T, width uint32, height uint32) []byte {
+	b := encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 1, 1)))
+	binary.BigEndian.PutUint32(b[16:], width)
+	binary.BigEndian.PutUint32(b[20:], height)
+	binary.BigEndian.PutUint32(b[29:], crc32.ChecksumIEEE(b[12:29]))
+	return b
+}
+
+func TestTooBigImages(t *testing.T) {
+	still, _ := emote.Parse("resize(32)")
+	animated, _ := emote.Parse("spin")
+	inputs := map[string]struct {
+		p   *emote.Pipeline
+		src []byte
+	}{
+		"pixels":         {still, hugePNG(t, 30000, 30000)},
+		"effect pixels":  {animated, hugePNG(t, 30000, 30000)},
+		"one long side":  {still, hugePNG(t, 1<<25, 1)},
+		"frames":         {still, blinkingGIF(t, 600)},
+		"emoji frames":   {nil, blinkingGIF(t, 600)},
+		"combine pixels": {nil, hugePNG(t, 30000, 30000)},
+	}
+	for name, input := range inputs {
+		var err error
+		switch {
+		case input.p != nil:
+			_, _, err = input.p.Render(input.src)
+		case name == "emoji frames":
+			_, _, err = emote.FitEmoji(input.src)
+		default:
+			_, _, err = emote.Combine(emote.LayoutSide, input.src, input.src)
+		}
+		if err != emote.ErrImageTooBig {
+			t.Error(name, ": unexpected error ", err)
+		}
+	}
+
+	//Gifs within the limits are still read
+	if _, _, err := still.Render(blinkingGIF(t, 400)); err != nil {
+		t.Error(err)
+	}
+}
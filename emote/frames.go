@@ -0,0 +1,203 @@
+package emote
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+)
+
+const (
+	//maxPixels - most pixels read from one image, the upload limit only bounds how well it compresses
+	maxPixels = 4096 * 4096
+	//maxSourceFrames - longest gif read
+	maxSourceFrames = 500
+	//maxAnimationPixels - most pixels read from every frame of a gif together
+	maxAnimationPixels = 64 << 20
+)
+
+var ErrImageTooBig = errors.New("the image is too big to work with, try a smaller one")
+
+//animation - whole frames ready to draw on, with delays when it is animated
+type animation struct {
+	frames []*image.NRGBA
+	delays []int
+}
+
+//decodeAnimation reads every frame of a gif as it is shown, or the only frame of anything else
+func decodeAnimation(src []byte) (*animation, error) {
+	if err := checkSize(src); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(src, []byte("GIF8")) {
+		img, _, err := image.Decode(bytes.NewReader(src))
+		if err != nil {
+			return nil, err
+		}
+		return &animation{frames: []*image.NRGBA{toNRGBA(img)}}, nil
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+	a := &animation{delays: g.Delay}
+	canvas := image.NewNRGBA(bounds)
+	for i, frame := range g.Image {
+		var previous *image.NRGBA
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			previous = toNRGBA(canvas)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		a.frames = append(a.frames, toNRGBA(canvas))
+
+		if i < len(g.Disposal) {
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = previous
+			}
+		}
+	}
+	return a, nil
+}
+
+//checkPixels reads only the header, refusing images too big to decode
+func checkPixels(src []byte) (int, error) {
+	c, _, err := image.DecodeConfig(bytes.NewReader(src))
+	if err != nil {
+		return 0, err
+	}
+	pixels := c.Width * c.Height
+	if c.Width > maxPixels || c.Height > maxPixels || pixels > maxPixels {
+		return 0, ErrImageTooBig
+	}
+	return pixels, nil
+}
+
+//checkSize refuses images too big to decode, and gifs with too many frames to decode them all
+func checkSize(src []byte) error {
+	_, err := decodedPixels(src)
+	return err
+}
+
+//decodedPixels is how many pixels every frame of an image takes together, checked against the limits
+func decodedPixels(src []byte) (int, error) {
+	pixels, err := checkPixels(src)
+	if err != nil || !bytes.HasPrefix(src, []byte("GIF8")) {
+		return pixels, err
+	}
+	frames := gifFrameCount(src)
+	if frames > maxSourceFrames || frames*pixels > maxAnimationPixels {
+		return 0, ErrImageTooBig
+	}
+	return frames * pixels, nil
+}
+
+//gifFrameCount walks the blocks of a gif without decoding them, a broken gif is counted up to where it breaks
+func gifFrameCount(src []byte) int {
+	//Header and logical screen descriptor
+	i := 13
+	if len(src) < i {
+		return 0
+	}
+	if flags := src[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+	skipBlocks := func() {
+		for i < len(src) && src[i] != 0 {
+			i += int(src[i]) + 1
+		}
+		i++
+	}
+	frames := 0
+	for i < len(src) {
+		switch src[i] {
+		case 0x21:
+			//Extension introducer and label
+			i += 2
+			skipBlocks()
+		case 0x2C:
+			//Image descriptor, then the local color table and LZW code size
+			if i+9 >= len(src) {
+				return frames
+			}
+			if flags := src[i+9]; flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			i += 11
+			skipBlocks()
+			frames++
+		default:
+			return frames
+		}
+	}
+	return frames
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+func (a *animation) animated() bool {
+	return a.delays != nil
+}
+
+//frame loops shorter animations when drawn alongside longer ones
+func (a *animation) frame(i int) *image.NRGBA {
+	return a.frames[i%len(a.frames)]
+}
+
+//encodeToFit makes the frames smaller until the result fits in an upload
+func (a *animation) encodeToFit() ([]byte, string, error) {
+	longest := a.frames[0].Bounds().Dx()
+	if height := a.frames[0].Bounds().Dy(); height > longest {
+		longest = height
+	}
+	for size := float64(longest); ; size *= shrinkStep {
+		fitted := a
+		if int(size) < longest {
+			fitted = a.fit(int(size))
+			if fitted.frames[0].Bounds().Dx() < 16 {
+				return nil, "", ErrTooLarge
+			}
+		}
+		b, extension, err := fitted.encode()
+		if err != nil || len(b) <= MaxUploadSize {
+			return b, extension, err
+		}
+	}
+}
+
+//encode writes a png for a single still frame and a gif otherwise
+func (a *animation) encode() ([]byte, string, error) {
+	buff := new(bytes.Buffer)
+	if !a.animated() {
+		if err := png.Encode(buff, a.frames[0]); err != nil {
+			return nil, "", err
+		}
+		return buff.Bytes(), ExtensionPNG, nil
+	}
+
+	g := &gif.GIF{Delay: a.delays, Disposal: make([]byte, len(a.frames))}
+	q := newQuantizer()
+	for i, frame := range a.frames {
+		g.Image = append(g.Image, q.paletted(frame))
+		//Transparent parts would show the frame before otherwise
+		g.Disposal[i] = gif.DisposalBackground
+	}
+	if err := gif.EncodeAll(buff, g); err != nil {
+		return nil, "", err
+	}
+	return buff.Bytes(), ExtensionGIF, nil
+}
@@ -0,0 +1,100 @@
+package emote
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"math"
+
+	"github.com/disintegration/gift"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	ExtensionPNG = ".png"
+	ExtensionGIF = ".gif"
+	//defaultFrameDelay - what browsers show a gif frame without a delay for, in hundredths of a second
+	defaultFrameDelay = 10
+	//minFrameDelay - browsers slow down gif frames shorter than this
+	minFrameDelay = 2
+)
+
+//Render applies the pipeline to a png, gif, jpeg or webp, keeping gifs animated and turning anything else into a png.
+//Effects always make a gif. It returns the encoded result with the file extension it needs.
+func (p *Pipeline) Render(src []byte) ([]byte, string, error) {
+	if p.Animated() {
+		b, err := p.renderEffects(src)
+		return b, ExtensionGIF, err
+	}
+	if bytes.HasPrefix(src, []byte("GIF8")) {
+		b, err := p.renderGif(src)
+		return b, ExtensionGIF, err
+	}
+	b, err := p.renderStatic(src)
+	return b, ExtensionPNG, err
+}
+
+func (p *Pipeline) gift(first image.Image) *gift.GIFT {
+	g := gift.New()
+	for _, f := range p.Filters {
+		g.Add(f(g.Bounds(first.Bounds())))
+	}
+	return g
+}
+
+func (p *Pipeline) renderStatic(src []byte) ([]byte, error) {
+	if _, err := checkPixels(src); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	g := p.gift(img)
+	dst := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(dst, img)
+
+	buff := new(bytes.Buffer)
+	if err := png.Encode(buff, dst); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+func (p *Pipeline) renderGif(src []byte) ([]byte, error) {
+	if err := checkSize(src); err != nil {
+		return nil, err
+	}
+	srcGif, err := gif.DecodeAll(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	g := p.gift(srcGif.Image[0])
+	bounds := g.Bounds(srcGif.Image[0].Rect)
+	srcGif.Config.Height = bounds.Max.Y
+	srcGif.Config.Width = bounds.Max.X
+	for i, frame := range srcGif.Image {
+		dst := image.NewPaletted(g.Bounds(frame.Bounds()), frame.Palette)
+		g.Draw(dst, frame)
+		srcGif.Image[i] = dst
+		srcGif.Delay[i] = p.frameDelay(srcGif.Delay[i])
+	}
+
+	buff := new(bytes.Buffer)
+	if err := gif.EncodeAll(buff, srcGif); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+func (p *Pipeline) frameDelay(delay int) int {
+	if p.Speed == 0 || p.Speed == 1 {
+		return delay
+	}
+	if delay == 0 {
+		delay = defaultFrameDelay
+	}
+	return int(math.Max(minFrameDelay, math.Round(float64(delay)/p.Speed)))
+}
@@ -0,0 +1,117 @@
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const cacheFileExtension = ".json"
+
+type cacheEntry struct {
+	URL          string
+	ETag         string
+	LastModified string
+	Stored       time.Time
+	Body         []byte
+}
+
+//diskCache keeps one file per link and drops the least recently stored files past the limit
+type diskCache struct {
+	dir     string
+	entries int
+	mu      sync.Mutex
+}
+
+func (c *diskCache) path(link string) string {
+	sum := sha256.Sum256([]byte(link))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+cacheFileExtension)
+}
+
+func (c *diskCache) get(link string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, err := ioutil.ReadFile(c.path(link))
+	if err != nil {
+		return nil
+	}
+	e := &cacheEntry{}
+	if err := json.Unmarshal(b, e); err != nil || e.URL != link {
+		return nil
+	}
+	return e
+}
+
+func (c *diskCache) put(link string, e *cacheEntry) {
+	e.Stored = time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.write(link, e); err != nil {
+		log.WithField("cache", c.dir).Error(err)
+		return
+	}
+	c.prune()
+}
+
+//touch marks an entry the server said is still current
+func (c *diskCache) touch(link string, e *cacheEntry) {
+	e.Stored = time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.write(link, e); err != nil {
+		log.WithField("cache", c.dir).Error(err)
+	}
+}
+
+func (c *diskCache) write(link string, e *cacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	//Written to the side first so a reader never sees half a file
+	tmp := c.path(link) + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path(link))
+}
+
+func (c *diskCache) prune() {
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		log.WithField("cache", c.dir).Error(err)
+		return
+	}
+	var entries []os.FileInfo
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), cacheFileExtension) {
+			entries = append(entries, f)
+		}
+	}
+	if len(entries) <= c.entries {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+	for _, f := range entries[:len(entries)-c.entries] {
+		if err := os.Remove(filepath.Join(c.dir, f.Name())); err != nil {
+			log.WithField("cache", c.dir).Error(err)
+		}
+	}
+}
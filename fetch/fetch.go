@@ -0,0 +1,359 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultTimeout         = 20 * time.Second
+	defaultMaxBodySize     = 8 << 20
+	defaultHostInterval    = time.Second
+	defaultHostConcurrency = 2
+	defaultRetries         = 2
+	defaultRetryBackoff    = time.Second
+	defaultCacheEntries    = 200
+	//maxRetryAfter - longest a Retry-After header is waited on
+	maxRetryAfter = time.Minute
+)
+
+var ErrTooLarge = errors.New("response body too large")
+var ErrPrivateAddress = errors.New("refusing to connect to a private address")
+
+//privateNetworks - addresses only reachable from inside, like cloud metadata at 169.254.169.254
+var privateNetworks = parseCIDRs("0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+	"192.168.0.0/16", "::/128", "::1/128", "fc00::/7", "fe80::/10")
+
+//Config - zero values use the defaults. Retries below 0 turn retrying off.
+type Config struct {
+	Timeout time.Duration
+	//MaxBodySize - bytes read from a response before giving up
+	MaxBodySize int64
+	//HostInterval - least time between two requests to the same host
+	HostInterval time.Duration
+	//HostConcurrency - requests to the same host at once
+	HostConcurrency int
+	Retries         int
+	//RetryBackoff - wait before the first retry, doubled for each one after
+	RetryBackoff time.Duration
+	//CacheDir - where responses are kept between runs, no cache when empty
+	CacheDir     string
+	CacheEntries int
+	//CacheFor - how long a cached response is used without asking the server again
+	CacheFor  time.Duration
+	UserAgent string
+	//AllowPrivate - lets requests reach loopback, private and link-local addresses, which links posted by users never should
+	AllowPrivate bool
+	//Client - used as it is, AllowPrivate does not apply to it
+	Client *http.Client
+}
+
+//HostStats - counts for one host since the fetcher started
+type HostStats struct {
+	Requests    int
+	Failures    int
+	Retries     int
+	CacheHits   int
+	NotModified int
+	LastError   string
+	LastFailure time.Time
+}
+
+//Fetcher makes GET requests shared by everything scraping or downloading, limited per host
+type Fetcher struct {
+	config Config
+	client *http.Client
+	cache  *diskCache
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+	stats map[string]*HostStats
+}
+
+func New(c Config) *Fetcher {
+	if c.Timeout == 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.MaxBodySize == 0 {
+		c.MaxBodySize = defaultMaxBodySize
+	}
+	if c.HostInterval == 0 {
+		c.HostInterval = defaultHostInterval
+	}
+	if c.HostConcurrency == 0 {
+		c.HostConcurrency = defaultHostConcurrency
+	}
+	if c.Retries == 0 {
+		c.Retries = defaultRetries
+	}
+	if c.RetryBackoff == 0 {
+		c.RetryBackoff = defaultRetryBackoff
+	}
+	if c.CacheEntries == 0 {
+		c.CacheEntries = defaultCacheEntries
+	}
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: c.Timeout, Transport: newTransport(c.AllowPrivate)}
+	}
+	f := &Fetcher{
+		config: c,
+		client: client,
+		hosts:  make(map[string]*hostLimiter),
+		stats:  make(map[string]*HostStats),
+	}
+	if c.CacheDir != "" {
+		f.cache = &diskCache{dir: c.CacheDir, entries: c.CacheEntries}
+	}
+	return f
+}
+
+//Get returns the body of a successful response, from the cache when the server says it has not changed
+func (f *Fetcher) Get(link string) ([]byte, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	host := strings.ToLower(u.Hostname())
+
+	var cached *cacheEntry
+	if f.cache != nil {
+		cached = f.cache.get(link)
+		if cached != nil && f.config.CacheFor > 0 && time.Since(cached.Stored) < f.config.CacheFor {
+			f.count(host, func(s *HostStats) { s.CacheHits++ })
+			return cached.Body, nil
+		}
+	}
+
+	var body []byte
+	retries := f.config.Retries
+	if retries < 0 {
+		retries = 0
+	}
+	for attempt := 0; ; attempt++ {
+		var wait time.Duration
+		var retry bool
+		body, wait, retry, err = f.try(host, link, cached)
+		if err == nil || !retry || attempt == retries {
+			break
+		}
+		f.count(host, func(s *HostStats) { s.Retries++ })
+		if wait == 0 {
+			wait = f.config.RetryBackoff << uint(attempt)
+		}
+		time.Sleep(wait)
+	}
+
+	if err != nil {
+		f.count(host, func(s *HostStats) {
+			s.Failures++
+			s.LastError = err.Error()
+			s.LastFailure = time.Now()
+		})
+		return nil, err
+	}
+	return body, nil
+}
+
+//try makes one request, saying whether it is worth trying again and how long to wait when the server asked
+func (f *Fetcher) try(host string, link string, cached *cacheEntry) ([]byte, time.Duration, bool, error) {
+	limiter := f.limiter(host)
+	limiter.acquire(f.config.HostInterval)
+	defer limiter.release()
+	f.count(host, func(s *HostStats) { s.Requests++ })
+
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	req.Header.Add("Accept", "*/*")
+	req.Header.Add("Accept-Language", "en-US,en;q=0.9")
+	if f.config.UserAgent != "" {
+		req.Header.Set("User-Agent", f.config.UserAgent)
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, 0, !errors.Is(err, ErrPrivateAddress), err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached != nil:
+		f.count(host, func(s *HostStats) { s.NotModified++ })
+		f.cache.touch(link, cached)
+		return cached.Body, 0, false, nil
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return nil, retryAfter(res.Header.Get("Retry-After")), true, fmt.Errorf("error status code %v from %s", res.StatusCode, host)
+	case res.StatusCode != http.StatusOK:
+		return nil, 0, false, fmt.Errorf("error status code %v from %s", res.StatusCode, host)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, f.config.MaxBodySize+1))
+	if err != nil {
+		return nil, 0, true, err
+	}
+	if int64(len(body)) > f.config.MaxBodySize {
+		return nil, 0, false, ErrTooLarge
+	}
+
+	entry := &cacheEntry{URL: link, ETag: res.Header.Get("ETag"), LastModified: res.Header.Get("Last-Modified"), Body: body}
+	//Responses are only worth keeping when they can be checked or reused as they are
+	if f.cache != nil && (entry.ETag != "" || entry.LastModified != "" || f.config.CacheFor > 0) {
+		f.cache.put(link, entry)
+	}
+	return body, 0, false, nil
+}
+
+//newTransport checks every address connected to, so redirects and hosts resolving to private addresses are refused too
+func newTransport(allowPrivate bool) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if allowPrivate {
+		return t
+	}
+	//A proxy would make the connection instead, where it can not be checked
+	t.Proxy = nil
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: refusePrivate}
+	t.DialContext = dialer.DialContext
+	return t
+}
+
+func refusePrivate(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsMulticast() {
+		return ErrPrivateAddress
+	}
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, n)
+	}
+	return networks
+}
+
+//retryAfter reads a Retry-After header given in seconds
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	wait := time.Duration(seconds) * time.Second
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
+
+func (f *Fetcher) limiter(host string) *hostLimiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	l, ok := f.hosts[host]
+	if !ok {
+		l = &hostLimiter{slots: make(chan struct{}, f.config.HostConcurrency)}
+		f.hosts[host] = l
+	}
+	return l
+}
+
+func (f *Fetcher) count(host string, update func(*HostStats)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.stats[host]
+	if !ok {
+		s = &HostStats{}
+		f.stats[host] = s
+	}
+	update(s)
+}
+
+//Stats returns a copy of the counts for every host requested
+func (f *Fetcher) Stats() map[string]HostStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := make(map[string]HostStats, len(f.stats))
+	for host, s := range f.stats {
+		result[host] = *s
+	}
+	return result
+}
+
+//LogStats writes the counts of hosts that have failed
+func (f *Fetcher) LogStats() {
+	for host, s := range f.Stats() {
+		if s.Failures == 0 {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"host":         host,
+			"requests":     s.Requests,
+			"failures":     s.Failures,
+			"retries":      s.Retries,
+			"cache hits":   s.CacheHits,
+			"not modified": s.NotModified,
+			"last failure": s.LastFailure,
+		}).Warn(s.LastError)
+	}
+}
+
+//hostLimiter spaces out requests to a host and caps how many run at once
+type hostLimiter struct {
+	slots chan struct{}
+	mu    sync.Mutex
+	next  time.Time
+}
+
+func (h *hostLimiter) acquire(interval time.Duration) {
+	h.slots <- struct{}{}
+	h.mu.Lock()
+	now := time.Now()
+	start := h.next
+	if start.Before(now) {
+		start = now
+	}
+	h.next = start.Add(interval)
+	h.mu.Unlock()
+	if wait := start.Sub(now); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+func (h *hostLimiter) release() {
+	<-h.slots
+}
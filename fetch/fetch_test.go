@@ -0,0 +1,247 @@
+package fetch_test
+
+import (
+	"discordbot/fetch"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//newTestServer - replies with the handlers in order, repeating the last one
+func newTestServer(handlers ...http.HandlerFunc) (*httptest.Server, *[]*http.Request) {
+	var mu sync.Mutex
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r)
+		i := len(requests) - 1
+		mu.Unlock()
+		if i >= len(handlers) {
+			i = len(handlers) - 1
+		}
+		handlers[i](w, r)
+	}))
+	return server, &requests
+}
+
+func reply(status int, body string, headers ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i+1 < len(headers); i += 2 {
+			w.Header().Set(headers[i], headers[i+1])
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+func testConfig() fetch.Config {
+	return fetch.Config{HostInterval: time.Millisecond, RetryBackoff: time.Millisecond, AllowPrivate: true}
+}
+
+func host(server *httptest.Server) string {
+	u, _ := url.Parse(server.URL)
+	return u.Hostname()
+}
+
+func TestRetries(t *testing.T) {
+	server, requests := newTestServer(reply(http.StatusBadGateway, ""), reply(http.StatusTooManyRequests, ""), reply(http.StatusOK, "page"))
+	defer server.Close()
+	f := fetch.New(testConfig())
+
+	body, err := f.Get(server.URL + "/manga")
+
+	if err != nil || string(body) != "page" || len(*requests) != 3 {
+		t.Fatal("Request not retried ", err, " ", len(*requests))
+	}
+	s := f.Stats()[host(server)]
+	if s.Requests != 3 || s.Retries != 2 || s.Failures != 0 {
+		t.Error("Unexpected stats ", s)
+	}
+}
+
+func TestFailuresCounted(t *testing.T) {
+	server, requests := newTestServer(reply(http.StatusInternalServerError, ""), reply(http.StatusNotFound, ""))
+	defer server.Close()
+	f := fetch.New(testConfig())
+
+	_, err := f.Get(server.URL + "/manga")
+
+	//Not found is not worth asking again
+	if err == nil || len(*requests) != 2 {
+		t.Fatal("Expected one retry then a failure ", err, " ", len(*requests))
+	}
+	s := f.Stats()[host(server)]
+	if s.Failures != 1 || s.LastError != "error status code 404 from "+host(server) || s.LastFailure.IsZero() {
+		t.Error("Failure not counted ", s)
+	}
+}
+
+func TestNoRetries(t *testing.T) {
+	server, requests := newTestServer(reply(http.StatusServiceUnavailable, ""))
+	defer server.Close()
+	c := testConfig()
+	c.Retries = -1
+	f := fetch.New(c)
+
+	if _, err := f.Get(server.URL); err == nil || len(*requests) != 1 {
+		t.Error("Request retried ", len(*requests))
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	server, _ := newTestServer(reply(http.StatusOK, strings.Repeat("a", 11)))
+	defer server.Close()
+	c := testConfig()
+	c.MaxBodySize = 10
+	f := fetch.New(c)
+
+	if _, err := f.Get(server.URL); err != fetch.ErrTooLarge {
+		t.Error("Large body not refused ", err)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	//Given: A page with an etag
+	server, requests := newTestServer(
+		reply(http.StatusOK, "chapter 1", "ETag", `"v1"`),
+		reply(http.StatusNotModified, ""),
+	)
+	defer server.Close()
+	c := testConfig()
+	c.CacheDir = t.TempDir()
+
+	//When: It is fetched again, even by a fetcher started later
+	if _, err := fetch.New(c).Get(server.URL + "/manga"); err != nil {
+		t.Fatal(err)
+	}
+	f := fetch.New(c)
+	body, err := f.Get(server.URL + "/manga")
+
+	//Then: The server is asked whether it changed and the cached page is used
+	if err != nil || string(body) != "chapter 1" {
+		t.Fatal("Cached page not returned ", err, " ", string(body))
+	}
+	if len(*requests) != 2 || (*requests)[1].Header.Get("If-None-Match") != `"v1"` {
+		t.Error("Conditional request not made")
+	}
+	if s := f.Stats()[host(server)]; s.NotModified != 1 {
+		t.Error("Not modified not counted ", s)
+	}
+}
+
+func TestCacheFor(t *testing.T) {
+	server, requests := newTestServer(reply(http.StatusOK, "emoji"))
+	defer server.Close()
+	c := testConfig()
+	c.CacheDir = t.TempDir()
+	c.CacheFor = time.Hour
+	f := fetch.New(c)
+
+	f.Get(server.URL + "/1.png")
+	body, err := f.Get(server.URL + "/1.png")
+
+	if err != nil || string(body) != "emoji" || len(*requests) != 1 {
+		t.Error("Cached response not reused ", err, " ", len(*requests))
+	}
+	if s := f.Stats()[host(server)]; s.CacheHits != 1 {
+		t.Error("Cache hit not counted ", s)
+	}
+}
+
+func TestCacheEntriesLimited(t *testing.T) {
+	server, requests := newTestServer(reply(http.StatusOK, "emoji"))
+	defer server.Close()
+	c := testConfig()
+	c.CacheDir = t.TempDir()
+	c.CacheFor = time.Hour
+	c.CacheEntries = 2
+	f := fetch.New(c)
+
+	for _, path := range []string{"/1.png", "/2.png", "/3.png"} {
+		f.Get(server.URL + path)
+		//Keeps the file times apart on coarse file systems
+		time.Sleep(10 * time.Millisecond)
+	}
+	f.Get(server.URL + "/3.png")
+	f.Get(server.URL + "/1.png")
+
+	if len(*requests) != 4 {
+		t.Error("Oldest entry not dropped ", len(*requests))
+	}
+}
+
+func TestHostConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	running, most := 0, 0
+	server, _ := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		if running > most {
+			most = running
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+	defer server.Close()
+	c := testConfig()
+	c.HostConcurrency = 2
+	f := fetch.New(c)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.Get(server.URL)
+		}()
+	}
+	wg.Wait()
+
+	if most != 2 {
+		t.Error("Requests to the host not limited ", most)
+	}
+}
+
+func TestHostInterval(t *testing.T) {
+	server, _ := newTestServer(reply(http.StatusOK, ""))
+	defer server.Close()
+	c := testConfig()
+	c.HostInterval = 30 * time.Millisecond
+	f := fetch.New(c)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		f.Get(server.URL)
+	}
+
+	if time.Since(start) < 60*time.Millisecond {
+		t.Error("Requests not spaced out ", time.Since(start))
+	}
+}
+
+func TestPrivateAddressesRefused(t *testing.T) {
+	server, requests := newTestServer(reply(http.StatusOK, "secret"))
+	defer server.Close()
+	c := testConfig()
+	c.AllowPrivate = false
+	f := fetch.New(c)
+
+	u, _ := url.Parse(server.URL)
+	links := []string{server.URL, "http://localhost:" + u.Port(), "http://169.254.169.254/latest/meta-data/", "http://[::1]/", "http://0.0.0.0:" + u.Port()}
+	for _, link := range links {
+		if _, err := f.Get(link); !errors.Is(err, fetch.ErrPrivateAddress) {
+			t.Error(link, ": unexpected error ", err)
+		}
+	}
+	if len(*requests) != 0 || f.Stats()[host(server)].Retries != 0 {
+		t.Error("Private address reached ", len(*requests))
+	}
+}
@@ -10,6 +10,7 @@ require (
 	github.com/dghubble/oauth1 v0.7.0
 	github.com/disintegration/gift v1.2.1
 	github.com/go-co-op/gocron v1.11.0
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/klauspost/compress v1.13.6 // indirect
 	github.com/mattn/go-sqlite3 v1.14.9
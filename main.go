@@ -9,12 +9,15 @@ import (
 
 	"discordbot/challonge"
 	"discordbot/commands"
+	"discordbot/fetch"
+	"discordbot/manga"
 	"discordbot/repositories"
 	"discordbot/repositories/rolecommand"
 	strawpollrepo "discordbot/repositories/strawpolldeadline"
 	"discordbot/repositories/tourneyrepo"
 	"discordbot/repositories/twitterfollow"
 	"discordbot/repositories/users_repository"
+	"discordbot/repositories/votepoll"
 	"discordbot/strawpoll"
 	myTwitter "discordbot/twitter"
 
@@ -42,6 +45,7 @@ type botConfig struct {
 	TwitterConfig   myTwitter.TwitterClientConfig
 	StrawPollConfig strawpoll.StrawPollConfig
 	ChallongeConfig challonge.Config
+	FetchConfig     fetch.Config
 }
 
 type discordBot struct {
@@ -63,6 +67,7 @@ type repositoryContainer struct {
 	tournamentRepo        commands.TournamentRepository
 	mangaNotificationRepo commands.MangaNotificationRepository
 	mangaLinkRepo         commands.MangaLinksRepository
+	votePollRepo          commands.VotePollRepository
 }
 
 func main() {
@@ -83,6 +88,10 @@ func main() {
 			Username: os.Getenv("CHALLONGE_USERNAME"),
 			Apikey:   os.Getenv("CHALLONGE_API_KEY"),
 		},
+		FetchConfig: fetch.Config{
+			CacheDir: "cache",
+			CacheFor: 10 * time.Minute,
+		},
 	}
 	client := disgord.New(disgord.Config{
 		BotToken: botConfig.DiscordConfig.botToken,
@@ -110,12 +119,15 @@ func initializeBot(s disgord.Session, config botConfig) (*discordBot, *middlewar
 	twitterClient := myTwitter.NewClient(config.TwitterConfig)
 	strawpollClient := strawpoll.New(config.StrawPollConfig)
 	challongeClient := challonge.New(config.ChallongeConfig)
+	fetcher := fetch.New(config.FetchConfig)
+	mangaSources := manga.DefaultRegistry(fetcher)
 
 	commands.RestartTwitterFollows(s, repos.twitterFollowRepo, twitterClient)
-	commands.RestartStrawpollDeadlines(s, repos.strawpollRepo, strawpollClient)
 
 	discordSession := commands.NewSimpleDiscordSession(s)
-	customMiddleWare, err := newMiddlewareHolder(discordSession, jobQueue, repos, twitterClient, strawpollClient, challongeClient)
+	commands.RestartStrawpollDeadlines(discordSession, repos.strawpollRepo, strawpollClient)
+	commands.RestartVotePolls(discordSession, repos.votePollRepo)
+	customMiddleWare, err := newMiddlewareHolder(discordSession, jobQueue, repos, twitterClient, strawpollClient, challongeClient, fetcher, mangaSources)
 	
 	if err != nil {
 		log.Fatal(err)
@@ -124,7 +136,12 @@ func initializeBot(s disgord.Session, config botConfig) (*discordBot, *middlewar
 	discordBot := &discordBot{jobQueue: jobQueue}
 
 	scheduler := gocron.NewScheduler(time.UTC)
-	scheduler.Every(1).Hour().Do(commands.LookForNewMangaChapter, repos.mangaLinkRepo, discordSession)
+	scheduler.Every(1).Hour().Do(commands.LookForNewMangaChapter, repos.mangaLinkRepo, mangaSources, discordSession)
+	scheduler.Every(5).Minutes().Do(commands.PostStrawpollStandings, repos.strawpollRepo, strawpollClient, discordSession)
+	scheduler.Every(1).Minute().Do(commands.AnnounceTournamentMatches, repos.tournamentRepo, customMiddleWare.challongeClient, discordSession)
+	scheduler.Every(1).Minute().Do(commands.RunTournamentCheckIns, repos.tournamentRepo, customMiddleWare.challongeClient, discordSession)
+
+	scheduler.Every(1).Hour().Do(fetcher.LogStats)
 
 	scheduler.StartAsync()
 
@@ -141,6 +158,7 @@ func newRepositoryContainer() *repositoryContainer {
 		tournamentRepo:        tourneyrepo.NewRepository(sqlDb),
 		mangaNotificationRepo: repositories.NewMangaNotificationRepository(sqlDb),
 		mangaLinkRepo:         repositories.NewMangaLinkRepository(sqlDb),
+		votePollRepo:          votepoll.New(sqlDb),
 	}
 }
 
@@ -153,6 +171,9 @@ func run(client *disgord.Client, bot *discordBot, customMiddleWare *middlewareHo
 	client.Gateway().
 		WithMiddleware(customMiddleWare.filterBotMsg, customMiddleWare.commandInUse, customMiddleWare.createMessageContentForNonCommand).
 		MessageCreate(bot.handleMessageCreate)
+	client.Gateway().
+		WithMiddleware(customMiddleWare.filterBotMsg, customMiddleWare.createMangaSearchReply).
+		MessageCreate(bot.handleMessageCreate)
 	client.Gateway().
 		WithMiddleware(customMiddleWare.filterBotMsg, content.StripPrefix, customMiddleWare.handleDiscordEvent).
 		MessageCreate(bot.handleMessageCreate)
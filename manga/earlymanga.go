@@ -0,0 +1,55 @@
+package manga
+
+import (
+	"errors"
+	"net/url"
+	"time"
+)
+
+const earlyMangaTimeFormat = "2006-01-02 15:04:05 MST (-07:00)"
+
+var (
+	earlyMangaTitle    = MustCompile(".manga-info h1")
+	earlyMangaChapters = MustCompile("div.chapter-row")
+	earlyMangaLink     = MustCompile("a[href*=/chapter-]")
+	earlyMangaTime     = MustCompile("[title]")
+)
+
+type EarlyManga struct{}
+
+func (s *EarlyManga) Name() string {
+	return "earlymanga.org"
+}
+
+func (s *EarlyManga) Matches(u *url.URL) bool {
+	return isHost(u, "earlymanga.org")
+}
+
+func (s *EarlyManga) Fetch(f Fetcher, link string) (Manga, error) {
+	doc, err := fetchHTML(f, link)
+	if err != nil {
+		return Manga{}, err
+	}
+
+	m := Manga{URL: link}
+	title := earlyMangaTitle.First(doc)
+	if title == nil {
+		return m, errors.New("earlymanga title not found at " + link)
+	}
+	m.Title = Text(title)
+
+	//The chapter list starts with header rows that have no chapter link
+	for _, row := range earlyMangaChapters.All(doc) {
+		a := earlyMangaLink.First(row)
+		if a == nil {
+			continue
+		}
+		c := Chapter{Title: Text(a), URL: resolve(link, Attr(a, "href"))}
+		c.Number = parseChapterNumber(c.Title, c.URL)
+		if t := earlyMangaTime.First(row); t != nil {
+			c.Released, _ = time.Parse(earlyMangaTimeFormat, Attr(t, "title"))
+		}
+		m.Chapters = append(m.Chapters, c)
+	}
+	return m, nil
+}
@@ -0,0 +1,180 @@
+package manga
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const mangaDexAPIURL = "https://api.mangadex.org"
+const mangaDexChapterURL = "https://mangadex.org/chapter/"
+const mangaDexTitleURL = "https://mangadex.org/title/"
+const mangaDexCoverURL = "https://uploads.mangadex.org/covers/"
+
+//mangaDexSearchLength - results asked for, most relevant first
+const mangaDexSearchLength = 5
+
+//mangaDexFeedLength - chapters asked for, newest first
+const mangaDexFeedLength = 100
+
+var mangaDexID = regexp.MustCompile(`^/title/([0-9a-f-]{36})`)
+
+type mangaDexManga struct {
+	Data struct {
+		ID         string
+		Attributes struct {
+			Title     map[string]string
+			AltTitles []map[string]string
+		}
+	}
+}
+
+type mangaDexFeed struct {
+	Data []struct {
+		ID         string
+		Attributes struct {
+			Chapter   string
+			Title     string
+			PublishAt time.Time
+		}
+	}
+}
+
+type mangaDexSearch struct {
+	Data []struct {
+		ID         string
+		Attributes struct {
+			Title       map[string]string
+			AltTitles   []map[string]string
+			LastChapter string
+		}
+		Relationships []struct {
+			Type       string
+			Attributes struct {
+				FileName string
+			}
+		}
+	}
+}
+
+//MangaDex reads from the public api, chapters are the english translations
+type MangaDex struct{}
+
+func (s *MangaDex) Name() string {
+	return "mangadex.org"
+}
+
+func (s *MangaDex) Matches(u *url.URL) bool {
+	return isHost(u, "mangadex.org") && mangaDexID.MatchString(u.Path)
+}
+
+func (s *MangaDex) Fetch(f Fetcher, link string) (Manga, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return Manga{}, err
+	}
+	match := mangaDexID.FindStringSubmatch(u.Path)
+	if match == nil {
+		return Manga{}, errors.New("no mangadex id in " + link)
+	}
+	ID := match[1]
+
+	body, err := f.Get(mangaDexAPIURL + "/manga/" + ID)
+	if err != nil {
+		return Manga{}, err
+	}
+	info := mangaDexManga{}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return Manga{}, err
+	}
+
+	m := Manga{URL: link, Title: mangaDexDisplayTitle(info.Data.Attributes.Title, info.Data.Attributes.AltTitles)}
+
+	body, err = f.Get(fmt.Sprintf("%s/manga/%s/feed?translatedLanguage[]=en&order[chapter]=desc&limit=%d", mangaDexAPIURL, ID, mangaDexFeedLength))
+	if err != nil {
+		return Manga{}, err
+	}
+	feed := mangaDexFeed{}
+	if err := json.Unmarshal(body, &feed); err != nil {
+		return Manga{}, err
+	}
+
+	for _, d := range feed.Data {
+		c := Chapter{Title: d.Attributes.Title, URL: mangaDexChapterURL + d.ID, Released: d.Attributes.PublishAt}
+		c.Number, _ = strconv.ParseFloat(d.Attributes.Chapter, 64)
+		if c.Title == "" {
+			c.Title = "Chapter " + d.Attributes.Chapter
+		} else if d.Attributes.Chapter != "" {
+			c.Title = "Chapter " + d.Attributes.Chapter + ": " + c.Title
+		}
+		m.Chapters = append(m.Chapters, c)
+	}
+	return m, nil
+}
+
+//mangaDexDisplayTitle falls back on the alternative titles when there is no main one
+func mangaDexDisplayTitle(titles map[string]string, alts []map[string]string) string {
+	if t := mangaDexTitle(titles); t != "" {
+		return t
+	}
+	for _, alt := range alts {
+		if t := mangaDexTitle(alt); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+//mangaDexTitle prefers the english title then the first one given
+func mangaDexTitle(titles map[string]string) string {
+	if t, ok := titles["en"]; ok {
+		return t
+	}
+	var langs []string
+	for lang := range titles {
+		langs = append(langs, lang)
+	}
+	if len(langs) == 0 {
+		return ""
+	}
+	//Map order is random so the pick is made stable
+	min := langs[0]
+	for _, l := range langs {
+		if strings.Compare(l, min) < 0 {
+			min = l
+		}
+	}
+	return titles[min]
+}
+
+func (s *MangaDex) Search(f Fetcher, title string) ([]SearchResult, error) {
+	body, err := f.Get(fmt.Sprintf("%s/manga?title=%s&limit=%d&includes[]=cover_art&order[relevance]=desc",
+		mangaDexAPIURL, url.QueryEscape(title), mangaDexSearchLength))
+	if err != nil {
+		return nil, err
+	}
+	search := mangaDexSearch{}
+	if err := json.Unmarshal(body, &search); err != nil {
+		return nil, err
+	}
+
+	var result []SearchResult
+	for _, d := range search.Data {
+		r := SearchResult{Title: mangaDexDisplayTitle(d.Attributes.Title, d.Attributes.AltTitles), URL: mangaDexTitleURL + d.ID}
+		if d.Attributes.LastChapter != "" {
+			r.LatestChapter = "Chapter " + d.Attributes.LastChapter
+		}
+		for _, rel := range d.Relationships {
+			if rel.Type == "cover_art" && rel.Attributes.FileName != "" {
+				r.Cover = mangaDexCoverURL + d.ID + "/" + rel.Attributes.FileName + ".256.jpg"
+			}
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package manga
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const manganatoTimeFormat = "Jan 2,2006 15:04"
+
+//manganatoZone - chapter times are shown in UTC+8
+var manganatoZone = time.FixedZone("UTC+8", 8*60*60)
+
+var (
+	manganatoTitle    = MustCompile(".story-info-right h1")
+	manganatoChapters = MustCompile("ul.row-content-chapter > li")
+	manganatoLink     = MustCompile("a.chapter-name")
+	manganatoTime     = MustCompile("span.chapter-time")
+
+	manganatoSearchItems   = MustCompile("div.search-story-item")
+	manganatoSearchTitle   = MustCompile("a.item-title")
+	manganatoSearchCover   = MustCompile("a.item-img img")
+	manganatoSearchChapter = MustCompile("a.item-chapter")
+)
+
+const manganatoSearchURL = "https://manganato.com/search/story/"
+
+//manganatoSearchLength - results read from the first page
+const manganatoSearchLength = 5
+
+//manganatoSearchWords - the search page takes the title as words joined by underscores
+var manganatoSearchWords = regexp.MustCompile(`[^a-z0-9]+`)
+
+type Manganato struct{}
+
+func (s *Manganato) Name() string {
+	return "manganato.com"
+}
+
+func (s *Manganato) Matches(u *url.URL) bool {
+	return isHost(u, "manganato.com", "readmanganato.com", "chapmanganato.com", "chapmanganato.to")
+}
+
+func (s *Manganato) Fetch(f Fetcher, link string) (Manga, error) {
+	doc, err := fetchHTML(f, link)
+	if err != nil {
+		return Manga{}, err
+	}
+
+	m := Manga{URL: link}
+	title := manganatoTitle.First(doc)
+	if title == nil {
+		return m, errors.New("manganato title not found at " + link)
+	}
+	m.Title = Text(title)
+
+	for _, row := range manganatoChapters.All(doc) {
+		a := manganatoLink.First(row)
+		if a == nil {
+			continue
+		}
+		c := Chapter{Title: Text(a), URL: resolve(link, Attr(a, "href"))}
+		c.Number = parseChapterNumber(c.Title, c.URL)
+		if t := manganatoTime.First(row); t != nil {
+			c.Released, _ = time.ParseInLocation(manganatoTimeFormat, Attr(t, "title"), manganatoZone)
+		}
+		m.Chapters = append(m.Chapters, c)
+	}
+	return m, nil
+}
+
+func (s *Manganato) Search(f Fetcher, title string) ([]SearchResult, error) {
+	query := strings.Trim(manganatoSearchWords.ReplaceAllString(strings.ToLower(title), "_"), "_")
+	if query == "" {
+		return nil, nil
+	}
+	doc, err := fetchHTML(f, manganatoSearchURL+query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []SearchResult
+	for _, item := range manganatoSearchItems.All(doc) {
+		a := manganatoSearchTitle.First(item)
+		if a == nil {
+			continue
+		}
+		r := SearchResult{Title: Text(a), URL: Attr(a, "href")}
+		if img := manganatoSearchCover.First(item); img != nil {
+			r.Cover = Attr(img, "src")
+		}
+		if c := manganatoSearchChapter.First(item); c != nil {
+			r.LatestChapter = Text(c)
+		}
+		result = append(result, r)
+		if len(result) == manganatoSearchLength {
+			break
+		}
+	}
+	return result, nil
+}
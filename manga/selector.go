@@ -0,0 +1,223 @@
+package manga
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+//attrTest - an attribute check, op is "" to only need the attribute, "=" for an exact value or "*=" for a value containing it
+type attrTest struct {
+	key   string
+	op    string
+	value string
+}
+
+//compound - one step of a selector like a.chapter-name[href]
+type compound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrTest
+	//child - the step must be a direct child of the previous one instead of any descendant
+	child bool
+}
+
+//Selector is the small part of css selectors the scrapers need: tags, .class, #id, [attr], [attr=value] and [attr*=value]
+//joined by descendant (space) or child (>) combinators.
+type Selector []compound
+
+//MustCompile panics on a bad selector, selectors are written into the providers so a bad one is a programming error
+func MustCompile(s string) Selector {
+	sel, err := Compile(s)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+func Compile(s string) (Selector, error) {
+	var sel Selector
+	child := false
+	for _, part := range strings.Fields(strings.ReplaceAll(s, ">", " > ")) {
+		if part == ">" {
+			if len(sel) == 0 || child {
+				return nil, fmt.Errorf("unexpected > in selector %q", s)
+			}
+			child = true
+			continue
+		}
+		c, err := compileCompound(part)
+		if err != nil {
+			return nil, fmt.Errorf("%v in selector %q", err, s)
+		}
+		c.child = child
+		child = false
+		sel = append(sel, c)
+	}
+	if len(sel) == 0 || child {
+		return nil, fmt.Errorf("incomplete selector %q", s)
+	}
+	return sel, nil
+}
+
+func compileCompound(s string) (compound, error) {
+	c := compound{}
+	i := strings.IndexAny(s, ".#[")
+	if i < 0 {
+		i = len(s)
+	}
+	c.tag = strings.ToLower(s[:i])
+	s = s[i:]
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '.', '#':
+			end := strings.IndexAny(s[1:], ".#[")
+			if end < 0 {
+				end = len(s) - 1
+			}
+			name := s[1 : end+1]
+			if name == "" {
+				return c, fmt.Errorf("empty name after %c", s[0])
+			}
+			if s[0] == '.' {
+				c.classes = append(c.classes, name)
+			} else {
+				c.id = name
+			}
+			s = s[end+1:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return c, fmt.Errorf("unclosed [")
+			}
+			c.attrs = append(c.attrs, compileAttr(s[1:end]))
+			s = s[end+1:]
+		default:
+			return c, fmt.Errorf("unexpected %q", s)
+		}
+	}
+	return c, nil
+}
+
+func compileAttr(s string) attrTest {
+	for _, op := range []string{"*=", "="} {
+		if i := strings.Index(s, op); i > 0 {
+			return attrTest{key: strings.ToLower(s[:i]), op: op, value: strings.Trim(s[i+len(op):], `"'`)}
+		}
+	}
+	return attrTest{key: strings.ToLower(s)}
+}
+
+func (c compound) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode || (c.tag != "" && c.tag != "*" && n.Data != c.tag) {
+		return false
+	}
+	if c.id != "" && Attr(n, "id") != c.id {
+		return false
+	}
+	classes := strings.Fields(Attr(n, "class"))
+	for _, want := range c.classes {
+		if !containsString(classes, want) {
+			return false
+		}
+	}
+	for _, a := range c.attrs {
+		value, ok := attrValue(n, a.key)
+		switch {
+		case !ok:
+			return false
+		case a.op == "=" && value != a.value:
+			return false
+		case a.op == "*=" && !strings.Contains(value, a.value):
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+//matchesFrom checks step i matches n and the steps before it match n's ancestors, stopping at root
+func (s Selector) matchesFrom(n *html.Node, i int, root *html.Node) bool {
+	if !s[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	for p := n.Parent; p != nil && p != root.Parent; p = p.Parent {
+		if s.matchesFrom(p, i-1, root) {
+			return true
+		}
+		if s[i].child {
+			return false
+		}
+	}
+	return false
+}
+
+//All returns the elements under root matching the selector in document order
+func (s Selector) All(root *html.Node) []*html.Node {
+	var result []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if s.matchesFrom(c, len(s)-1, root) {
+				result = append(result, c)
+			}
+			walk(c)
+		}
+	}
+	walk(root)
+	return result
+}
+
+//First returns the first element under root matching the selector or nil
+func (s Selector) First(root *html.Node) *html.Node {
+	if all := s.All(root); len(all) > 0 {
+		return all[0]
+	}
+	return nil
+}
+
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+//Attr returns an attribute of the element or "" when it is missing
+func Attr(n *html.Node, key string) string {
+	v, _ := attrValue(n, key)
+	return v
+}
+
+//Text returns the text inside an element with the whitespace collapsed
+func Text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
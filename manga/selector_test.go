@@ -0,0 +1,77 @@
+package manga_test
+
+import (
+	"discordbot/manga"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const selectorPage = `<html><body>
+<div id="list" class="chapters main">
+	<ul>
+		<li><a class="chapter" href="/chapter-2">  Chapter
+			2 </a><span title="new">today</span></li>
+		<li><a class="chapter old" href="/chapter-1">Chapter 1</a></li>
+	</ul>
+	<a class="chapter" href="/other">Not in a list</a>
+</div>
+</body></html>`
+
+func parsePage(t *testing.T, page string) *html.Node {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestSelectorAll(t *testing.T) {
+	doc := parsePage(t, selectorPage)
+	inputs := []struct {
+		selector string
+		expected []string
+	}{
+		{"a.chapter", []string{"/chapter-2", "/chapter-1", "/other"}},
+		{"li a", []string{"/chapter-2", "/chapter-1"}},
+		{"#list > a", []string{"/other"}},
+		{"div.chapters.main ul a.old", []string{"/chapter-1"}},
+		{"a[href*=chapter-]", []string{"/chapter-2", "/chapter-1"}},
+		{"a[href=/other]", []string{"/other"}},
+		{"ul > a", nil},
+		{"a.missing", nil},
+	}
+	for _, input := range inputs {
+		var hrefs []string
+		for _, n := range manga.MustCompile(input.selector).All(doc) {
+			hrefs = append(hrefs, manga.Attr(n, "href"))
+		}
+		if strings.Join(hrefs, ",") != strings.Join(input.expected, ",") {
+			t.Error("Unexpected matches for ", input.selector, ": ", hrefs)
+		}
+	}
+}
+
+func TestSelectorText(t *testing.T) {
+	doc := parsePage(t, selectorPage)
+
+	a := manga.MustCompile("li a").First(doc)
+	if manga.Text(a) != "Chapter 2" {
+		t.Error("Text not collapsed ", manga.Text(a))
+	}
+	if span := manga.MustCompile("li [title]").First(doc); manga.Attr(span, "title") != "new" {
+		t.Error("Attribute not found")
+	}
+	if manga.MustCompile("span.missing").First(doc) != nil {
+		t.Error("Found a node that is not there")
+	}
+}
+
+func TestCompileInvalidSelector(t *testing.T) {
+	for _, s := range []string{"", "a >", "a[href"} {
+		if _, err := manga.Compile(s); err == nil {
+			t.Error("Invalid selector compiled ", s)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package manga
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+//Chapter - Number is 0 when the site does not give one
+type Chapter struct {
+	Number   float64
+	Title    string
+	URL      string
+	Released time.Time
+}
+
+//Manga - Chapters are newest first
+type Manga struct {
+	Title    string
+	URL      string
+	Chapters []Chapter
+}
+
+//Fetcher gets pages and api responses for the sources
+type Fetcher interface {
+	Get(link string) ([]byte, error)
+}
+
+//MangaSource reads a manga and its chapters from one site
+type MangaSource interface {
+	//Name - the site shown to users
+	Name() string
+	//Matches - whether the link is a manga on this site
+	Matches(u *url.URL) bool
+	Fetch(f Fetcher, link string) (Manga, error)
+}
+
+//SearchResult - LatestChapter and Cover are empty when the site does not list them
+type SearchResult struct {
+	Title         string
+	URL           string
+	Cover         string
+	LatestChapter string
+	Source        string
+}
+
+//Searcher is implemented by sources that can look a manga up by title
+type Searcher interface {
+	Search(f Fetcher, title string) ([]SearchResult, error)
+}
+
+var ErrUnsupported = errors.New("no manga source for link")
+
+//Registry picks the source for a link
+type Registry struct {
+	fetcher Fetcher
+	sources []MangaSource
+}
+
+func NewRegistry(f Fetcher, sources ...MangaSource) *Registry {
+	return &Registry{fetcher: f, sources: sources}
+}
+
+//DefaultRegistry has every source this package knows
+func DefaultRegistry(f Fetcher) *Registry {
+	return NewRegistry(f, &Manganato{}, &EarlyManga{}, &MangaDex{})
+}
+
+func (r *Registry) Register(s MangaSource) {
+	r.sources = append(r.sources, s)
+}
+
+//Find returns the source for a link or ErrUnsupported
+func (r *Registry) Find(link string) (MangaSource, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range r.sources {
+		if s.Matches(u) {
+			return s, nil
+		}
+	}
+	return nil, ErrUnsupported
+}
+
+func (r *Registry) Fetch(link string) (Manga, error) {
+	s, err := r.Find(link)
+	if err != nil {
+		return Manga{}, err
+	}
+	return s.Fetch(r.fetcher, link)
+}
+
+//Search asks every source that can search, taking the best results from each in turn
+func (r *Registry) Search(title string, limit int) ([]SearchResult, error) {
+	var found [][]SearchResult
+	var lastErr error
+	for _, s := range r.sources {
+		searcher, ok := s.(Searcher)
+		if !ok {
+			continue
+		}
+		rs, err := searcher.Search(r.fetcher, title)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for i := range rs {
+			rs[i].Source = s.Name()
+		}
+		found = append(found, rs)
+	}
+	if len(found) == 0 {
+		return nil, lastErr
+	}
+
+	var result []SearchResult
+	for i := 0; len(result) < limit; i++ {
+		added := false
+		for _, rs := range found {
+			if i < len(rs) && len(result) < limit {
+				result = append(result, rs[i])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return result, nil
+}
+
+//Names lists the supported sites
+func (r *Registry) Names() []string {
+	var names []string
+	for _, s := range r.sources {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+func fetchHTML(f Fetcher, link string) (*html.Node, error) {
+	body, err := f.Get(link)
+	if err != nil {
+		return nil, err
+	}
+	return html.Parse(bytes.NewReader(body))
+}
+
+func isHost(u *url.URL, hosts ...string) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, h := range hosts {
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
+
+var chapterNumber = regexp.MustCompile(`(?i)chapter[\s_-]*([0-9]+(?:\.[0-9]+)?)`)
+
+//parseChapterNumber finds the number in text like "Chapter 12.5: Title" or a link like /chapter-12
+func parseChapterNumber(s ...string) float64 {
+	for _, text := range s {
+		if m := chapterNumber.FindStringSubmatch(text); m != nil {
+			n, _ := strconv.ParseFloat(m[1], 64)
+			return n
+		}
+	}
+	return 0
+}
+
+//resolve makes a link found on a page absolute
+func resolve(base string, link string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return link
+	}
+	l, err := b.Parse(link)
+	if err != nil {
+		return link
+	}
+	return l.String()
+}
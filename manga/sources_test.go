@@ -0,0 +1,176 @@
+package manga_test
+
+import (
+	"discordbot/manga"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// fixtureFetcher - serves files from testdata by link
+type fixtureFetcher map[string]string
+
+func (f fixtureFetcher) Get(link string) ([]byte, error) {
+	file, ok := f[link]
+	if !ok {
+		return nil, errors.New("unexpected fetch of " + link)
+	}
+	return ioutil.ReadFile("testdata/" + file)
+}
+
+const mangaDexTitleID = "32d76d19-8a05-4db0-9fc2-e0b0648fe9d0"
+
+func newFixtureRegistry() *manga.Registry {
+	return manga.DefaultRegistry(fixtureFetcher{
+		"https://manganato.com/manga-dr980474":                                                                              "manganato.html",
+		"https://earlymanga.org/manga/a-returner-s-magic-should-be-special":                                                 "earlymanga.html",
+		"https://api.mangadex.org/manga/" + mangaDexTitleID:                                                                 "mangadex_manga.json",
+		"https://api.mangadex.org/manga/" + mangaDexTitleID + "/feed?translatedLanguage[]=en&order[chapter]=desc&limit=100": "mangadex_feed.json",
+		"https://manganato.com/search/story/solo_leveling":                                                                  "manganato_search.html",
+		"https://api.mangadex.org/manga?title=Solo+Leveling%21&limit=5&includes[]=cover_art&order[relevance]=desc":          "mangadex_search.json",
+	})
+}
+
+func TestFetchManga(t *testing.T) {
+	inputs := []struct {
+		link     string
+		title    string
+		chapters []manga.Chapter
+	}{
+		{"https://manganato.com/manga-dr980474", "Solo Leveling", []manga.Chapter{
+			{200.5, "Chapter 200.5: Side Story", "https://chapmanganato.com/manga-dr980474/chapter-200.5", time.Date(2022, 1, 2, 1, 30, 0, 0, time.UTC)},
+			{200, "Chapter 200", "https://chapmanganato.com/manga-dr980474/chapter-200", time.Date(2021, 12, 29, 13, 5, 0, 0, time.UTC)},
+			{199, "Chapter 199: The Last Battle", "https://chapmanganato.com/manga-dr980474/chapter-199", time.Date(2021, 12, 22, 13, 0, 0, 0, time.UTC)},
+		}},
+		{"https://earlymanga.org/manga/a-returner-s-magic-should-be-special", "A Returner's Magic Should Be Special", []manga.Chapter{
+			{187, "Chapter 187", "https://earlymanga.org/manga/a-returner-s-magic-should-be-special/chapter-187", time.Date(2022, 1, 5, 14, 0, 0, 0, time.UTC)},
+			{186, "Chapter 186", "https://earlymanga.org/manga/a-returner-s-magic-should-be-special/chapter-186", time.Date(2021, 12, 29, 14, 0, 0, 0, time.UTC)},
+		}},
+		{"https://mangadex.org/title/" + mangaDexTitleID + "/solo-leveling", "Solo Leveling", []manga.Chapter{
+			{180, "Chapter 180: Epilogue", "https://mangadex.org/chapter/a1b2c3d4-0000-4000-8000-000000000180", time.Date(2022, 1, 3, 10, 15, 0, 0, time.UTC)},
+			{179, "Chapter 179", "https://mangadex.org/chapter/a1b2c3d4-0000-4000-8000-000000000179", time.Date(2021, 12, 27, 9, 0, 0, 0, time.UTC)},
+		}},
+	}
+
+	registry := newFixtureRegistry()
+	for _, input := range inputs {
+		m, err := registry.Fetch(input.link)
+		if err != nil {
+			t.Error(input.link, " ", err)
+			continue
+		}
+		if m.Title != input.title || m.URL != input.link {
+			t.Error("Unexpected manga ", m.Title, " ", m.URL)
+		}
+		if len(m.Chapters) != len(input.chapters) {
+			t.Error("Unexpected chapters for ", input.link, ": ", m.Chapters)
+			continue
+		}
+		for i, c := range m.Chapters {
+			e := input.chapters[i]
+			if c.Number != e.Number || c.Title != e.Title || c.URL != e.URL || !c.Released.Equal(e.Released) {
+				t.Error("Unexpected chapter ", c, " expected ", e)
+			}
+		}
+	}
+}
+
+func TestFindSource(t *testing.T) {
+	inputs := []struct {
+		link     string
+		expected string
+	}{
+		{"https://readmanganato.com/manga-dr980474", "manganato.com"},
+		{"https://www.earlymanga.org/manga/some-manga", "earlymanga.org"},
+		{"https://mangadex.org/title/" + mangaDexTitleID, "mangadex.org"},
+		{"https://mangadex.org/chapter/" + mangaDexTitleID, ""},
+		{"https://example.com/manga/1", ""},
+	}
+
+	registry := newFixtureRegistry()
+	for _, input := range inputs {
+		s, err := registry.Find(input.link)
+		if input.expected == "" {
+			if err != manga.ErrUnsupported {
+				t.Error("Link should be unsupported ", input.link)
+			}
+			continue
+		}
+		if err != nil || s.Name() != input.expected {
+			t.Error("Wrong source for ", input.link, " ", err)
+		}
+	}
+}
+
+type testSource struct{}
+
+func (s *testSource) Name() string {
+	return "example.com"
+}
+
+func (s *testSource) Matches(u *url.URL) bool {
+	return u.Host == "example.com"
+}
+
+func (s *testSource) Fetch(f manga.Fetcher, link string) (manga.Manga, error) {
+	return manga.Manga{Title: "Example", URL: link}, nil
+}
+
+func TestRegisterSource(t *testing.T) {
+	registry := newFixtureRegistry()
+	registry.Register(&testSource{})
+
+	m, err := registry.Fetch("https://example.com/manga/1")
+	if err != nil || m.Title != "Example" {
+		t.Error("Registered source not used ", err)
+	}
+	if names := registry.Names(); len(names) != 4 || names[3] != "example.com" {
+		t.Error("Unexpected names ", names)
+	}
+}
+
+func TestSearchManga(t *testing.T) {
+	registry := newFixtureRegistry()
+
+	rs, err := registry.Search("Solo Leveling!", 3)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	//Results alternate between the sources that can search
+	expected := []manga.SearchResult{
+		{"Solo Leveling", "https://manganato.com/manga-dr980474", "https://avt.mkklcdnv6temp.com/19/k/20-1583501895.jpg", "Chapter 200", "manganato.com"},
+		{"Solo Leveling", "https://mangadex.org/title/" + mangaDexTitleID, "https://uploads.mangadex.org/covers/" + mangaDexTitleID + "/solo.jpg.256.jpg", "Chapter 179", "mangadex.org"},
+		{"Solo Leveling: Ragnarok", "https://manganato.com/manga-ko987549", "https://avt.mkklcdnv6temp.com/1/x/ragnarok.jpg", "", "manganato.com"},
+	}
+	if len(rs) != len(expected) {
+		t.Fatal("Unexpected results ", rs)
+	}
+	for i := range rs {
+		if rs[i] != expected[i] {
+			t.Error("Unexpected result ", rs[i], " expected ", expected[i])
+		}
+	}
+
+	//Every result is a link the registry can follow
+	for _, r := range rs {
+		if _, err := registry.Find(r.URL); err != nil {
+			t.Error("Result can not be followed ", r.URL)
+		}
+	}
+
+	rs, _ = registry.Search("Solo Leveling!", 10)
+	if len(rs) != 4 || rs[3].Title != "俺だけレベルアップな件" {
+		t.Error("Alternative title not used ", rs)
+	}
+}
+
+func TestSearchFailures(t *testing.T) {
+	registry := manga.DefaultRegistry(fixtureFetcher{})
+
+	if _, err := registry.Search("Solo Leveling", 5); err == nil {
+		t.Error("Expected an error when every source fails")
+	}
+}
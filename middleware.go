@@ -3,17 +3,21 @@ package main
 import (
 	"discordbot/challonge"
 	"discordbot/commands"
+	"discordbot/fetch"
+	"discordbot/manga"
 	"discordbot/strawpoll"
 	"discordbot/twitter"
 	"errors"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/andersfylling/disgord"
 )
 
 type middlewareHolder struct {
 	session        commands.DiscordSession
+	challongeClient *middlewareChallongeClient
 	commandFactory map[string]func(data *disgord.MessageCreate, user *commands.Users)interface{}
 	myself         *disgord.User
 	*jobQueue
@@ -28,28 +32,80 @@ type middlewareChallongeClient struct {
 	client *challonge.Client
 }
 
-func (c *middlewareChallongeClient) GetParticipants(tourneyID string) []challonge.Participant {
-	pc := c.client.Participant.Index(tourneyID)
+func (c *middlewareChallongeClient) GetParticipants(tourneyID string) ([]challonge.Participant, error) {
+	pc, err := c.client.Participant.Index(tourneyID)
 	var r []challonge.Participant
 	for _, p := range pc {
 		r = append(r, p.Participant)
 	}
-	return r
+	return r, err
 }
-func (c *middlewareChallongeClient) GetMatches(tourneyID string) []challonge.Match {
-	mc := c.client.Match.Index(tourneyID)
+func (c *middlewareChallongeClient) GetMatches(tourneyID string) ([]challonge.Match, error) {
+	mc, err := c.client.Match.Index(tourneyID)
 	var r []challonge.Match
 	for _, m := range mc {
 		r = append(r, m.Match)
 	}
-	return r
+	return r, err
 }
 func (c *middlewareChallongeClient) GetMatch(tourneyID string, matchID int) challonge.Match {
 	mc := c.client.Match.Show(tourneyID, strconv.Itoa(matchID))
 	return mc.Match
 }
-func (c *middlewareChallongeClient) UpdateMatch(tourneyID string, matchID int, params challonge.MatchQueryParams) {
-	c.client.Match.Update(tourneyID, strconv.Itoa(matchID), params)
+func (c *middlewareChallongeClient) UpdateMatch(tourneyID string, matchID int, params challonge.MatchQueryParams) error {
+	return c.client.Match.Update(tourneyID, strconv.Itoa(matchID), params)
+}
+func (c *middlewareChallongeClient) ReopenMatch(tourneyID string, matchID int) error {
+	return c.client.Match.Reopen(tourneyID, strconv.Itoa(matchID))
+}
+func (c *middlewareChallongeClient) CreateTournament(params challonge.TournamentParams) (challonge.Tournament, error) {
+	t, err := c.client.Tournament.Create(params)
+	if err != nil {
+		return challonge.Tournament{}, err
+	}
+	return t.Tournament, nil
+}
+func (c *middlewareChallongeClient) StartTournament(tourneyID string) error {
+	_, err := c.client.Tournament.Start(tourneyID)
+	return err
+}
+func (c *middlewareChallongeClient) ResetTournament(tourneyID string) error {
+	_, err := c.client.Tournament.Reset(tourneyID)
+	return err
+}
+func (c *middlewareChallongeClient) FinalizeTournament(tourneyID string) error {
+	_, err := c.client.Tournament.Finalize(tourneyID)
+	return err
+}
+func (c *middlewareChallongeClient) OpenCheckIn(tourneyID string, duration time.Duration) error {
+	_, err := c.client.Tournament.OpenCheckIn(tourneyID, duration)
+	return err
+}
+func (c *middlewareChallongeClient) AddParticipant(tourneyID string, name string) (challonge.Participant, error) {
+	p, err := c.client.Participant.Create(tourneyID, challonge.ParticipantParams{Name: name})
+	if err != nil {
+		return challonge.Participant{}, err
+	}
+	return p.Participant, nil
+}
+func (c *middlewareChallongeClient) RemoveParticipant(tourneyID string, participantID int) error {
+	return c.client.Participant.Destroy(tourneyID, participantID)
+}
+func (c *middlewareChallongeClient) SeedParticipant(tourneyID string, participantID int, seed int) error {
+	_, err := c.client.Participant.Update(tourneyID, participantID, challonge.ParticipantParams{Seed: seed})
+	return err
+}
+func (c *middlewareChallongeClient) CheckInParticipant(tourneyID string, participantID int) error {
+	_, err := c.client.Participant.CheckIn(tourneyID, participantID)
+	return err
+}
+func (c *middlewareChallongeClient) UndoCheckInParticipant(tourneyID string, participantID int) error {
+	_, err := c.client.Participant.UndoCheckIn(tourneyID, participantID)
+	return err
+}
+func (c *middlewareChallongeClient) ProcessCheckIns(tourneyID string) error {
+	_, err := c.client.Tournament.ProcessCheckIns(tourneyID)
+	return err
 }
 
 func newMiddlewareHolder(discordSession commands.DiscordSession,
@@ -57,16 +113,19 @@ func newMiddlewareHolder(discordSession commands.DiscordSession,
 	repos *repositoryContainer,
 	twitterClient *twitter.TwitterClient,
 	strawpollClient *strawpoll.Client,
-	challongeeClient *challonge.Client) (m *middlewareHolder, err error) {
+	challongeeClient *challonge.Client,
+	fetcher *fetch.Fetcher,
+	mangaSources *manga.Registry) (m *middlewareHolder, err error) {
 
 	cclient := &middlewareChallongeClient{challongeeClient}
 
 	roleCommandFactory := commands.NewRoleCommandRequestFactory(discordSession, repos.roleCommandRepo)
 	twitterCommandFactory := commands.NewTwitterFollowCommandFactory(discordSession, twitterClient, repos.twitterFollowRepo)
 	strawpollFactory := commands.NewCommandFactory(discordSession, strawpollClient, repos.strawpollRepo)
-	tourneyFactory := commands.NewTourneyCommandRequestFactory(discordSession, repos.tournamentRepo, cclient)
-	mangaNotificationFactory := commands.NewMangaNotificationFactory(repos.mangaNotificationRepo, repos.mangaLinkRepo, discordSession)
-	emojifyCommandFactory := commands.NewEmojifyCommandFactory(discordSession)
+	tourneyFactory := commands.NewTourneyCommandRequestFactory(discordSession, repos.tournamentRepo, repos.usersRepo, cclient)
+	mangaNotificationFactory := commands.NewMangaNotificationFactory(repos.mangaNotificationRepo, repos.mangaLinkRepo, discordSession, mangaSources)
+	emojifyCommandFactory := commands.NewEmojifyCommandFactory(discordSession, fetcher)
+	votePollFactory := commands.NewVotePollCommandFactory(discordSession, repos.votePollRepo)
 
 	commandMap := make(map[string]func(data *disgord.MessageCreate, user *commands.Users)interface{})
 	
@@ -75,13 +134,29 @@ func newMiddlewareHolder(discordSession commands.DiscordSession,
 	commandMap[commands.TwitterFollowListString] = twitterCommandFactory.CreateFollowListRequest
 	commandMap[commands.TwitterUnfollowString] = twitterCommandFactory.CreateUnfollowRequest
 	commandMap[commands.StrawPollDeadlineString] = strawpollFactory.CreateRequest
+	commandMap[commands.StrawPollString] = strawpollFactory.CreatePollRequest
 	commandMap[commands.TournamentCommandString] = tourneyFactory.CreateRequest
 	commandMap[commands.TournamentAddOrganizerString] = tourneyFactory.CreateAddOrganizerCommand
+	commandMap[commands.TournamentRemoveOrganizerString] = tourneyFactory.CreateRemoveOrganizerCommand
+	commandMap[commands.TournamentOrganizerListString] = tourneyFactory.CreateOrganizerListCommand
 	commandMap[commands.TournamentNextLosersMatchString] = tourneyFactory.CreateNextLosersCommnad
+	commandMap[commands.TournamentNextMatchString] = tourneyFactory.CreateNextMatchCommand
+	commandMap[commands.TournamentMatchQueueString] = tourneyFactory.CreateMatchQueueCommand
 	commandMap[commands.TournamentMatchWinString] = tourneyFactory.CreateWinnerCommand
+	commandMap[commands.TournamentReportString] = tourneyFactory.CreateReportCommand
 	commandMap[commands.TournamentFinishString] = tourneyFactory.CreateTourneyCloseCommand
+	commandMap[commands.TournamentBracketString] = tourneyFactory.CreateBracketCommand
+	commandMap[commands.TournamentStandingsString] = tourneyFactory.CreateStandingsCommand
+	commandMap[commands.TournamentResultsString] = tourneyFactory.CreateResultsCommand
+	commandMap[commands.TournamentPlayerStatsString] = tourneyFactory.CreatePlayerStatsCommand
+	commandMap[commands.TournamentHeadToHeadString] = tourneyFactory.CreateHeadToHeadCommand
+	commandMap[commands.TournamentRatingString] = tourneyFactory.CreateRatingCommand
+	commandMap[commands.TournamentLeaderboardString] = tourneyFactory.CreateLeaderboardCommand
+	commandMap[commands.TournamentCheckInString] = tourneyFactory.CreateCheckInCommand
 	commandMap[commands.MangaNotificationString] = mangaNotificationFactory.CreateRequest
+	commandMap[commands.MangaString] = mangaNotificationFactory.CreateMangaCommand
 	commandMap[commands.EmojifyString] = emojifyCommandFactory.CreateRequest
+	commandMap[commands.VoteString] = votePollFactory.CreateRequest
 
 	// var commandList []help.PrintHelp
 	// for _, c := range commands {
@@ -91,6 +166,7 @@ func newMiddlewareHolder(discordSession commands.DiscordSession,
 
 	m = &middlewareHolder{
 		session:             discordSession,
+		challongeClient:     cclient,
 		jobQueue:            jobQueue,
 		commandFactory:      commandMap,
 		repositoryContainer: repos}
@@ -176,34 +252,83 @@ func (m *middlewareHolder) createMessageContentForNonCommand(evt interface{}) in
 	return evt
 }
 
-func (m *middlewareHolder) reactionAdd(e *disgord.MessageReactionAdd) interface{} {
-	if isCommand, err := m.roleCommandRepo.IsRoleCommandMessage(e.MessageID, e.PartialEmoji.ID); err != nil || !isCommand {
+//createMangaSearchReply - replies to a manga search follow the result they name
+func (m *middlewareHolder) createMangaSearchReply(evt interface{}) interface{} {
+	e, ok := evt.(*disgord.MessageCreate)
+	if !ok || e.Message.MessageReference == nil {
+		return nil
+	}
+	if isSearch, err := m.mangaNotificationRepo.IsMangaSearchMessage(e.Message.MessageReference.MessageID); err != nil || !isSearch {
 		return nil
 	}
 
+	user := commands.Users{DiscordUsersID: e.Message.Author.ID, UserName: e.Message.Author.Username}
+	if !m.usersRepo.DoesUserExist(e.Message.Author.ID) {
+		err := m.usersRepo.SaveUser(&user)
+		if err != nil {
+			log.Println(err)
+			return nil
+		}
+	} else {
+		user, _ = m.usersRepo.GetUserByDiscordId(e.Message.Author.ID)
+	}
+
+	m.jobQueue.onMessageCreate.PushBack(commands.NewMangaSearchReply(m.mangaNotificationRepo, m.mangaLinkRepo, m.session, e, &user))
+	return evt
+}
+
+func (m *middlewareHolder) reactionAdd(e *disgord.MessageReactionAdd) interface{} {
 	c := m.createReactionAddAction(e)
+	if c == nil {
+		return nil
+	}
+
 	m.jobQueue.onReactionAdd.PushBack(c)
 
 	return e
 }
 
 func (m *middlewareHolder) createReactionAddAction(e *disgord.MessageReactionAdd) onReactionAdd {
-	return commands.NewAddRoleReact(m.roleCommandRepo, m.session, e)
+	if isCommand, err := m.roleCommandRepo.IsRoleCommandMessage(e.MessageID, e.PartialEmoji.ID); err == nil && isCommand {
+		return commands.NewAddRoleReact(m.roleCommandRepo, m.session, e)
+	}
+	if isPoll, err := m.votePollRepo.IsVotePollMessage(e.MessageID); err == nil && isPoll {
+		return commands.NewAddVoteReact(m.votePollRepo, m.session, e)
+	}
+	if isReport, err := m.tournamentRepo.IsMatchReportMessage(e.MessageID); err == nil && isReport {
+		return commands.NewMatchReportReact(m.tournamentRepo, m.session, m.challongeClient, e)
+	}
+	if isCheckIn, err := m.tournamentRepo.IsCheckInMessage(e.MessageID); err == nil && isCheckIn {
+		return commands.NewCheckInReact(m.tournamentRepo, m.session, m.challongeClient, e)
+	}
+	if isSearch, err := m.mangaNotificationRepo.IsMangaSearchMessage(e.MessageID); err == nil && isSearch {
+		return commands.NewMangaSearchReact(m.mangaNotificationRepo, m.mangaLinkRepo, m.usersRepo, m.session, e)
+	}
+	return nil
 }
 
 func (m *middlewareHolder) reactionRemove(e *disgord.MessageReactionRemove) interface{} {
-	if isCommand, err := m.roleCommandRepo.IsRoleCommandMessage(e.MessageID, e.PartialEmoji.ID); err != nil || !isCommand {
+	c := m.createReactionRemoveAction(e)
+	if c == nil {
 		return nil
 	}
 
-	c := m.createReactionRemoveAction(e)
 	m.jobQueue.onReactionRemove.PushBack(c)
 
 	return e
 }
 
 func (m *middlewareHolder) createReactionRemoveAction(e *disgord.MessageReactionRemove) onReactionRemove {
-	return commands.NewRemoveRoleReact(m.roleCommandRepo, m.session, e)
+	if isCommand, err := m.roleCommandRepo.IsRoleCommandMessage(e.MessageID, e.PartialEmoji.ID); err == nil && isCommand {
+		return commands.NewRemoveRoleReact(m.roleCommandRepo, m.session, e)
+	}
+	if isPoll, err := m.votePollRepo.IsVotePollMessage(e.MessageID); err == nil && isPoll {
+		return commands.NewRemoveVoteReact(m.votePollRepo, m.session, e)
+	}
+	if isCheckIn, err := m.tournamentRepo.IsCheckInMessage(e.MessageID); err == nil && isCheckIn {
+		return commands.NewCheckOutReact(m.tournamentRepo, m.session, m.challongeClient, e)
+	}
+	return nil
 }
 
 func (m *middlewareHolder) isFromAdmin(evt interface{}) interface{} {
@@ -3,6 +3,7 @@ package repositories
 import (
 	"database/sql"
 	"discordbot/commands"
+	"time"
 )
 
 type mangaNotificationRepo struct {
@@ -26,7 +27,7 @@ func NewMangaLinkRepository(db *sql.DB) *mangaLinkRepo {
 }
 
 func (r *mangaNotificationRepo) SaveMangaNotification(m *commands.MangaNotification) error {
-	const query = `INSERT INTO manga_notification (author, guild, channel, role) VALUES (?, ?, ?, ?);`
+	const query = `INSERT INTO manga_notification (author, guild, channel, role, discord_user) VALUES (?, ?, ?, ?, ?);`
 	tx, err := r.db.Begin()
 
 	if err != nil {
@@ -45,7 +46,8 @@ func (r *mangaNotificationRepo) SaveMangaNotification(m *commands.MangaNotificat
 		m.User,
 		m.Guild,
 		m.Channel,
-		m.Role)
+		m.Role,
+		m.DiscordUserID)
 
 	if err != nil {
 		return err
@@ -62,7 +64,7 @@ func (r *mangaNotificationRepo) SaveMangaNotification(m *commands.MangaNotificat
 }
 
 func (r *mangaNotificationRepo) GetAllMangaNotifications() ([]commands.MangaNotification, error) {
-	const query = `SELECT * FROM manga_notification;`
+	const query = `SELECT manga_notification_id, author, guild, channel, role, discord_user FROM manga_notification;`
 
 	rows, _ := r.db.Query(query)
 	if rows.Err() != nil {
@@ -78,7 +80,8 @@ func (r *mangaNotificationRepo) GetAllMangaNotifications() ([]commands.MangaNoti
 			&row.User,
 			&row.Guild,
 			&row.Channel,
-			&row.Role)
+			&row.Role,
+			&row.DiscordUserID)
 		if err != nil {
 			return []commands.MangaNotification{}, err
 		}
@@ -114,6 +117,63 @@ func (r *mangaNotificationRepo) AddMangaLink(mangaNotificationId int64, mangaLin
 	return nil
 }
 
+const mangaNotificationWithLinkQuery = `SELECT mn.manga_notification_id, mn.author, mn.guild, mn.channel, mn.role, mn.discord_user, ml.manga_link
+	FROM manga_notification AS mn
+	JOIN manga_notification_links AS mnl ON mnl.manga_notification_id = mn.manga_notification_id
+	JOIN manga_links AS ml ON ml.manga_link_id = mnl.manga_link_id`
+
+//GetMangaNotifications returns the server's channel subscriptions and the user's DM subscriptions with their manga
+func (r *mangaNotificationRepo) GetMangaNotifications(guild commands.Snowflake, discordUserID commands.Snowflake) ([]commands.MangaNotification, error) {
+	const query = mangaNotificationWithLinkQuery + `
+	WHERE (mn.guild = ? AND mn.discord_user = 0) OR mn.discord_user = ?
+	ORDER BY mn.manga_notification_id;`
+
+	rows, err := r.db.Query(query, guild, discordUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []commands.MangaNotification
+	for rows.Next() {
+		row := commands.MangaNotification{}
+		err := rows.Scan(
+			&row.MangaNotificationID,
+			&row.User,
+			&row.Guild,
+			&row.Channel,
+			&row.Role,
+			&row.DiscordUserID,
+			&row.Link)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+//RemoveMangaNotification deletes a subscription and any manga nobody is subscribed to anymore
+func (r *mangaNotificationRepo) RemoveMangaNotification(ID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM manga_notification_links WHERE manga_notification_id = ?;`, ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM manga_notification WHERE manga_notification_id = ?;`, ID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM manga_links WHERE manga_link_id NOT IN (SELECT manga_link_id FROM manga_notification_links);`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (r *mangaLinkRepo) SaveMangaLink(m *commands.MangaLink) error {
 	const query = `INSERT INTO manga_links(manga_link) VALUES (?);`
 	tx, err := r.db.Begin()
@@ -147,7 +207,7 @@ func (r *mangaLinkRepo) SaveMangaLink(m *commands.MangaLink) error {
 }
 
 func (r *mangaLinkRepo) GetMangaLinkByLink(link string) (commands.MangaLink, error) {
-	const query = `SELECT * FROM manga_links WHERE manga_link = ?;`
+	const query = `SELECT manga_link_id, manga_link, last_chapter, last_chapter_url FROM manga_links WHERE manga_link = ?;`
 
 	row := r.db.QueryRow(query, link)
 	if row.Err() != nil {
@@ -158,7 +218,9 @@ func (r *mangaLinkRepo) GetMangaLinkByLink(link string) (commands.MangaLink, err
 
 	err := row.Scan(
 		&completedCommand.MangaLinkID,
-		&completedCommand.MangaLink)
+		&completedCommand.MangaLink,
+		&completedCommand.LastChapter,
+		&completedCommand.LastChapterURL)
 
 	if err != nil && err.Error() == "sql: no rows in result set" {
 		return commands.MangaLink{}, nil
@@ -166,7 +228,7 @@ func (r *mangaLinkRepo) GetMangaLinkByLink(link string) (commands.MangaLink, err
 		return commands.MangaLink{}, err
 	}
 
-	const query2 = `SELECT mn.manga_notification_id, mn.author, mn.guild, mn.channel, mn.role FROM manga_notification_links as mnl 
+	const query2 = `SELECT mn.manga_notification_id, mn.author, mn.guild, mn.channel, mn.role, mn.discord_user FROM manga_notification_links as mnl 
 					JOIN manga_notification as mn on mn.manga_notification_id = mnl.manga_notification_id
 					WHERE mnl.manga_link_id = ?;`
 	subqueryrows, _ := r.db.Query(query2, completedCommand.MangaLinkID)
@@ -181,7 +243,8 @@ func (r *mangaLinkRepo) GetMangaLinkByLink(link string) (commands.MangaLink, err
 			&row.User,
 			&row.Guild,
 			&row.Channel,
-			&row.Role)
+			&row.Role,
+			&row.DiscordUserID)
 		if err != nil {
 			return commands.MangaLink{}, err
 		}
@@ -192,7 +255,7 @@ func (r *mangaLinkRepo) GetMangaLinkByLink(link string) (commands.MangaLink, err
 }
 
 func (r *mangaLinkRepo) GetAllMangaLinks() ([]commands.MangaLink, error) {
-	const query = `SELECT * FROM manga_links;`
+	const query = `SELECT manga_link_id, manga_link, last_chapter, last_chapter_url FROM manga_links;`
 
 	rows, _ := r.db.Query(query)
 	if rows.Err() != nil {
@@ -206,7 +269,9 @@ func (r *mangaLinkRepo) GetAllMangaLinks() ([]commands.MangaLink, error) {
 		row := commands.MangaLink{MangaNotifications: []commands.MangaNotification{}}
 		err := rows.Scan(
 			&row.MangaLinkID,
-			&row.MangaLink)
+			&row.MangaLink,
+			&row.LastChapter,
+			&row.LastChapterURL)
 		if err != nil {
 			return []commands.MangaLink{}, err
 		}
@@ -217,7 +282,7 @@ func (r *mangaLinkRepo) GetAllMangaLinks() ([]commands.MangaLink, error) {
 		return []commands.MangaLink{}, rows.Err()
 	}
 
-	const query2 = `SELECT mn.manga_notification_id, mn.author, mn.guild, mn.channel, mn.role FROM manga_notification_links as mnl 
+	const query2 = `SELECT mn.manga_notification_id, mn.author, mn.guild, mn.channel, mn.role, mn.discord_user FROM manga_notification_links as mnl 
 					JOIN manga_notification as mn on mn.manga_notification_id = mnl.manga_notification_id
 					WHERE mnl.manga_link_id = ?;`
 	for i := range completedCommand {
@@ -233,7 +298,8 @@ func (r *mangaLinkRepo) GetAllMangaLinks() ([]commands.MangaLink, error) {
 				&row.User,
 				&row.Guild,
 				&row.Channel,
-				&row.Role)
+				&row.Role,
+				&row.DiscordUserID)
 			if err != nil {
 				return []commands.MangaLink{}, err
 			}
@@ -244,3 +310,91 @@ func (r *mangaLinkRepo) GetAllMangaLinks() ([]commands.MangaLink, error) {
 
 	return completedCommand, nil
 }
+
+//UpdateLastChapter records the newest chapter announced for a link
+func (r *mangaLinkRepo) UpdateLastChapter(m *commands.MangaLink) error {
+	const query = `UPDATE manga_links SET last_chapter = ?, last_chapter_url = ? WHERE manga_link_id = ?;`
+	_, err := r.db.Exec(query, m.LastChapter, m.LastChapterURL, m.MangaLinkID)
+	return err
+}
+
+func (r *mangaNotificationRepo) SaveMangaSearch(m *commands.MangaSearch) error {
+	const query = `INSERT INTO manga_search (guild, channel, msg, created) VALUES (?, ?, ?, ?);`
+	const resultQuery = `INSERT INTO manga_search_result (manga_search_id, position, title, manga_link) VALUES (?, ?, ?, ?);`
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, m.Guild, m.Channel, m.Message, m.Created.Unix())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	ID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, res := range m.Results {
+		if _, err := tx.Exec(resultQuery, ID, res.Position, res.Title, res.Link); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	m.MangaSearchID = ID
+	return nil
+}
+
+func (r *mangaNotificationRepo) GetMangaSearchByMessage(msg commands.Snowflake) (commands.MangaSearch, error) {
+	const query = `SELECT manga_search_id, guild, channel, msg, created FROM manga_search WHERE msg = ?;`
+	const resultQuery = `SELECT position, title, manga_link FROM manga_search_result WHERE manga_search_id = ? ORDER BY position;`
+
+	m := commands.MangaSearch{}
+	var created int64
+	err := r.db.QueryRow(query, msg).Scan(&m.MangaSearchID, &m.Guild, &m.Channel, &m.Message, &created)
+	if err != nil {
+		return commands.MangaSearch{}, err
+	}
+	m.Created = time.Unix(created, 0)
+
+	rows, err := r.db.Query(resultQuery, m.MangaSearchID)
+	if err != nil {
+		return commands.MangaSearch{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		res := commands.MangaSearchResult{}
+		if err := rows.Scan(&res.Position, &res.Title, &res.Link); err != nil {
+			return commands.MangaSearch{}, err
+		}
+		m.Results = append(m.Results, res)
+	}
+	return m, rows.Err()
+}
+
+func (r *mangaNotificationRepo) IsMangaSearchMessage(msg commands.Snowflake) (bool, error) {
+	const query = `SELECT COUNT(*) FROM manga_search WHERE msg = ?;`
+
+	var count int
+	err := r.db.QueryRow(query, msg).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+//RemoveMangaSearchesBefore clears out searches nobody is going to pick from anymore
+func (r *mangaNotificationRepo) RemoveMangaSearchesBefore(t time.Time) error {
+	const query = `DELETE FROM manga_search WHERE created < ?;`
+
+	_, err := r.db.Exec(query, t.Unix())
+
+	return err
+}
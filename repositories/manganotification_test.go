@@ -8,6 +8,7 @@ import (
 	"log"
 	"reflect"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -45,7 +46,7 @@ func TestSaveMangaNotification(t *testing.T) {
 		return
 	}
 
-	row := db.QueryRow(`SELECT * FROM manga_notification WHERE manga_notification_id = 1`)
+	row := db.QueryRow(`SELECT manga_notification_id, author, guild, channel, role FROM manga_notification WHERE manga_notification_id = 1`)
 	result := commands.MangaNotification{}
 	err = row.Scan(
 		&result.MangaNotificationID,
@@ -190,7 +191,7 @@ func TestSaveMangaLink(t *testing.T) {
 		return
 	}
 
-	row := db.QueryRow(`SELECT * FROM manga_links WHERE manga_link_id = 1`)
+	row := db.QueryRow(`SELECT manga_link_id, manga_link FROM manga_links WHERE manga_link_id = 1`)
 	result := commands.MangaLink{}
 	err = row.Scan(
 		&result.MangaLinkID,
@@ -311,4 +312,133 @@ func TestGetAllMangaLinks(t *testing.T) {
 	if !reflect.DeepEqual(rs[1], mn2) {
 		t.Error("Error with retrieving manga link.")
 	}
-}
\ No newline at end of file
+}
+
+func TestUpdateLastChapter(t *testing.T) {
+	db := initDB()
+	defer db.Close()
+
+	d := repositories.NewMangaLinkRepository(db)
+
+	mangalink := commands.MangaLink{MangaLink: "manga.com/manga"}
+	if err := d.SaveMangaLink(&mangalink); err != nil {
+		t.Fatal(err)
+	}
+
+	mangalink.LastChapter = 12.5
+	mangalink.LastChapterURL = "manga.com/manga/chapter-12.5"
+	if err := d.UpdateLastChapter(&mangalink); err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := d.GetMangaLinkByLink("manga.com/manga")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.LastChapter != 12.5 || rs.LastChapterURL != "manga.com/manga/chapter-12.5" {
+		t.Error("Last chapter not saved ", rs)
+	}
+
+	all, err := d.GetAllMangaLinks()
+	if err != nil || len(all) != 1 || all[0].LastChapterURL != "manga.com/manga/chapter-12.5" {
+		t.Error("Last chapter not returned with all links ", all, err)
+	}
+}
+
+func TestGetAndRemoveMangaNotifications(t *testing.T) {
+	db := initDB()
+	defer db.Close()
+
+	mndb := repositories.NewMangaNotificationRepository(db)
+	mldb := repositories.NewMangaLinkRepository(db)
+
+	ns := []commands.MangaNotification{
+		{User: 1234, Guild: 1, Channel: 2, Role: 3, Link: "manga.com/manga"},
+		{User: 1234, Guild: 1, DiscordUserID: 5678, Link: "manga.com/manga"},
+		{User: 1234, Guild: 1, DiscordUserID: 9999, Link: "manga.com/other"},
+		{User: 1234, Guild: 2, Channel: 4, Role: 5, Link: "manga.com/other"},
+	}
+	for i := range ns {
+		if err := mndb.SaveMangaNotification(&ns[i]); err != nil {
+			t.Fatal(err)
+		}
+		link, _ := mldb.GetMangaLinkByLink(ns[i].Link)
+		if link.MangaLinkID == 0 {
+			link.MangaLink = ns[i].Link
+			if err := mldb.SaveMangaLink(&link); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := mndb.AddMangaLink(ns[i].MangaNotificationID, link.MangaLinkID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//The server's channel subscriptions and the user's own DMs
+	rs, err := mndb.GetMangaNotifications(1, 5678)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rs, ns[:2]) {
+		t.Error("Unexpected notifications ", rs)
+	}
+
+	//Removing the last subscription to a manga removes the manga
+	if err := mndb.RemoveMangaNotification(ns[3].MangaNotificationID); err != nil {
+		t.Fatal(err)
+	}
+	if link, _ := mldb.GetMangaLinkByLink("manga.com/other"); link.MangaLinkID == 0 {
+		t.Error("Manga removed while still followed")
+	}
+	if err := mndb.RemoveMangaNotification(ns[2].MangaNotificationID); err != nil {
+		t.Fatal(err)
+	}
+	if link, _ := mldb.GetMangaLinkByLink("manga.com/other"); link.MangaLinkID != 0 {
+		t.Error("Manga nobody follows not removed")
+	}
+	all, _ := mndb.GetAllMangaNotifications()
+	if len(all) != 2 {
+		t.Error("Wrong notifications left ", all)
+	}
+}
+
+func TestMangaSearches(t *testing.T) {
+	db := initDB()
+	defer db.Close()
+
+	d := repositories.NewMangaNotificationRepository(db)
+
+	old := commands.MangaSearch{Guild: 1, Channel: 2, Message: 3, Created: time.Unix(1000, 0), Results: []commands.MangaSearchResult{
+		{Position: 1, Title: "Old Manga", Link: "manga.com/old"},
+	}}
+	search := commands.MangaSearch{Guild: 1, Channel: 2, Message: 4, Created: time.Unix(2000, 0), Results: []commands.MangaSearchResult{
+		{Position: 1, Title: "Manga", Link: "manga.com/manga"},
+		{Position: 2, Title: "Other Manga", Link: "manga.com/other"},
+	}}
+	for _, s := range []*commands.MangaSearch{&old, &search} {
+		if err := d.SaveMangaSearch(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := d.GetMangaSearchByMessage(4)
+	if err != nil || !reflect.DeepEqual(result, search) {
+		t.Error("Mismatched search found ", result, err)
+	}
+	if is, err := d.IsMangaSearchMessage(4); err != nil || !is {
+		t.Error("Search message not recognized ", err)
+	}
+
+	//Old searches are removed along with their results
+	if err := d.RemoveMangaSearchesBefore(time.Unix(1500, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if is, _ := d.IsMangaSearchMessage(3); is {
+		t.Error("Old search not removed")
+	}
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM manga_search_result`).Scan(&count)
+	if count != 2 {
+		t.Error("Unexpected results left ", count)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"log"
 	"reflect"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -188,4 +189,83 @@ func TestDeleteStrawpollDeadline(t *testing.T) {
 		t.Error("Error in deleting strawpoll deadline. ", err)
 		return
 	}
+}
+
+func TestGetStrawpollDeadlinesByGuild(t *testing.T) {
+	db := initDB()
+	defer db.Close()
+
+	spDB := strawpolldeadline.New(db)
+
+	s1 := commands.StrawpollDeadline{User: 1234, StrawpollID: "abc", Guild: 1, Channel: 5678, Role: 1357}
+	s2 := commands.StrawpollDeadline{User: 1234, StrawpollID: "def", Guild: 2, Channel: 5678, Role: 1357}
+	spDB.SaveStrawpollDeadline(&s1)
+	spDB.SaveStrawpollDeadline(&s2)
+
+	rs, err := spDB.GetStrawpollDeadlinesByGuild(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(rs) != 1 || !reflect.DeepEqual(rs[0], s2) {
+		t.Error("Wrong strawpoll deadlines returned for guild ", rs)
+	}
+
+	r, err := spDB.GetStrawpollDeadlineByID(s1.StrawpollDeadlineID)
+	if err != nil || !reflect.DeepEqual(r, s1) {
+		t.Error("Strawpoll deadline not found by id ", r, err)
+	}
+
+	_, err = spDB.GetStrawpollDeadlineByID(100)
+	if err == nil {
+		t.Error("Expected error for missing strawpoll deadline.")
+	}
+}
+
+func TestSaveStrawpollStandings(t *testing.T) {
+	db := initDB()
+	defer db.Close()
+
+	spDB := strawpolldeadline.New(db)
+
+	d := commands.StrawpollDeadline{User: 1234, StrawpollID: "abc", Guild: 1, Channel: 5678, Role: 1357}
+	spDB.SaveStrawpollDeadline(&d)
+
+	standings := commands.StrawpollStandings{
+		StrawpollDeadlineID: d.StrawpollDeadlineID,
+		UpdateInterval:      6 * time.Hour,
+		Reminder:            time.Hour,
+		Pinned:              true,
+		LastUpdate:          time.Unix(1700000000, 0),
+	}
+	err := spDB.SaveStrawpollStandings(&standings)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	standings.Message = 42
+	standings.ReminderSent = true
+	err = spDB.SaveStrawpollStandings(&standings)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result, err := spDB.GetStrawpollStandings(d.StrawpollDeadlineID)
+	if err != nil || !reflect.DeepEqual(result, standings) {
+		t.Error("Standings not saved correctly ", result, err)
+	}
+
+	all, _ := spDB.GetAllStrawpollStandings()
+	if len(all) != 1 {
+		t.Error("Expected one standings row. Got ", all)
+	}
+
+	spDB.DeleteStrawpollDeadlineByID(d.StrawpollDeadlineID)
+	all, _ = spDB.GetAllStrawpollStandings()
+	if len(all) != 0 {
+		t.Error("Standings not removed with deadline.")
+	}
 }
\ No newline at end of file
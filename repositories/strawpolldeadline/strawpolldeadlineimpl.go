@@ -3,6 +3,7 @@ package strawpolldeadline
 import (
 	"database/sql"
 	"discordbot/commands"
+	"time"
 )
 
 type StrawpollDeadlineRepository struct {
@@ -82,6 +83,55 @@ func (r *StrawpollDeadlineRepository) GetAllStrawpollDeadlines() ([]commands.Str
 	return completedCommand, nil
 }
 
+func (r *StrawpollDeadlineRepository) GetStrawpollDeadlinesByGuild(guild commands.Snowflake) ([]commands.StrawpollDeadline, error) {
+	const query = `SELECT * FROM strawpoll_deadline WHERE guild = ?;`
+
+	rows, err := r.db.Query(query, guild)
+	if err != nil {
+		return []commands.StrawpollDeadline{}, err
+	}
+	defer rows.Close()
+
+	completedCommand := []commands.StrawpollDeadline{}
+
+	for rows.Next() {
+		row := commands.StrawpollDeadline{}
+		err := rows.Scan(
+			&row.StrawpollDeadlineID,
+			&row.User,
+			&row.StrawpollID,
+			&row.Guild,
+			&row.Channel,
+			&row.Role)
+		if err != nil {
+			return []commands.StrawpollDeadline{}, err
+		}
+		completedCommand = append(completedCommand, row)
+	}
+
+	return completedCommand, rows.Err()
+}
+
+func (r *StrawpollDeadlineRepository) GetStrawpollDeadlineByID(ID int64) (commands.StrawpollDeadline, error) {
+	const query = `SELECT * FROM strawpoll_deadline WHERE strawpoll_deadline_id = ?;`
+
+	row := r.db.QueryRow(query, ID)
+	result := commands.StrawpollDeadline{}
+	err := row.Scan(
+		&result.StrawpollDeadlineID,
+		&result.User,
+		&result.StrawpollID,
+		&result.Guild,
+		&result.Channel,
+		&result.Role)
+
+	if err != nil {
+		return commands.StrawpollDeadline{}, err
+	}
+
+	return result, nil
+}
+
 func (r *StrawpollDeadlineRepository) DeleteStrawpollDeadlineByID(ID int64) error {
 	const query = `DELETE FROM strawpoll_deadline WHERE strawpoll_deadline_id = ?;`
 
@@ -96,3 +146,70 @@ func (r *StrawpollDeadlineRepository) DeleteStrawpollDeadlineByID(ID int64) erro
 	}
 	return nil
 }
+
+
+func (r *StrawpollDeadlineRepository) SaveStrawpollStandings(s *commands.StrawpollStandings) error {
+	const query = `REPLACE INTO strawpoll_standings(strawpoll_deadline_id, update_interval, reminder, pinned, msg, last_update, reminder_sent) VALUES (?, ?, ?, ?, ?, ?, ?);`
+
+	_, err := r.db.Exec(query,
+		s.StrawpollDeadlineID,
+		int64(s.UpdateInterval/time.Second),
+		int64(s.Reminder/time.Second),
+		s.Pinned,
+		s.Message,
+		s.LastUpdate.Unix(),
+		s.ReminderSent)
+
+	return err
+}
+
+func (r *StrawpollDeadlineRepository) GetStrawpollStandings(strawpollDeadlineID int64) (commands.StrawpollStandings, error) {
+	const query = `SELECT * FROM strawpoll_standings WHERE strawpoll_deadline_id = ?;`
+
+	return scanStrawpollStandings(r.db.QueryRow(query, strawpollDeadlineID))
+}
+
+func (r *StrawpollDeadlineRepository) GetAllStrawpollStandings() ([]commands.StrawpollStandings, error) {
+	const query = `SELECT * FROM strawpoll_standings;`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return []commands.StrawpollStandings{}, err
+	}
+	defer rows.Close()
+
+	result := []commands.StrawpollStandings{}
+	for rows.Next() {
+		s, err := scanStrawpollStandings(rows)
+		if err != nil {
+			return []commands.StrawpollStandings{}, err
+		}
+		result = append(result, s)
+	}
+
+	return result, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStrawpollStandings(row scanner) (commands.StrawpollStandings, error) {
+	s := commands.StrawpollStandings{}
+	var interval, reminder, lastUpdate int64
+	err := row.Scan(
+		&s.StrawpollDeadlineID,
+		&interval,
+		&reminder,
+		&s.Pinned,
+		&s.Message,
+		&lastUpdate,
+		&s.ReminderSent)
+	if err != nil {
+		return commands.StrawpollStandings{}, err
+	}
+	s.UpdateInterval = time.Duration(interval) * time.Second
+	s.Reminder = time.Duration(reminder) * time.Second
+	s.LastUpdate = time.Unix(lastUpdate, 0)
+	return s, nil
+}
\ No newline at end of file
@@ -0,0 +1,135 @@
+package tourneyrepo
+
+import (
+	"database/sql"
+	"discordbot/commands"
+)
+
+const ratingColumns = `player_rating_id, discord_server_id, game, discord_user_id, name, rating, wins, losses`
+
+func scanRating(row interface{ Scan(...interface{}) error }) (commands.PlayerRating, error) {
+	p := commands.PlayerRating{}
+	err := row.Scan(
+		&p.PlayerRatingID,
+		&p.DiscordServerID,
+		&p.Game,
+		&p.DiscordUserID,
+		&p.Name,
+		&p.Rating,
+		&p.Wins,
+		&p.Losses,
+	)
+	return p, err
+}
+
+func (r *repository) SavePlayerRating(p *commands.PlayerRating) error {
+	if p.PlayerRatingID != 0 {
+		const query = `UPDATE player_rating SET (discord_server_id, game, discord_user_id, name, rating, wins, losses) = (?, ?, ?, ?, ?, ?, ?) WHERE player_rating_id = ?;`
+
+		_, err := r.db.Exec(query, p.DiscordServerID, p.Game, p.DiscordUserID, p.Name, p.Rating, p.Wins, p.Losses, p.PlayerRatingID)
+		return err
+	}
+
+	const query = `INSERT INTO player_rating (discord_server_id, game, discord_user_id, name, rating, wins, losses) VALUES (?, ?, ?, ?, ?, ?, ?);`
+
+	result, err := r.db.Exec(query, p.DiscordServerID, p.Game, p.DiscordUserID, p.Name, p.Rating, p.Wins, p.Losses)
+
+	if err != nil {
+		return err
+	}
+
+	p.PlayerRatingID, err = result.LastInsertId()
+
+	return err
+}
+
+//GetPlayerRating finds a player on a ladder by discord user, or by name for players without one.
+//A player with no rating yet comes back empty with no error.
+func (r *repository) GetPlayerRating(discordServerID commands.Snowflake, game string, discordUserID commands.Snowflake, name string) (commands.PlayerRating, error) {
+	var row *sql.Row
+	if discordUserID != 0 {
+		const query = `SELECT ` + ratingColumns + ` FROM player_rating WHERE discord_server_id = ? AND game = ? COLLATE NOCASE AND discord_user_id = ?;`
+
+		row = r.db.QueryRow(query, discordServerID, game, discordUserID)
+	} else {
+		const query = `SELECT ` + ratingColumns + ` FROM player_rating WHERE discord_server_id = ? AND game = ? COLLATE NOCASE AND discord_user_id = 0 AND name = ? COLLATE NOCASE;`
+
+		row = r.db.QueryRow(query, discordServerID, game, name)
+	}
+
+	p, err := scanRating(row)
+
+	if err == sql.ErrNoRows {
+		return commands.PlayerRating{}, nil
+	}
+
+	return p, err
+}
+
+func (r *repository) GetPlayerRatingByID(ID int64) (commands.PlayerRating, error) {
+	const query = `SELECT ` + ratingColumns + ` FROM player_rating WHERE player_rating_id = ?;`
+
+	return scanRating(r.db.QueryRow(query, ID))
+}
+
+func (r *repository) GetPlayerRatingsByServer(discordServerID commands.Snowflake) ([]commands.PlayerRating, error) {
+	const query = `SELECT ` + ratingColumns + ` FROM player_rating WHERE discord_server_id = ? ORDER BY rating DESC, player_rating_id;`
+
+	rows, err := r.db.Query(query, discordServerID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []commands.PlayerRating
+	for rows.Next() {
+		p, err := scanRating(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, p)
+	}
+
+	return result, nil
+}
+
+func (r *repository) SaveRatedMatch(m *commands.RatedMatch) error {
+	const query = `INSERT OR REPLACE INTO rated_match (discord_server_id, challonge_id, match_id, winner_rating_id, loser_rating_id, change) VALUES (?, ?, ?, ?, ?, ?);`
+
+	_, err := r.db.Exec(query, m.DiscordServerID, m.ChallongeID, m.MatchID, m.WinnerRatingID, m.LoserRatingID, m.Change)
+
+	return err
+}
+
+//GetRatedMatch comes back empty with no error when the match has not been rated
+func (r *repository) GetRatedMatch(discordServerID commands.Snowflake, challongeID string, matchID int) (commands.RatedMatch, error) {
+	const query = `SELECT discord_server_id, challonge_id, match_id, winner_rating_id, loser_rating_id, change FROM rated_match
+	WHERE discord_server_id = ? AND challonge_id = ? AND match_id = ?;`
+
+	m := commands.RatedMatch{}
+	err := r.db.QueryRow(query, discordServerID, challongeID, matchID).Scan(
+		&m.DiscordServerID,
+		&m.ChallongeID,
+		&m.MatchID,
+		&m.WinnerRatingID,
+		&m.LoserRatingID,
+		&m.Change,
+	)
+
+	if err == sql.ErrNoRows {
+		return commands.RatedMatch{}, nil
+	}
+
+	return m, err
+}
+
+func (r *repository) RemoveRatedMatch(discordServerID commands.Snowflake, challongeID string, matchID int) error {
+	const query = `DELETE FROM rated_match WHERE discord_server_id = ? AND challonge_id = ? AND match_id = ?;`
+
+	_, err := r.db.Exec(query, discordServerID, challongeID, matchID)
+
+	return err
+}
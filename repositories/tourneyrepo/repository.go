@@ -3,6 +3,8 @@ package tourneyrepo
 import (
 	"database/sql"
 	"discordbot/commands"
+	"errors"
+	"time"
 )
 
 type repository struct {
@@ -15,6 +17,14 @@ func NewRepository(db *sql.DB) *repository {
 	}
 }
 
+//unixTime stores an unset time as 0
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
 func (r *repository) SaveTourney(t *commands.Tournament) error {
 	if t.TournamentID == 0 {
 		return r.saveNewTourney(t)
@@ -23,7 +33,7 @@ func (r *repository) SaveTourney(t *commands.Tournament) error {
 }
 
 func (r *repository) updateTourney(t *commands.Tournament) error {
-	const query = "UPDATE tournament SET (author, challonge_id, discord_server_id, current_match) = (?, ?, ?, ?);"
+	const query = "UPDATE tournament SET (author, challonge_id, discord_server_id, name, game, check_in_message, check_in_channel, check_in_ends, check_in_reminded, channel_id, announce_channel, current_match, self_reporting, last_reported_match) = (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) WHERE tournament_id = ?;"
 
 	tx, err := r.db.Begin()
 
@@ -43,11 +53,23 @@ func (r *repository) updateTourney(t *commands.Tournament) error {
 		t.User,
 		t.ChallongeID,
 		t.DiscordServerID,
+		t.Name,
+		t.Game,
+		t.CheckInMessage,
+		t.CheckInChannel,
+		unixTime(t.CheckInEnds),
+		t.CheckInReminded,
+		t.ChannelID,
+		t.AnnounceChannel,
 		t.CurrentMatch,
+		t.SelfReporting,
+		t.LastReportedMatch,
+		t.TournamentID,
 	)
 
 	if err != nil {
-		return tx.Rollback()
+		tx.Rollback()
+		return err
 	}
 
 	tx.Commit()
@@ -64,13 +86,19 @@ func (r *repository) updateTourney(t *commands.Tournament) error {
 		return err
 	}
 
+	err = r.saveStations(t.TournamentID, t.Stations)
+
+	if err != nil {
+		return err
+	}
+
 	
 
 	return nil
 }
 
 func (r *repository) saveNewTourney(t *commands.Tournament) error {
-	const query = "INSERT INTO tournament (author, challonge_id, discord_server_id, current_match) VALUES (?, ?, ?, ?);"
+	const query = "INSERT INTO tournament (author, challonge_id, discord_server_id, name, game, check_in_message, check_in_channel, check_in_ends, check_in_reminded, channel_id, announce_channel, current_match, self_reporting, last_reported_match) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);"
 
 	tx, err := r.db.Begin()
 
@@ -90,15 +118,27 @@ func (r *repository) saveNewTourney(t *commands.Tournament) error {
 		t.User,
 		t.ChallongeID,
 		t.DiscordServerID,
+		t.Name,
+		t.Game,
+		t.CheckInMessage,
+		t.CheckInChannel,
+		unixTime(t.CheckInEnds),
+		t.CheckInReminded,
+		t.ChannelID,
+		t.AnnounceChannel,
 		t.CurrentMatch,
+		t.SelfReporting,
+		t.LastReportedMatch,
 	)
 
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
 	tourneyID, err := result.LastInsertId()
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 	tx.Commit()
@@ -117,6 +157,12 @@ func (r *repository) saveNewTourney(t *commands.Tournament) error {
 		return err
 	}
 
+	err = r.saveStations(tourneyID, t.Stations)
+
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -131,19 +177,25 @@ func (r *repository) saveOrganizers(tournamentID int64, os []commands.Users) err
 
 	const insertquery = `INSERT INTO tournament_organizer_xref (tournament_id, users_id) VALUES (?, ?);`
 	tx, err := r.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
 	for _, o := range(os) {
 		if o.UsersID == 0 {
-			return tx.Rollback()
+			tx.Rollback()
+			return errors.New("organizer has no user id")
 		}
 
 		_, err = tx.Exec(insertquery, tournamentID, o.UsersID)
 
 		if err != nil {
-			return tx.Rollback()
+			tx.Rollback()
+			return err
 		}
 	}
-	tx.Commit()
-	return nil
+	return tx.Commit()
 }
 
 func (r *repository) saveParticipants(tournamentID int64, ps []commands.TournamentParticipant) error {
@@ -156,9 +208,15 @@ func (r *repository) saveParticipants(tournamentID int64, ps []commands.Tourname
 	}
 
 	const insertquery = `INSERT INTO tournament_participant_xref (tournament_id, tournament_participant_id) VALUES (?, ?);`
-	for _, p := range(ps) {
+	for i := range(ps) {
+		p := &ps[i]
 		if p.TournamentParticipantID == 0 {
-			r.saveNewParticipant(&p, tournamentID)
+			err = r.saveNewParticipant(p, tournamentID)
+		} else {
+			err = r.updateParticipant(p)
+		}
+		if err != nil {
+			return err
 		}
 
 		_, err = r.db.Exec(insertquery, tournamentID, p.TournamentParticipantID)
@@ -170,8 +228,44 @@ func (r *repository) saveParticipants(tournamentID int64, ps []commands.Tourname
 	return nil
 }
 
+func (r *repository) saveStations(tournamentID int64, ss []commands.TournamentStation) error {
+	const deletequery = `DELETE FROM tournament_station WHERE tournament_id = ?;`
+
+	_, err := r.db.Exec(deletequery, tournamentID)
+
+	if err != nil {
+		return err
+	}
+
+	const insertquery = `INSERT INTO tournament_station (tournament_id, name, current_match) VALUES (?, ?, ?);`
+	for _, s := range ss {
+		_, err = r.db.Exec(insertquery, tournamentID, s.Name, s.CurrentMatch)
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//saveNewParticipant reuses the row of a challonge participant already saved, as when a bracket is linked again or in another server.
+//Challonge ids are unique, a discord link made before is kept.
 func (r *repository) saveNewParticipant(p *commands.TournamentParticipant, t int64) error {
-	const query = `INSERT INTO tournament_participant (name, challonge_id) VALUES (?, ?);`
+	const findquery = `SELECT tournament_participant_id, discord_user_id FROM tournament_participant WHERE challonge_id = ?;`
+
+	var discordUserID commands.Snowflake
+	err := r.db.QueryRow(findquery, p.ChallongeID).Scan(&p.TournamentParticipantID, &discordUserID)
+	if err == nil {
+		if p.DiscordUserID == 0 {
+			p.DiscordUserID = discordUserID
+		}
+		return r.updateParticipant(p)
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	const query = `INSERT INTO tournament_participant (name, challonge_id, discord_user_id) VALUES (?, ?, ?);`
 
 	tx, err := r.db.Begin()
 
@@ -190,6 +284,7 @@ func (r *repository) saveNewParticipant(p *commands.TournamentParticipant, t int
 	result, err := stmt.Exec(
 		p.Name,
 		p.ChallongeID,
+		p.DiscordUserID,
 	)
 
 	if err != nil {
@@ -207,25 +302,137 @@ func (r *repository) saveNewParticipant(p *commands.TournamentParticipant, t int
 	return nil
 }
 
-func (r *repository) GetTourneyByServer(t commands.Snowflake) (commands.Tournament, error) {
-	const query = `SELECT * FROM tournament
-	 WHERE discord_server_id = ?`
+func (r *repository) updateParticipant(p *commands.TournamentParticipant) error {
+	const query = `UPDATE tournament_participant SET (name, challonge_id, discord_user_id) = (?, ?, ?) WHERE tournament_participant_id = ?;`
+
+	_, err := r.db.Exec(query, p.Name, p.ChallongeID, p.DiscordUserID, p.TournamentParticipantID)
+	return err
+}
+
+func (r *repository) GetTourneysByParticipant(discordUserID commands.Snowflake) ([]commands.Tournament, error) {
+	const query = `SELECT t.tournament_id FROM tournament as t
+	JOIN tournament_participant_xref as tpxref ON tpxref.tournament_id = t.tournament_id
+	JOIN tournament_participant as tp ON tp.tournament_participant_id = tpxref.tournament_participant_id
+	WHERE tp.discord_user_id = ?;`
 
-	row := r.db.QueryRow(query, t)
+	return r.getTourneys(query, discordUserID)
+}
+
+func (r *repository) GetTourneysByServer(discordServerID commands.Snowflake) ([]commands.Tournament, error) {
+	const query = `SELECT tournament_id FROM tournament WHERE discord_server_id = ? ORDER BY tournament_id;`
+
+	return r.getTourneys(query, discordServerID)
+}
+
+func (r *repository) GetAnnouncedTourneys() ([]commands.Tournament, error) {
+	const query = `SELECT tournament_id FROM tournament WHERE announce_channel != 0;`
+
+	return r.getTourneys(query)
+}
+
+func (r *repository) GetCheckInTourneys() ([]commands.Tournament, error) {
+	const query = `SELECT tournament_id FROM tournament WHERE check_in_message != 0;`
+
+	return r.getTourneys(query)
+}
+
+func (r *repository) GetTourneyByCheckInMessage(msg commands.Snowflake) (commands.Tournament, error) {
+	ts, err := r.getTourneys(`SELECT tournament_id FROM tournament WHERE check_in_message = ?;`, msg)
+
+	if err != nil {
+		return commands.Tournament{}, err
+	}
+
+	if len(ts) == 0 {
+		return commands.Tournament{}, errors.New("no tournament with check in message " + msg.String())
+	}
+
+	return ts[0], nil
+}
+
+func (r *repository) IsCheckInMessage(msg commands.Snowflake) (bool, error) {
+	const query = `SELECT COUNT(*) FROM tournament WHERE check_in_message = ?;`
+
+	var count int
+	err := r.db.QueryRow(query, msg).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+//getTourneys loads every tournament whose id is returned by the query
+func (r *repository) getTourneys(query string, args ...interface{}) ([]commands.Tournament, error) {
+	rows, err := r.db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	var result []commands.Tournament
+	for _, id := range ids {
+		t, err := r.GetTourneyByID(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+func (r *repository) GetTourneyByID(tournamentID int64) (commands.Tournament, error) {
+	const query = `SELECT tournament_id, author, challonge_id, discord_server_id, name, game, check_in_message, check_in_channel, check_in_ends, check_in_reminded, channel_id, announce_channel, current_match, self_reporting, last_reported_match
+	 FROM tournament WHERE tournament_id = ?`
+
+	row := r.db.QueryRow(query, tournamentID)
 	if row.Err() != nil {
 		return commands.Tournament{}, row.Err()
 	}
 
 	result := commands.Tournament{}
+	var checkInEnds int64
 
 	row.Scan(
 		&result.TournamentID,
 		&result.User,
 		&result.ChallongeID,
 		&result.DiscordServerID,
+		&result.Name,
+		&result.Game,
+		&result.CheckInMessage,
+		&result.CheckInChannel,
+		&checkInEnds,
+		&result.CheckInReminded,
+		&result.ChannelID,
+		&result.AnnounceChannel,
 		&result.CurrentMatch,
+		&result.SelfReporting,
+		&result.LastReportedMatch,
 	)
 
+	if checkInEnds != 0 {
+		result.CheckInEnds = time.Unix(checkInEnds, 0)
+	}
+
 	to, err := r.getTournamentOrganizers(result.TournamentID)
 
 	if err != nil {
@@ -238,14 +445,21 @@ func (r *repository) GetTourneyByServer(t commands.Snowflake) (commands.Tourname
 		return commands.Tournament{}, err
 	}
 
+	ts, err := r.getTournamentStations(result.TournamentID)
+
+	if err != nil {
+		return commands.Tournament{}, err
+	}
+
 	result.Participants = tp
 	result.Organizers = to
+	result.Stations = ts
 
 	return result, nil
 }
 
 func (r *repository) getTournamentParticipants(tournamentID int64) ([]commands.TournamentParticipant, error) {
-	const query = `SELECT tp.tournament_participant_id, tp.name, tp.challonge_id 
+	const query = `SELECT tp.tournament_participant_id, tp.name, tp.challonge_id, tp.discord_user_id
 	FROM tournament_participant_xref as tpxref
 	JOIN tournament_participant as tp ON tp.tournament_participant_id = tpxref.tournament_participant_id
 	WHERE tpxref.tournament_id = ?;`
@@ -263,6 +477,7 @@ func (r *repository) getTournamentParticipants(tournamentID int64) ([]commands.T
 			&t.TournamentParticipantID,
 			&t.Name,
 			&t.ChallongeID,
+			&t.DiscordUserID,
 		)
 
 		if err != nil {
@@ -276,6 +491,34 @@ func (r *repository) getTournamentParticipants(tournamentID int64) ([]commands.T
 	return result, nil
 }
 
+func (r *repository) getTournamentStations(tournamentID int64) ([]commands.TournamentStation, error) {
+	const query = `SELECT name, current_match FROM tournament_station WHERE tournament_id = ? ORDER BY name;`
+
+	rows, err := r.db.Query(query, tournamentID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []commands.TournamentStation
+	for rows.Next() {
+		s := commands.TournamentStation{}
+		err = rows.Scan(
+			&s.Name,
+			&s.CurrentMatch,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, s)
+	}
+
+	return result, nil
+}
+
 func (r *repository) getTournamentOrganizers(tournamentID int64) ([]commands.Users, error) {
 	const query = `SELECT users.users_id, users.discord_users_id, users.user_name, users.is_admin
 	FROM tournament_organizer_xref as toxref
@@ -310,19 +553,32 @@ func (r *repository) getTournamentOrganizers(tournamentID int64) ([]commands.Use
 }
 
 func (r *repository) AddTourneyOrganizer(userID int64, tourneyID int64) error {
-	return r.saveOrganizers(tourneyID, []commands.Users{{UsersID: userID}})
+	const query = `INSERT OR IGNORE INTO tournament_organizer_xref (tournament_id, users_id) VALUES (?, ?);`
+
+	_, err := r.db.Exec(query, tourneyID, userID)
+
+	return err
+}
+
+func (r *repository) RemoveTourneyOrganizer(userID int64, tourneyID int64) error {
+	const query = `DELETE FROM tournament_organizer_xref WHERE users_id = ? AND tournament_id = ?;`
+
+	_, err := r.db.Exec(query, userID, tourneyID)
+
+	return err
 }
 
 func (r *repository) IsUserTourneyOrganizer(userID int64, tourneyID int64) (bool, error) {
-	const query = `SELECT * FROM tournament_organizer_xref WHERE users_id = ? AND tournament_id = ?;`
+	const query = `SELECT COUNT(*) FROM tournament_organizer_xref WHERE users_id = ? AND tournament_id = ?;`
 
-	row := r.db.QueryRow(query, userID, tourneyID)
+	var count int
+	err := r.db.QueryRow(query, userID, tourneyID).Scan(&count)
 
-	if row.Err() != nil {
-		return false, row.Err()
+	if err != nil {
+		return false, err
 	}
 
-	return true, nil
+	return count > 0, nil
 }
 
 func (r *repository) HasMatchInProgress(discordServerID int64) (bool, error) {
@@ -337,9 +593,9 @@ func (r *repository) HasMatchInProgress(discordServerID int64) (bool, error) {
 	return true, nil
 }
 
-func (r *repository) RemoveTourney(discordServerID commands.Snowflake) error {
-	const query = `DELETE FROM tournament WHERE discord_server_id = ?;`
-	_, err := r.db.Exec(query, discordServerID)
+func (r *repository) RemoveTourney(tournamentID int64) error {
+	const query = `DELETE FROM tournament WHERE tournament_id = ?;`
+	_, err := r.db.Exec(query, tournamentID)
 	
 	if err != nil {
 		return err
@@ -347,3 +603,123 @@ func (r *repository) RemoveTourney(discordServerID commands.Snowflake) error {
 	
 	return nil
 }
+
+func (r *repository) SaveMatchReport(m *commands.MatchReport) error {
+	const query = `INSERT INTO tournament_match_report (tournament_id, guild, match_id, reporter, winner_id, player1_score, player2_score, channel, msg, disputed)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(tournament_id, match_id) DO UPDATE SET
+	reporter = excluded.reporter, winner_id = excluded.winner_id, player1_score = excluded.player1_score, player2_score = excluded.player2_score,
+	channel = excluded.channel, msg = excluded.msg, disputed = excluded.disputed;`
+
+	_, err := r.db.Exec(query, m.TournamentID, m.Guild, m.MatchID, m.Reporter, m.WinnerID, m.Player1Score, m.Player2Score, m.Channel, m.Message, m.Disputed)
+
+	if err != nil {
+		return err
+	}
+
+	saved, err := r.GetMatchReport(m.TournamentID, m.MatchID)
+
+	if err != nil {
+		return err
+	}
+
+	m.MatchReportID = saved.MatchReportID
+	return nil
+}
+
+const matchReportColumns = `tournament_match_report_id, tournament_id, guild, match_id, reporter, winner_id, player1_score, player2_score, channel, msg, disputed`
+
+func scanMatchReport(row *sql.Row) (commands.MatchReport, error) {
+	m := commands.MatchReport{}
+	err := row.Scan(
+		&m.MatchReportID,
+		&m.TournamentID,
+		&m.Guild,
+		&m.MatchID,
+		&m.Reporter,
+		&m.WinnerID,
+		&m.Player1Score,
+		&m.Player2Score,
+		&m.Channel,
+		&m.Message,
+		&m.Disputed,
+	)
+	return m, err
+}
+
+func (r *repository) GetMatchReport(tournamentID int64, matchID int) (commands.MatchReport, error) {
+	const query = `SELECT ` + matchReportColumns + ` FROM tournament_match_report WHERE tournament_id = ? AND match_id = ?;`
+
+	return scanMatchReport(r.db.QueryRow(query, tournamentID, matchID))
+}
+
+func (r *repository) GetMatchReportByMessage(msg commands.Snowflake) (commands.MatchReport, error) {
+	const query = `SELECT ` + matchReportColumns + ` FROM tournament_match_report WHERE msg = ?;`
+
+	return scanMatchReport(r.db.QueryRow(query, msg))
+}
+
+func (r *repository) IsMatchReportMessage(msg commands.Snowflake) (bool, error) {
+	const query = `SELECT COUNT(*) FROM tournament_match_report WHERE msg = ?;`
+
+	var count int
+	err := r.db.QueryRow(query, msg).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *repository) RemoveMatchReport(ID int64) error {
+	const query = `DELETE FROM tournament_match_report WHERE tournament_match_report_id = ?;`
+
+	_, err := r.db.Exec(query, ID)
+	return err
+}
+
+func (r *repository) GetMatchAnnouncements(tournamentID int64) ([]commands.MatchAnnouncement, error) {
+	const query = `SELECT tournament_id, match_id, event FROM tournament_match_announcement WHERE tournament_id = ?;`
+
+	rows, err := r.db.Query(query, tournamentID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []commands.MatchAnnouncement
+	for rows.Next() {
+		a := commands.MatchAnnouncement{}
+		err = rows.Scan(
+			&a.TournamentID,
+			&a.MatchID,
+			&a.Event,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+func (r *repository) SaveMatchAnnouncement(a *commands.MatchAnnouncement) error {
+	const query = `INSERT OR IGNORE INTO tournament_match_announcement (tournament_id, match_id, event) VALUES (?, ?, ?);`
+
+	_, err := r.db.Exec(query, a.TournamentID, a.MatchID, a.Event)
+
+	return err
+}
+
+func (r *repository) RemoveMatchAnnouncement(a *commands.MatchAnnouncement) error {
+	const query = `DELETE FROM tournament_match_announcement WHERE tournament_id = ? AND match_id = ? AND event = ?;`
+
+	_, err := r.db.Exec(query, a.TournamentID, a.MatchID, a.Event)
+
+	return err
+}
@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"log"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -47,7 +48,7 @@ func TestSaveNewTourney(t *testing.T) {
 		t.FailNow()
 	}
 
-	r, err := repo.GetTourneyByServer(123)
+	r, err := repo.GetTourneyByID(1)
 
 	if err != nil {
 		log.Println(err)
@@ -90,7 +91,7 @@ func TestUpdateTourney(t *testing.T) {
 		t.FailNow()
 	}
 
-	r, err := repo.GetTourneyByServer(123)
+	r, err := repo.GetTourneyByID(1)
 
 	if err != nil {
 		log.Println(err)
@@ -108,7 +109,112 @@ func TestUpdateTourney(t *testing.T) {
 		log.Println("Error updating Organizers")
 		t.FailNow()
 	}
- 	
+
+}
+
+func TestUpdateTourneyOnlyChangesOneTourney(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	first := &commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC"}
+	second := &commands.Tournament{User: 1234, DiscordServerID: 456, ChallongeID: "DEF"}
+	repo.SaveTourney(first)
+	repo.SaveTourney(second)
+
+	second.CurrentMatch = 42
+	err := repo.SaveTourney(second)
+
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	r, _ := repo.GetTourneyByID(1)
+	if r.ChallongeID != "ABC" || r.CurrentMatch != 0 {
+		log.Println("Other tournament was changed by update ", r)
+		t.FailNow()
+	}
+}
+
+func TestGetTourneysByServer(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	repo.SaveTourney(&commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC", Name: "melee"})
+	repo.SaveTourney(&commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "DEF", Name: "ult", ChannelID: 55})
+	repo.SaveTourney(&commands.Tournament{User: 1234, DiscordServerID: 456, ChallongeID: "GHI", Name: "melee"})
+
+	//Names only have to be unique within a server
+	err := repo.SaveTourney(&commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "JKL", Name: "melee"})
+	if err == nil {
+		log.Println("Duplicate tournament name saved")
+		t.FailNow()
+	}
+
+	r, err := repo.GetTourneysByServer(123)
+
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if len(r) != 2 || r[0].Name != "melee" || r[1].Name != "ult" || r[1].ChannelID != 55 {
+		log.Println("Tournaments not fetched by server ", r)
+		t.FailNow()
+	}
+}
+
+func TestSaveTourneyStations(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	tourney := &commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC", CurrentMatch: 7,
+		Stations: []commands.TournamentStation{{Name: "2", CurrentMatch: 9}, {Name: "1", CurrentMatch: 8}}}
+
+	err := repo.SaveTourney(tourney)
+
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	tourney.Stations = tourney.Stations[1:]
+	err = repo.SaveTourney(tourney)
+
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	r, _ := repo.GetTourneyByID(1)
+	if r.CurrentMatch != 7 || len(r.Stations) != 1 || r.Stations[0].Name != "1" || r.Stations[0].CurrentMatch != 8 {
+		log.Println("Stations not saved correctly ", r.Stations)
+		t.FailNow()
+	}
+}
+
+func TestGetTourneysByParticipant(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	tourney := &commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC",
+		Participants: []commands.TournamentParticipant{{Name: "test", ChallongeID: 1}, {Name: "person", ChallongeID: 2}}}
+	repo.SaveTourney(tourney)
+
+	//Linking an existing participant updates it
+	tourney.Participants[1].DiscordUserID = 5678
+	err := repo.SaveTourney(tourney)
+
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	r, err := repo.GetTourneysByParticipant(5678)
+
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+	if len(r) != 1 || r[0].ChallongeID != "ABC" || len(r[0].Participants) != 2 || r[0].Participants[1].DiscordUserID != 5678 {
+		log.Println("Tournament not found by participant ", r)
+		t.FailNow()
+	}
 }
 
 func TestGetTourney(t *testing.T) {
@@ -120,7 +226,7 @@ func TestGetTourney(t *testing.T) {
 	db.Exec("INSERT INTO tournament_participant_xref (tournament_id, tournament_participant_id) VALUES (1, 1), (1,2), (1,3);")
 	db.Exec("INSERT INTO tournament_organizer_xref (tournament_id, users_id) VALUES (1, 1234);")
 
-	r, err := repo.GetTourneyByServer(567)
+	r, err := repo.GetTourneyByID(1)
 
 	if err != nil {
 		log.Println(err)
@@ -144,8 +250,9 @@ func TestGetTourney(t *testing.T) {
 func TestAddTourneyOrganizer(t *testing.T) {
 	db := initDB()
 	repo := tourneyrepo.NewRepository(db)
+	db.Exec(`INSERT INTO users(users_id, discord_users_id, user_name) VALUES (4321, 8765, 'helper');`)
 
-	tourney := &commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC"}
+	tourney := &commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC", Organizers: []commands.Users{{UsersID: 1234}}}
 
 	err := repo.SaveTourney(tourney)
 
@@ -154,15 +261,20 @@ func TestAddTourneyOrganizer(t *testing.T) {
 		t.FailNow()
 	}
 
-	repo.AddTourneyOrganizer(1234, 1)
+	err = repo.AddTourneyOrganizer(4321, 1)
+
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
 
-	r, err := repo.GetTourneyByServer(123)
+	r, err := repo.GetTourneyByID(1)
 
 	if err != nil {
 		log.Println(err)
 		t.FailNow()
 	}
-	if len(r.Organizers) != 1 || r.Organizers[0].UsersID == 0 {
+	if len(r.Organizers) != 2 || r.Organizers[0].UsersID == 0 {
 		log.Println("Wrong number of organizers returned.")
 		t.FailNow()
 	}
@@ -191,6 +303,33 @@ func TestIsUserTourneyOrganizer(t *testing.T) {
 		log.Println("User is not organizer when they should be an organizer")
 		t.Fail()
 	}
+
+	result, _ = repo.IsUserTourneyOrganizer(4321, 1)
+	if result {
+		log.Println("User is organizer when they should not be an organizer")
+		t.Fail()
+	}
+}
+
+func TestRemoveTourneyOrganizer(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+
+	tourney := &commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC", Organizers: []commands.Users{{UsersID: 1234}}}
+	repo.SaveTourney(tourney)
+
+	err := repo.RemoveTourneyOrganizer(1234, 1)
+
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	result, _ := repo.IsUserTourneyOrganizer(1234, 1)
+	if result {
+		log.Println("Organizer not removed")
+		t.Fail()
+	}
 }
 
 func TestRemoveTournament(t *testing.T) {
@@ -206,17 +345,269 @@ func TestRemoveTournament(t *testing.T) {
 		t.FailNow()
 	}
 
-	err = repo.RemoveTourney(123)
+	err = repo.RemoveTourney(1)
 
 	if err != nil {
 		log.Println(err)
 		t.FailNow()
 	}
 
-	r, _ := repo.GetTourneyByServer(123)
+	r, _ := repo.GetTourneyByID(1)
 
 	if r.TournamentID != 0 {
 		log.Println("Tournament not deleted correctly")
 		t.Fail()
 	}
-}
\ No newline at end of file
+}
+func TestRelinkTournamentParticipants(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+
+	first := &commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC",
+		Participants: []commands.TournamentParticipant{{Name: "test", ChallongeID: 1, DiscordUserID: 77}}}
+	if err := repo.SaveTourney(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RemoveTourney(first.TournamentID); err != nil {
+		t.Fatal(err)
+	}
+
+	//The same bracket is linked again after ending, and in another server at once
+	again := &commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC",
+		Participants: []commands.TournamentParticipant{{Name: "renamed", ChallongeID: 1}}}
+	other := &commands.Tournament{User: 1234, DiscordServerID: 456, ChallongeID: "ABC",
+		Participants: []commands.TournamentParticipant{{Name: "renamed", ChallongeID: 1}}}
+	for _, tourney := range []*commands.Tournament{again, other} {
+		if err := repo.SaveTourney(tourney); err != nil {
+			t.Fatal(err)
+		}
+		r, err := repo.GetTourneyByID(tourney.TournamentID)
+		if err != nil || len(r.Participants) != 1 {
+			t.Fatal("Participants not saved ", err)
+		}
+		if p := r.Participants[0]; p.Name != "renamed" || p.DiscordUserID != 77 {
+			t.Error("Unexpected participant ", p)
+		}
+	}
+}
+
+func TestMatchReports(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	tourney := &commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC", SelfReporting: true}
+	repo.SaveTourney(tourney)
+
+	report := &commands.MatchReport{TournamentID: tourney.TournamentID, Guild: 123, MatchID: 7, Reporter: 5678, WinnerID: 1, Player1Score: 2, Player2Score: 1, Channel: 10, Message: 20}
+	err := repo.SaveMatchReport(report)
+
+	if err != nil || report.MatchReportID == 0 {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	//Saving again updates the same report
+	report.Disputed = true
+	repo.SaveMatchReport(report)
+
+	r, err := repo.GetMatchReportByMessage(20)
+	if err != nil || r.MatchReportID != report.MatchReportID || !r.Disputed || r.Player1Score != 2 {
+		log.Println("Match report not saved correctly ", r, err)
+		t.FailNow()
+	}
+	if isReport, _ := repo.IsMatchReportMessage(20); !isReport {
+		t.FailNow()
+	}
+
+	to, _ := repo.GetTourneyByID(1)
+	if !to.SelfReporting {
+		log.Println("Self reporting not saved.")
+		t.FailNow()
+	}
+
+	repo.RemoveMatchReport(report.MatchReportID)
+	if isReport, _ := repo.IsMatchReportMessage(20); isReport {
+		log.Println("Match report not removed.")
+		t.FailNow()
+	}
+}
+
+func TestMatchAnnouncements(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	repo.SaveTourney(&commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC", AnnounceChannel: 55})
+	repo.SaveTourney(&commands.Tournament{User: 1234, DiscordServerID: 456, ChallongeID: "DEF"})
+
+	ts, err := repo.GetAnnouncedTourneys()
+	if err != nil || len(ts) != 1 || ts[0].AnnounceChannel != 55 {
+		log.Println("Announced tournaments not found ", ts, err)
+		t.FailNow()
+	}
+
+	//Saving the same announcement twice keeps one
+	repo.SaveMatchAnnouncement(&commands.MatchAnnouncement{TournamentID: 1, MatchID: 7, Event: "open"})
+	repo.SaveMatchAnnouncement(&commands.MatchAnnouncement{TournamentID: 1, MatchID: 7, Event: "open"})
+	err = repo.SaveMatchAnnouncement(&commands.MatchAnnouncement{TournamentID: 1, MatchID: 7, Event: "complete"})
+	if err != nil {
+		log.Println(err)
+		t.FailNow()
+	}
+
+	as, err := repo.GetMatchAnnouncements(1)
+	if err != nil || len(as) != 2 {
+		log.Println("Announcements not saved ", as, err)
+		t.FailNow()
+	}
+
+	repo.RemoveMatchAnnouncement(&commands.MatchAnnouncement{TournamentID: 1, MatchID: 7, Event: "complete"})
+	as, _ = repo.GetMatchAnnouncements(1)
+	if len(as) != 1 || as[0].Event != "open" {
+		log.Println("Announcement not removed ", as)
+		t.FailNow()
+	}
+}
+
+func TestTournamentResults(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	older := commands.TournamentResult{DiscordServerID: 123, Name: "week1", ChallongeID: "ABC", EndedAt: time.Unix(1000, 0),
+		Placements: []commands.TournamentPlacement{{Name: "a", Seed: 1, Rank: 2}, {Name: "late", Seed: 3}, {Name: "b", DiscordUserID: 801, Seed: 2, Rank: 1}},
+		Matches:    []commands.TournamentResultMatch{{MatchID: 1, Round: 1, Player1: "a", Player2: "b", Player2DiscordID: 801, Winner: 2, ScoresCsv: "1-3"}}}
+	newer := commands.TournamentResult{DiscordServerID: 123, Name: "week2", ChallongeID: "DEF", EndedAt: time.Unix(2000, 0)}
+	other := commands.TournamentResult{DiscordServerID: 456, Name: "other", ChallongeID: "GHI", EndedAt: time.Unix(3000, 0)}
+	for _, r := range []*commands.TournamentResult{&older, &newer, &other} {
+		if err := repo.SaveTournamentResult(r); err != nil {
+			log.Println(err)
+			t.FailNow()
+		}
+	}
+
+	r, err := repo.GetTournamentResult(older.TournamentResultID)
+	if err != nil || r.Name != "week1" || !r.EndedAt.Equal(older.EndedAt) {
+		log.Println("Result not found ", r, err)
+		t.FailNow()
+	}
+	if len(r.Placements) != 3 || r.Placements[0].Name != "b" || r.Placements[0].DiscordUserID != 801 || r.Placements[2].Name != "late" {
+		log.Println("Placements not ordered by rank ", r.Placements)
+		t.Fail()
+	}
+	if len(r.Matches) != 1 || r.Matches[0].Winner != 2 || r.Matches[0].ScoresCsv != "1-3" {
+		log.Println("Matches not saved ", r.Matches)
+		t.Fail()
+	}
+
+	rs, err := repo.GetTournamentResultsByServer(123)
+	if err != nil || len(rs) != 2 || rs[0].Name != "week2" || rs[1].Name != "week1" {
+		log.Println("Results not listed newest first ", rs, err)
+		t.Fail()
+	}
+
+	if _, err := repo.GetTournamentResult(99); err == nil {
+		log.Println("Missing result found")
+		t.Fail()
+	}
+}
+
+func TestGetTournamentResultsByPlayer(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	repo.SaveTournamentResult(&commands.TournamentResult{DiscordServerID: 123, Name: "placed", EndedAt: time.Unix(1000, 0),
+		Placements: []commands.TournamentPlacement{{Name: "a", DiscordUserID: 800, Rank: 1}}})
+	repo.SaveTournamentResult(&commands.TournamentResult{DiscordServerID: 123, Name: "played", EndedAt: time.Unix(2000, 0),
+		Matches: []commands.TournamentResultMatch{{MatchID: 1, Player1: "b", Player2: "a", Player2DiscordID: 800, Winner: 2}}})
+	repo.SaveTournamentResult(&commands.TournamentResult{DiscordServerID: 123, Name: "absent", EndedAt: time.Unix(3000, 0),
+		Placements: []commands.TournamentPlacement{{Name: "b", DiscordUserID: 801, Rank: 1}}})
+	repo.SaveTournamentResult(&commands.TournamentResult{DiscordServerID: 456, Name: "elsewhere", EndedAt: time.Unix(4000, 0),
+		Placements: []commands.TournamentPlacement{{Name: "a", DiscordUserID: 800, Rank: 1}}})
+
+	rs, err := repo.GetTournamentResultsByPlayer(123, 800)
+	if err != nil || len(rs) != 2 || rs[0].Name != "played" || rs[1].Name != "placed" {
+		log.Println("Player results incorrect ", rs, err)
+		t.Fail()
+	}
+}
+
+func TestPlayerRatings(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	linked := commands.PlayerRating{DiscordServerID: 123, Game: "Melee", DiscordUserID: 800, Name: "a", Rating: 1510, Wins: 1}
+	imported := commands.PlayerRating{DiscordServerID: 123, Game: "Melee", Name: "Imported", Rating: 1490, Losses: 1}
+	repo.SavePlayerRating(&linked)
+	repo.SavePlayerRating(&imported)
+	repo.SavePlayerRating(&commands.PlayerRating{DiscordServerID: 456, Game: "Melee", DiscordUserID: 800, Name: "a", Rating: 2000})
+
+	p, err := repo.GetPlayerRating(123, "melee", 800, "")
+	if err != nil || p.PlayerRatingID != linked.PlayerRatingID {
+		log.Println("Linked rating not found ", p, err)
+		t.Fail()
+	}
+	p, err = repo.GetPlayerRating(123, "Melee", 0, "imported")
+	if err != nil || p.PlayerRatingID != imported.PlayerRatingID {
+		log.Println("Imported rating not found by name ", p, err)
+		t.Fail()
+	}
+	p, err = repo.GetPlayerRating(123, "Ultimate", 800, "a")
+	if err != nil || p.PlayerRatingID != 0 {
+		log.Println("Rating found on another ladder ", p, err)
+		t.Fail()
+	}
+
+	imported.Rating = 1600
+	repo.SavePlayerRating(&imported)
+	ps, err := repo.GetPlayerRatingsByServer(123)
+	if err != nil || len(ps) != 2 || ps[0].Name != "Imported" || ps[0].Rating != 1600 {
+		log.Println("Ratings not listed by rating ", ps, err)
+		t.Fail()
+	}
+
+	m := commands.RatedMatch{DiscordServerID: 123, ChallongeID: "ABC", MatchID: 7, WinnerRatingID: 1, LoserRatingID: 2, Change: 16}
+	repo.SaveRatedMatch(&m)
+	rm, err := repo.GetRatedMatch(123, "ABC", 7)
+	if err != nil || rm != m {
+		log.Println("Rated match not saved ", rm, err)
+		t.Fail()
+	}
+	repo.RemoveRatedMatch(123, "ABC", 7)
+	rm, err = repo.GetRatedMatch(123, "ABC", 7)
+	if err != nil || rm.MatchID != 0 {
+		log.Println("Rated match not removed ", rm, err)
+		t.Fail()
+	}
+}
+
+func TestCheckInTourneys(t *testing.T) {
+	db := initDB()
+	repo := tourneyrepo.NewRepository(db)
+	ends := time.Unix(5000, 0)
+	open := commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "ABC", Name: "a", CheckInMessage: 77, CheckInChannel: 10, CheckInEnds: ends}
+	repo.SaveTourney(&open)
+	repo.SaveTourney(&commands.Tournament{User: 1234, DiscordServerID: 123, ChallongeID: "DEF", Name: "b"})
+
+	ts, err := repo.GetCheckInTourneys()
+	if err != nil || len(ts) != 1 || !ts[0].CheckInEnds.Equal(ends) || ts[0].CheckInChannel != 10 {
+		log.Println("Check in tournaments not found ", ts, err)
+		t.FailNow()
+	}
+
+	if ok, err := repo.IsCheckInMessage(77); err != nil || !ok {
+		log.Println("Check in message not found ", err)
+		t.Fail()
+	}
+	if tourney, err := repo.GetTourneyByCheckInMessage(77); err != nil || tourney.TournamentID != open.TournamentID {
+		log.Println("Tournament not found by check in message ", tourney, err)
+		t.Fail()
+	}
+
+	//Closing check in clears the time
+	open.CheckInMessage = 0
+	open.CheckInEnds = time.Time{}
+	repo.SaveTourney(&open)
+	tourney, err := repo.GetTourneyByID(open.TournamentID)
+	if err != nil || !tourney.CheckInEnds.IsZero() {
+		log.Println("Check in not cleared ", tourney, err)
+		t.Fail()
+	}
+	if ok, _ := repo.IsCheckInMessage(77); ok {
+		log.Println("Closed check in message still found")
+		t.Fail()
+	}
+}
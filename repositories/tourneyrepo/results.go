@@ -0,0 +1,212 @@
+package tourneyrepo
+
+import (
+	"discordbot/commands"
+	"time"
+)
+
+func (r *repository) SaveTournamentResult(t *commands.TournamentResult) error {
+	const query = `INSERT INTO tournament_result (discord_server_id, name, challonge_id, ended_at) VALUES (?, ?, ?, ?);`
+	const placementQuery = `INSERT INTO tournament_result_placement (tournament_result_id, name, discord_user_id, seed, final_rank) VALUES (?, ?, ?, ?, ?);`
+	const matchQuery = `INSERT INTO tournament_result_match (tournament_result_id, match_id, round, player1_name, player1_discord_id, player2_name, player2_discord_id, winner, scores_csv)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`
+
+	tx, err := r.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, t.DiscordServerID, t.Name, t.ChallongeID, t.EndedAt.Unix())
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	ID, err := result.LastInsertId()
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, p := range t.Placements {
+		_, err = tx.Exec(placementQuery, ID, p.Name, p.DiscordUserID, p.Seed, p.Rank)
+
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	for _, m := range t.Matches {
+		_, err = tx.Exec(matchQuery, ID, m.MatchID, m.Round, m.Player1, m.Player1DiscordID, m.Player2, m.Player2DiscordID, m.Winner, m.ScoresCsv)
+
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return err
+	}
+
+	t.TournamentResultID = ID
+
+	return nil
+}
+
+func (r *repository) GetTournamentResultsByServer(discordServerID commands.Snowflake) ([]commands.TournamentResult, error) {
+	const query = `SELECT tournament_result_id FROM tournament_result WHERE discord_server_id = ? ORDER BY ended_at DESC, tournament_result_id DESC;`
+
+	return r.getTournamentResults(query, discordServerID)
+}
+
+func (r *repository) GetTournamentResultsByPlayer(discordServerID commands.Snowflake, discordUserID commands.Snowflake) ([]commands.TournamentResult, error) {
+	const query = `SELECT tournament_result_id FROM tournament_result r WHERE discord_server_id = ? AND (
+	EXISTS (SELECT 1 FROM tournament_result_placement p WHERE p.tournament_result_id = r.tournament_result_id AND p.discord_user_id = ?)
+	OR EXISTS (SELECT 1 FROM tournament_result_match m WHERE m.tournament_result_id = r.tournament_result_id AND (m.player1_discord_id = ? OR m.player2_discord_id = ?)))
+	ORDER BY ended_at DESC, tournament_result_id DESC;`
+
+	return r.getTournamentResults(query, discordServerID, discordUserID, discordUserID, discordUserID)
+}
+
+func (r *repository) getTournamentResults(query string, args ...interface{}) ([]commands.TournamentResult, error) {
+	rows, err := r.db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	var result []commands.TournamentResult
+	for _, id := range ids {
+		t, err := r.GetTournamentResult(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+func (r *repository) GetTournamentResult(ID int64) (commands.TournamentResult, error) {
+	const query = `SELECT tournament_result_id, discord_server_id, name, challonge_id, ended_at FROM tournament_result WHERE tournament_result_id = ?;`
+
+	result := commands.TournamentResult{}
+	var endedAt int64
+
+	err := r.db.QueryRow(query, ID).Scan(
+		&result.TournamentResultID,
+		&result.DiscordServerID,
+		&result.Name,
+		&result.ChallongeID,
+		&endedAt,
+	)
+
+	if err != nil {
+		return commands.TournamentResult{}, err
+	}
+
+	result.EndedAt = time.Unix(endedAt, 0)
+
+	result.Placements, err = r.getResultPlacements(ID)
+
+	if err != nil {
+		return commands.TournamentResult{}, err
+	}
+
+	result.Matches, err = r.getResultMatches(ID)
+
+	if err != nil {
+		return commands.TournamentResult{}, err
+	}
+
+	return result, nil
+}
+
+func (r *repository) getResultPlacements(ID int64) ([]commands.TournamentPlacement, error) {
+	const query = `SELECT name, discord_user_id, seed, final_rank FROM tournament_result_placement WHERE tournament_result_id = ?
+	ORDER BY final_rank = 0, final_rank, seed;`
+
+	rows, err := r.db.Query(query, ID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []commands.TournamentPlacement
+	for rows.Next() {
+		p := commands.TournamentPlacement{}
+		err = rows.Scan(
+			&p.Name,
+			&p.DiscordUserID,
+			&p.Seed,
+			&p.Rank,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, p)
+	}
+
+	return result, nil
+}
+
+func (r *repository) getResultMatches(ID int64) ([]commands.TournamentResultMatch, error) {
+	const query = `SELECT match_id, round, player1_name, player1_discord_id, player2_name, player2_discord_id, winner, scores_csv
+	FROM tournament_result_match WHERE tournament_result_id = ? ORDER BY match_id;`
+
+	rows, err := r.db.Query(query, ID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []commands.TournamentResultMatch
+	for rows.Next() {
+		m := commands.TournamentResultMatch{}
+		err = rows.Scan(
+			&m.MatchID,
+			&m.Round,
+			&m.Player1,
+			&m.Player1DiscordID,
+			&m.Player2,
+			&m.Player2DiscordID,
+			&m.Winner,
+			&m.ScoresCsv,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, m)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,223 @@
+package votepoll
+
+import (
+	"database/sql"
+	"discordbot/commands"
+	"time"
+)
+
+type VotePollRepository struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *VotePollRepository {
+	return &VotePollRepository{
+		db: db,
+	}
+}
+
+func (r *VotePollRepository) SaveVotePoll(poll *commands.VotePoll) error {
+	const query = `INSERT INTO vote_poll(author, guild, channel, msg, role, title, multi_choice, anonymous, deadline) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	const optionQuery = `INSERT INTO vote_poll_option(vote_poll_id, position, value) VALUES (?, ?, ?);`
+
+	tx, err := r.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query,
+		poll.User,
+		poll.Guild,
+		poll.Channel,
+		poll.Message,
+		poll.Role,
+		poll.Title,
+		poll.MultiChoice,
+		poll.Anonymous,
+		poll.Deadline.Unix())
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	ID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for i, o := range poll.Options {
+		_, err = tx.Exec(optionQuery, ID, i, o)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	poll.VotePollID = ID
+	return nil
+}
+
+func (r *VotePollRepository) GetVotePollByMessage(msg commands.Snowflake) (commands.VotePoll, error) {
+	const query = `SELECT * FROM vote_poll WHERE msg = ?;`
+
+	row := r.db.QueryRow(query, msg)
+	poll, err := scanVotePoll(row)
+	if err != nil {
+		return commands.VotePoll{}, err
+	}
+
+	poll.Options, err = r.getOptions(poll.VotePollID)
+	if err != nil {
+		return commands.VotePoll{}, err
+	}
+
+	return poll, nil
+}
+
+func (r *VotePollRepository) GetAllVotePolls() ([]commands.VotePoll, error) {
+	const query = `SELECT * FROM vote_poll;`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return []commands.VotePoll{}, err
+	}
+
+	polls := []commands.VotePoll{}
+	for rows.Next() {
+		poll, err := scanVotePoll(rows)
+		if err != nil {
+			rows.Close()
+			return []commands.VotePoll{}, err
+		}
+		polls = append(polls, poll)
+	}
+	rows.Close()
+
+	for i := range polls {
+		polls[i].Options, err = r.getOptions(polls[i].VotePollID)
+		if err != nil {
+			return []commands.VotePoll{}, err
+		}
+	}
+
+	return polls, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanVotePoll(row scanner) (commands.VotePoll, error) {
+	poll := commands.VotePoll{}
+	var deadline int64
+	err := row.Scan(
+		&poll.VotePollID,
+		&poll.User,
+		&poll.Guild,
+		&poll.Channel,
+		&poll.Message,
+		&poll.Role,
+		&poll.Title,
+		&poll.MultiChoice,
+		&poll.Anonymous,
+		&deadline)
+	if err != nil {
+		return commands.VotePoll{}, err
+	}
+	poll.Deadline = time.Unix(deadline, 0)
+	return poll, nil
+}
+
+func (r *VotePollRepository) getOptions(pollID int64) ([]string, error) {
+	const query = `SELECT value FROM vote_poll_option WHERE vote_poll_id = ? ORDER BY position;`
+
+	rows, err := r.db.Query(query, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var options []string
+	for rows.Next() {
+		var o string
+		if err := rows.Scan(&o); err != nil {
+			return nil, err
+		}
+		options = append(options, o)
+	}
+
+	return options, rows.Err()
+}
+
+func (r *VotePollRepository) IsVotePollMessage(msg commands.Snowflake) (bool, error) {
+	const query = `SELECT COUNT(*) FROM vote_poll WHERE msg = ?;`
+
+	var count int
+	err := r.db.QueryRow(query, msg).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *VotePollRepository) DeleteVotePollByID(ID int64) error {
+	const query = `DELETE FROM vote_poll WHERE vote_poll_id = ?;`
+
+	_, err := r.db.Exec(query, ID)
+
+	return err
+}
+
+func (r *VotePollRepository) AddVote(vote *commands.VotePollVote) error {
+	const query = `INSERT OR IGNORE INTO vote_poll_vote(vote_poll_id, position, discord_users_id) VALUES (?, ?, ?);`
+
+	_, err := r.db.Exec(query, vote.VotePollID, vote.Option, vote.User)
+
+	return err
+}
+
+func (r *VotePollRepository) RemoveVote(vote *commands.VotePollVote) error {
+	const query = `DELETE FROM vote_poll_vote WHERE vote_poll_id = ? AND position = ? AND discord_users_id = ?;`
+
+	_, err := r.db.Exec(query, vote.VotePollID, vote.Option, vote.User)
+
+	return err
+}
+
+func (r *VotePollRepository) RemoveUserVotes(pollID int64, user commands.Snowflake) error {
+	const query = `DELETE FROM vote_poll_vote WHERE vote_poll_id = ? AND discord_users_id = ?;`
+
+	_, err := r.db.Exec(query, pollID, user)
+
+	return err
+}
+
+func (r *VotePollRepository) GetVotes(pollID int64) ([]commands.VotePollVote, error) {
+	const query = `SELECT vote_poll_id, position, discord_users_id FROM vote_poll_vote WHERE vote_poll_id = ? ORDER BY position, discord_users_id;`
+
+	rows, err := r.db.Query(query, pollID)
+	if err != nil {
+		return []commands.VotePollVote{}, err
+	}
+	defer rows.Close()
+
+	votes := []commands.VotePollVote{}
+	for rows.Next() {
+		v := commands.VotePollVote{}
+		if err := rows.Scan(&v.VotePollID, &v.Option, &v.User); err != nil {
+			return []commands.VotePollVote{}, err
+		}
+		votes = append(votes, v)
+	}
+
+	return votes, rows.Err()
+}
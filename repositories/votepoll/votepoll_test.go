@@ -0,0 +1,162 @@
+package votepoll_test
+
+import (
+	"database/sql"
+	"discordbot/commands"
+	"discordbot/repositories/votepoll"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func initDB() *sql.DB {
+	client, _ := sql.Open("sqlite3", ":memory:?_foreign_keys=on")
+
+	query, _ := ioutil.ReadFile("../../dbscript.sql")
+
+	if _, err := client.Exec(string(query)); err != nil {
+		log.Fatal(err)
+	}
+
+	client.Exec(`INSERT INTO users(users_id, discord_users_id) VALUES (1234, 5678);`)
+
+	return client
+}
+
+func newPoll() commands.VotePoll {
+	return commands.VotePoll{
+		User:        1234,
+		Guild:       1,
+		Channel:     2,
+		Message:     3,
+		Role:        4,
+		Title:       "Best game",
+		Options:     []string{"Melee", "Ultimate", "Project M"},
+		MultiChoice: true,
+		Deadline:    time.Unix(1700000000, 0),
+	}
+}
+
+func TestSaveAndGetVotePoll(t *testing.T) {
+	db := initDB()
+	defer db.Close()
+
+	repo := votepoll.New(db)
+
+	poll := newPoll()
+	err := repo.SaveVotePoll(&poll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if poll.VotePollID == 0 {
+		t.Error("Poll ID not set on save.")
+	}
+
+	result, err := repo.GetVotePollByMessage(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(poll, result) {
+		t.Error("Mismatched structs found on save. Expected ", poll, " got ", result)
+	}
+
+	isPoll, _ := repo.IsVotePollMessage(3)
+	if !isPoll {
+		t.Error("Poll message not found.")
+	}
+	isPoll, _ = repo.IsVotePollMessage(4)
+	if isPoll {
+		t.Error("Non poll message found as poll.")
+	}
+}
+
+func TestGetAllVotePolls(t *testing.T) {
+	db := initDB()
+	defer db.Close()
+
+	repo := votepoll.New(db)
+
+	p1 := newPoll()
+	p2 := newPoll()
+	p2.Message = 10
+	p2.Options = []string{"yes", "no"}
+	repo.SaveVotePoll(&p1)
+	repo.SaveVotePoll(&p2)
+
+	polls, err := repo.GetAllVotePolls()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(polls) != 2 {
+		t.Fatal("Wrong number of polls returned. Expected 2 received ", len(polls))
+	}
+	if !reflect.DeepEqual(polls[1], p2) {
+		t.Error("Error retrieving vote poll.")
+	}
+}
+
+func TestVotes(t *testing.T) {
+	db := initDB()
+	defer db.Close()
+
+	repo := votepoll.New(db)
+
+	poll := newPoll()
+	repo.SaveVotePoll(&poll)
+
+	repo.AddVote(&commands.VotePollVote{VotePollID: poll.VotePollID, Option: 0, User: 100})
+	repo.AddVote(&commands.VotePollVote{VotePollID: poll.VotePollID, Option: 0, User: 100})
+	repo.AddVote(&commands.VotePollVote{VotePollID: poll.VotePollID, Option: 1, User: 100})
+	repo.AddVote(&commands.VotePollVote{VotePollID: poll.VotePollID, Option: 1, User: 200})
+
+	votes, _ := repo.GetVotes(poll.VotePollID)
+	if len(votes) != 3 {
+		t.Error("Duplicate votes should be ignored. Got ", votes)
+	}
+
+	repo.RemoveVote(&commands.VotePollVote{VotePollID: poll.VotePollID, Option: 1, User: 200})
+	votes, _ = repo.GetVotes(poll.VotePollID)
+	if len(votes) != 2 {
+		t.Error("Vote not removed. Got ", votes)
+	}
+
+	repo.RemoveUserVotes(poll.VotePollID, 100)
+	votes, _ = repo.GetVotes(poll.VotePollID)
+	if len(votes) != 0 {
+		t.Error("User votes not removed. Got ", votes)
+	}
+}
+
+func TestDeleteVotePollRemovesVotes(t *testing.T) {
+	db := initDB()
+	defer db.Close()
+
+	repo := votepoll.New(db)
+
+	poll := newPoll()
+	repo.SaveVotePoll(&poll)
+	repo.AddVote(&commands.VotePollVote{VotePollID: poll.VotePollID, Option: 2, User: 100})
+
+	err := repo.DeleteVotePollByID(poll.VotePollID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polls, _ := repo.GetAllVotePolls()
+	if len(polls) != 0 {
+		t.Error("Poll not deleted.")
+	}
+
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM vote_poll_vote;`).Scan(&count)
+	if count != 0 {
+		t.Error("Votes not deleted with poll.")
+	}
+}
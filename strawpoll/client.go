@@ -1,6 +1,7 @@
 package strawpoll
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -10,22 +11,30 @@ import (
 type Client struct {
 	httpClient http.Client
 	apiKey string
+	apiURL string
 }
 
 type StrawPollConfig struct {
 	ApiKey string
+	//ApiURL - optional override of the strawpoll api location
+	ApiURL string
 }
 
 func New(config StrawPollConfig) *Client {
+	apiURL := config.ApiURL
+	if apiURL == "" {
+		apiURL = strawpollAPIURL
+	}
 	return &Client {
 		httpClient: *http.DefaultClient,
 		apiKey: config.ApiKey,
+		apiURL: apiURL,
 	}
 }
 
 func (c *Client) GetPoll(ID string) (*StrawPollResults, error) {
 	
-	req, err := http.NewRequest("GET", strawpollGetEndpoint + ID , nil)
+	req, err := http.NewRequest("GET", c.apiURL + pollsEndpoint + "/" + ID , nil)
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +46,10 @@ func (c *Client) GetPoll(ID string) (*StrawPollResults, error) {
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error status code %v", res.StatusCode)
+	}
+
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
@@ -50,4 +63,41 @@ func (c *Client) GetPoll(ID string) (*StrawPollResults, error) {
 	}
 	
 	return &strawPollResults, nil
-}
\ No newline at end of file
+}
+
+func (c *Client) CreatePoll(poll *CreatePollRequest) (*CreatePollResponse, error) {
+	payload, err := json.Marshal(poll)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", c.apiURL + pollsEndpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("X-API-KEY", c.apiKey)
+	req.Header.Add("Content-Type", "application/json")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("error status code %v", res.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	createPollResponse := CreatePollResponse{}
+	err = json.Unmarshal(body, &createPollResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &createPollResponse, nil
+}
@@ -2,8 +2,12 @@ package strawpoll_test
 
 import (
 	"discordbot/strawpoll"
+	"encoding/json"
 	"fmt"
 	"image"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -16,6 +20,9 @@ func thingy(v string) {
 }
 //Channel 803072579765403669
 func TestThing(t *testing.T) {
+	if os.Getenv("STRAWPOLL_TOKEN") == "" {
+		t.Skip("STRAWPOLL_TOKEN not set")
+	}
 	client := strawpoll.New(strawpoll.StrawPollConfig{
 		ApiKey: os.Getenv("STRAWPOLL_TOKEN"),
 	})
@@ -75,4 +82,127 @@ func scaleTo(src image.Image, rect image.Rectangle, scale draw.Scaler) image.Ima
 	dst := image.NewRGBA(rect)
 	scale.Scale(dst, rect, src, src.Bounds(), draw.Over, nil)
 	return dst
-}
\ No newline at end of file
+}
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *strawpoll.Client) {
+	server := httptest.NewServer(handler)
+	client := strawpoll.New(strawpoll.StrawPollConfig{
+		ApiKey: "key",
+		ApiURL: server.URL,
+	})
+	return server, client
+}
+
+func TestCreatePoll(t *testing.T) {
+	//Given: A strawpoll api that accepts new polls
+	var received strawpoll.CreatePollRequest
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/polls" {
+			t.Error("Unexpected request ", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("X-API-KEY") != "key" {
+			t.Error("Api key not sent.")
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		w.Write([]byte(`{"id": "abc123"}`))
+	})
+	defer server.Close()
+
+	//When: A poll is created with a deadline
+	deadline := time.Unix(1700000000, 0)
+	poll, err := client.CreatePoll(strawpoll.NewCreatePollRequest("Best game", []string{"a", "b"}, deadline))
+
+	//Then: The poll is returned with a link
+	if err != nil {
+		t.Fatal(err)
+	}
+	if poll.ID != "abc123" || poll.Link() != "https://strawpoll.com/polls/abc123" {
+		t.Error("Unexpected poll response ", poll)
+	}
+	//And: The request carried the title, options and deadline
+	if received.Title != "Best game" || len(received.PollOptions) != 2 || received.PollOptions[1].Value != "b" {
+		t.Error("Poll request not encoded correctly ", received)
+	}
+	if received.PollConfig.DeadlineAt != 1700000000 {
+		t.Error("Deadline not sent. Got ", received.PollConfig.DeadlineAt)
+	}
+}
+
+func TestCreatePollErrorStatus(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	defer server.Close()
+
+	_, err := client.CreatePoll(strawpoll.NewCreatePollRequest("Title", []string{"a", "b"}, time.Time{}))
+
+	if err == nil {
+		t.Error("Expected error on failed poll creation.")
+	}
+}
+
+func TestGetPoll(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/polls/abc123" {
+			t.Error("Unexpected path ", r.URL.Path)
+		}
+		w.Write([]byte(`{"poll": {"id": "abc123", "title": "Best game", "poll_config": {"deadline_at": 1700000000},
+			"poll_options": [{"value": "a", "vote_count": 3}, {"value": "b", "vote_count": 5}]}}`))
+	})
+	defer server.Close()
+
+	r, err := client.GetPoll("abc123")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Poll.Title != "Best game" || r.Poll.PollConfig.DeadlineAt != 1700000000 {
+		t.Error("Poll not decoded correctly ", r.Poll)
+	}
+	if len(r.Poll.PollOptions) != 2 || r.Poll.PollOptions[1].VoteCount != 5 {
+		t.Error("Poll options not decoded correctly ", r.Poll.PollOptions)
+	}
+}
+
+func TestGetPollNotFound(t *testing.T) {
+	server, client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "not found"}`))
+	})
+	defer server.Close()
+
+	_, err := client.GetPoll("missing")
+
+	if err == nil {
+		t.Error("Expected error for missing poll.")
+	}
+}
+
+func TestPollIDFromURL(t *testing.T) {
+	valid := map[string]string{
+		"https://strawpoll.com/polls/05Zd1mAaEy6":  "05Zd1mAaEy6",
+		"https://strawpoll.com/polls/05Zd1mAaEy6/": "05Zd1mAaEy6",
+		"https://www.strawpoll.com/05Zd1mAaEy6":    "05Zd1mAaEy6",
+	}
+	for link, expected := range valid {
+		ID, err := strawpoll.PollIDFromURL(link)
+		if err != nil || ID != expected {
+			t.Error("For link ", link, " expected ", expected, " got ", ID, err)
+		}
+	}
+
+	invalid := []string{
+		"https://strawpoll.com/polls/",
+		"https://strawpoll.com",
+		"https://example.com/polls/abc",
+		"https://notstrawpoll.com/polls/abc",
+		"https://strawpoll.com/polls/abc/results",
+		"not a link",
+	}
+	for _, link := range invalid {
+		if ID, err := strawpoll.PollIDFromURL(link); err == nil {
+			t.Error("Expected error for link ", link, " got ", ID)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package strawpoll
+
+import "time"
+
+const strawpollURL = "https://strawpoll.com/polls/"
+
+type StrawPollCreateClient interface {
+	CreatePoll(*CreatePollRequest) (*CreatePollResponse, error)
+}
+
+type CreatePollRequest struct {
+	Title       string             `json:"title"`
+	Type        string             `json:"type"`
+	PollOptions []CreatePollOption `json:"poll_options"`
+	PollConfig  CreatePollConfig   `json:"poll_config"`
+}
+
+type CreatePollOption struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type CreatePollConfig struct {
+	DeadlineAt int64 `json:"deadline_at,omitempty"`
+	IsPrivate  bool  `json:"is_private"`
+}
+
+type CreatePollResponse struct {
+	ID  string
+	URL string `json:"url"`
+}
+
+//NewCreatePollRequest - multiple choice poll with text options. A zero deadline leaves the poll open.
+func NewCreatePollRequest(title string, options []string, deadline time.Time) *CreatePollRequest {
+	r := &CreatePollRequest{
+		Title: title,
+		Type:  "multiple_choice",
+	}
+	for _, o := range options {
+		r.PollOptions = append(r.PollOptions, CreatePollOption{Type: "text", Value: o})
+	}
+	if !deadline.IsZero() {
+		r.PollConfig.DeadlineAt = deadline.Unix()
+	}
+	return r
+}
+
+//Link - url to the poll, falling back to building it from the ID
+func (r *CreatePollResponse) Link() string {
+	if r.URL != "" {
+		return r.URL
+	}
+	return PollURL(r.ID)
+}
+
+//PollURL - link to a poll by ID
+func PollURL(ID string) string {
+	return strawpollURL + ID
+}
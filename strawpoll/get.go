@@ -1,6 +1,13 @@
 package strawpoll
 
-const strawpollGetEndpoint = "https://api.strawpoll.com/v2/polls/"
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+const strawpollAPIURL = "https://api.strawpoll.com/v2"
+const pollsEndpoint = "/polls"
 
 type StrawPollGetClient interface {
 	GetPoll(ID string) (*StrawPollResults, error)
@@ -35,3 +42,26 @@ type PollOptions struct {
 	Position	   int
 	VoteCount      int `json:"vote_count"`
 }
+
+//PollIDFromURL - extracts the poll ID from links like https://strawpoll.com/polls/{id}
+func PollIDFromURL(link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host != "strawpoll.com" && !strings.HasSuffix(host, ".strawpoll.com") {
+		return "", errors.New("not a strawpoll link")
+	}
+
+	ID := strings.Trim(u.Path, "/")
+	if ID == "polls" || strings.HasPrefix(ID, "polls/") {
+		ID = strings.TrimPrefix(ID[len("polls"):], "/")
+	}
+	if ID == "" || strings.Contains(ID, "/") {
+		return "", errors.New("strawpoll link is missing the poll id")
+	}
+
+	return ID, nil
+}